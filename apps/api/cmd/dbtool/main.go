@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jimvid/sidekick/internal/migrations"
+	"github.com/jimvid/sidekick/internal/tablecopy"
+)
+
+const usage = `Usage: dbtool <command> [flags]
+
+Commands:
+  copy     Copy every item from one table to another
+  verify   Compare item counts and checksums of two tables
+  migrate  Apply pending item migrations to a table or a user's partition
+
+Run "dbtool <command> -h" for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "copy":
+		err = runCopy(ctx, os.Args[2:])
+	case "verify":
+		err = runVerify(ctx, os.Args[2:])
+	case "migrate":
+		err = runMigrate(ctx, os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		slog.Error("Command failed", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+type tableFlags struct {
+	source   string
+	target   string
+	region   string
+	endpoint string
+	segments int
+}
+
+func (f *tableFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.source, "source", "", "source table name (required)")
+	fs.StringVar(&f.target, "target", "", "target table name (required)")
+	fs.StringVar(&f.region, "region", "", "AWS region, defaults to the SDK's region resolution")
+	fs.StringVar(&f.endpoint, "endpoint", "", "DynamoDB endpoint override, e.g. http://localhost:8000")
+	fs.IntVar(&f.segments, "segments", 4, "number of parallel scan segments")
+}
+
+func (f *tableFlags) validate() error {
+	if f.source == "" || f.target == "" {
+		return fmt.Errorf("both -source and -target are required")
+	}
+	if f.source == f.target {
+		return fmt.Errorf("-source and -target must differ")
+	}
+	if f.segments < 1 {
+		return fmt.Errorf("-segments must be at least 1")
+	}
+	return nil
+}
+
+func (f *tableFlags) client(ctx context.Context) (*dynamodb.Client, error) {
+	return newClient(ctx, f.region, f.endpoint)
+}
+
+func newClient(ctx context.Context, region, endpoint string) (*dynamodb.Client, error) {
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+
+	return dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	}), nil
+}
+
+func runCopy(ctx context.Context, args []string) error {
+	var tables tableFlags
+	fs := flag.NewFlagSet("copy", flag.ExitOnError)
+	tables.register(fs)
+	dryRun := fs.Bool("dry-run", false, "scan and count the source without writing")
+	verify := fs.Bool("verify", true, "compare counts and checksums after copying")
+	maxRetries := fs.Int("max-retries", 8, "retries for unprocessed or failed batch writes")
+	progress := fs.Duration("progress", tablecopy.DefaultProgressInterval, "interval between progress reports")
+	fs.Parse(args)
+
+	if err := tables.validate(); err != nil {
+		return err
+	}
+
+	db, err := tables.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	copier := tablecopy.NewCopier(db, tablecopy.Options{
+		Source:           tables.source,
+		Target:           tables.target,
+		Segments:         tables.segments,
+		DryRun:           *dryRun,
+		MaxRetries:       *maxRetries,
+		ProgressInterval: *progress,
+	})
+
+	_, err = copier.Copy(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun || !*verify {
+		return nil
+	}
+
+	_, _, err = copier.Verify(ctx)
+	return err
+}
+
+func runVerify(ctx context.Context, args []string) error {
+	var tables tableFlags
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	tables.register(fs)
+	fs.Parse(args)
+
+	if err := tables.validate(); err != nil {
+		return err
+	}
+
+	db, err := tables.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	copier := tablecopy.NewCopier(db, tablecopy.Options{
+		Source:   tables.source,
+		Target:   tables.target,
+		Segments: tables.segments,
+	})
+
+	_, _, err = copier.Verify(ctx)
+	return err
+}
+
+func runMigrate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	table := fs.String("table", "", "table name (required)")
+	region := fs.String("region", "", "AWS region, defaults to the SDK's region resolution")
+	endpoint := fs.String("endpoint", "", "DynamoDB endpoint override, e.g. http://localhost:8000")
+	userId := fs.String("user", "", "only migrate this user's partition")
+	dryRun := fs.Bool("dry-run", false, "report what would change without writing")
+	status := fs.Bool("status", false, "list applied migrations and exit")
+	fs.Parse(args)
+
+	if *table == "" {
+		return fmt.Errorf("-table is required")
+	}
+
+	db, err := newClient(ctx, *region, *endpoint)
+	if err != nil {
+		return err
+	}
+
+	migrator := migrations.NewMigrator(migrations.All)
+	runner := migrations.NewRunner(db, *table, migrator, *dryRun)
+
+	if *status {
+		partition := migrations.TablePartition
+		if *userId != "" {
+			partition = *userId
+		}
+		records, err := runner.Applied(ctx, partition)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			fmt.Printf("%6d  %-40s  %-30s  migrated %d/%d\n", record.Version, record.Name, record.Scope, record.ItemsMigrated, record.ItemsScanned)
+		}
+		fmt.Printf("latest known version: %d\n", migrator.Latest())
+		return nil
+	}
+
+	if *userId != "" {
+		_, err = runner.RunUser(ctx, *userId)
+		return err
+	}
+
+	_, err = runner.RunTable(ctx)
+	return err
+}
@@ -0,0 +1,56 @@
+// Command outbox relays the domain events whose asynchronous subscribers
+// did not finish, such as webhooks when the API stopped first. In Lambda it
+// runs on every scheduled event, elsewhere it runs once, for example from
+// cron.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	lambdaevents "github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/events"
+	"github.com/jimvid/sidekick/internal/startup"
+	"github.com/jimvid/sidekick/internal/webhooks"
+)
+
+func main() {
+	at := flag.String("now", "", "relay the events due at this RFC 3339 time instead of now")
+	flag.Parse()
+
+	cfg, shutdown := startup.Setup()
+	defer shutdown()
+
+	db := startup.DynamoDB(cfg)
+
+	webhookService := webhooks.NewWebhookService(webhooks.NewWebhookStorage(db, cfg), webhooks.NewSender())
+	bus := webhooks.NewEventBus(db, cfg, webhookService)
+
+	if config.InLambda() {
+		lambda.Start(func(ctx context.Context, event lambdaevents.CloudWatchEvent) (events.RelayResult, error) {
+			return bus.Relay(ctx, event.Time)
+		})
+		return
+	}
+
+	now := time.Now()
+	if *at != "" {
+		var err error
+		now, err = time.Parse(time.RFC3339, *at)
+		if err != nil {
+			slog.Error("Invalid -now, expected RFC 3339 like 2026-03-02T20:00:00+01:00", "error", err)
+			os.Exit(2)
+		}
+	}
+
+	_, err := bus.Relay(context.Background(), now)
+	if err != nil {
+		slog.Error("Outbox relay failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,85 @@
+// Command reminders sends the habit reminders that are due. In Lambda it
+// runs on every scheduled event, elsewhere it runs once, for example from
+// cron.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/push"
+	"github.com/jimvid/sidekick/internal/reminders"
+	"github.com/jimvid/sidekick/internal/startup"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+func main() {
+	at := flag.String("now", "", "evaluate reminders at this RFC 3339 time instead of now")
+	dryRun := flag.Bool("dry-run", false, "log due reminders without sending or marking them")
+	window := flag.Duration("window", reminders.DefaultWindow, "how long after its time a reminder may still fire")
+	flag.Parse()
+
+	cfg, shutdown := startup.Setup()
+	defer shutdown()
+
+	db := startup.DynamoDB(cfg)
+
+	notifiers := reminders.MultiNotifier{reminders.LogNotifier{}}
+	if cfg.ReminderWebhookURL != "" {
+		notifiers = append(notifiers, reminders.NewWebhookNotifier(cfg.ReminderWebhookURL))
+	}
+	if cfg.PushEnabled() {
+		// Keys were validated by config.Load
+		sender, _ := push.NewSender(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+		notifiers = append(notifiers, reminders.NewPushNotifier(push.NewPushService(push.NewPushStorage(db, cfg), sender)))
+	}
+
+	evaluator := reminders.NewEvaluator()
+	evaluator.Window = *window
+	runner := reminders.NewRunner(
+		reminders.NewReminderStorage(db, cfg),
+		habits.NewHabitStorage(db, cfg),
+		user.NewUserStorage(db, cfg),
+		notifiers,
+		evaluator,
+	)
+	runner.DryRun = *dryRun
+
+	run := runner.Run
+	if !cfg.FeatureEnabled(config.FeatureReminders) {
+		run = func(ctx context.Context, _ time.Time) (reminders.RunResult, error) {
+			slog.InfoContext(ctx, "Reminders are turned off in FEATURES")
+			return reminders.RunResult{}, nil
+		}
+	}
+
+	if config.InLambda() {
+		lambda.Start(func(ctx context.Context, event events.CloudWatchEvent) (reminders.RunResult, error) {
+			return run(ctx, event.Time)
+		})
+		return
+	}
+
+	now := time.Now()
+	if *at != "" {
+		var err error
+		now, err = time.Parse(time.RFC3339, *at)
+		if err != nil {
+			slog.Error("Invalid -now, expected RFC 3339 like 2026-03-02T20:00:00+01:00", "error", err)
+			os.Exit(2)
+		}
+	}
+
+	_, err := run(context.Background(), now)
+	if err != nil {
+		slog.Error("Reminders run failed", "error", err)
+		os.Exit(1)
+	}
+}
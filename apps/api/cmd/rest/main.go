@@ -2,28 +2,63 @@ package main
 
 import (
 	"context"
+	"log/slog"
+	"net/http"
+	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/awslabs/aws-lambda-go-api-proxy/chi"
 	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/go-chi/chi/v5"
 	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/metrics"
 	"github.com/jimvid/sidekick/internal/router"
+	"github.com/jimvid/sidekick/internal/startup"
 )
 
 var chiLambda *chiadapter.ChiLambda
-var cfg = config.NewConfig()
+var chiRouter *chi.Mux
+var cfg *config.Config
+var shutdownTracing func()
+
+// Lambda sets AWS_LAMBDA_FUNCTION_NAME, anywhere else we run a plain HTTP server
+var inLambda = config.InLambda()
 
 func handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	return chiLambda.ProxyWithContext(ctx, req)
 }
 
 func init() {
-	clerk.SetKey(cfg.CLERK_SECRET)
-	router := router.NewRouter(cfg)
-	chiLambda = chiadapter.New(router)
+	cfg, shutdownTracing = startup.Setup()
+
+	var recorder metrics.Recorder = metrics.NewRegistry()
+	if inLambda {
+		recorder = metrics.NewEMF(os.Stdout)
+	}
+
+	clerk.SetKey(cfg.ClerkSecret)
+	var err error
+	chiRouter, err = router.NewRouter(cfg, recorder)
+	if err != nil {
+		slog.Error("Failed to set up routes", "error", err)
+		os.Exit(1)
+	}
+	chiLambda = chiadapter.New(chiRouter)
 }
 
 func main() {
-	lambda.Start(handler)
+	if inLambda {
+		lambda.Start(handler)
+		return
+	}
+
+	addr := ":" + cfg.Port
+	slog.Info("Starting HTTP server", "addr", addr)
+	err := http.ListenAndServe(addr, chiRouter)
+	shutdownTracing()
+	if err != nil {
+		slog.Error("HTTP server stopped", "error", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jimvid/sidekick/pkg/client"
+)
+
+const defaultAPIURL = "https://api.sidekick.jimvid.xyz"
+
+// fileConfig is stored as JSON in the user's config dir. It holds a token,
+// so it is only readable by the user.
+type fileConfig struct {
+	APIURL string `json:"apiUrl,omitempty"`
+	Token  string `json:"token,omitempty"`
+	// TokenId is set when login created the token, so logout can revoke it.
+	TokenId string `json:"tokenId,omitempty"`
+}
+
+func defaultConfigPath() (string, error) {
+	if path := os.Getenv("SIDEKICK_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("find config dir: %w", err)
+	}
+	return filepath.Join(dir, "sidekick", "config.json"), nil
+}
+
+// readConfig returns the saved settings without defaults or environment
+// overrides, so they can be changed and written back.
+func (c *cli) readConfig() (fileConfig, error) {
+	var cfg fileConfig
+
+	data, err := os.ReadFile(c.configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("read config: %w", err)
+	}
+
+	err = json.Unmarshal(data, &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("parse config %s: %w", c.configPath, err)
+	}
+	return cfg, nil
+}
+
+// loadConfig returns the settings commands should use.
+func (c *cli) loadConfig() (fileConfig, error) {
+	cfg, err := c.readConfig()
+	if err != nil {
+		return cfg, err
+	}
+
+	if token := c.getenv("SIDEKICK_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+	if apiURL := c.getenv("SIDEKICK_API_URL"); apiURL != "" {
+		cfg.APIURL = apiURL
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultAPIURL
+	}
+	return cfg, nil
+}
+
+func (c *cli) saveConfig(cfg fileConfig) error {
+	err := os.MkdirAll(filepath.Dir(c.configPath), 0o700)
+	if err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Written to a temporary file first so an interrupted write cannot
+	// leave a truncated config behind
+	tmp := c.configPath + ".tmp"
+	err = os.WriteFile(tmp, append(data, '\n'), 0o600)
+	if err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return os.Rename(tmp, c.configPath)
+}
+
+func (c *cli) runLogin(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	token := fs.String("token", "", "session or personal API token, read from stdin when empty")
+	fs.Parse(args)
+
+	if *token == "" {
+		fmt.Fprint(c.out, "Paste your token: ")
+		line, err := bufio.NewReader(c.in).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("read token: %w", err)
+		}
+		*token = line
+	}
+
+	*token = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(*token), "Bearer "))
+	if *token == "" {
+		return errors.New("token must not be empty")
+	}
+
+	cfg, err := c.readConfig()
+	if err != nil {
+		return err
+	}
+	cfg.Token, cfg.TokenId = *token, ""
+
+	// A session token expires within a minute, so it is only used to create
+	// a personal API token, which is saved instead
+	if !strings.HasPrefix(*token, client.TokenPrefix) {
+		created, err := c.createToken(ctx, *token)
+		if err != nil {
+			return fmt.Errorf("create API token: %w", err)
+		}
+		cfg.Token, cfg.TokenId = created.Value, created.ID
+	}
+
+	err = c.saveConfig(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Saved token to %s\n", c.configPath)
+	return nil
+}
+
+// createToken creates a personal API token for this machine, signed in
+// with sessionToken.
+func (c *cli) createToken(ctx context.Context, sessionToken string) (client.CreatedToken, error) {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return client.CreatedToken{}, err
+	}
+	cfg.Token = sessionToken
+
+	name := "sidekick CLI"
+	if host, err := os.Hostname(); err == nil {
+		name += " on " + host
+	}
+	return c.newClient(cfg).Tokens.Create(ctx, client.TokenRequest{Name: name})
+}
+
+func (c *cli) runLogout(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("logout", flag.ExitOnError)
+	fs.Parse(args)
+
+	cfg, err := c.readConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Token == "" {
+		fmt.Fprintln(c.out, "Not logged in")
+		return nil
+	}
+
+	// Revoked when login created it, a token the user pasted may be in use
+	// elsewhere
+	if cfg.TokenId != "" {
+		settings, err := c.loadConfig()
+		if err != nil {
+			return err
+		}
+		settings.Token = cfg.Token
+		err = c.newClient(settings).Tokens.Delete(ctx, cfg.TokenId)
+		if err != nil && !client.IsNotFound(err) {
+			fmt.Fprintf(c.out, "Could not revoke the token, it is still valid: %v\n", err)
+		}
+	}
+	cfg.Token, cfg.TokenId = "", ""
+
+	err = c.saveConfig(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, "Removed saved token")
+	return nil
+}
+
+const configUsage = `Usage: sidekick config [show | path | set <key> <value> | unset <key>]
+
+Keys:
+  api-url   Base URL of the Sidekick API, defaults to ` + defaultAPIURL + `
+`
+
+func (c *cli) runConfig(args []string) error {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), configUsage) }
+	args = parseFlags(fs, args)
+
+	if len(args) == 0 {
+		args = []string{"show"}
+	}
+
+	switch args[0] {
+	case "show":
+		cfg, err := c.loadConfig()
+		if err != nil {
+			return err
+		}
+		token := "not set"
+		if cfg.Token != "" {
+			token = maskToken(cfg.Token)
+		}
+		fmt.Fprintf(c.out, "api-url  %s\ntoken    %s\n", cfg.APIURL, token)
+		return nil
+	case "path":
+		fmt.Fprintln(c.out, c.configPath)
+		return nil
+	case "set":
+		if len(args) != 3 {
+			return errors.New("usage: sidekick config set <key> <value>")
+		}
+		return c.setConfig(args[1], args[2])
+	case "unset":
+		if len(args) != 2 {
+			return errors.New("usage: sidekick config unset <key>")
+		}
+		return c.setConfig(args[1], "")
+	default:
+		return fmt.Errorf("unknown config command %q", args[0])
+	}
+}
+
+func (c *cli) setConfig(key, value string) error {
+	cfg, err := c.readConfig()
+	if err != nil {
+		return err
+	}
+
+	switch key {
+	case "api-url":
+		if value != "" {
+			u, err := url.Parse(value)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("api-url must be an http(s) URL, got %q", value)
+			}
+		}
+		cfg.APIURL = strings.TrimSuffix(value, "/")
+	default:
+		return fmt.Errorf("unknown config key %q, tokens are set with sidekick login", key)
+	}
+
+	return c.saveConfig(cfg)
+}
+
+// maskToken shows just enough of a token to tell two apart.
+func maskToken(token string) string {
+	if len(token) <= 8 {
+		return "****"
+	}
+	return token[:4] + "…" + token[len(token)-4:]
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jimvid/sidekick/pkg/client"
+)
+
+// parseDate accepts today, yesterday or a YYYY-MM-DD date.
+func parseDate(value string, now time.Time) (string, error) {
+	switch strings.ToLower(value) {
+	case "", "today":
+		return now.Format(time.DateOnly), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1).Format(time.DateOnly), nil
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return "", fmt.Errorf("date must be today, yesterday or YYYY-MM-DD, got %q", value)
+	}
+	return date.Format(time.DateOnly), nil
+}
+
+// resolveHabit finds the habit a user typed, by ID, full name or a name
+// prefix that only one habit has.
+func resolveHabit(habits []client.Habit, query string) (client.Habit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return client.Habit{}, errors.New("a habit name is required")
+	}
+
+	for _, habit := range habits {
+		if habit.ID == query || strings.EqualFold(habit.Name, query) {
+			return habit, nil
+		}
+	}
+
+	var matches []client.Habit
+	for _, habit := range habits {
+		if strings.HasPrefix(strings.ToLower(habit.Name), strings.ToLower(query)) {
+			matches = append(matches, habit)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return client.Habit{}, fmt.Errorf("no habit matches %q", query)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, habit := range matches {
+			names[i] = habit.Name
+		}
+		return client.Habit{}, fmt.Errorf("%q matches several habits: %s", query, strings.Join(names, ", "))
+	}
+}
+
+// sortHabits orders habits by name, which is how they are shown everywhere.
+func sortHabits(habits []client.Habit) {
+	sort.Slice(habits, func(i, j int) bool {
+		return strings.ToLower(habits[i].Name) < strings.ToLower(habits[j].Name)
+	})
+}
+
+func (c *cli) loadHabitsAndLogs(ctx context.Context, api *client.Client) ([]client.Habit, []client.HabitLog, error) {
+	habits, err := api.Habits.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	logs, err := api.Logs.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sortHabits(habits)
+	return habits, logs, nil
+}
+
+func (c *cli) runHabits(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("habits", flag.ExitOnError)
+	showIds := fs.Bool("ids", false, "show habit IDs")
+	fs.Parse(args)
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	habits, err := api.Habits.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(habits) == 0 {
+		fmt.Fprintln(c.out, "No habits yet, create them in the app")
+		return nil
+	}
+	sortHabits(habits)
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	for _, habit := range habits {
+		if *showIds {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", habit.ID, habit.Name, habit.Description)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", habit.Name, habit.Description)
+		}
+	}
+	return w.Flush()
+}
+
+func (c *cli) runStatus(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	dateFlag := fs.String("date", "today", "day to show, today, yesterday or YYYY-MM-DD")
+	fs.Parse(args)
+
+	now := c.now()
+	date, err := parseDate(*dateFlag, now)
+	if err != nil {
+		return err
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	habits, logs, err := c.loadHabitsAndLogs(ctx, api)
+	if err != nil {
+		return err
+	}
+	if len(habits) == 0 {
+		fmt.Fprintln(c.out, "No habits yet, create them in the app")
+		return nil
+	}
+
+	done := map[string]bool{}
+	for _, log := range logs {
+		if log.Date == date {
+			done[log.HabitId] = true
+		}
+	}
+	stats := client.ComputeStats(logs, now)
+
+	day, _ := time.Parse(time.DateOnly, date)
+	fmt.Fprintf(c.out, "%s\n\n", day.Format("Monday 2 January 2006"))
+
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	completed := 0
+	for _, habit := range habits {
+		check := "[ ]"
+		if done[habit.ID] {
+			check = "[x]"
+			completed++
+		}
+		fmt.Fprintf(w, "  %s %s\t%s\n", check, habit.Name, streakLabel(stats[habit.ID]))
+	}
+	w.Flush()
+	// Habits without a streak leave trailing padding
+	for _, line := range strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n") {
+		fmt.Fprintln(c.out, strings.TrimRight(line, " "))
+	}
+
+	fmt.Fprintf(c.out, "\n%d of %d done\n", completed, len(habits))
+	return nil
+}
+
+func streakLabel(stats client.HabitStats) string {
+	switch {
+	case stats.CurrentStreak == 1:
+		return fmt.Sprintf("1 day streak, best %d", stats.LongestStreak)
+	case stats.CurrentStreak > 1:
+		return fmt.Sprintf("%d day streak, best %d", stats.CurrentStreak, stats.LongestStreak)
+	case stats.LongestStreak > 0:
+		return fmt.Sprintf("best %d", stats.LongestStreak)
+	default:
+		return ""
+	}
+}
+
+func (c *cli) runDone(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("done", flag.ExitOnError)
+	dateFlag := fs.String("date", "today", "day to check off, today, yesterday or YYYY-MM-DD")
+	note := fs.String("note", "", "note to save with the log")
+	query := strings.Join(parseFlags(fs, args), " ")
+
+	date, err := parseDate(*dateFlag, c.now())
+	if err != nil {
+		return err
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	habits, logs, err := c.loadHabitsAndLogs(ctx, api)
+	if err != nil {
+		return err
+	}
+	habit, err := resolveHabit(habits, query)
+	if err != nil {
+		return err
+	}
+
+	for _, log := range logs {
+		if log.HabitId == habit.ID && log.Date == date {
+			fmt.Fprintf(c.out, "%s is already done for %s\n", habit.Name, date)
+			return nil
+		}
+	}
+
+	log, err := api.Logs.Create(ctx, client.HabitLogRequest{HabitId: habit.ID, Date: date, Note: *note})
+	if err != nil {
+		return err
+	}
+
+	stats := client.ComputeStats(append(logs, log), c.now())[habit.ID]
+	fmt.Fprintf(c.out, "Done: %s for %s", habit.Name, date)
+	if stats.CurrentStreak > 1 {
+		fmt.Fprintf(c.out, ", %d day streak", stats.CurrentStreak)
+	}
+	fmt.Fprintln(c.out)
+	return nil
+}
+
+func (c *cli) runUndo(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	dateFlag := fs.String("date", "today", "day to uncheck, today, yesterday or YYYY-MM-DD")
+	query := strings.Join(parseFlags(fs, args), " ")
+
+	date, err := parseDate(*dateFlag, c.now())
+	if err != nil {
+		return err
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	habits, logs, err := c.loadHabitsAndLogs(ctx, api)
+	if err != nil {
+		return err
+	}
+	habit, err := resolveHabit(habits, query)
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, log := range logs {
+		if log.HabitId != habit.ID || log.Date != date {
+			continue
+		}
+		err = api.Logs.Delete(ctx, log.ID)
+		if err != nil && !client.IsNotFound(err) {
+			return err
+		}
+		removed++
+	}
+
+	if removed == 0 {
+		fmt.Fprintf(c.out, "%s was not done for %s\n", habit.Name, date)
+		return nil
+	}
+	fmt.Fprintf(c.out, "Unchecked %s for %s\n", habit.Name, date)
+	return nil
+}
+
+func (c *cli) runStreaks(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("streaks", flag.ExitOnError)
+	fs.Parse(args)
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	habits, logs, err := c.loadHabitsAndLogs(ctx, api)
+	if err != nil {
+		return err
+	}
+	if len(habits) == 0 {
+		fmt.Fprintln(c.out, "No habits yet, create them in the app")
+		return nil
+	}
+
+	stats := client.ComputeStats(logs, c.now())
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HABIT\tCURRENT\tLONGEST\tDAYS\tLAST")
+	for _, habit := range habits {
+		s := stats[habit.ID]
+		last := s.LastLogged
+		if last == "" {
+			last = "never"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", habit.Name, s.CurrentStreak, s.LongestStreak, s.TotalDays, last)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jimvid/sidekick/pkg/client"
+)
+
+var heatLevels = []string{"·", "░", "▒", "▓", "█"}
+
+// renderHeatmap draws one column per week and one row per weekday, ending
+// with the week of today. counts holds how many habits were done per
+// YYYY-MM-DD date, out of total.
+func renderHeatmap(counts map[string]int, total int, today time.Time, weeks int) string {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	start := heatmapStart(today, weeks)
+
+	var b strings.Builder
+
+	// Month labels above the first week of each month
+	// Room for a label over the last column
+	labels := []byte(strings.Repeat(" ", 4+2*weeks+2))
+	labelEnd := 0
+	for week := 0; week < weeks; week++ {
+		monday := start.AddDate(0, 0, 7*week)
+		// A month is labelled at its first week, which the first column only
+		// is when the month starts that week
+		previous := monday.AddDate(0, 0, -7)
+		if monday.Month() == previous.Month() || (week == 0 && monday.Day() > 7) {
+			continue
+		}
+		col := 4 + 2*week
+		if col < labelEnd {
+			continue
+		}
+		label := monday.Format("Jan")
+		if col+len(label) > len(labels) {
+			continue
+		}
+		copy(labels[col:], label)
+		labelEnd = col + len(label) + 1
+	}
+	b.WriteString(strings.TrimRight(string(labels), " "))
+	b.WriteString("\n")
+
+	for weekday := 0; weekday < 7; weekday++ {
+		day := start.AddDate(0, 0, weekday)
+		b.WriteString(day.Format("Mon")[:2] + "  ")
+
+		var cells []string
+		for week := 0; week < weeks; week++ {
+			date := day.AddDate(0, 0, 7*week)
+			if date.After(today) {
+				cells = append(cells, " ")
+				continue
+			}
+			cells = append(cells, heatLevel(counts[date.Format(time.DateOnly)], total))
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, " "), " "))
+		b.WriteString("\n")
+	}
+
+	if total > 1 {
+		b.WriteString("\n    Less " + strings.Join(heatLevels, " ") + " More\n")
+	}
+	return b.String()
+}
+
+func heatLevel(count, total int) string {
+	if count <= 0 || total <= 0 {
+		return heatLevels[0]
+	}
+	level := (count*(len(heatLevels)-1) + total - 1) / total
+	return heatLevels[min(level, len(heatLevels)-1)]
+}
+
+// heatmapStart is the Monday of the first week shown. Weeks start on Monday.
+func heatmapStart(today time.Time, weeks int) time.Time {
+	offset := (int(today.Weekday()) + 6) % 7
+	return today.AddDate(0, 0, -offset-7*(weeks-1))
+}
+
+func (c *cli) runHeatmap(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("heatmap", flag.ExitOnError)
+	weeks := fs.Int("weeks", 12, "number of weeks to show")
+	query := strings.Join(parseFlags(fs, args), " ")
+
+	if *weeks < 1 || *weeks > 53 {
+		return fmt.Errorf("-weeks must be between 1 and 53")
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	habits, err := api.Habits.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := client.HeatmapOptions{}
+	total := len(habits)
+	if query != "" {
+		habit, err := resolveHabit(habits, query)
+		if err != nil {
+			return err
+		}
+		opts.HabitId = habit.ID
+		total = 1
+		fmt.Fprintf(c.out, "%s\n\n", habit.Name)
+	} else {
+		fmt.Fprintf(c.out, "All habits\n\n")
+	}
+
+	now := c.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	opts.From = heatmapStart(today, *weeks).Format(time.DateOnly)
+	opts.To = today.Format(time.DateOnly)
+
+	heatmap, err := api.Stats.Heatmap(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, day := range heatmap.Days {
+		counts[day.Date] = day.Count
+	}
+	fmt.Fprint(c.out, renderHeatmap(counts, total, now, *weeks))
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jimvid/sidekick/pkg/client"
+)
+
+const usage = `Usage: sidekick <command> [flags]
+
+Commands:
+  status            Show today's habits with their streaks
+  habits            List habits
+  done <habit>      Check off a habit for today, or -date
+  undo <habit>      Remove a habit's check for today, or -date
+  streaks           Show current and longest streaks
+  heatmap [habit]   Show a text heatmap of the last weeks
+  login             Sign in with a session or API token, from -token or stdin
+  logout            Remove the saved token, revoking it if login created it
+  config            Show or change settings, see "sidekick config -h"
+
+Habits are matched by ID, name or a unique name prefix, case insensitively.
+SIDEKICK_TOKEN and SIDEKICK_API_URL override the saved settings.
+
+Run "sidekick <command> -h" for command flags.
+`
+
+// cli holds what commands need, so tests can run them against a fake API
+// and capture their output.
+type cli struct {
+	out        io.Writer
+	in         io.Reader
+	configPath string
+	getenv     func(string) string
+	now        func() time.Time
+	// newClient builds the API client, overridden in tests to skip retries.
+	newClient func(cfg fileConfig) *client.Client
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	configPath, err := defaultConfigPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sidekick: %v\n", err)
+		os.Exit(1)
+	}
+
+	c := &cli{
+		out:        os.Stdout,
+		in:         os.Stdin,
+		configPath: configPath,
+		getenv:     os.Getenv,
+		now:        time.Now,
+		newClient:  newAPIClient,
+	}
+
+	if os.Args[1] == "-h" || os.Args[1] == "-help" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Fprint(os.Stdout, usage)
+		return
+	}
+
+	err = c.run(ctx, os.Args[1], os.Args[2:])
+	var unknown unknownCommandError
+	if errors.As(err, &unknown) {
+		fmt.Fprintf(os.Stderr, "%v\n\n%s", err, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sidekick: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type unknownCommandError string
+
+func (e unknownCommandError) Error() string {
+	return fmt.Sprintf("unknown command %q", string(e))
+}
+
+func (c *cli) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "status":
+		return c.runStatus(ctx, args)
+	case "habits":
+		return c.runHabits(ctx, args)
+	case "done":
+		return c.runDone(ctx, args)
+	case "undo":
+		return c.runUndo(ctx, args)
+	case "streaks":
+		return c.runStreaks(ctx, args)
+	case "heatmap":
+		return c.runHeatmap(ctx, args)
+	case "login":
+		return c.runLogin(ctx, args)
+	case "logout":
+		return c.runLogout(ctx, args)
+	case "config":
+		return c.runConfig(args)
+	default:
+		return unknownCommandError(command)
+	}
+}
+
+// parseFlags parses fs from args and returns the positional arguments, which
+// may come before, between or after the flags.
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newAPIClient(cfg fileConfig) *client.Client {
+	return client.New(cfg.APIURL,
+		client.WithToken(cfg.Token),
+		client.WithUserAgent("sidekick-cli"),
+	)
+}
+
+// client loads the settings and builds an API client, failing early when
+// there is no token to authenticate with.
+func (c *cli) client() (*client.Client, error) {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Token == "" {
+		return nil, errors.New(`not logged in, run "sidekick login" or set SIDEKICK_TOKEN`)
+	}
+	return c.newClient(cfg), nil
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jimvid/sidekick/pkg/client"
+)
+
+// fakeAPI keeps habits and logs in memory and serves the routes the CLI uses.
+type fakeAPI struct {
+	mu     sync.Mutex
+	habits []client.Habit
+	logs   []client.HabitLog
+	// heatmapQuery is the query string of the last heatmap request.
+	heatmapQuery string
+	// apiTokens maps the personal API tokens created to their IDs.
+	apiTokens map[string]string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if _, ok := f.apiTokens[token]; !ok && token != "test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/habits":
+		json.NewEncoder(w).Encode(f.habits)
+	case r.Method == http.MethodGet && r.URL.Path == "/habit-logs":
+		json.NewEncoder(w).Encode(f.logs)
+	case r.Method == http.MethodGet && r.URL.Path == "/heatmap":
+		query := r.URL.Query()
+		heatmap := client.Heatmap{From: query.Get("from"), To: query.Get("to"), HabitId: query.Get("habitId")}
+		f.heatmapQuery = r.URL.RawQuery
+		counts := map[string]int{}
+		for _, log := range f.logs {
+			if log.Date < heatmap.From || log.Date > heatmap.To || (heatmap.HabitId != "" && log.HabitId != heatmap.HabitId) {
+				continue
+			}
+			counts[log.Date]++
+			heatmap.Total++
+		}
+		for date, count := range counts {
+			heatmap.Days = append(heatmap.Days, client.HeatmapDay{Date: date, Count: count})
+		}
+		json.NewEncoder(w).Encode(heatmap)
+	case r.Method == http.MethodPost && r.URL.Path == "/habit-logs":
+		var req client.HabitLogRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		log := client.HabitLog{ID: "log-" + req.HabitId + "-" + req.Date, HabitId: req.HabitId, Date: req.Date, Note: req.Note}
+		f.logs = append(f.logs, log)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(log)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/habit-logs/"):
+		id := strings.TrimPrefix(r.URL.Path, "/habit-logs/")
+		for i, log := range f.logs {
+			if log.ID == id {
+				f.logs = append(f.logs[:i], f.logs[i+1:]...)
+				break
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Successfully deleted log"})
+	case r.Method == http.MethodPost && r.URL.Path == "/me/tokens":
+		var req client.TokenRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		created := client.CreatedToken{Token: client.Token{ID: fmt.Sprintf("t%d", len(f.apiTokens)+1), Name: req.Name}}
+		created.Value = client.TokenPrefix + created.ID
+		f.apiTokens[created.Value] = created.ID
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/me/tokens/"):
+		id := strings.TrimPrefix(r.URL.Path, "/me/tokens/")
+		for value, tokenId := range f.apiTokens {
+			if tokenId == id {
+				delete(f.apiTokens, value)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Successfully deleted API token"})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func setupCLI(t *testing.T) (*cli, *fakeAPI, *bytes.Buffer) {
+	t.Helper()
+
+	api := &fakeAPI{
+		habits: []client.Habit{
+			{ID: "h1", Name: "Meditate"},
+			{ID: "h2", Name: "Read a book"},
+			{ID: "h3", Name: "Run"},
+		},
+		logs: []client.HabitLog{
+			{ID: "l1", HabitId: "h1", Date: "2026-02-06"},
+			{ID: "l2", HabitId: "h1", Date: "2026-02-07"},
+		},
+		apiTokens: map[string]string{},
+	}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	out := &bytes.Buffer{}
+	env := map[string]string{"SIDEKICK_API_URL": server.URL, "SIDEKICK_TOKEN": "test-token"}
+	c := &cli{
+		out:        out,
+		in:         strings.NewReader(""),
+		configPath: filepath.Join(t.TempDir(), "sidekick", "config.json"),
+		getenv:     func(key string) string { return env[key] },
+		now:        func() time.Time { return time.Date(2026, 2, 8, 9, 0, 0, 0, time.Local) },
+		newClient: func(cfg fileConfig) *client.Client {
+			return client.New(cfg.APIURL, client.WithToken(cfg.Token), client.WithRetries(0))
+		},
+	}
+	return c, api, out
+}
+
+func TestDone(t *testing.T) {
+	c, api, out := setupCLI(t)
+	ctx := context.Background()
+
+	err := c.run(ctx, "done", []string{"med"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "Done: Meditate for 2026-02-08, 3 day streak\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+
+	out.Reset()
+	err = c.run(ctx, "done", []string{"meditate"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "already done") {
+		t.Errorf("expected already done, got %q", out.String())
+	}
+	if len(api.logs) != 3 {
+		t.Errorf("expected a single new log, got %d logs", len(api.logs))
+	}
+
+	t.Run("flags after the habit name", func(t *testing.T) {
+		out.Reset()
+		err := c.run(ctx, "done", []string{"read", "a", "book", "-date", "yesterday", "-note", "chapter 3"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		last := api.logs[len(api.logs)-1]
+		if last.HabitId != "h2" || last.Date != "2026-02-07" || last.Note != "chapter 3" {
+			t.Errorf("unexpected log %+v", last)
+		}
+	})
+
+	t.Run("ambiguous name", func(t *testing.T) {
+		err := c.run(ctx, "done", []string{"r"})
+		if err == nil || !strings.Contains(err.Error(), "several habits") {
+			t.Errorf("expected ambiguous match error, got %v", err)
+		}
+	})
+}
+
+func TestUndo(t *testing.T) {
+	c, api, out := setupCLI(t)
+
+	err := c.run(context.Background(), "undo", []string{"meditate", "-date", "2026-02-07"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(api.logs) != 1 || api.logs[0].Date != "2026-02-06" {
+		t.Errorf("expected the 2026-02-07 log to be removed, got %+v", api.logs)
+	}
+	if out.String() != "Unchecked Meditate for 2026-02-07\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestStatus(t *testing.T) {
+	c, _, out := setupCLI(t)
+	c.run(context.Background(), "done", []string{"run"})
+	out.Reset()
+
+	err := c.run(context.Background(), "status", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `Sunday 8 February 2026
+
+  [ ] Meditate     2 day streak, best 2
+  [ ] Read a book
+  [x] Run          1 day streak, best 1
+
+1 of 3 done
+`
+	if out.String() != want {
+		t.Errorf("unexpected output\n%s\nexpected\n%s", out.String(), want)
+	}
+}
+
+func TestNotLoggedIn(t *testing.T) {
+	c, _, _ := setupCLI(t)
+	c.getenv = func(string) string { return "" }
+
+	err := c.run(context.Background(), "status", nil)
+	if err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("expected not logged in error, got %v", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	c, _, out := setupCLI(t)
+	c.getenv = func(string) string { return "" }
+	c.in = strings.NewReader("Bearer skpat_secret-1234\n")
+
+	err := c.run(context.Background(), "login", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(c.configPath)
+	if err != nil {
+		t.Fatalf("expected config file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected config to be private, got %v", info.Mode().Perm())
+	}
+
+	err = c.run(context.Background(), "config", []string{"set", "api-url", "http://localhost:8080/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = c.run(context.Background(), "config", []string{"set", "api-url", "localhost"})
+	if err == nil {
+		t.Error("expected invalid URL to be rejected")
+	}
+
+	out.Reset()
+	c.run(context.Background(), "config", nil)
+	if out.String() != "api-url  http://localhost:8080\ntoken    skpa…1234\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	c.run(context.Background(), "logout", nil)
+	cfg, _ := c.readConfig()
+	if cfg.Token != "" || cfg.APIURL != "http://localhost:8080" {
+		t.Errorf("expected only the token to be removed, got %+v", cfg)
+	}
+}
+
+func TestLoginCreatesToken(t *testing.T) {
+	c, api, out := setupCLI(t)
+	getenv := c.getenv
+	c.getenv = func(key string) string {
+		if key == "SIDEKICK_TOKEN" {
+			return ""
+		}
+		return getenv(key)
+	}
+
+	err := c.run(context.Background(), "login", []string{"-token", "test-token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, _ := c.readConfig()
+	if cfg.TokenId == "" || api.apiTokens[cfg.Token] != cfg.TokenId {
+		t.Fatalf("expected a personal API token to be saved instead of the session token, got %+v", cfg)
+	}
+
+	out.Reset()
+	err = c.run(context.Background(), "status", nil)
+	if err != nil {
+		t.Fatalf("expected the saved token to work, got %v", err)
+	}
+
+	c.run(context.Background(), "logout", nil)
+	if len(api.apiTokens) != 0 {
+		t.Errorf("expected logout to revoke the token, got %v", api.apiTokens)
+	}
+	cfg, _ = c.readConfig()
+	if cfg.Token != "" || cfg.TokenId != "" {
+		t.Errorf("expected the token to be removed, got %+v", cfg)
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	c, api, out := setupCLI(t)
+
+	err := c.run(context.Background(), "heatmap", []string{"-weeks", "2", "med"})
+	if err != nil {
+		t.Fatalf("heatmap failed: %v", err)
+	}
+
+	// Sunday 8 February, so two weeks start on Monday 26 January
+	if api.heatmapQuery != "from=2026-01-26&habitId=h1&to=2026-02-08" {
+		t.Errorf("unexpected heatmap query %q", api.heatmapQuery)
+	}
+	if !strings.HasPrefix(out.String(), "Meditate\n") || !strings.Contains(out.String(), "Fr  · █") || !strings.Contains(out.String(), "Sa  · █") {
+		t.Errorf("unexpected output\n%s", out.String())
+	}
+}
+
+func TestRenderHeatmap(t *testing.T) {
+	today := time.Date(2026, 2, 4, 0, 0, 0, 0, time.UTC) // Wednesday
+	counts := map[string]int{
+		"2026-01-26": 1, // Monday of the first week
+		"2026-02-02": 2,
+		"2026-02-04": 4,
+	}
+
+	got := renderHeatmap(counts, 4, today, 6)
+	want := `      Jan     Feb
+Mo  · · · · ░ ▒
+Tu  · · · · · ·
+We  · · · · · █
+Th  · · · · ·
+Fr  · · · · ·
+Sa  · · · · ·
+Su  · · · · ·
+
+    Less · ░ ▒ ▓ █ More
+`
+	if got != want {
+		t.Errorf("unexpected heatmap\n%s\nexpected\n%s", got, want)
+	}
+}
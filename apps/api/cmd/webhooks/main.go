@@ -0,0 +1,61 @@
+// Command webhooks retries the webhook deliveries that are due. In Lambda it
+// runs on every scheduled event, elsewhere it runs once, for example from
+// cron.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/startup"
+	"github.com/jimvid/sidekick/internal/webhooks"
+)
+
+func main() {
+	at := flag.String("now", "", "retry the deliveries due at this RFC 3339 time instead of now")
+	flag.Parse()
+
+	cfg, shutdown := startup.Setup()
+	defer shutdown()
+
+	db := startup.DynamoDB(cfg)
+
+	service := webhooks.NewWebhookService(webhooks.NewWebhookStorage(db, cfg), webhooks.NewSender())
+
+	retryDue := service.RetryDue
+	if !cfg.FeatureEnabled(config.FeatureWebhooks) {
+		retryDue = func(ctx context.Context, _ time.Time) (webhooks.RetryResult, error) {
+			slog.InfoContext(ctx, "Webhooks are turned off in FEATURES")
+			return webhooks.RetryResult{}, nil
+		}
+	}
+
+	if config.InLambda() {
+		lambda.Start(func(ctx context.Context, event events.CloudWatchEvent) (webhooks.RetryResult, error) {
+			return retryDue(ctx, event.Time)
+		})
+		return
+	}
+
+	now := time.Now()
+	if *at != "" {
+		var err error
+		now, err = time.Parse(time.RFC3339, *at)
+		if err != nil {
+			slog.Error("Invalid -now, expected RFC 3339 like 2026-03-02T20:00:00+01:00", "error", err)
+			os.Exit(2)
+		}
+	}
+
+	_, err := retryDue(context.Background(), now)
+	if err != nil {
+		slog.Error("Webhook retries failed", "error", err)
+		os.Exit(1)
+	}
+}
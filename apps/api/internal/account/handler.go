@@ -0,0 +1,110 @@
+package account
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+const maxWebhookBodyBytes = 1 << 20
+
+type AccountHandler struct {
+	service       *AccountService
+	webhookSecret string
+	getUserId     func(r *http.Request) (string, error)
+	now           func() time.Time
+}
+
+func NewAccountHandler(service *AccountService, webhookSecret string) *AccountHandler {
+	return &AccountHandler{
+		service:       service,
+		webhookSecret: webhookSecret,
+		getUserId:     user.GetUserId,
+		now:           time.Now,
+	}
+}
+
+func (h *AccountHandler) writeErrorResponse(w http.ResponseWriter, r *http.Request, p problem.Problem) {
+	problem.Write(w, r, p)
+}
+
+func (h *AccountHandler) writeSuccessResponse(w http.ResponseWriter, statusCode int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *AccountHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	audit, err := h.service.DeleteAccount(r.Context(), userId, DeletionSourceApi)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete account", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Could not delete account"))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Account deleted", "itemsDeleted", audit.ItemsDeleted)
+	h.writeSuccessResponse(w, http.StatusOK, map[string]string{"message": "Successfully deleted account"})
+}
+
+func (h *AccountHandler) ClerkWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.webhookSecret == "" {
+		slog.ErrorContext(r.Context(), "Clerk webhook received but no webhook secret is configured")
+		h.writeErrorResponse(w, r, problem.New(http.StatusServiceUnavailable, problem.CodeUnavailable, "Webhook not configured"))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read webhook body", "error", err)
+		h.writeErrorResponse(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "Could not read body"))
+		return
+	}
+
+	err = verifyWebhook(h.webhookSecret, r.Header, body, h.now())
+	if err != nil {
+		slog.WarnContext(r.Context(), "Rejected Clerk webhook", "error", err)
+		h.writeErrorResponse(w, r, problem.New(http.StatusUnauthorized, problem.CodeInvalidSignature, "Invalid webhook signature"))
+		return
+	}
+
+	var event ClerkWebhookEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to parse JSON", "error", err)
+		h.writeErrorResponse(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "Could not parse JSON"))
+		return
+	}
+
+	// Clerk sends every subscribed event type here, only deletions need handling
+	if event.Type != "user.deleted" {
+		h.writeSuccessResponse(w, http.StatusOK, map[string]string{"message": "Event ignored"})
+		return
+	}
+
+	if event.Data.ID == "" {
+		slog.WarnContext(r.Context(), "user.deleted webhook without user ID")
+		h.writeErrorResponse(w, r, problem.Validation([]problem.InvalidParam{{Name: "data.id", Reason: "User ID is required"}}))
+		return
+	}
+
+	audit, err := h.service.DeleteAccount(r.Context(), event.Data.ID, DeletionSourceClerkWebhook)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete account", "error", err, "userId", event.Data.ID)
+		h.writeErrorResponse(w, r, problem.Internal("Could not delete account"))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Account deleted", "userId", event.Data.ID, "itemsDeleted", audit.ItemsDeleted)
+	h.writeSuccessResponse(w, http.StatusOK, map[string]string{"message": "Successfully deleted account"})
+}
@@ -0,0 +1,168 @@
+package account
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	testUserId        = "test-user-1"
+	testWebhookSecret = "whsec_dGVzdC1zZWNyZXQtdGVzdC1zZWNyZXQ="
+)
+
+var testNow = time.Unix(1700000000, 0)
+
+func setupHandler(t *testing.T) (*AccountHandler, *chi.Mux) {
+	t.Helper()
+
+	storage := setupTestDB(t)
+	service := NewAccountService(storage)
+	handler := &AccountHandler{
+		service:       service,
+		webhookSecret: testWebhookSecret,
+		getUserId: func(r *http.Request) (string, error) {
+			return testUserId, nil
+		},
+		now: func() time.Time { return testNow },
+	}
+
+	r := chi.NewRouter()
+	r.Delete("/me", handler.DeleteMe)
+	r.Post("/webhooks/clerk", handler.ClerkWebhook)
+
+	return handler, r
+}
+
+func signWebhook(t *testing.T, req *http.Request, id string, sentAt time.Time, body string) {
+	t.Helper()
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(testWebhookSecret, webhookSecretPrefix))
+	if err != nil {
+		t.Fatalf("failed to decode secret: %v", err)
+	}
+
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + timestamp + "." + body))
+
+	req.Header.Set("svix-id", id)
+	req.Header.Set("svix-timestamp", timestamp)
+	req.Header.Set("svix-signature", "v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func TestHandlerDeleteMe(t *testing.T) {
+	handler, router := setupHandler(t)
+	putItems(t, handler.service.storage, testUserId, 30)
+
+	req := httptest.NewRequest(http.MethodDelete, "/me", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if count := countItems(t, handler.service.storage, testUserId); count != 0 {
+		t.Errorf("expected 0 remaining items, got %d", count)
+	}
+
+	audits, err := handler.service.storage.GetAllDeletionAudits(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllDeletionAudits failed: %v", err)
+	}
+	if len(audits) != 1 || audits[0].Source != DeletionSourceApi || audits[0].ItemsDeleted != 30 {
+		t.Errorf("unexpected audits: %+v", audits)
+	}
+}
+
+func TestHandlerClerkWebhook(t *testing.T) {
+	handler, router := setupHandler(t)
+
+	t.Run("user.deleted erases data", func(t *testing.T) {
+		putItems(t, handler.service.storage, "user_clerk", 5)
+
+		body := `{"type":"user.deleted","object":"event","data":{"id":"user_clerk","deleted":true,"object":"user"}}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/clerk", strings.NewReader(body))
+		signWebhook(t, req, "msg_1", testNow, body)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if count := countItems(t, handler.service.storage, "user_clerk"); count != 0 {
+			t.Errorf("expected 0 remaining items, got %d", count)
+		}
+	})
+
+	t.Run("other events are ignored", func(t *testing.T) {
+		putItems(t, handler.service.storage, "user_other", 2)
+
+		body := `{"type":"user.updated","data":{"id":"user_other"}}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/clerk", strings.NewReader(body))
+		signWebhook(t, req, "msg_2", testNow, body)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if count := countItems(t, handler.service.storage, "user_other"); count != 2 {
+			t.Errorf("expected 2 remaining items, got %d", count)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		putItems(t, handler.service.storage, "user_forged", 2)
+
+		body := `{"type":"user.deleted","data":{"id":"user_forged"}}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/clerk", strings.NewReader(body))
+		signWebhook(t, req, "msg_3", testNow, `{"type":"user.updated"}`)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+		if count := countItems(t, handler.service.storage, "user_forged"); count != 2 {
+			t.Errorf("expected 2 remaining items, got %d", count)
+		}
+	})
+
+	t.Run("stale timestamp", func(t *testing.T) {
+		body := `{"type":"user.deleted","data":{"id":"user_stale"}}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/clerk", strings.NewReader(body))
+		signWebhook(t, req, "msg_4", testNow.Add(-time.Hour), body)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("missing headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/clerk", strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+}
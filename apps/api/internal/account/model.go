@@ -0,0 +1,26 @@
+package account
+
+type deletionAuditItem struct {
+	UserId string `json:"-" dynamodbav:"userId"` // Always auditPartition
+	ItemId string `json:"-" dynamodbav:"itemId"`
+	DeletionAuditModel
+}
+
+// DeletionAuditModel records that a user's data was erased. It is stored
+// outside the user's own partition so it survives the erasure.
+type DeletionAuditModel struct {
+	ID           string `json:"id" dynamodbav:"ID"`
+	UserId       string `json:"userId" dynamodbav:"DeletedUserId"`
+	Source       string `json:"source" dynamodbav:"Source"`
+	ItemsDeleted int    `json:"itemsDeleted" dynamodbav:"ItemsDeleted"`
+	DeletedAt    int64  `json:"deletedAt" dynamodbav:"DeletedAt"`
+}
+
+// ClerkWebhookEvent is the part of a Clerk webhook payload the API reads.
+type ClerkWebhookEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		ID      string `json:"id"`
+		Deleted bool   `json:"deleted"`
+	} `json:"data"`
+}
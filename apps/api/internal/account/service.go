@@ -0,0 +1,61 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DeletionSourceApi          = "api"
+	DeletionSourceClerkWebhook = "clerk-webhook"
+)
+
+// Eraser removes the items a package keeps about a user outside their
+// partition, which DeleteAllUserItems cannot find, and returns how many it
+// removed. It runs before the partition is deleted, so it can still read it.
+type Eraser interface {
+	EraseUser(ctx context.Context, userId string) (int, error)
+}
+
+type AccountService struct {
+	storage *AccountStorage
+	erasers []Eraser
+}
+
+func NewAccountService(storage *AccountStorage, erasers ...Eraser) *AccountService {
+	return &AccountService{
+		storage: storage,
+		erasers: erasers,
+	}
+}
+
+// DeleteAccount erases all of the user's items and records an audit entry
+// describing the erasure. It is safe to call more than once for a user.
+func (s *AccountService) DeleteAccount(ctx context.Context, userId, source string) (DeletionAuditModel, error) {
+	deleted := 0
+	for _, eraser := range s.erasers {
+		erased, err := eraser.EraseUser(ctx, userId)
+		if err != nil {
+			return DeletionAuditModel{}, err
+		}
+		deleted += erased
+	}
+
+	partition, err := s.storage.DeleteAllUserItems(ctx, userId)
+	if err != nil {
+		return DeletionAuditModel{}, err
+	}
+	deleted += partition
+
+	audit := DeletionAuditModel{
+		ID:           uuid.New().String(),
+		UserId:       userId,
+		Source:       source,
+		ItemsDeleted: deleted,
+		DeletedAt:    time.Now().Unix(),
+	}
+
+	return audit, s.storage.CreateDeletionAudit(ctx, audit)
+}
@@ -0,0 +1,46 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// eraser remembers how many items the user's partition had when it ran.
+type eraser struct {
+	t       *testing.T
+	storage *AccountStorage
+	seen    int
+	err     error
+}
+
+func (e *eraser) EraseUser(_ context.Context, userId string) (int, error) {
+	e.seen = countItems(e.t, e.storage, userId)
+	return 2, e.err
+}
+
+func TestServiceDeleteAccountErasers(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+	putItems(t, storage, testUserId, 3)
+
+	failing := &eraser{t: t, storage: storage, err: errors.New("erase failed")}
+	if _, err := NewAccountService(storage, failing).DeleteAccount(ctx, testUserId, DeletionSourceApi); err == nil {
+		t.Fatal("expected the eraser's error")
+	}
+	if count := countItems(t, storage, testUserId); count != 3 {
+		t.Errorf("expected the partition to be kept for a retry, got %d items", count)
+	}
+
+	ok := &eraser{t: t, storage: storage}
+	audit, err := NewAccountService(storage, ok).DeleteAccount(ctx, testUserId, DeletionSourceApi)
+	if err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	if ok.seen != 3 {
+		t.Errorf("expected the eraser to run before the partition was deleted, it saw %d items", ok.seen)
+	}
+	if audit.ItemsDeleted != 5 {
+		t.Errorf("expected the erased items to be counted, got %d", audit.ItemsDeleted)
+	}
+}
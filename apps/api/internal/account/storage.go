@@ -0,0 +1,171 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/config"
+)
+
+const (
+	auditPartition         = "audit"
+	itemPrefixDeletion     = "account-deletion#"
+	batchWriteLimit        = 25
+	maxUnprocessedAttempts = 5
+)
+
+var errUnprocessedItems = errors.New("batch write still has unprocessed items after retries")
+
+type AccountStorage struct {
+	db  *dynamodb.Client
+	cfg *config.Config
+}
+
+func NewAccountStorage(db *dynamodb.Client, cfg *config.Config) *AccountStorage {
+	return &AccountStorage{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// DeleteAllUserItems removes every item in the user's partition, one query
+// page at a time, and returns the number of items deleted.
+func (s *AccountStorage) DeleteAllUserItems(ctx context.Context, userId string) (int, error) {
+	deleted := 0
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		KeyConditionExpression: aws.String("userId = :userId"),
+		ProjectionExpression:   aws.String("userId, itemId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+		},
+	}
+
+	for {
+		result, err := s.db.Query(ctx, input)
+		if err != nil {
+			slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err, "userId", userId)
+			return deleted, err
+		}
+
+		for i := 0; i < len(result.Items); i += batchWriteLimit {
+			end := min(i+batchWriteLimit, len(result.Items))
+			requests := make([]types.WriteRequest, 0, end-i)
+			for _, key := range result.Items[i:end] {
+				requests = append(requests, types.WriteRequest{
+					DeleteRequest: &types.DeleteRequest{Key: key},
+				})
+			}
+
+			err = s.batchWrite(ctx, requests)
+			if err != nil {
+				return deleted, err
+			}
+			deleted += len(requests)
+		}
+
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	slog.InfoContext(ctx, "User items deleted", "userId", userId, "count", deleted)
+	return deleted, nil
+}
+
+// batchWrite sends a single BatchWriteItem request and retries any
+// unprocessed items with exponential backoff.
+func (s *AccountStorage) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	pending := map[string][]types.WriteRequest{s.cfg.TableName: requests}
+	backoff := 50 * time.Millisecond
+
+	for attempt := 1; ; attempt++ {
+		result, err := s.db.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: pending})
+		if err != nil {
+			slog.ErrorContext(ctx, "DynamoDB BatchWriteItem failed", "error", err, "table", s.cfg.TableName)
+			return err
+		}
+
+		if len(result.UnprocessedItems) == 0 {
+			return nil
+		}
+		if attempt == maxUnprocessedAttempts {
+			return errUnprocessedItems
+		}
+
+		pending = result.UnprocessedItems
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *AccountStorage) CreateDeletionAudit(ctx context.Context, audit DeletionAuditModel) error {
+	newItem := deletionAuditItem{
+		UserId:             auditPartition,
+		ItemId:             itemPrefixDeletion + audit.ID,
+		DeletionAuditModel: audit,
+	}
+
+	attributeValue, err := attributevalue.MarshalMap(newItem)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal deletion audit", "error", err)
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      attributeValue,
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "table", s.cfg.TableName)
+		return err
+	}
+
+	return nil
+}
+
+func (s *AccountStorage) GetAllDeletionAudits(ctx context.Context) ([]DeletionAuditModel, error) {
+	var items []deletionAuditItem
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: auditPartition},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixDeletion},
+		},
+	}
+
+	result, err := s.db.Query(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err)
+		return nil, err
+	}
+
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal deletion audits", "error", err)
+		return nil, err
+	}
+
+	audits := make([]DeletionAuditModel, len(items))
+	for i, item := range items {
+		audits[i] = item.DeletionAuditModel
+	}
+
+	return audits, nil
+}
@@ -0,0 +1,122 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-account"
+
+func setupTestDB(t *testing.T) *AccountStorage {
+	t.Helper()
+
+	return NewAccountStorage(dynamotest.NewTable(t, testTableName))
+}
+
+func putItems(t *testing.T, storage *AccountStorage, userId string, count int) {
+	t.Helper()
+
+	for i := range count {
+		_, err := storage.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+			TableName: aws.String(testTableName),
+			Item: map[string]types.AttributeValue{
+				"userId": &types.AttributeValueMemberS{Value: userId},
+				"itemId": &types.AttributeValueMemberS{Value: fmt.Sprintf("habit#%03d", i)},
+				"Name":   &types.AttributeValueMemberS{Value: "test"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to put item: %v", err)
+		}
+	}
+}
+
+func countItems(t *testing.T, storage *AccountStorage, userId string) int {
+	t.Helper()
+
+	result, err := storage.db.Query(context.Background(), &dynamodb.QueryInput{
+		TableName:              aws.String(testTableName),
+		KeyConditionExpression: aws.String("userId = :userId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to query items: %v", err)
+	}
+
+	return len(result.Items)
+}
+
+func TestStorageDeleteAllUserItems(t *testing.T) {
+	storage := setupTestDB(t)
+
+	t.Run("deletes more than one batch", func(t *testing.T) {
+		putItems(t, storage, "user-1", 60)
+		putItems(t, storage, "user-2", 3)
+
+		deleted, err := storage.DeleteAllUserItems(context.Background(), "user-1")
+		if err != nil {
+			t.Fatalf("DeleteAllUserItems failed: %v", err)
+		}
+		if deleted != 60 {
+			t.Errorf("expected 60 deleted items, got %d", deleted)
+		}
+		if count := countItems(t, storage, "user-1"); count != 0 {
+			t.Errorf("expected 0 remaining items for user-1, got %d", count)
+		}
+		if count := countItems(t, storage, "user-2"); count != 3 {
+			t.Errorf("expected 3 remaining items for user-2, got %d", count)
+		}
+	})
+
+	t.Run("empty partition", func(t *testing.T) {
+		deleted, err := storage.DeleteAllUserItems(context.Background(), "user-999")
+		if err != nil {
+			t.Fatalf("DeleteAllUserItems failed: %v", err)
+		}
+		if deleted != 0 {
+			t.Errorf("expected 0 deleted items, got %d", deleted)
+		}
+	})
+}
+
+func TestStorageCreateDeletionAudit(t *testing.T) {
+	storage := setupTestDB(t)
+
+	err := storage.CreateDeletionAudit(context.Background(), DeletionAuditModel{
+		ID:           "audit-1",
+		UserId:       "user-1",
+		Source:       DeletionSourceApi,
+		ItemsDeleted: 4,
+		DeletedAt:    1700000000,
+	})
+	if err != nil {
+		t.Fatalf("CreateDeletionAudit failed: %v", err)
+	}
+
+	audits, err := storage.GetAllDeletionAudits(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllDeletionAudits failed: %v", err)
+	}
+	if len(audits) != 1 {
+		t.Fatalf("expected 1 audit, got %d", len(audits))
+	}
+	if audits[0].UserId != "user-1" {
+		t.Errorf("expected userId %q, got %q", "user-1", audits[0].UserId)
+	}
+	if audits[0].ItemsDeleted != 4 {
+		t.Errorf("expected itemsDeleted %d, got %d", 4, audits[0].ItemsDeleted)
+	}
+
+	// The audit must not live in the deleted user's partition
+	if count := countItems(t, storage, "user-1"); count != 0 {
+		t.Errorf("expected audit outside user partition, found %d items", count)
+	}
+}
@@ -0,0 +1,70 @@
+package account
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clerk delivers webhooks through Svix, which signs "id.timestamp.body" with
+// HMAC-SHA256 using the base64 part of the "whsec_" secret.
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookTolerance    = 5 * time.Minute
+)
+
+var (
+	errMissingWebhookHeaders = errors.New("missing webhook signature headers")
+	errInvalidWebhookSecret  = errors.New("invalid webhook secret")
+	errWebhookTimestamp      = errors.New("webhook timestamp outside tolerance")
+	errWebhookSignature      = errors.New("no matching webhook signature")
+)
+
+func verifyWebhook(secret string, header http.Header, body []byte, now time.Time) error {
+	id := header.Get("svix-id")
+	timestamp := header.Get("svix-timestamp")
+	signatures := header.Get("svix-signature")
+	if id == "" || timestamp == "" || signatures == "" {
+		return errMissingWebhookHeaders
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, webhookSecretPrefix))
+	if err != nil || len(key) == 0 {
+		return errInvalidWebhookSecret
+	}
+
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errWebhookTimestamp
+	}
+	if delta := now.Sub(time.Unix(sentAt, 0)); delta > webhookTolerance || delta < -webhookTolerance {
+		return errWebhookTimestamp
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	// The header holds space separated "version,signature" pairs
+	for _, versioned := range strings.Fields(signatures) {
+		version, signature, ok := strings.Cut(versioned, ",")
+		if !ok || version != "v1" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(signature)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+
+	return errWebhookSignature
+}
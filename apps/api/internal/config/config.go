@@ -1,28 +1,344 @@
 package config
 
 import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
+// FileEnv names the environment variable pointing at an optional YAML or
+// TOML config file.
+const FileEnv = "CONFIG_FILE"
+
+// Config holds every setting read at startup. The env tag names the
+// environment variable setting a field; config files use the lower case name.
+// List fields are comma separated in the environment.
 type Config struct {
-	TABLE_NAME   string
-	CLERK_SECRET string
+	Environment        string   `env:"ENVIRONMENT" yaml:"environment" toml:"environment"`
+	TableName          string   `env:"TABLE_NAME" yaml:"table_name" toml:"table_name"`
+	ClerkSecret        string   `env:"CLERK_SECRET" yaml:"clerk_secret" toml:"clerk_secret"`
+	ClerkWebhookSecret string   `env:"CLERK_WEBHOOK_SECRET" yaml:"clerk_webhook_secret" toml:"clerk_webhook_secret"`
+	LogLevel           string   `env:"LOG_LEVEL" yaml:"log_level" toml:"log_level"`
+	TraceExporter      string   `env:"TRACE_EXPORTER" yaml:"trace_exporter" toml:"trace_exporter"`
+	Port               string   `env:"PORT" yaml:"port" toml:"port"`
+	DynamoDBEndpoint   string   `env:"DYNAMODB_ENDPOINT" yaml:"dynamodb_endpoint" toml:"dynamodb_endpoint"`
+	DynamoDBRegion     string   `env:"DYNAMODB_REGION" yaml:"dynamodb_region" toml:"dynamodb_region"`
+	CORSOrigins        []string `env:"CORS_ORIGINS" yaml:"cors_origins" toml:"cors_origins"`
+	CORSHeaders        []string `env:"CORS_HEADERS" yaml:"cors_headers" toml:"cors_headers"`
+	CORSMethods        []string `env:"CORS_METHODS" yaml:"cors_methods" toml:"cors_methods"`
+	CORSMaxAge         int      `env:"CORS_MAX_AGE" yaml:"cors_max_age" toml:"cors_max_age"`
+	RateLimits         []string `env:"RATE_LIMITS" yaml:"rate_limits" toml:"rate_limits"`
+	RateLimitBackend   string   `env:"RATE_LIMIT_BACKEND" yaml:"rate_limit_backend" toml:"rate_limit_backend"`
+	Features           []string `env:"FEATURES" yaml:"features" toml:"features"`
+	ReminderWebhookURL string   `env:"REMINDER_WEBHOOK_URL" yaml:"reminder_webhook_url" toml:"reminder_webhook_url"`
+	VAPIDPublicKey     string   `env:"VAPID_PUBLIC_KEY" yaml:"vapid_public_key" toml:"vapid_public_key"`
+	VAPIDPrivateKey    string   `env:"VAPID_PRIVATE_KEY" yaml:"vapid_private_key" toml:"vapid_private_key"`
+	VAPIDSubject       string   `env:"VAPID_SUBJECT" yaml:"vapid_subject" toml:"vapid_subject"`
+}
+
+const (
+	EnvironmentLocal = "local"
+	EnvironmentDev   = "dev"
+	EnvironmentProd  = "prod"
+)
+
+// corsOrigins are the origins allowed when CORS_ORIGINS is not set. Preview
+// deployments of the web app are served from subdomains of the dev domain.
+var corsOrigins = map[string][]string{
+	EnvironmentLocal: {"http://localhost:3000", "http://127.0.0.1:3000"},
+	EnvironmentDev:   {"https://dev.sidekick.jimvid.xyz", "https://*.dev.sidekick.jimvid.xyz"},
+	EnvironmentProd:  {"https://sidekick.jimvid.xyz"},
 }
 
-var AppConfig *Config
+const (
+	RateLimitBackendNone     = "none"
+	RateLimitBackendMemory   = "memory"
+	RateLimitBackendDynamoDB = "dynamodb"
+)
+
+// Features that can be turned off by leaving them out of FEATURES.
+const (
+	FeatureReminders = "reminders"
+	FeaturePush      = "push"
+	FeatureWebhooks  = "webhooks"
+)
+
+var (
+	features  = []string{FeatureReminders, FeaturePush, FeatureWebhooks}
+	logLevels = []string{"debug", "info", "warn", "error"}
+)
 
-func NewConfig() *Config {
+var corsMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// Default returns the configuration used for anything not set by a file or
+// the environment. CORS_ORIGINS depends on ENVIRONMENT and is filled in by
+// Load.
+func Default() *Config {
 	return &Config{
-		TABLE_NAME:   MustGetEnv("TABLE_NAME"),
-		CLERK_SECRET: MustGetEnv("CLERK_SECRET"),
+		Environment:      EnvironmentLocal,
+		LogLevel:         "info",
+		TraceExporter:    "none",
+		Port:             "8080",
+		CORSHeaders:      []string{"Authorization", "Content-Type", "traceparent", "tracestate"},
+		CORSMethods:      []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		CORSMaxAge:       300,
+		RateLimits:       []string{"*=120/m", "POST /habit-logs=30/m"},
+		RateLimitBackend: RateLimitBackendMemory,
+		Features:         slices.Clone(features),
+	}
+}
+
+// Load builds the configuration from defaults, the optional file named by
+// CONFIG_FILE and then environment variables, and validates the result.
+func Load() (*Config, error) {
+	return load(os.LookupEnv)
+}
+
+func load(lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	if path, ok := lookupEnv(FileEnv); ok && path != "" {
+		err := cfg.loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	errs := cfg.loadEnv(lookupEnv)
+
+	if cfg.CORSOrigins == nil {
+		cfg.CORSOrigins = corsOrigins[cfg.Environment]
 	}
+
+	err := cfg.Validate()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return cfg, nil
 }
 
-func MustGetEnv(key string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		panic(fmt.Sprintf("Environment variable %s is required", key))
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(c)
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), c)
+		if err == nil && len(meta.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown keys %v", meta.Undecoded())
+		}
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
 	}
-	return value
+
+	return nil
+}
+
+func (c *Config) loadEnv(lookupEnv func(string) (string, bool)) []error {
+	var errs []error
+
+	v := reflect.ValueOf(c).Elem()
+	for i := range v.NumField() {
+		name := v.Type().Field(i).Tag.Get("env")
+		// Set but empty still counts, so FEATURES= turns every feature off
+		value, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		switch field := v.Field(i).Addr().Interface().(type) {
+		case *string:
+			*field = value
+		case *[]string:
+			*field = splitList(value)
+		case *int:
+			if value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %q must be a whole number", name, value))
+				continue
+			}
+			*field = n
+		}
+	}
+
+	return errs
+}
+
+// splitList returns an empty, not nil, list for an empty value, which tells
+// an explicitly empty setting apart from one left unset.
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Validate checks every setting and reports all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if _, ok := corsOrigins[c.Environment]; !ok {
+		errs = append(errs, fmt.Errorf("ENVIRONMENT %q must be local, dev or prod", c.Environment))
+	}
+
+	if c.TableName == "" {
+		errs = append(errs, errors.New("TABLE_NAME is required"))
+	}
+	if c.ClerkSecret == "" {
+		errs = append(errs, errors.New("CLERK_SECRET is required"))
+	}
+
+	if !slices.Contains(logLevels, strings.ToLower(strings.TrimSpace(c.LogLevel))) {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL %q must be debug, info, warn or error", c.LogLevel))
+	}
+
+	if !slices.Contains([]string{"none", "stdout", "otlp"}, c.TraceExporter) {
+		errs = append(errs, fmt.Errorf("TRACE_EXPORTER %q must be none, stdout or otlp", c.TraceExporter))
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	if err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT %q must be a number between 1 and 65535", c.Port))
+	}
+
+	if c.DynamoDBEndpoint != "" {
+		endpoint, err := url.Parse(c.DynamoDBEndpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			errs = append(errs, fmt.Errorf("DYNAMODB_ENDPOINT %q must be an http or https URL", c.DynamoDBEndpoint))
+		}
+	}
+
+	if len(c.CORSOrigins) == 0 {
+		errs = append(errs, errors.New("CORS_ORIGINS must list at least one origin"))
+	}
+	for _, origin := range c.CORSOrigins {
+		err := validateOrigin(origin)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, header := range c.CORSHeaders {
+		if header == "*" {
+			errs = append(errs, errors.New("CORS_HEADERS must list headers explicitly, not *"))
+		}
+	}
+	for _, method := range c.CORSMethods {
+		if !slices.Contains(corsMethods, method) {
+			errs = append(errs, fmt.Errorf("CORS_METHODS entry %q must be one of %s", method, strings.Join(corsMethods, ", ")))
+		}
+	}
+	if c.CORSMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("CORS_MAX_AGE %d must not be negative", c.CORSMaxAge))
+	}
+
+	if !slices.Contains([]string{RateLimitBackendNone, RateLimitBackendMemory, RateLimitBackendDynamoDB}, c.RateLimitBackend) {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_BACKEND %q must be none, memory or dynamodb", c.RateLimitBackend))
+	}
+
+	for _, feature := range c.Features {
+		if !slices.Contains(features, feature) {
+			errs = append(errs, fmt.Errorf("FEATURES entry %q must be one of %s", feature, strings.Join(features, ", ")))
+		}
+	}
+
+	if c.ReminderWebhookURL != "" {
+		webhook, err := url.Parse(c.ReminderWebhookURL)
+		if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+			errs = append(errs, fmt.Errorf("REMINDER_WEBHOOK_URL %q must be an http or https URL", c.ReminderWebhookURL))
+		}
+	}
+
+	if c.VAPIDPublicKey != "" || c.VAPIDPrivateKey != "" {
+		err := validateVAPID(c.VAPIDPublicKey, c.VAPIDPrivateKey)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if !strings.HasPrefix(c.VAPIDSubject, "mailto:") && !strings.HasPrefix(c.VAPIDSubject, "https://") {
+			errs = append(errs, fmt.Errorf("VAPID_SUBJECT %q must be a mailto: or https: URL", c.VAPIDSubject))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateVAPID checks the keys are a base64url P-256 key pair.
+func validateVAPID(publicKey, privateKey string) error {
+	d, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(privateKey, "="))
+	if err != nil {
+		return errors.New("VAPID_PRIVATE_KEY must be a base64url P-256 private key")
+	}
+	key, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return errors.New("VAPID_PRIVATE_KEY must be a base64url P-256 private key")
+	}
+	public, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(publicKey, "="))
+	if err != nil || !bytes.Equal(public, key.PublicKey().Bytes()) {
+		return errors.New("VAPID_PUBLIC_KEY must be the public key of VAPID_PRIVATE_KEY")
+	}
+	return nil
+}
+
+// PushEnabled reports whether push is turned on and VAPID keys are set to
+// send Web Push with.
+func (c *Config) PushEnabled() bool {
+	return c.FeatureEnabled(FeaturePush) && c.VAPIDPrivateKey != ""
+}
+
+// validateOrigin accepts a scheme and host with at most one * standing in for
+// part of the host, such as https://*.dev.sidekick.jimvid.xyz.
+func validateOrigin(origin string) error {
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || (scheme != "http" && scheme != "https") || host == "" {
+		return fmt.Errorf("CORS_ORIGINS entry %q must start with http:// or https://", origin)
+	}
+	if strings.Contains(host, "/") {
+		return fmt.Errorf("CORS_ORIGINS entry %q must not contain a path", origin)
+	}
+	if strings.Count(host, "*") > 1 || host == "*" {
+		return fmt.Errorf("CORS_ORIGINS entry %q may only use * for part of the host", origin)
+	}
+	return nil
+}
+
+// InLambda reports whether the process runs in AWS Lambda, which freezes it
+// between invocations, so nothing may be left running in the background.
+func InLambda() bool {
+	return os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != ""
+}
+
+// FeatureEnabled reports whether the named feature is listed in FEATURES.
+func (c *Config) FeatureEnabled(name string) bool {
+	return slices.Contains(c.Features, name)
 }
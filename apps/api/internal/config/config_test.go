@@ -0,0 +1,251 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadDefaultsAndEnv(t *testing.T) {
+	cfg, err := load(envLookup(map[string]string{
+		"TABLE_NAME":   "sidekick",
+		"CLERK_SECRET": "sk_test_abc",
+		"CORS_ORIGINS": "https://a.example.com, https://b.example.com",
+		"FEATURES":     "reminders,webhooks",
+	}))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if cfg.TableName != "sidekick" || cfg.LogLevel != "info" || cfg.Port != "8080" || cfg.TraceExporter != "none" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if !slices.Equal(cfg.CORSOrigins, []string{"https://a.example.com", "https://b.example.com"}) {
+		t.Errorf("unexpected CORS_ORIGINS %v", cfg.CORSOrigins)
+	}
+	if !cfg.FeatureEnabled(FeatureReminders) || !cfg.FeatureEnabled(FeatureWebhooks) || cfg.FeatureEnabled(FeaturePush) {
+		t.Errorf("unexpected feature flags %v", cfg.Features)
+	}
+}
+
+func TestLoadEmptyEnv(t *testing.T) {
+	cfg, err := load(envLookup(map[string]string{
+		"TABLE_NAME":    "sidekick",
+		"CLERK_SECRET":  "sk_test_abc",
+		"FEATURES":      "",
+		"RATE_LIMITS":   "",
+		"CORS_MAX_AGE":  "",
+		"VAPID_SUBJECT": "",
+	}))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if len(cfg.Features) != 0 || len(cfg.RateLimits) != 0 {
+		t.Errorf("expected empty FEATURES and RATE_LIMITS to clear the defaults, got %v and %v", cfg.Features, cfg.RateLimits)
+	}
+	if cfg.CORSMaxAge != 300 {
+		t.Errorf("expected an empty CORS_MAX_AGE to keep the default, got %d", cfg.CORSMaxAge)
+	}
+
+	_, err = load(envLookup(map[string]string{
+		"TABLE_NAME":   "sidekick",
+		"CLERK_SECRET": "sk_test_abc",
+		"CORS_ORIGINS": "",
+	}))
+	if err == nil || !strings.Contains(err.Error(), "CORS_ORIGINS must list at least one origin") {
+		t.Errorf("expected an empty CORS_ORIGINS to be rejected, got %v", err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `
+table_name: from-file
+clerk_secret: sk_test_file
+log_level: debug
+dynamodb_endpoint: http://localhost:8000
+cors_origins:
+  - http://localhost:3000
+`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `
+table_name = "from-file"
+clerk_secret = "sk_test_file"
+log_level = "debug"
+dynamodb_endpoint = "http://localhost:8000"
+cors_origins = ["http://localhost:3000"]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.file, tt.content)
+
+			cfg, err := load(envLookup(map[string]string{
+				FileEnv:     path,
+				"LOG_LEVEL": "warn",
+			}))
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+
+			if cfg.TableName != "from-file" || cfg.DynamoDBEndpoint != "http://localhost:8000" {
+				t.Errorf("expected values from file, got %+v", cfg)
+			}
+			if cfg.LogLevel != "warn" {
+				t.Errorf("expected environment to override file, got LOG_LEVEL %q", cfg.LogLevel)
+			}
+			if cfg.Port != "8080" {
+				t.Errorf("expected default PORT, got %q", cfg.Port)
+			}
+		})
+	}
+}
+
+func TestLoadFileUnknownKey(t *testing.T) {
+	path := writeFile(t, "config.yaml", "table_nmae: typo\n")
+
+	_, err := load(envLookup(map[string]string{FileEnv: path}))
+	if err == nil || !strings.Contains(err.Error(), "table_nmae") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestValidateAggregatesErrors(t *testing.T) {
+	_, err := load(envLookup(map[string]string{
+		"LOG_LEVEL":            "loud",
+		"PORT":                 "http",
+		"DYNAMODB_ENDPOINT":    "localhost:8000",
+		"CORS_ORIGINS":         "example.com",
+		"REMINDER_WEBHOOK_URL": "hooks.example.com",
+		"FEATURES":             "heatmap",
+	}))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	for _, want := range []string{"TABLE_NAME", "CLERK_SECRET", "LOG_LEVEL", "PORT", "DYNAMODB_ENDPOINT", "CORS_ORIGINS", "REMINDER_WEBHOOK_URL", "FEATURES"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %s, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestCORSDefaultsPerEnvironment(t *testing.T) {
+	for env, want := range corsOrigins {
+		t.Run(env, func(t *testing.T) {
+			cfg, err := load(envLookup(map[string]string{
+				"ENVIRONMENT":  env,
+				"TABLE_NAME":   "sidekick",
+				"CLERK_SECRET": "sk_test_abc",
+			}))
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if !slices.Equal(cfg.CORSOrigins, want) {
+				t.Errorf("expected origins %v, got %v", want, cfg.CORSOrigins)
+			}
+		})
+	}
+}
+
+func TestValidateOrigin(t *testing.T) {
+	tests := []struct {
+		origin string
+		valid  bool
+	}{
+		{origin: "https://sidekick.jimvid.xyz", valid: true},
+		{origin: "http://localhost:3000", valid: true},
+		{origin: "https://*.dev.sidekick.jimvid.xyz", valid: true},
+		{origin: "*", valid: false},
+		{origin: "https://*", valid: false},
+		{origin: "https://*.*.example.com", valid: false},
+		{origin: "https://example.com/app", valid: false},
+		{origin: "example.com", valid: false},
+	}
+
+	for _, tt := range tests {
+		err := validateOrigin(tt.origin)
+		if tt.valid && err != nil {
+			t.Errorf("expected %q to be valid, got %v", tt.origin, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("expected %q to be rejected", tt.origin)
+		}
+	}
+}
+
+func TestValidateVAPID(t *testing.T) {
+	const (
+		publicKey  = "BJTqdgjYqRtNT8ej7WCNSUZf4cHvsdkXwHxdQhwVlmZq3iV16d8pxetuUZUQ93AbHjR5X7NSahHsZvfxfJOFPZ8"
+		privateKey = "hbDQpO0_ZJgpMv2jYNTYgPQ4rWolhVbynrBpjxQpBRU"
+		otherKey   = "BH_C7s7w6v8YvHGqACxpvmmBBLRHbFURJpyC1zZIheNSWHs49CITkdB1QAHCP7xzgq7isSJTQGrr5qMc8kdn4iM"
+	)
+	base := map[string]string{"TABLE_NAME": "sidekick", "CLERK_SECRET": "sk_test_abc"}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "key pair", env: map[string]string{"VAPID_PUBLIC_KEY": publicKey, "VAPID_PRIVATE_KEY": privateKey, "VAPID_SUBJECT": "mailto:ops@sidekick.example"}},
+		{name: "keys of different pairs", env: map[string]string{"VAPID_PUBLIC_KEY": otherKey, "VAPID_PRIVATE_KEY": privateKey, "VAPID_SUBJECT": "mailto:ops@sidekick.example"}, want: "VAPID_PUBLIC_KEY"},
+		{name: "malformed private key", env: map[string]string{"VAPID_PUBLIC_KEY": publicKey, "VAPID_PRIVATE_KEY": "secret", "VAPID_SUBJECT": "mailto:ops@sidekick.example"}, want: "VAPID_PRIVATE_KEY"},
+		{name: "missing subject", env: map[string]string{"VAPID_PUBLIC_KEY": publicKey, "VAPID_PRIVATE_KEY": privateKey}, want: "VAPID_SUBJECT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{}
+			for k, v := range base {
+				env[k] = v
+			}
+			for k, v := range tt.env {
+				env[k] = v
+			}
+
+			cfg, err := load(envLookup(env))
+			if tt.want == "" {
+				if err != nil || !cfg.PushEnabled() {
+					t.Fatalf("expected push to be enabled, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error to mention %s, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -1,13 +1,32 @@
 package database
 
 import (
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 )
 
-func NewDynamoDB() *dynamodb.DynamoDB {
-	dbSession := session.Must(session.NewSession())
-	db := dynamodb.New(dbSession)
+func NewDynamoDB(optFns ...func(*dynamodb.Options)) *dynamodb.Client {
+	awsCfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load AWS config: %v", err))
+	}
+
+	return dynamodb.NewFromConfig(awsCfg, optFns...)
+}
 
-	return db
+// WithOverrides points the client at a specific region and endpoint, such as
+// DynamoDB Local. Empty values keep the SDK defaults.
+func WithOverrides(region, endpoint string) func(*dynamodb.Options) {
+	return func(o *dynamodb.Options) {
+		if region != "" {
+			o.Region = region
+		}
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	}
 }
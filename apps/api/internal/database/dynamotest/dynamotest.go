@@ -0,0 +1,107 @@
+// Package dynamotest creates the Sidekick table in the DynamoDB Local that
+// tests run against, with the keys and indexes the table is deployed with in
+// cdk/lib/constructs/api-with-dynamo.ts.
+package dynamotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/config"
+)
+
+// Endpoint is where DynamoDB Local listens.
+const Endpoint = "http://localhost:8000"
+
+// Indexes are the table's global secondary indexes. The names are those of
+// habits.IndexByDate, reminders.IndexReminders, webhooks.IndexRetries and
+// events.IndexOutbox, which cannot be imported here as their tests use this
+// package.
+var Indexes = []types.GlobalSecondaryIndex{
+	{
+		IndexName: aws.String("byDate"),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("userId"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("Date"), KeyType: types.KeyTypeRange},
+		},
+		Projection: &types.Projection{
+			ProjectionType:   types.ProjectionTypeInclude,
+			NonKeyAttributes: []string{"HabitId"},
+		},
+	},
+	{
+		IndexName: aws.String("byReminder"),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("ReminderIndex"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("itemId"), KeyType: types.KeyTypeRange},
+		},
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	},
+	{
+		IndexName: aws.String("byRetry"),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("RetryIndex"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("NextAttemptAt"), KeyType: types.KeyTypeRange},
+		},
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	},
+	{
+		IndexName: aws.String("byOutbox"),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("OutboxIndex"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("CreatedAt"), KeyType: types.KeyTypeRange},
+		},
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	},
+}
+
+// NewClient returns a client for DynamoDB Local.
+func NewClient() *dynamodb.Client {
+	return dynamodb.New(dynamodb.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(Endpoint),
+		Credentials:  credentials.NewStaticCredentialsProvider("fake", "fake", ""),
+	})
+}
+
+// NewTable creates the table as name and deletes it when the test ends.
+// Packages use their own name, so their tests can run at the same time.
+func NewTable(t testing.TB, name string) (*dynamodb.Client, *config.Config) {
+	t.Helper()
+
+	db := NewClient()
+	_, err := db.CreateTable(context.Background(), &dynamodb.CreateTableInput{
+		TableName: aws.String(name),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("userId"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("itemId"), KeyType: types.KeyTypeRange},
+		},
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("userId"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("itemId"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("Date"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("ReminderIndex"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("RetryIndex"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("NextAttemptAt"), AttributeType: types.ScalarAttributeTypeN},
+			{AttributeName: aws.String("OutboxIndex"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("CreatedAt"), AttributeType: types.ScalarAttributeTypeN},
+		},
+		GlobalSecondaryIndexes: Indexes,
+		BillingMode:            types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		t.Fatalf("failed to create test table %s: %v", name, err)
+	}
+
+	t.Cleanup(func() {
+		db.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{
+			TableName: aws.String(name),
+		})
+	})
+
+	return db, &config.Config{TableName: name}
+}
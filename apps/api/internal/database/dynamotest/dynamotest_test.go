@@ -0,0 +1,60 @@
+package dynamotest_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+	"github.com/jimvid/sidekick/internal/events"
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/reminders"
+	"github.com/jimvid/sidekick/internal/webhooks"
+)
+
+const construct = "../../../../../cdk/lib/constructs/api-with-dynamo.ts"
+
+var cdkIndex = regexp.MustCompile(`indexName: "(\w+)",\s*partitionKey: {\s*name: "(\w+)",[^}]*},\s*sortKey: {\s*name: "(\w+)"`)
+
+// TestIndexesMatchCDK fails when an index is added to or changed in the CDK
+// table without updating Indexes.
+func TestIndexesMatchCDK(t *testing.T) {
+	source, err := os.ReadFile(construct)
+	if err != nil {
+		t.Fatalf("failed to read the CDK construct: %v", err)
+	}
+
+	deployed := map[string][2]string{}
+	for _, match := range cdkIndex.FindAllStringSubmatch(string(source), -1) {
+		deployed[match[1]] = [2]string{match[2], match[3]}
+	}
+	if len(deployed) == 0 {
+		t.Fatal("found no indexes in the CDK construct")
+	}
+
+	tested := map[string][2]string{}
+	for _, index := range dynamotest.Indexes {
+		tested[aws.ToString(index.IndexName)] = [2]string{
+			aws.ToString(index.KeySchema[0].AttributeName),
+			aws.ToString(index.KeySchema[1].AttributeName),
+		}
+	}
+
+	for name, keys := range deployed {
+		if tested[name] != keys {
+			t.Errorf("index %s is deployed with keys %v, tests create %v", name, keys, tested[name])
+		}
+	}
+	for name := range tested {
+		if _, ok := deployed[name]; !ok {
+			t.Errorf("tests create index %s, which is not deployed", name)
+		}
+	}
+
+	for _, name := range []string{habits.IndexByDate, reminders.IndexReminders, webhooks.IndexRetries, events.IndexOutbox} {
+		if _, ok := tested[name]; !ok {
+			t.Errorf("storage queries index %s, which tests do not create", name)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"sync"
+)
+
+// IndexShards is how many partitions a sparse index shared by every user is
+// spread over, so that its writes do not all land on one partition.
+const IndexShards = 8
+
+// ShardKey returns key with a suffix from #0 to #IndexShards-1, picked by
+// hashing id so an item keeps its shard when it is written again.
+func ShardKey(key, id string) string {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return key + "#" + strconv.Itoa(int(h.Sum32()%IndexShards))
+}
+
+// ShardKeys returns every shard of key. The bare key comes first, items
+// written before the index was sharded still hold it.
+func ShardKeys(key string) []string {
+	keys := []string{key}
+	for shard := range IndexShards {
+		keys = append(keys, key+"#"+strconv.Itoa(shard))
+	}
+	return keys
+}
+
+// QueryShards runs query for every shard of key in parallel and returns the
+// results in the order of ShardKeys.
+func QueryShards[T any](ctx context.Context, key string, query func(ctx context.Context, shardKey string) ([]T, error)) ([]T, error) {
+	keys := ShardKeys(key)
+	results := make([][]T, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, shardKey := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = query(ctx, shardKey)
+		}()
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []T
+	for _, result := range results {
+		all = append(all, result...)
+	}
+	return all, nil
+}
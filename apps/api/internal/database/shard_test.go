@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestShardKey(t *testing.T) {
+	keys := ShardKeys("reminder")
+	if len(keys) != IndexShards+1 || keys[0] != "reminder" {
+		t.Fatalf("unexpected shard keys %v", keys)
+	}
+
+	used := map[string]bool{}
+	for i := range 100 {
+		id := "item-" + strconv.Itoa(i)
+		key := ShardKey("reminder", id)
+		if key != ShardKey("reminder", id) {
+			t.Fatalf("expected %s to keep its shard", id)
+		}
+		if !slices.Contains(keys[1:], key) {
+			t.Fatalf("unexpected shard key %q", key)
+		}
+		used[key] = true
+	}
+	if len(used) < IndexShards/2 {
+		t.Errorf("expected items to spread over the shards, got %d used", len(used))
+	}
+}
+
+func TestQueryShards(t *testing.T) {
+	ctx := context.Background()
+
+	items, err := QueryShards(ctx, "outbox", func(ctx context.Context, shardKey string) ([]string, error) {
+		return []string{shardKey}, nil
+	})
+	if err != nil {
+		t.Fatalf("QueryShards failed: %v", err)
+	}
+	if !slices.Equal(items, ShardKeys("outbox")) {
+		t.Errorf("expected one result per shard in order, got %v", items)
+	}
+
+	failed := errors.New("query failed")
+	_, err = QueryShards(ctx, "outbox", func(ctx context.Context, shardKey string) ([]string, error) {
+		if shardKey == "outbox#3" {
+			return nil, failed
+		}
+		return []string{shardKey}, nil
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("expected the failed shard's error, got %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+// Package events publishes domain events, like a habit being created, to
+// the subscribers that act on them, such as webhooks and the audit log.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/jimvid/sidekick/internal/tracing"
+)
+
+// AllEvents subscribes to every event type.
+const AllEvents = "*"
+
+// Event is a typed domain event. It is encoded as JSON in the outbox and by
+// subscribers like webhooks, so its fields need json tags.
+type Event interface {
+	EventType() string
+}
+
+// Envelope is a published event with what is known about it.
+type Envelope struct {
+	// ID is unique per event and the same when the outbox relays it, so
+	// subscribers can drop duplicates.
+	ID         string
+	Type       string
+	UserId     string
+	OccurredAt time.Time
+	Event      Event
+
+	// async names the asynchronous subscribers Prepare chose, which
+	// Publish starts.
+	async []string
+}
+
+// Handler acts on an event. Events are published after the change is
+// saved, so an error never undoes it.
+type Handler func(ctx context.Context, env Envelope) error
+
+// Wants reports whether an asynchronous subscriber has anything to do for
+// the user's event, so events nobody acts on are not written to the outbox.
+type Wants func(ctx context.Context, userId, eventType string) (bool, error)
+
+type asyncSubscriber struct {
+	name      string
+	eventType string
+	wants     Wants
+	handler   Handler
+}
+
+// Bus runs synchronous subscribers before Publish returns and asynchronous
+// ones in the background. Asynchronous subscribers are recorded in the
+// outbox with the change, see Prepare, so Relay runs them again if the
+// process stops before they finish.
+type Bus struct {
+	// RelayOnly leaves asynchronous subscribers to Relay instead of
+	// starting them in Publish, for runtimes like Lambda that freeze the
+	// process as soon as the request is answered.
+	RelayOnly bool
+
+	mu    sync.RWMutex
+	sync  map[string][]Handler
+	async []asyncSubscriber
+	types map[string]reflect.Type
+
+	// outbox is nil when asynchronous subscribers are best effort.
+	outbox  *OutboxStorage
+	running sync.WaitGroup
+	now     func() time.Time
+}
+
+func NewBus(outbox *OutboxStorage) *Bus {
+	return &Bus{
+		sync:   map[string][]Handler{},
+		types:  map[string]reflect.Type{},
+		outbox: outbox,
+		now:    time.Now,
+	}
+}
+
+// Register makes event types decodable, which Relay needs to run them from
+// the outbox.
+func (b *Bus) Register(events ...Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, event := range events {
+		b.types[event.EventType()] = reflect.TypeOf(event)
+	}
+}
+
+// Subscribe runs handler for eventType, or AllEvents, before Publish
+// returns. Keep it quick, it runs while the request waits.
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sync[eventType] = append(b.sync[eventType], handler)
+}
+
+// SubscribeAsync runs handler for eventType, or AllEvents, in the
+// background. The name identifies the subscriber in the outbox, so it must
+// not change between deploys while records are pending.
+func (b *Bus) SubscribeAsync(name, eventType string, handler Handler) {
+	b.SubscribeAsyncWhen(name, eventType, nil, handler)
+}
+
+// SubscribeAsyncWhen is SubscribeAsync for the users' events wants reports
+// true for. A nil wants runs handler for every user.
+func (b *Bus) SubscribeAsyncWhen(name, eventType string, wants Wants, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subscriber := range b.async {
+		if subscriber.name == name {
+			panic(fmt.Sprintf("events: async subscriber %q registered twice", name))
+		}
+	}
+	b.async = append(b.async, asyncSubscriber{name: name, eventType: eventType, wants: wants, handler: handler})
+}
+
+func (b *Bus) subscribers(eventType string) ([]Handler, []asyncSubscriber) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	handlers := append(append([]Handler(nil), b.sync[eventType]...), b.sync[AllEvents]...)
+
+	var async []asyncSubscriber
+	for _, subscriber := range b.async {
+		if subscriber.eventType == eventType || subscriber.eventType == AllEvents {
+			async = append(async, subscriber)
+		}
+	}
+	return handlers, async
+}
+
+// Prepare makes the envelope of an event and the outbox records of the
+// asynchronous subscribers wanting it, as writes for the transaction saving
+// the change that causes it. The event is then recorded exactly when the
+// change is. Publish the envelope once the transaction succeeds.
+func (b *Bus) Prepare(ctx context.Context, userId string, event Event) (Envelope, []types.TransactWriteItem, error) {
+	env := Envelope{
+		ID:         uuid.Must(uuid.NewV7()).String(),
+		Type:       event.EventType(),
+		UserId:     userId,
+		OccurredAt: b.now(),
+		Event:      event,
+	}
+
+	_, subscribers := b.subscribers(env.Type)
+	var async []asyncSubscriber
+	for _, subscriber := range subscribers {
+		if subscriber.wants != nil {
+			wanted, err := subscriber.wants(ctx, userId, env.Type)
+			if err != nil {
+				// Recording an event nobody wants only costs a write
+				slog.WarnContext(ctx, "Could not tell if the subscriber wants the event", "error", err, "subscriber", subscriber.name, "event", env.Type)
+			} else if !wanted {
+				continue
+			}
+		}
+		async = append(async, subscriber)
+		env.async = append(env.async, subscriber.name)
+	}
+	if b.outbox == nil || len(async) == 0 {
+		return env, nil, nil
+	}
+
+	data, err := json.Marshal(env.Event)
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+
+	records := make([]OutboxRecord, len(async))
+	for i, subscriber := range async {
+		records[i] = OutboxRecord{
+			EventId:    env.ID,
+			Type:       env.Type,
+			Subscriber: subscriber.name,
+			Data:       string(data),
+			OccurredAt: env.OccurredAt.UnixMilli(),
+			CreatedAt:  b.now().Unix(),
+		}
+	}
+
+	items, err := b.outbox.RecordItems(userId, records)
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+	return env, items, nil
+}
+
+// Publish runs the synchronous subscribers of a prepared event and starts
+// the asynchronous ones Prepare chose, unless RelayOnly is set. It returns
+// the errors of the synchronous ones.
+func (b *Bus) Publish(ctx context.Context, env Envelope) error {
+	ctx, span := tracing.Tracer().Start(ctx, "Bus.Publish")
+	defer span.End()
+
+	handlers, _ := b.subscribers(env.Type)
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, env); err != nil {
+			slog.ErrorContext(ctx, "Event subscriber failed", "error", err, "event", env.Type, "eventId", env.ID)
+			errs = append(errs, err)
+		}
+	}
+
+	// The request may end before they do, so they must not be canceled with it
+	if b.RelayOnly {
+		return errors.Join(errs...)
+	}
+	background := context.WithoutCancel(ctx)
+	for _, name := range env.async {
+		subscriber, ok := b.asyncSubscriber(name)
+		if !ok {
+			continue
+		}
+		b.running.Add(1)
+		go func() {
+			defer b.running.Done()
+			b.runAsync(background, env, subscriber)
+		}()
+	}
+
+	return errors.Join(errs...)
+}
+
+// runAsync runs a subscriber and clears its outbox record when it succeeds.
+// A failure is left for Relay.
+func (b *Bus) runAsync(ctx context.Context, env Envelope, subscriber asyncSubscriber) {
+	ctx, span := tracing.Tracer().Start(ctx, "Bus.runAsync")
+	defer span.End()
+
+	err := subscriber.handler(ctx, env)
+	if err != nil {
+		slog.WarnContext(ctx, "Async event subscriber failed, the outbox will retry it", "error", err, "subscriber", subscriber.name, "event", env.Type, "eventId", env.ID)
+		return
+	}
+
+	if b.outbox != nil {
+		if err := b.outbox.DeleteRecord(ctx, env.UserId, env.ID, subscriber.name); err != nil {
+			slog.ErrorContext(ctx, "Failed to clear outbox record", "error", err, "subscriber", subscriber.name, "eventId", env.ID)
+		}
+	}
+}
+
+// Wait blocks until the asynchronous subscribers started so far are done,
+// for tests and graceful shutdowns.
+func (b *Bus) Wait() {
+	b.running.Wait()
+}
+
+func (b *Bus) decode(record OutboxRecord) (Envelope, error) {
+	b.mu.RLock()
+	t, ok := b.types[record.Type]
+	b.mu.RUnlock()
+	if !ok {
+		return Envelope{}, fmt.Errorf("event type %q is not registered", record.Type)
+	}
+
+	value := reflect.New(t)
+	if err := json.Unmarshal([]byte(record.Data), value.Interface()); err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{
+		ID:         record.EventId,
+		Type:       record.Type,
+		OccurredAt: time.UnixMilli(record.OccurredAt),
+		Event:      value.Elem().Interface().(Event),
+	}, nil
+}
@@ -0,0 +1,316 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-events"
+
+func setupTestDB(t *testing.T) *OutboxStorage {
+	t.Helper()
+
+	return NewOutboxStorage(dynamotest.NewTable(t, testTableName))
+}
+
+// publish prepares and publishes an event, saving its outbox records on
+// their own like a storage would with the change causing it.
+func publish(t *testing.T, bus *Bus, userId string, event Event) error {
+	t.Helper()
+	ctx := context.Background()
+
+	env, outbox, err := bus.Prepare(ctx, userId, event)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if len(outbox) > 0 {
+		_, err := bus.outbox.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: outbox})
+		if err != nil {
+			t.Fatalf("TransactWriteItems failed: %v", err)
+		}
+	}
+	return bus.Publish(ctx, env)
+}
+
+type noteAdded struct {
+	Note string `json:"note"`
+}
+
+func (noteAdded) EventType() string { return "note.added" }
+
+type noteRemoved struct {
+	ID string `json:"id"`
+}
+
+func (noteRemoved) EventType() string { return "note.removed" }
+
+// recorder is a subscriber remembering what it was given, failing while
+// fail is set.
+type recorder struct {
+	mu   sync.Mutex
+	envs []Envelope
+	fail bool
+}
+
+func (r *recorder) handle(_ context.Context, env Envelope) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return errors.New("subscriber failed")
+	}
+	r.envs = append(r.envs, env)
+	return nil
+}
+
+func (r *recorder) setFail(fail bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fail = fail
+}
+
+func (r *recorder) received() []Envelope {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Envelope(nil), r.envs...)
+}
+
+func TestBusPublish(t *testing.T) {
+	bus := NewBus(nil)
+
+	added, all, async := &recorder{}, &recorder{}, &recorder{}
+	bus.Subscribe("note.added", added.handle)
+	bus.Subscribe(AllEvents, all.handle)
+	bus.SubscribeAsync("async", "note.removed", async.handle)
+
+	err := publish(t, bus, "user-1", noteAdded{Note: "hi"})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	publish(t, bus, "user-1", noteRemoved{ID: "1"})
+	bus.Wait()
+
+	if got := added.received(); len(got) != 1 || got[0].Event != (noteAdded{Note: "hi"}) || got[0].UserId != "user-1" || got[0].Type != "note.added" || got[0].ID == "" || got[0].OccurredAt.IsZero() {
+		t.Errorf("expected note.added, got %+v", got)
+	}
+	if got := all.received(); len(got) != 2 {
+		t.Errorf("expected every event, got %+v", got)
+	}
+	if got := async.received(); len(got) != 1 || got[0].Event != (noteRemoved{ID: "1"}) {
+		t.Errorf("expected note.removed in the background, got %+v", got)
+	}
+
+	t.Run("sync errors are returned", func(t *testing.T) {
+		added.setFail(true)
+		defer added.setFail(false)
+
+		err := publish(t, bus, "user-1", noteAdded{Note: "again"})
+		if err == nil {
+			t.Error("expected the subscriber's error")
+		}
+		if got := all.received(); len(got) != 3 {
+			t.Errorf("expected the other subscribers to run, got %d events", len(got))
+		}
+	})
+
+	t.Run("async names are unique", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		bus.SubscribeAsync("async", AllEvents, async.handle)
+	})
+}
+
+func TestBusRelayOnly(t *testing.T) {
+	outbox := setupTestDB(t)
+	bus := NewBus(outbox)
+	bus.Register(noteAdded{})
+	bus.RelayOnly = true
+	now := time.Unix(1700000000, 0)
+	bus.now = func() time.Time { return now }
+
+	async := &recorder{}
+	bus.SubscribeAsync("async", AllEvents, async.handle)
+
+	if err := publish(t, bus, "user-1", noteAdded{Note: "hi"}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	bus.Wait()
+	if got := async.received(); len(got) != 0 {
+		t.Errorf("expected the subscriber to be left to the relay, got %+v", got)
+	}
+
+	result, err := bus.Relay(context.Background(), now.Add(RelayDelay))
+	if err != nil || result.Succeeded != 1 || len(async.received()) != 1 {
+		t.Errorf("expected the relay to run the subscriber, got %+v: %v", result, err)
+	}
+}
+
+func TestBusWants(t *testing.T) {
+	outbox := setupTestDB(t)
+	bus := NewBus(outbox)
+	bus.Register(noteAdded{})
+	bus.RelayOnly = true
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	bus.now = func() time.Time { return now }
+
+	wants := func(_ context.Context, userId, _ string) (bool, error) {
+		if userId == "broken" {
+			return false, errors.New("lookup failed")
+		}
+		return userId == "user-1", nil
+	}
+	bus.SubscribeAsyncWhen("picky", AllEvents, wants, (&recorder{}).handle)
+
+	for _, userId := range []string{"user-1", "user-2", "broken"} {
+		if err := publish(t, bus, userId, noteAdded{Note: "hi"}); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+
+	records, err := outbox.GetRecords(ctx, now)
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	var users []string
+	for _, record := range records {
+		users = append(users, record.UserId)
+	}
+	slices.Sort(users)
+	if !slices.Equal(users, []string{"broken", "user-1"}) {
+		t.Errorf("expected records for the wanted user and the failed lookup, got %v", users)
+	}
+}
+
+func TestBusOutbox(t *testing.T) {
+	outbox := setupTestDB(t)
+	bus := NewBus(outbox)
+	bus.Register(noteAdded{}, noteRemoved{})
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	bus.now = func() time.Time { return now }
+
+	ok, flaky := &recorder{}, &recorder{}
+	bus.SubscribeAsync("ok", AllEvents, ok.handle)
+	bus.SubscribeAsync("flaky", "note.added", flaky.handle)
+
+	flaky.setFail(true)
+	publish(t, bus, "user-1", noteAdded{Note: "hi"})
+	bus.Wait()
+
+	// Only the failed subscriber's record is left
+	records, err := outbox.GetRecords(ctx, now)
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if len(records) != 1 || records[0].Subscriber != "flaky" || records[0].UserId != "user-1" || records[0].Data != `{"note":"hi"}` {
+		t.Fatalf("expected the flaky record, got %+v", records)
+	}
+	eventId := ok.received()[0].ID
+
+	t.Run("relay waits for the delay", func(t *testing.T) {
+		result, err := bus.Relay(ctx, now)
+		if err != nil || result.Due != 0 {
+			t.Errorf("expected nothing due yet, got %+v: %v", result, err)
+		}
+	})
+
+	t.Run("relay counts failures", func(t *testing.T) {
+		result, err := bus.Relay(ctx, now.Add(RelayDelay))
+		if err != nil || result.Due != 1 || result.Failed != 1 {
+			t.Fatalf("expected one failure, got %+v: %v", result, err)
+		}
+
+		records, _ := outbox.GetRecords(ctx, now)
+		if len(records) != 1 || records[0].Attempts != 1 || records[0].Error != "subscriber failed" {
+			t.Errorf("expected the attempt to be recorded, got %+v", records)
+		}
+	})
+
+	t.Run("relay delivers the same event", func(t *testing.T) {
+		flaky.setFail(false)
+
+		result, err := bus.Relay(ctx, now.Add(RelayDelay))
+		if err != nil || result.Succeeded != 1 {
+			t.Fatalf("expected one success, got %+v: %v", result, err)
+		}
+
+		got := flaky.received()
+		if len(got) != 1 || got[0].ID != eventId || got[0].UserId != "user-1" || got[0].Event != (noteAdded{Note: "hi"}) || !got[0].OccurredAt.Equal(now) {
+			t.Errorf("expected the published event, got %+v", got)
+		}
+		if records, _ := outbox.GetRecords(ctx, now); len(records) != 0 {
+			t.Errorf("expected the outbox to be empty, got %+v", records)
+		}
+	})
+}
+
+func TestBusRelayGivesUp(t *testing.T) {
+	outbox := setupTestDB(t)
+	bus := NewBus(outbox)
+	bus.Register(noteAdded{})
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	failing := &recorder{fail: true}
+	bus.SubscribeAsync("failing", AllEvents, failing.handle)
+
+	outbox.PutRecord(ctx, "user-1", OutboxRecord{EventId: "1", Type: "note.added", Subscriber: "failing", Data: `{"note":"hi"}`, Attempts: MaxRelayAttempts - 1, CreatedAt: now.Unix()})
+	outbox.PutRecord(ctx, "user-1", OutboxRecord{EventId: "2", Type: "note.added", Subscriber: "removed", Data: `{}`, CreatedAt: now.Unix()})
+	outbox.PutRecord(ctx, "user-1", OutboxRecord{EventId: "3", Type: "note.unknown", Subscriber: "failing", Data: `{}`, CreatedAt: now.Unix()})
+
+	result, err := bus.Relay(ctx, now.Add(RelayDelay))
+	if err != nil {
+		t.Fatalf("Relay failed: %v", err)
+	}
+	if result.Due != 3 || result.GaveUp != 1 || result.Failed != 1 {
+		t.Errorf("expected one given up and one unknown type failing, got %+v", result)
+	}
+
+	records, _ := outbox.GetRecords(ctx, now)
+	if len(records) != 1 || records[0].EventId != "3" || records[0].Error == "" {
+		t.Errorf("expected only the unknown event type to be retried, got %+v", records)
+	}
+}
+
+func TestOutboxGetRecordsUnshardedKey(t *testing.T) {
+	outbox := setupTestDB(t)
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	outbox.PutRecord(ctx, "user-1", OutboxRecord{EventId: "2", Type: "note.added", Subscriber: "ok", Data: `{}`, CreatedAt: now.Unix()})
+
+	// Written before IndexOutbox was sharded
+	item, err := attributevalue.MarshalMap(outboxItem{
+		UserId:       "user-2",
+		ItemId:       recordItemId("1", "ok"),
+		OutboxIndex:  outboxIndexKey,
+		OutboxRecord: OutboxRecord{EventId: "1", Type: "note.added", Subscriber: "ok", Data: `{}`, CreatedAt: now.Unix() - 60},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal record: %v", err)
+	}
+	_, err = outbox.db.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(outbox.cfg.TableName), Item: item})
+	if err != nil {
+		t.Fatalf("PutItem failed: %v", err)
+	}
+
+	records, err := outbox.GetRecords(ctx, now)
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if len(records) != 2 || records[0].EventId != "1" || records[1].EventId != "2" {
+		t.Errorf("expected both records, oldest first, got %+v", records)
+	}
+}
@@ -0,0 +1,15 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogHandler is a synchronous subscriber writing every event to the log as
+// an audit trail.
+func LogHandler(logger *slog.Logger) Handler {
+	return func(ctx context.Context, env Envelope) error {
+		logger.InfoContext(ctx, "Event", "event", env.Type, "eventId", env.ID, "userId", env.UserId)
+		return nil
+	}
+}
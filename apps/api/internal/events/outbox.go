@@ -0,0 +1,207 @@
+package events
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/database"
+)
+
+const (
+	itemPrefixOutbox = "outbox#"
+
+	// IndexOutbox is a sparse index holding every user's outbox records
+	// still to be relayed, so the relay can find them without scanning the
+	// table. They are spread over database.IndexShards partitions, keyed by
+	// OutboxIndex and sorted by CreatedAt.
+	IndexOutbox = "byOutbox"
+
+	outboxIndexKey = "outbox"
+
+	// OutboxRetention is how long a record stays in the table, given up on
+	// records included.
+	OutboxRetention = 7 * 24 * time.Hour
+)
+
+type outboxItem struct {
+	UserId string `dynamodbav:"userId"`
+	ItemId string `dynamodbav:"itemId"`
+	// OutboxIndex puts the item in IndexOutbox, until it is given up on.
+	OutboxIndex string `dynamodbav:"OutboxIndex,omitempty"`
+	// ExpiresAt lets the table's TTL remove the record, in unix seconds.
+	ExpiresAt int64 `dynamodbav:"ExpiresAt"`
+	OutboxRecord
+}
+
+// OutboxRecord is an event an asynchronous subscriber has not finished.
+type OutboxRecord struct {
+	EventId    string `dynamodbav:"EventId"`
+	Type       string `dynamodbav:"Type"`
+	Subscriber string `dynamodbav:"Subscriber"`
+	// Data is the event as JSON.
+	Data string `dynamodbav:"Data"`
+	// OccurredAt is in unix milliseconds.
+	OccurredAt int64 `dynamodbav:"OccurredAt"`
+	// Attempts counts the relay's attempts, Error is the last one's.
+	Attempts int    `dynamodbav:"Attempts"`
+	Error    string `dynamodbav:"Error,omitempty"`
+	// GaveUp takes the record out of IndexOutbox, it stays in the table
+	// until it expires so it can be looked into.
+	GaveUp    bool  `dynamodbav:"GaveUp"`
+	CreatedAt int64 `dynamodbav:"CreatedAt"`
+}
+
+// PendingRecord is an outbox record with its owner.
+type PendingRecord struct {
+	UserId string
+	OutboxRecord
+}
+
+type OutboxStorage struct {
+	db  *dynamodb.Client
+	cfg *config.Config
+}
+
+func NewOutboxStorage(db *dynamodb.Client, cfg *config.Config) *OutboxStorage {
+	return &OutboxStorage{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+func recordItemId(eventId, subscriber string) string {
+	return itemPrefixOutbox + eventId + "#" + subscriber
+}
+
+func (s *OutboxStorage) marshalRecord(userId string, record OutboxRecord) (map[string]types.AttributeValue, error) {
+	itemId := recordItemId(record.EventId, record.Subscriber)
+	item := outboxItem{
+		UserId:       userId,
+		ItemId:       itemId,
+		ExpiresAt:    time.Unix(record.CreatedAt, 0).Add(OutboxRetention).Unix(),
+		OutboxRecord: record,
+	}
+	if !record.GaveUp {
+		item.OutboxIndex = database.ShardKey(outboxIndexKey, itemId)
+	}
+
+	return attributevalue.MarshalMap(item)
+}
+
+func (s *OutboxStorage) PutRecord(ctx context.Context, userId string, record OutboxRecord) error {
+	attributeValue, err := s.marshalRecord(userId, record)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal outbox record", "error", err)
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      attributeValue,
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "eventId", record.EventId, "subscriber", record.Subscriber)
+		return err
+	}
+
+	return nil
+}
+
+// RecordItems returns the writes saving the records of one event, one per
+// subscriber, for the transaction saving the change that caused it.
+func (s *OutboxStorage) RecordItems(userId string, records []OutboxRecord) ([]types.TransactWriteItem, error) {
+	items := make([]types.TransactWriteItem, len(records))
+	for i, record := range records {
+		attributeValue, err := s.marshalRecord(userId, record)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = types.TransactWriteItem{Put: &types.Put{
+			TableName: aws.String(s.cfg.TableName),
+			Item:      attributeValue,
+		}}
+	}
+	return items, nil
+}
+
+// DeleteRecord removes a record once its subscriber is done. A record that
+// is already gone is not an error, the relay may have finished it.
+func (s *OutboxStorage) DeleteRecord(ctx context.Context, userId, eventId, subscriber string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: recordItemId(eventId, subscriber)},
+		},
+	}
+
+	_, err := s.db.DeleteItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB DeleteItem failed", "error", err, "eventId", eventId, "subscriber", subscriber)
+		return err
+	}
+
+	return nil
+}
+
+// GetRecords returns every user's records created at or before before, that
+// have not been given up on, oldest first.
+func (s *OutboxStorage) GetRecords(ctx context.Context, before time.Time) ([]PendingRecord, error) {
+	items, err := database.QueryShards(ctx, outboxIndexKey, func(ctx context.Context, shardKey string) ([]outboxItem, error) {
+		return s.getShardRecords(ctx, shardKey, before)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(items, func(a, b outboxItem) int { return cmp.Compare(a.CreatedAt, b.CreatedAt) })
+
+	records := make([]PendingRecord, len(items))
+	for i, item := range items {
+		records[i] = PendingRecord{UserId: item.UserId, OutboxRecord: item.OutboxRecord}
+	}
+
+	return records, nil
+}
+
+func (s *OutboxStorage) getShardRecords(ctx context.Context, shardKey string, before time.Time) ([]outboxItem, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		IndexName:              aws.String(IndexOutbox),
+		KeyConditionExpression: aws.String("OutboxIndex = :key AND CreatedAt <= :before"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":key":    &types.AttributeValueMemberS{Value: shardKey},
+			":before": &types.AttributeValueMemberN{Value: strconv.FormatInt(before.Unix(), 10)},
+		},
+	}
+
+	var items []outboxItem
+	paginator := dynamodb.NewQueryPaginator(s.db, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err, "index", IndexOutbox, "shard", shardKey)
+			return nil, err
+		}
+
+		var pageItems []outboxItem
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageItems)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to unmarshal outbox records", "error", err)
+			return nil, err
+		}
+		items = append(items, pageItems...)
+	}
+
+	return items, nil
+}
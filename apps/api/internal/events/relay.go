@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/logging"
+	"github.com/jimvid/sidekick/internal/tracing"
+)
+
+const (
+	// RelayDelay is how old a record must be before the relay runs it, so
+	// it does not race the subscriber Publish started.
+	RelayDelay = time.Minute
+	// MaxRelayAttempts is how many times the relay runs a record before it
+	// gives up on it.
+	MaxRelayAttempts = 10
+)
+
+// RelayResult counts the records of a relay run.
+type RelayResult struct {
+	Due       int `json:"due"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	GaveUp    int `json:"gaveUp"`
+}
+
+// Relay runs the asynchronous subscribers whose outbox records are older
+// than RelayDelay at now, one at a time. Records of subscribers that no
+// longer exist are removed.
+func (b *Bus) Relay(ctx context.Context, now time.Time) (RelayResult, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Bus.Relay")
+	defer span.End()
+
+	if b.outbox == nil {
+		return RelayResult{}, nil
+	}
+
+	records, err := b.outbox.GetRecords(ctx, now.Add(-RelayDelay))
+	if err != nil {
+		return RelayResult{}, err
+	}
+
+	result := RelayResult{Due: len(records)}
+	var errs []error
+	for _, record := range records {
+		// Logged with every line of this record, as in the request that published it
+		ctx := logging.WithAttrs(ctx, slog.String("userId", record.UserId))
+
+		subscriber, ok := b.asyncSubscriber(record.Subscriber)
+		if !ok {
+			slog.WarnContext(ctx, "Removing outbox record of an unknown subscriber", "subscriber", record.Subscriber, "eventId", record.EventId)
+			if err := b.outbox.DeleteRecord(ctx, record.UserId, record.EventId, record.Subscriber); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		env, err := b.decode(record.OutboxRecord)
+		if err == nil {
+			env.UserId = record.UserId
+			err = subscriber.handler(ctx, env)
+		}
+		if err == nil {
+			result.Succeeded++
+			if err := b.outbox.DeleteRecord(ctx, record.UserId, record.EventId, record.Subscriber); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		record.Attempts++
+		record.Error = err.Error()
+		if record.Attempts >= MaxRelayAttempts {
+			record.GaveUp = true
+			result.GaveUp++
+			slog.ErrorContext(ctx, "Giving up on outbox record", "error", err, "subscriber", record.Subscriber, "event", record.Type, "eventId", record.EventId)
+		} else {
+			result.Failed++
+			slog.WarnContext(ctx, "Outbox record failed", "error", err, "subscriber", record.Subscriber, "event", record.Type, "eventId", record.EventId, "attempts", record.Attempts)
+		}
+		if err := b.outbox.PutRecord(ctx, record.UserId, record.OutboxRecord); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Outbox relayed", "due", result.Due, "succeeded", result.Succeeded, "failed", result.Failed, "gaveUp", result.GaveUp)
+	return result, errors.Join(errs...)
+}
+
+func (b *Bus) asyncSubscriber(name string) (asyncSubscriber, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, subscriber := range b.async {
+		if subscriber.name == name {
+			return subscriber, true
+		}
+	}
+	return asyncSubscriber{}, false
+}
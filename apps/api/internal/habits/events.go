@@ -0,0 +1,97 @@
+package habits
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/events"
+)
+
+// Event types HabitService publishes.
+const (
+	EventHabitCreated = "habit.created"
+	EventHabitUpdated = "habit.updated"
+	EventHabitDeleted = "habit.deleted"
+	EventLogCreated   = "log.created"
+	EventLogDeleted   = "log.deleted"
+)
+
+// EventTypes lists every event type, in the order they are documented.
+var EventTypes = []string{
+	EventHabitCreated,
+	EventHabitUpdated,
+	EventHabitDeleted,
+	EventLogCreated,
+	EventLogDeleted,
+}
+
+// Events are zero values of every event, for events.Bus.Register.
+var Events = []events.Event{
+	HabitCreated{},
+	HabitUpdated{},
+	HabitDeleted{},
+	LogCreated{},
+	LogDeleted{},
+}
+
+// HabitCreated encodes as the habit.
+type HabitCreated struct {
+	HabitModel
+}
+
+func (HabitCreated) EventType() string { return EventHabitCreated }
+
+// HabitUpdated encodes as the habit after the update.
+type HabitUpdated struct {
+	HabitModel
+}
+
+func (HabitUpdated) EventType() string { return EventHabitUpdated }
+
+type HabitDeleted struct {
+	ID string `json:"id"`
+}
+
+func (HabitDeleted) EventType() string { return EventHabitDeleted }
+
+// LogCreated encodes as the log.
+type LogCreated struct {
+	HabitLogModel
+}
+
+func (LogCreated) EventType() string { return EventLogCreated }
+
+type LogDeleted struct {
+	ID      string `json:"id"`
+	HabitId string `json:"habitId"`
+}
+
+func (LogDeleted) EventType() string { return EventLogDeleted }
+
+// Publisher is told about changes, see events.Bus. An event is prepared
+// before its change, the outbox writes are saved with the change, and it is
+// published after.
+type Publisher interface {
+	Prepare(ctx context.Context, userId string, event events.Event) (events.Envelope, []types.TransactWriteItem, error)
+	Publish(ctx context.Context, env events.Envelope) error
+}
+
+// prepare returns the event's envelope and the outbox writes to save with
+// its change, none when nothing listens.
+func (s *HabitService) prepare(ctx context.Context, userId string, event events.Event) (events.Envelope, []types.TransactWriteItem, error) {
+	if s.events == nil {
+		return events.Envelope{}, nil, nil
+	}
+	return s.events.Prepare(ctx, userId, event)
+}
+
+// publish never fails the change, it is saved already.
+func (s *HabitService) publish(ctx context.Context, env events.Envelope) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(ctx, env); err != nil {
+		slog.WarnContext(ctx, "Publishing event failed", "error", err, "event", env.Type)
+	}
+}
@@ -2,10 +2,16 @@ package habits
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/request"
+	"github.com/jimvid/sidekick/internal/tracing"
 	"github.com/jimvid/sidekick/internal/user"
 )
 
@@ -21,13 +27,14 @@ func NewHabitHandler(service *HabitService) *HabitHandler {
 	}
 }
 
-func (h *HabitHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+func (h *HabitHandler) writeErrorResponse(w http.ResponseWriter, r *http.Request, p problem.Problem) {
+	problem.Write(w, r, p)
 }
 
-func (h *HabitHandler) writeSuccessResponse(w http.ResponseWriter, statusCode int, data any) {
+func (h *HabitHandler) writeSuccessResponse(w http.ResponseWriter, r *http.Request, statusCode int, data any) {
+	_, span := tracing.Tracer().Start(r.Context(), "json.encode")
+	defer span.End()
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(data)
@@ -36,246 +43,354 @@ func (h *HabitHandler) writeSuccessResponse(w http.ResponseWriter, statusCode in
 func (h *HabitHandler) GetAllHabits(w http.ResponseWriter, r *http.Request) {
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
 		return
 	}
 
-	habits, err := h.service.GetAllHabits(userId)
+	habits, err := h.service.GetAllHabits(r.Context(), userId)
 	if err != nil {
-		slog.Error("Failed to get all habits", "error", err, "userId", userId)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get all habits")
+		slog.ErrorContext(r.Context(), "Failed to get all habits", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get all habits"))
 		return
 	}
 
-	h.writeSuccessResponse(w, http.StatusOK, habits)
+	h.writeSuccessResponse(w, r, http.StatusOK, habits)
 }
 
 func (h *HabitHandler) CreateHabit(w http.ResponseWriter, r *http.Request) {
 	var habitReq HabitReq
-	err := json.NewDecoder(r.Body).Decode(&habitReq)
+	err := request.DecodeJSON(w, r, &habitReq)
 	if err != nil {
-		slog.Error("Failed to parse JSON", "error", err)
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not parse JSON")
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := habitReq.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
 		return
 	}
 
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
 		return
 	}
 
-	habit, err := h.service.CreateHabit(userId, habitReq)
+	habit, err := h.service.CreateHabit(r.Context(), userId, habitReq)
 	if err != nil {
-		slog.Error("Failed to create habit", "error", err, "userId", userId)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not create habit")
+		slog.ErrorContext(r.Context(), "Failed to create habit", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Could not create habit"))
 		return
 	}
 
-	slog.Info("Habit created", "habitId", habit.ID, "userId", userId)
-	h.writeSuccessResponse(w, http.StatusCreated, habit)
+	slog.InfoContext(r.Context(), "Habit created", "habitId", habit.ID)
+	h.writeSuccessResponse(w, r, http.StatusCreated, habit)
 }
 
 func (h *HabitHandler) FindHabitById(w http.ResponseWriter, r *http.Request) {
 	habitId := chi.URLParam(r, "habitId")
 	if habitId == "" {
-		slog.Warn("Could not get ID from URL")
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not get ID from URL")
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
 		return
 	}
 
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
 		return
 	}
 
-	habit, err := h.service.FindHabitById(userId, habitId)
+	habit, err := h.service.FindHabitById(r.Context(), userId, habitId)
 	if err != nil {
-		slog.Error("Could not find habit by ID", "error", err, "habitId", habitId)
-		h.writeErrorResponse(w, http.StatusNotFound, "Could not find habit by ID")
+		if errors.Is(err, ErrHabitNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find habit by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not find habit by ID", "error", err, "habitId", habitId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not get habit"))
 		return
 	}
 
-	h.writeSuccessResponse(w, http.StatusOK, habit)
+	h.writeSuccessResponse(w, r, http.StatusOK, habit)
 }
 
 func (h *HabitHandler) DeleteHabit(w http.ResponseWriter, r *http.Request) {
 	habitId := chi.URLParam(r, "habitId")
 	if habitId == "" {
-		slog.Warn("Could not get ID from URL")
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not get ID from URL")
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
 		return
 	}
 
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
 		return
 	}
 
-	err = h.service.DeleteHabit(userId, habitId)
+	err = h.service.DeleteHabit(r.Context(), userId, habitId)
 	if err != nil {
-		slog.Error("Could not delete habit", "error", err, "habitId", habitId)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not delete habit")
+		if errors.Is(err, ErrHabitNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find habit by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not delete habit", "error", err, "habitId", habitId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not delete habit"))
 		return
 	}
 
-	h.writeSuccessResponse(w, http.StatusOK, map[string]string{"message": "Successfully deleted habit"})
+	h.writeSuccessResponse(w, r, http.StatusOK, map[string]string{"message": "Successfully deleted habit"})
 }
 
 func (h *HabitHandler) UpdateHabit(w http.ResponseWriter, r *http.Request) {
 	var req HabitReq
-	err := json.NewDecoder(r.Body).Decode(&req)
+	err := request.DecodeJSON(w, r, &req)
 	if err != nil {
-		slog.Error("Failed to parse JSON", "error", err)
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not parse JSON")
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := req.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
 		return
 	}
 
 	habitId := chi.URLParam(r, "habitId")
 	if habitId == "" {
-		slog.Warn("Could not get ID from URL")
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not get ID from URL")
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
 		return
 	}
 
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
 		return
 	}
 
-	updatedHabit, err := h.service.UpdateHabit(userId, habitId, req)
+	updatedHabit, err := h.service.UpdateHabit(r.Context(), userId, habitId, req)
 	if err != nil {
-		slog.Error("Could not update habit", "error", err, "habitId", habitId)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not update habit")
+		if errors.Is(err, ErrHabitNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find habit by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not update habit", "error", err, "habitId", habitId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not update habit"))
 		return
 	}
 
-	h.writeSuccessResponse(w, http.StatusOK, updatedHabit)
+	h.writeSuccessResponse(w, r, http.StatusOK, updatedHabit)
 }
 
 // Habit logs
 func (h *HabitHandler) CreateHabitLog(w http.ResponseWriter, r *http.Request) {
 	var logReq HabitLogReq
-	err := json.NewDecoder(r.Body).Decode(&logReq)
+	err := request.DecodeJSON(w, r, &logReq)
 	if err != nil {
-		slog.Error("Failed to parse JSON", "error", err)
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not parse JSON")
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := logReq.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
 		return
 	}
 
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
 		return
 	}
 
-	log, err := h.service.CreateHabitLog(userId, logReq)
+	log, err := h.service.CreateHabitLog(r.Context(), userId, logReq)
 	if err != nil {
-		slog.Error("Failed to create log", "error", err, "userId", userId)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not create log")
+		slog.ErrorContext(r.Context(), "Failed to create log", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Could not create log"))
 		return
 	}
 
-	slog.Info("Log created", "logId", log.ID, "userId", userId)
-	h.writeSuccessResponse(w, http.StatusCreated, log)
+	slog.InfoContext(r.Context(), "Log created", "logId", log.ID)
+	h.writeSuccessResponse(w, r, http.StatusCreated, log)
 }
 
 func (h *HabitHandler) GetAllHabitLogs(w http.ResponseWriter, r *http.Request) {
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
 		return
 	}
 
-	logs, err := h.service.GetAllHabitLogs(userId)
+	logs, err := h.service.GetAllHabitLogs(r.Context(), userId)
 	if err != nil {
-		slog.Error("Failed to get all logs", "error", err, "userId", userId)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get all logs")
+		slog.ErrorContext(r.Context(), "Failed to get all logs", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get all logs"))
 		return
 	}
 
-	h.writeSuccessResponse(w, http.StatusOK, logs)
+	h.writeSuccessResponse(w, r, http.StatusOK, logs)
 }
 
 func (h *HabitHandler) FindHabitLogById(w http.ResponseWriter, r *http.Request) {
 	logId := chi.URLParam(r, "id")
 	if logId == "" {
-		slog.Warn("Could not get ID from URL")
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not get ID from URL")
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
 		return
 	}
 
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
 		return
 	}
 
-	log, err := h.service.FindHabitLogById(userId, logId)
+	log, err := h.service.FindHabitLogById(r.Context(), userId, logId)
 	if err != nil {
-		slog.Error("Could not find log by ID", "error", err, "logId", logId)
-		h.writeErrorResponse(w, http.StatusNotFound, "Could not find log by ID")
+		if errors.Is(err, ErrHabitLogNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find log by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not find log by ID", "error", err, "logId", logId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not get log"))
 		return
 	}
 
-	h.writeSuccessResponse(w, http.StatusOK, log)
+	h.writeSuccessResponse(w, r, http.StatusOK, log)
 }
 
 func (h *HabitHandler) DeleteHabitLog(w http.ResponseWriter, r *http.Request) {
 	logId := chi.URLParam(r, "id")
 	if logId == "" {
-		slog.Warn("Could not get ID from URL")
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not get ID from URL")
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
 		return
 	}
 
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
 		return
 	}
 
-	err = h.service.DeleteHabitLog(userId, logId)
+	err = h.service.DeleteHabitLog(r.Context(), userId, logId)
 	if err != nil {
-		slog.Error("Could not delete log", "error", err, "logId", logId)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not delete log")
+		if errors.Is(err, ErrHabitLogNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find log by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not delete log", "error", err, "logId", logId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not delete log"))
 		return
 	}
 
-	h.writeSuccessResponse(w, http.StatusOK, map[string]string{"message": "Successfully deleted log"})
+	h.writeSuccessResponse(w, r, http.StatusOK, map[string]string{"message": "Successfully deleted log"})
 }
 
 func (h *HabitHandler) UpdateHabitLog(w http.ResponseWriter, r *http.Request) {
 	var req HabitLogReq
-	err := json.NewDecoder(r.Body).Decode(&req)
+	err := request.DecodeJSON(w, r, &req)
 	if err != nil {
-		slog.Error("Failed to parse JSON", "error", err)
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not parse JSON")
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := req.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
 		return
 	}
 
 	logId := chi.URLParam(r, "id")
 	if logId == "" {
-		slog.Warn("Could not get ID from URL")
-		h.writeErrorResponse(w, http.StatusBadRequest, "Could not get ID from URL")
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	updatedLog, err := h.service.UpdateHabitLog(r.Context(), userId, logId, req)
+	if err != nil {
+		if errors.Is(err, ErrHabitLogNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find log by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not update log", "error", err, "logId", logId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not update log"))
 		return
 	}
 
+	h.writeSuccessResponse(w, r, http.StatusOK, updatedLog)
+}
+
+// GetHeatmap defaults to the year up to today in the user's time zone.
+func (h *HabitHandler) GetHeatmap(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	query := r.URL.Query()
+	req := HeatmapReq{
+		From:    query.Get("from"),
+		To:      query.Get("to"),
+		HabitId: query.Get("habitId"),
+	}
+	params := req.validate()
+	if len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
+		return
+	}
+
+	heatmap, err := h.service.GetHeatmap(r.Context(), userId, req)
+	if err != nil {
+		if errors.Is(err, ErrHabitNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find habit by ID"))
+			return
+		}
+		if errors.Is(err, ErrInvalidRange) {
+			h.writeErrorResponse(w, r, problem.Validation([]problem.InvalidParam{
+				{Name: "from", Reason: fmt.Sprintf("From must be on or before to, and at most %d days earlier", MaxHeatmapDays)},
+			}))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to get heatmap", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get heatmap"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, heatmap)
+}
+
+// GetDay accepts today for the current date in the user's time zone.
+func (h *HabitHandler) GetDay(w http.ResponseWriter, r *http.Request) {
 	userId, err := h.getUserId(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not get user")
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	date := chi.URLParam(r, "date")
+	if date == "today" {
+		date = ""
+	} else if _, err := time.Parse(time.DateOnly, date); err != nil {
+		h.writeErrorResponse(w, r, problem.Validation([]problem.InvalidParam{
+			{Name: "date", Reason: "Date must be today or a valid date like 2026-02-08"},
+		}))
 		return
 	}
 
-	updatedLog, err := h.service.UpdateHabitLog(userId, logId, req)
+	day, err := h.service.GetDay(r.Context(), userId, date)
 	if err != nil {
-		slog.Error("Could not update log", "error", err, "logId", logId)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not update log")
+		slog.ErrorContext(r.Context(), "Failed to get day", "error", err, "date", date)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get day"))
 		return
 	}
 
-	h.writeSuccessResponse(w, http.StatusOK, updatedLog)
+	h.writeSuccessResponse(w, r, http.StatusOK, day)
 }
@@ -1,13 +1,16 @@
 package habits
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/problem"
 )
 
 const testUserId = "test-user-1"
@@ -16,7 +19,7 @@ func setupHandler(t *testing.T) (*HabitHandler, *chi.Mux) {
 	t.Helper()
 
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 	handler := &HabitHandler{
 		service: service,
 		getUserId: func(r *http.Request) (string, error) {
@@ -37,6 +40,9 @@ func setupHandler(t *testing.T) (*HabitHandler, *chi.Mux) {
 	r.Delete("/habit-logs/{id}", handler.DeleteHabitLog)
 	r.Put("/habit-logs/{id}", handler.UpdateHabitLog)
 
+	r.Get("/heatmap", handler.GetHeatmap)
+	r.Get("/days/{date}", handler.GetDay)
+
 	return handler, r
 }
 
@@ -77,6 +83,56 @@ func TestHandlerCreateHabit(t *testing.T) {
 			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
 		}
 	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/habits", strings.NewReader(`{"nmae":"Exercise"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		var p problem.Problem
+		json.NewDecoder(w.Body).Decode(&p)
+		if w.Header().Get("Content-Type") != problem.ContentType {
+			t.Errorf("expected problem content type, got %q", w.Header().Get("Content-Type"))
+		}
+		if p.Code != problem.CodeInvalidBody || len(p.InvalidParams) != 1 || p.InvalidParams[0].Name != "nmae" {
+			t.Errorf("expected invalid body problem naming the field, got %+v", p)
+		}
+	})
+
+	t.Run("validation failed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/habits", strings.NewReader(`{"name":" ","color":"red"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		var p problem.Problem
+		json.NewDecoder(w.Body).Decode(&p)
+		if p.Code != problem.CodeValidationFailed || len(p.InvalidParams) != 2 {
+			t.Errorf("expected validation problem for name and color, got %+v", p)
+		}
+	})
+
+	t.Run("wrong content type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/habits", strings.NewReader(`{"name":"Exercise"}`))
+		req.Header.Set("Content-Type", "text/plain")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Fatalf("expected status %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+		}
+	})
 }
 
 func TestHandlerGetAllHabits(t *testing.T) {
@@ -201,8 +257,8 @@ func TestHandlerDeleteHabit(t *testing.T) {
 
 		router.ServeHTTP(w, req)
 
-		if w.Code != http.StatusInternalServerError {
-			t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
 		}
 	})
 }
@@ -419,8 +475,8 @@ func TestHandlerDeleteHabitLog(t *testing.T) {
 
 		router.ServeHTTP(w, req)
 
-		if w.Code != http.StatusInternalServerError {
-			t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
 		}
 	})
 }
@@ -476,3 +532,151 @@ func TestHandlerUpdateHabitLog(t *testing.T) {
 		}
 	})
 }
+
+func TestHandlerGetHeatmap(t *testing.T) {
+	handler, router := setupHandler(t)
+
+	habit, _ := handler.service.CreateHabit(context.Background(), testUserId, HabitReq{Name: "Read"})
+	today := time.Now().UTC().Format(time.DateOnly)
+	handler.service.CreateHabitLog(context.Background(), testUserId, HabitLogReq{HabitId: habit.ID, Date: today})
+
+	t.Run("defaults to the last year", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/heatmap", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var heatmap HeatmapModel
+		json.NewDecoder(w.Body).Decode(&heatmap)
+
+		from, _ := time.Parse(time.DateOnly, heatmap.From)
+		if heatmap.To != today || from.Weekday() != time.Monday || len(heatmap.Days) < 365 || len(heatmap.Days) > MaxHeatmapDays {
+			t.Errorf("expected 53 weeks up to %s, got %s to %s with %d days", today, heatmap.From, heatmap.To, len(heatmap.Days))
+		}
+		last := heatmap.Days[len(heatmap.Days)-1]
+		if last.Count != 1 || last.Ratio != 1 || heatmap.Total != 1 {
+			t.Errorf("expected today to be done, got %+v", last)
+		}
+	})
+
+	t.Run("range and habit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/heatmap?from="+today+"&to="+today+"&habitId="+habit.ID, nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var heatmap HeatmapModel
+		json.NewDecoder(w.Body).Decode(&heatmap)
+
+		if heatmap.HabitId != habit.ID || len(heatmap.Days) != 1 {
+			t.Errorf("expected one day for the habit, got %+v", heatmap)
+		}
+	})
+
+	t.Run("unknown habit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/heatmap?habitId=missing", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("validation failed", func(t *testing.T) {
+		tests := []struct {
+			query string
+			param string
+		}{
+			{query: "from=2026-13-01&to=2026-12-31", param: "from"},
+			{query: "from=2026-02-08&to=yesterday", param: "to"},
+			{query: "from=2026-02-08&to=2026-02-01", param: "to"},
+			{query: "from=2024-01-01&to=2026-01-01", param: "from"},
+			{query: "from=2099-01-01", param: "from"},
+		}
+
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, "/heatmap?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("%s: expected status %d, got %d", tt.query, http.StatusBadRequest, w.Code)
+			}
+
+			var body problem.Problem
+			json.NewDecoder(w.Body).Decode(&body)
+			if body.Code != problem.CodeValidationFailed || len(body.InvalidParams) != 1 || body.InvalidParams[0].Name != tt.param {
+				t.Errorf("%s: expected invalid %s, got %+v", tt.query, tt.param, body)
+			}
+		}
+	})
+}
+
+func TestHandlerGetDay(t *testing.T) {
+	handler, router := setupHandler(t)
+
+	habit, _ := handler.service.CreateHabit(context.Background(), testUserId, HabitReq{Name: "Read"})
+	today := time.Now().UTC().Format(time.DateOnly)
+	handler.service.CreateHabitLog(context.Background(), testUserId, HabitLogReq{HabitId: habit.ID, Date: today, Note: "20 pages"})
+
+	t.Run("today", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/days/today", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var day DayModel
+		json.NewDecoder(w.Body).Decode(&day)
+
+		if day.Date != today || day.Completed != 1 || day.Progress != 1 {
+			t.Errorf("expected today to be done, got %+v", day)
+		}
+		if len(day.Habits) != 1 || len(day.Habits[0].Logs) != 1 || day.Habits[0].Logs[0].Note != "20 pages" {
+			t.Errorf("expected the habit with its log, got %+v", day.Habits)
+		}
+	})
+
+	t.Run("empty day", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/days/2020-01-01", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var day DayModel
+		json.NewDecoder(w.Body).Decode(&day)
+
+		if day.Scheduled != 0 || day.Completed != 0 || len(day.Habits) != 1 || day.Habits[0].Scheduled {
+			t.Errorf("expected nothing scheduled before the habit existed, got %+v", day)
+		}
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/days/2026-02-30", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
@@ -1,8 +1,19 @@
 package habits
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jimvid/sidekick/internal/problem"
+)
+
 type habitItem struct {
-	UserId string `json:"-" dynamodbav:"userId"` // Used as primary key
-	ItemId string `json:"-" dynamodbav:"itemId"` // used for sorting key
+	UserId        string `json:"-" dynamodbav:"userId"` // Used as primary key
+	ItemId        string `json:"-" dynamodbav:"itemId"` // used for sorting key
+	SchemaVersion int    `json:"-" dynamodbav:"SchemaVersion,omitempty"`
 	HabitModel
 }
 
@@ -22,8 +33,9 @@ type HabitReq struct {
 }
 
 type habitLogItem struct {
-	UserId string `json:"-" dynamodbav:"userId"`
-	ItemId string `json:"-" dynamodbav:"itemId"`
+	UserId        string `json:"-" dynamodbav:"userId"`
+	ItemId        string `json:"-" dynamodbav:"itemId"`
+	SchemaVersion int    `json:"-" dynamodbav:"SchemaVersion,omitempty"`
 	HabitLogModel
 }
 
@@ -41,3 +53,113 @@ type HabitLogReq struct {
 	Date    string `json:"date"`
 	Note    string `json:"note"`
 }
+
+// HeatmapReq selects the days of a heatmap. Dates are YYYY-MM-DD and both
+// ends are included. Empty ends are filled in by HabitService.GetHeatmap.
+type HeatmapReq struct {
+	From    string
+	To      string
+	HabitId string
+}
+
+// MaxHeatmapDays fits a year view of 53 whole weeks.
+const MaxHeatmapDays = 371
+
+type HeatmapDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+	// Scheduled is how many habits could have been done that day, those that
+	// existed or were logged anyway.
+	Scheduled int     `json:"scheduled"`
+	Ratio     float64 `json:"ratio"`
+}
+
+type HeatmapModel struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	HabitId string       `json:"habitId,omitempty"`
+	Total   int          `json:"total"`
+	Days    []HeatmapDay `json:"days"`
+}
+
+// DayHabit is a habit as it stands on one day.
+type DayHabit struct {
+	Habit HabitModel `json:"habit"`
+	// Scheduled is false for habits created after the day, unless they were
+	// logged on it anyway.
+	Scheduled bool            `json:"scheduled"`
+	Done      bool            `json:"done"`
+	Logs      []HabitLogModel `json:"logs"`
+}
+
+// DayModel is everything the today screen shows for a date.
+type DayModel struct {
+	Date      string     `json:"date"`
+	Habits    []DayHabit `json:"habits"`
+	Scheduled int        `json:"scheduled"`
+	Completed int        `json:"completed"`
+	// Progress is Completed out of Scheduled, from 0 to 1.
+	Progress float64 `json:"progress"`
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func (r HabitReq) validate() []problem.InvalidParam {
+	var params []problem.InvalidParam
+
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		params = append(params, problem.InvalidParam{Name: "name", Reason: "Name is required"})
+	} else if utf8.RuneCountInString(name) > 100 {
+		params = append(params, problem.InvalidParam{Name: "name", Reason: "Name must be at most 100 characters"})
+	}
+	if utf8.RuneCountInString(r.Description) > 500 {
+		params = append(params, problem.InvalidParam{Name: "description", Reason: "Description must be at most 500 characters"})
+	}
+	if r.Color != "" && !hexColor.MatchString(r.Color) {
+		params = append(params, problem.InvalidParam{Name: "color", Reason: "Color must be a hex color like #22c55e"})
+	}
+
+	return params
+}
+
+func (r HabitLogReq) validate() []problem.InvalidParam {
+	var params []problem.InvalidParam
+
+	if strings.TrimSpace(r.HabitId) == "" {
+		params = append(params, problem.InvalidParam{Name: "habitId", Reason: "Habit ID is required"})
+	}
+	if _, err := time.Parse(time.DateOnly, r.Date); err != nil {
+		params = append(params, problem.InvalidParam{Name: "date", Reason: "Date must be a valid date like 2026-02-08"})
+	}
+	if utf8.RuneCountInString(r.Note) > 1000 {
+		params = append(params, problem.InvalidParam{Name: "note", Reason: "Note must be at most 1000 characters"})
+	}
+
+	return params
+}
+
+// validate checks the ends that are set, and the range once both are.
+func (r HeatmapReq) validate() []problem.InvalidParam {
+	var params []problem.InvalidParam
+
+	from, fromErr := time.Parse(time.DateOnly, r.From)
+	if fromErr != nil && r.From != "" {
+		params = append(params, problem.InvalidParam{Name: "from", Reason: "From must be a valid date like 2026-02-08"})
+	}
+	to, toErr := time.Parse(time.DateOnly, r.To)
+	if toErr != nil && r.To != "" {
+		params = append(params, problem.InvalidParam{Name: "to", Reason: "To must be a valid date like 2026-02-08"})
+	}
+	if fromErr != nil || toErr != nil {
+		return params
+	}
+
+	if to.Before(from) {
+		params = append(params, problem.InvalidParam{Name: "to", Reason: "To must not be before from"})
+	} else if int(to.Sub(from).Hours()/24)+1 > MaxHeatmapDays {
+		params = append(params, problem.InvalidParam{Name: "from", Reason: fmt.Sprintf("The range must be at most %d days", MaxHeatmapDays)})
+	}
+
+	return params
+}
@@ -1,48 +1,103 @@
 package habits
 
 import (
+	"context"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jimvid/sidekick/internal/tracing"
+	"github.com/jimvid/sidekick/internal/user"
 )
 
+// SettingsReader looks up the preferences a user's dates are computed with.
+type SettingsReader interface {
+	GetSettings(ctx context.Context, userId string) (user.SettingsModel, error)
+}
+
 type HabitService struct {
-	storage *HabitStorage
+	storage  *HabitStorage
+	settings SettingsReader
+	now      func() time.Time
+	// events are prepared with every change and published after it, nil
+	// when nothing listens.
+	events Publisher
 }
 
-func NewHabitService(storage *HabitStorage) *HabitService {
+// NewHabitService publishes every change to events, which may be nil.
+func NewHabitService(storage *HabitStorage, settings SettingsReader, events Publisher) *HabitService {
 	return &HabitService{
-		storage: storage,
+		storage:  storage,
+		settings: settings,
+		now:      time.Now,
+		events:   events,
 	}
 }
 
-func (s *HabitService) CreateHabit(userId string, req HabitReq) (HabitModel, error) {
+func (s *HabitService) CreateHabit(ctx context.Context, userId string, req HabitReq) (HabitModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.CreateHabit")
+	defer span.End()
+
 	habit := HabitModel{
 		ID:          uuid.New().String(),
 		Name:        req.Name,
 		Description: req.Description,
 		Color:       req.Color,
-		CreatedAt:   time.Now().Unix(),
-		UpdatedAt:   time.Now().Unix(),
+		CreatedAt:   s.now().Unix(),
+		UpdatedAt:   s.now().Unix(),
+	}
+
+	env, outbox, err := s.prepare(ctx, userId, HabitCreated{HabitModel: habit})
+	if err != nil {
+		return HabitModel{}, err
+	}
+
+	err = s.storage.CreateHabit(ctx, userId, habit, outbox...)
+	if err != nil {
+		return HabitModel{}, err
 	}
 
-	return habit, s.storage.CreateHabit(userId, habit)
+	s.publish(ctx, env)
+	return habit, nil
 }
 
-func (s *HabitService) GetAllHabits(userId string) ([]HabitModel, error) {
-	return s.storage.GetAllHabits(userId)
+func (s *HabitService) GetAllHabits(ctx context.Context, userId string) ([]HabitModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.GetAllHabits")
+	defer span.End()
+
+	return s.storage.GetAllHabits(ctx, userId)
 }
 
-func (s *HabitService) FindHabitById(userId, habitId string) (HabitModel, error) {
-	return s.storage.FindHabitById(userId, habitId)
+func (s *HabitService) FindHabitById(ctx context.Context, userId, habitId string) (HabitModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.FindHabitById")
+	defer span.End()
+
+	return s.storage.FindHabitById(ctx, userId, habitId)
 }
 
-func (s *HabitService) DeleteHabit(userId, habitId string) error {
-	return s.storage.DeleteHabit(userId, habitId)
+func (s *HabitService) DeleteHabit(ctx context.Context, userId, habitId string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.DeleteHabit")
+	defer span.End()
+
+	env, outbox, err := s.prepare(ctx, userId, HabitDeleted{ID: habitId})
+	if err != nil {
+		return err
+	}
+
+	err = s.storage.DeleteHabit(ctx, userId, habitId, outbox...)
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, env)
+	return nil
 }
 
-func (s *HabitService) UpdateHabit(userId, habitId string, req HabitReq) (HabitModel, error) {
-	existing, err := s.storage.FindHabitById(userId, habitId)
+func (s *HabitService) UpdateHabit(ctx context.Context, userId, habitId string, req HabitReq) (HabitModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.UpdateHabit")
+	defer span.End()
+
+	existing, err := s.storage.FindHabitById(ctx, userId, habitId)
 	if err != nil {
 		return HabitModel{}, err
 	}
@@ -50,43 +105,97 @@ func (s *HabitService) UpdateHabit(userId, habitId string, req HabitReq) (HabitM
 	existing.Name = req.Name
 	existing.Description = req.Description
 	existing.Color = req.Color
-	existing.UpdatedAt = time.Now().Unix()
+	existing.UpdatedAt = s.now().Unix()
+
+	env, outbox, err := s.prepare(ctx, userId, HabitUpdated{HabitModel: existing})
+	if err != nil {
+		return HabitModel{}, err
+	}
 
-	err = s.storage.UpdateHabit(userId, habitId, existing)
+	err = s.storage.UpdateHabit(ctx, userId, habitId, existing, outbox...)
 	if err != nil {
 		return HabitModel{}, err
 	}
 
+	s.publish(ctx, env)
 	return existing, nil
 }
 
-func (s *HabitService) CreateHabitLog(userId string, req HabitLogReq) (HabitLogModel, error) {
+func (s *HabitService) CreateHabitLog(ctx context.Context, userId string, req HabitLogReq) (HabitLogModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.CreateHabitLog")
+	defer span.End()
+
 	log := HabitLogModel{
 		ID:        uuid.New().String(),
 		HabitId:   req.HabitId,
 		Date:      req.Date,
 		Note:      req.Note,
-		CreatedAt: time.Now().Unix(),
-		UpdatedAt: time.Now().Unix(),
+		CreatedAt: s.now().Unix(),
+		UpdatedAt: s.now().Unix(),
+	}
+
+	env, outbox, err := s.prepare(ctx, userId, LogCreated{HabitLogModel: log})
+	if err != nil {
+		return HabitLogModel{}, err
 	}
 
-	return log, s.storage.CreateHabitLog(userId, log)
+	err = s.storage.CreateHabitLog(ctx, userId, log, outbox...)
+	if err != nil {
+		return HabitLogModel{}, err
+	}
+
+	s.publish(ctx, env)
+	return log, nil
 }
 
-func (s *HabitService) GetAllHabitLogs(userId string) ([]HabitLogModel, error) {
-	return s.storage.GetAllHabitLogs(userId)
+func (s *HabitService) GetAllHabitLogs(ctx context.Context, userId string) ([]HabitLogModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.GetAllHabitLogs")
+	defer span.End()
+
+	return s.storage.GetAllHabitLogs(ctx, userId)
 }
 
-func (s *HabitService) FindHabitLogById(userId, logId string) (HabitLogModel, error) {
-	return s.storage.FindHabitLogById(userId, logId)
+func (s *HabitService) FindHabitLogById(ctx context.Context, userId, logId string) (HabitLogModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.FindHabitLogById")
+	defer span.End()
+
+	return s.storage.FindHabitLogById(ctx, userId, logId)
 }
 
-func (s *HabitService) DeleteHabitLog(userId, logId string) error {
-	return s.storage.DeleteHabitLog(userId, logId)
+func (s *HabitService) DeleteHabitLog(ctx context.Context, userId, logId string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.DeleteHabitLog")
+	defer span.End()
+
+	// The event says which habit the log was for, so look it up first when
+	// anything listens
+	deleted := LogDeleted{ID: logId}
+	if s.events != nil {
+		log, err := s.storage.FindHabitLogById(ctx, userId, logId)
+		if err != nil {
+			return err
+		}
+		deleted.HabitId = log.HabitId
+	}
+
+	env, outbox, err := s.prepare(ctx, userId, deleted)
+	if err != nil {
+		return err
+	}
+
+	err = s.storage.DeleteHabitLog(ctx, userId, logId, outbox...)
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, env)
+	return nil
 }
 
-func (s *HabitService) UpdateHabitLog(userId, logId string, req HabitLogReq) (HabitLogModel, error) {
-	existing, err := s.storage.FindHabitLogById(userId, logId)
+func (s *HabitService) UpdateHabitLog(ctx context.Context, userId, logId string, req HabitLogReq) (HabitLogModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.UpdateHabitLog")
+	defer span.End()
+
+	existing, err := s.storage.FindHabitLogById(ctx, userId, logId)
 	if err != nil {
 		return HabitLogModel{}, err
 	}
@@ -94,12 +203,167 @@ func (s *HabitService) UpdateHabitLog(userId, logId string, req HabitLogReq) (Ha
 	existing.HabitId = req.HabitId
 	existing.Date = req.Date
 	existing.Note = req.Note
-	existing.UpdatedAt = time.Now().Unix()
+	existing.UpdatedAt = s.now().Unix()
 
-	err = s.storage.UpdateHabitLog(userId, logId, existing)
+	err = s.storage.UpdateHabitLog(ctx, userId, logId, existing)
 	if err != nil {
 		return HabitLogModel{}, err
 	}
 
 	return existing, nil
 }
+
+// GetHeatmap counts the habits done on each day of the range. A day's
+// scheduled habits are those created by then, plus any logged that day
+// before they were created. Logs of deleted habits are not counted.
+func (s *HabitService) GetHeatmap(ctx context.Context, userId string, req HeatmapReq) (HeatmapModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.GetHeatmap")
+	defer span.End()
+
+	settings, err := s.settings.GetSettings(ctx, userId)
+	if err != nil {
+		return HeatmapModel{}, err
+	}
+
+	// Without a start the range covers a year in whole weeks, so a year view
+	// can draw a column per week
+	if req.To == "" {
+		req.To = settings.Today(s.now())
+	}
+	if req.From == "" {
+		to, _ := time.Parse(time.DateOnly, req.To)
+		req.From = settings.StartOfWeek(to).AddDate(0, 0, -7*52).Format(time.DateOnly)
+	}
+	if len(req.validate()) > 0 {
+		return HeatmapModel{}, ErrInvalidRange
+	}
+
+	habits, err := s.storage.GetAllHabits(ctx, userId)
+	if err != nil {
+		return HeatmapModel{}, err
+	}
+
+	createdOn := map[string]string{}
+	for _, habit := range habits {
+		if req.HabitId == "" || habit.ID == req.HabitId {
+			createdOn[habit.ID] = settings.DateOf(habit.CreatedAt)
+		}
+	}
+	if req.HabitId != "" && len(createdOn) == 0 {
+		return HeatmapModel{}, ErrHabitNotFound
+	}
+
+	logs, err := s.storage.GetHabitLogsInRange(ctx, userId, req.From, req.To, req.HabitId)
+	if err != nil {
+		return HeatmapModel{}, err
+	}
+
+	done := map[string]map[string]bool{}
+	for _, log := range logs {
+		if _, ok := createdOn[log.HabitId]; !ok {
+			continue
+		}
+		if done[log.Date] == nil {
+			done[log.Date] = map[string]bool{}
+		}
+		done[log.Date][log.HabitId] = true
+	}
+
+	heatmap := HeatmapModel{
+		From:    req.From,
+		To:      req.To,
+		HabitId: req.HabitId,
+		Days:    []HeatmapDay{},
+	}
+
+	from, _ := time.Parse(time.DateOnly, req.From)
+	to, _ := time.Parse(time.DateOnly, req.To)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+
+		scheduled := 0
+		for habitId, created := range createdOn {
+			if created <= date || done[date][habitId] {
+				scheduled++
+			}
+		}
+
+		count := len(done[date])
+		ratio := 0.0
+		if scheduled > 0 {
+			ratio = math.Round(float64(count)/float64(scheduled)*100) / 100
+		}
+
+		heatmap.Total += count
+		heatmap.Days = append(heatmap.Days, HeatmapDay{
+			Date:      date,
+			Count:     count,
+			Scheduled: scheduled,
+			Ratio:     ratio,
+		})
+	}
+
+	return heatmap, nil
+}
+
+// GetDay joins the user's habits with their logs for date, or for today in
+// the user's time zone when date is empty.
+func (s *HabitService) GetDay(ctx context.Context, userId, date string) (DayModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HabitService.GetDay")
+	defer span.End()
+
+	settings, err := s.settings.GetSettings(ctx, userId)
+	if err != nil {
+		return DayModel{}, err
+	}
+	if date == "" {
+		date = settings.Today(s.now())
+	}
+
+	habits, err := s.storage.GetAllHabits(ctx, userId)
+	if err != nil {
+		return DayModel{}, err
+	}
+
+	logs, err := s.storage.GetHabitLogsOnDate(ctx, userId, date)
+	if err != nil {
+		return DayModel{}, err
+	}
+
+	logsByHabit := map[string][]HabitLogModel{}
+	for _, log := range logs {
+		logsByHabit[log.HabitId] = append(logsByHabit[log.HabitId], log)
+	}
+
+	day := DayModel{
+		Date:   date,
+		Habits: make([]DayHabit, 0, len(habits)),
+	}
+	for _, habit := range habits {
+		habitLogs := logsByHabit[habit.ID]
+		if habitLogs == nil {
+			habitLogs = []HabitLogModel{}
+		}
+
+		createdOn := settings.DateOf(habit.CreatedAt)
+		dayHabit := DayHabit{
+			Habit:     habit,
+			Done:      len(habitLogs) > 0,
+			Scheduled: createdOn <= date || len(habitLogs) > 0,
+			Logs:      habitLogs,
+		}
+		if dayHabit.Scheduled {
+			day.Scheduled++
+		}
+		if dayHabit.Done {
+			day.Completed++
+		}
+		day.Habits = append(day.Habits, dayHabit)
+	}
+
+	if day.Scheduled > 0 {
+		day.Progress = math.Round(float64(day.Completed)/float64(day.Scheduled)*100) / 100
+	}
+
+	return day, nil
+}
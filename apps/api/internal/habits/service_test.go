@@ -1,16 +1,34 @@
 package habits
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/events"
+	"github.com/jimvid/sidekick/internal/user"
 )
 
+// fixedSettings stands in for the user service.
+type fixedSettings user.SettingsModel
+
+func (s fixedSettings) GetSettings(context.Context, string) (user.SettingsModel, error) {
+	return user.SettingsModel(s), nil
+}
+
+var utcSettings = fixedSettings(user.DefaultSettings())
+
 func TestServiceCreateHabit(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
 	before := time.Now().Unix()
-	habit, err := service.CreateHabit("user-1", HabitReq{
+	habit, err := service.CreateHabit(context.Background(), "user-1", HabitReq{
 		Name:        "Exercise",
 		Description: "Daily workout",
 		Color:       "#ff0000",
@@ -40,7 +58,7 @@ func TestServiceCreateHabit(t *testing.T) {
 	}
 
 	// Verify persisted
-	result, err := storage.FindHabitById("user-1", habit.ID)
+	result, err := storage.FindHabitById(context.Background(), "user-1", habit.ID)
 	if err != nil {
 		t.Fatalf("FindHabitById after CreateHabit failed: %v", err)
 	}
@@ -51,10 +69,10 @@ func TestServiceCreateHabit(t *testing.T) {
 
 func TestServiceGetAllHabits(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
 	t.Run("empty", func(t *testing.T) {
-		habits, err := service.GetAllHabits("user-1")
+		habits, err := service.GetAllHabits(context.Background(), "user-1")
 		if err != nil {
 			t.Fatalf("GetAllHabits failed: %v", err)
 		}
@@ -64,10 +82,10 @@ func TestServiceGetAllHabits(t *testing.T) {
 	})
 
 	t.Run("returns results", func(t *testing.T) {
-		service.CreateHabit("user-1", HabitReq{Name: "A"})
-		service.CreateHabit("user-1", HabitReq{Name: "B"})
+		service.CreateHabit(context.Background(), "user-1", HabitReq{Name: "A"})
+		service.CreateHabit(context.Background(), "user-1", HabitReq{Name: "B"})
 
-		habits, err := service.GetAllHabits("user-1")
+		habits, err := service.GetAllHabits(context.Background(), "user-1")
 		if err != nil {
 			t.Fatalf("GetAllHabits failed: %v", err)
 		}
@@ -79,12 +97,12 @@ func TestServiceGetAllHabits(t *testing.T) {
 
 func TestServiceFindHabitById(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
-	created, _ := service.CreateHabit("user-1", HabitReq{Name: "Read"})
+	created, _ := service.CreateHabit(context.Background(), "user-1", HabitReq{Name: "Read"})
 
 	t.Run("found", func(t *testing.T) {
-		habit, err := service.FindHabitById("user-1", created.ID)
+		habit, err := service.FindHabitById(context.Background(), "user-1", created.ID)
 		if err != nil {
 			t.Fatalf("FindHabitById failed: %v", err)
 		}
@@ -94,7 +112,7 @@ func TestServiceFindHabitById(t *testing.T) {
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		_, err := service.FindHabitById("user-1", "does-not-exist")
+		_, err := service.FindHabitById(context.Background(), "user-1", "does-not-exist")
 		if err == nil {
 			t.Fatal("expected error for non-existent habit, got nil")
 		}
@@ -103,16 +121,16 @@ func TestServiceFindHabitById(t *testing.T) {
 
 func TestServiceDeleteHabit(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
-	created, _ := service.CreateHabit("user-1", HabitReq{Name: "Exercise"})
+	created, _ := service.CreateHabit(context.Background(), "user-1", HabitReq{Name: "Exercise"})
 
-	err := service.DeleteHabit("user-1", created.ID)
+	err := service.DeleteHabit(context.Background(), "user-1", created.ID)
 	if err != nil {
 		t.Fatalf("DeleteHabit failed: %v", err)
 	}
 
-	_, err = service.FindHabitById("user-1", created.ID)
+	_, err = service.FindHabitById(context.Background(), "user-1", created.ID)
 	if err == nil {
 		t.Fatal("expected error after delete, got nil")
 	}
@@ -120,9 +138,9 @@ func TestServiceDeleteHabit(t *testing.T) {
 
 func TestServiceUpdateHabit(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
-	created, _ := service.CreateHabit("user-1", HabitReq{
+	created, _ := service.CreateHabit(context.Background(), "user-1", HabitReq{
 		Name:        "Exercise",
 		Description: "Morning run",
 		Color:       "#ff0000",
@@ -130,7 +148,7 @@ func TestServiceUpdateHabit(t *testing.T) {
 
 	time.Sleep(time.Second) // ensure UpdatedAt differs
 
-	updated, err := service.UpdateHabit("user-1", created.ID, HabitReq{
+	updated, err := service.UpdateHabit(context.Background(), "user-1", created.ID, HabitReq{
 		Name:        "Yoga",
 		Description: "Evening yoga",
 		Color:       "#00ff00",
@@ -158,9 +176,9 @@ func TestServiceUpdateHabit(t *testing.T) {
 
 func TestServiceCreateHabitLog(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
-	log, err := service.CreateHabitLog("user-1", HabitLogReq{
+	log, err := service.CreateHabitLog(context.Background(), "user-1", HabitLogReq{
 		HabitId: "habit-1",
 		Date:    "2026-02-08",
 		Note:    "Morning run",
@@ -183,7 +201,7 @@ func TestServiceCreateHabitLog(t *testing.T) {
 	}
 
 	// Verify persisted
-	result, err := storage.FindHabitLogById("user-1", log.ID)
+	result, err := storage.FindHabitLogById(context.Background(), "user-1", log.ID)
 	if err != nil {
 		t.Fatalf("FindLogById after CreateLog failed: %v", err)
 	}
@@ -194,10 +212,10 @@ func TestServiceCreateHabitLog(t *testing.T) {
 
 func TestServiceGetAllHabitLogs(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
 	t.Run("empty", func(t *testing.T) {
-		logs, err := service.GetAllHabitLogs("user-1")
+		logs, err := service.GetAllHabitLogs(context.Background(), "user-1")
 		if err != nil {
 			t.Fatalf("GetAllLogs failed: %v", err)
 		}
@@ -207,10 +225,10 @@ func TestServiceGetAllHabitLogs(t *testing.T) {
 	})
 
 	t.Run("returns results", func(t *testing.T) {
-		service.CreateHabitLog("user-1", HabitLogReq{HabitId: "h1", Date: "2026-02-08"})
-		service.CreateHabitLog("user-1", HabitLogReq{HabitId: "h1", Date: "2026-02-09"})
+		service.CreateHabitLog(context.Background(), "user-1", HabitLogReq{HabitId: "h1", Date: "2026-02-08"})
+		service.CreateHabitLog(context.Background(), "user-1", HabitLogReq{HabitId: "h1", Date: "2026-02-09"})
 
-		logs, err := service.GetAllHabitLogs("user-1")
+		logs, err := service.GetAllHabitLogs(context.Background(), "user-1")
 		if err != nil {
 			t.Fatalf("GetAllLogs failed: %v", err)
 		}
@@ -222,12 +240,12 @@ func TestServiceGetAllHabitLogs(t *testing.T) {
 
 func TestServiceFindHabitLogById(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
-	created, _ := service.CreateHabitLog("user-1", HabitLogReq{HabitId: "h1", Date: "2026-02-08", Note: "test"})
+	created, _ := service.CreateHabitLog(context.Background(), "user-1", HabitLogReq{HabitId: "h1", Date: "2026-02-08", Note: "test"})
 
 	t.Run("found", func(t *testing.T) {
-		log, err := service.FindHabitLogById("user-1", created.ID)
+		log, err := service.FindHabitLogById(context.Background(), "user-1", created.ID)
 		if err != nil {
 			t.Fatalf("FindLogById failed: %v", err)
 		}
@@ -237,7 +255,7 @@ func TestServiceFindHabitLogById(t *testing.T) {
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		_, err := service.FindHabitLogById("user-1", "does-not-exist")
+		_, err := service.FindHabitLogById(context.Background(), "user-1", "does-not-exist")
 		if err == nil {
 			t.Fatal("expected error for non-existent log, got nil")
 		}
@@ -246,16 +264,16 @@ func TestServiceFindHabitLogById(t *testing.T) {
 
 func TestServiceDeleteHabitLog(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
-	created, _ := service.CreateHabitLog("user-1", HabitLogReq{HabitId: "h1", Date: "2026-02-08"})
+	created, _ := service.CreateHabitLog(context.Background(), "user-1", HabitLogReq{HabitId: "h1", Date: "2026-02-08"})
 
-	err := service.DeleteHabitLog("user-1", created.ID)
+	err := service.DeleteHabitLog(context.Background(), "user-1", created.ID)
 	if err != nil {
 		t.Fatalf("DeleteLog failed: %v", err)
 	}
 
-	_, err = service.FindHabitLogById("user-1", created.ID)
+	_, err = service.FindHabitLogById(context.Background(), "user-1", created.ID)
 	if err == nil {
 		t.Fatal("expected error after delete, got nil")
 	}
@@ -263,15 +281,15 @@ func TestServiceDeleteHabitLog(t *testing.T) {
 
 func TestServiceUpdateHabitLog(t *testing.T) {
 	storage := setupTestDB(t)
-	service := NewHabitService(storage)
+	service := NewHabitService(storage, utcSettings, nil)
 
-	created, _ := service.CreateHabitLog("user-1", HabitLogReq{
+	created, _ := service.CreateHabitLog(context.Background(), "user-1", HabitLogReq{
 		HabitId: "habit-1",
 		Date:    "2026-02-08",
 		Note:    "Morning run",
 	})
 
-	updated, err := service.UpdateHabitLog("user-1", created.ID, HabitLogReq{
+	updated, err := service.UpdateHabitLog(context.Background(), "user-1", created.ID, HabitLogReq{
 		HabitId: "habit-2",
 		Date:    "2026-02-09",
 		Note:    "Evening yoga",
@@ -293,3 +311,280 @@ func TestServiceUpdateHabitLog(t *testing.T) {
 		t.Errorf("expected ID to be preserved (%q), got %q", created.ID, updated.ID)
 	}
 }
+
+func TestServiceGetHeatmap(t *testing.T) {
+	storage := setupTestDB(t)
+	service := NewHabitService(storage, utcSettings, nil)
+	ctx := context.Background()
+
+	created := func(date string) int64 {
+		day, _ := time.Parse(time.DateOnly, date)
+		return day.Unix()
+	}
+
+	read := makeHabit("read", "Read")
+	read.CreatedAt = created("2026-02-01")
+	run := makeHabit("run", "Run")
+	run.CreatedAt = created("2026-02-03")
+	storage.CreateHabit(ctx, "user-1", read)
+	storage.CreateHabit(ctx, "user-1", run)
+
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l1", "read", "2026-02-02"))
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l2", "read", "2026-02-03"))
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l3", "run", "2026-02-03"))
+	// Duplicate log for the same day counts once
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l4", "run", "2026-02-03"))
+	// Backfilled before the habit was created
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l5", "run", "2026-02-01"))
+	// Deleted habit
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l6", "gone", "2026-02-02"))
+
+	t.Run("all habits", func(t *testing.T) {
+		heatmap, err := service.GetHeatmap(ctx, "user-1", HeatmapReq{From: "2026-01-31", To: "2026-02-04"})
+		if err != nil {
+			t.Fatalf("GetHeatmap failed: %v", err)
+		}
+
+		want := []HeatmapDay{
+			{Date: "2026-01-31", Count: 0, Scheduled: 0, Ratio: 0},
+			{Date: "2026-02-01", Count: 1, Scheduled: 2, Ratio: 0.5},
+			{Date: "2026-02-02", Count: 1, Scheduled: 1, Ratio: 1},
+			{Date: "2026-02-03", Count: 2, Scheduled: 2, Ratio: 1},
+			{Date: "2026-02-04", Count: 0, Scheduled: 2, Ratio: 0},
+		}
+		if !reflect.DeepEqual(heatmap.Days, want) {
+			t.Errorf("expected days %+v, got %+v", want, heatmap.Days)
+		}
+		if heatmap.Total != 4 {
+			t.Errorf("expected total 4, got %d", heatmap.Total)
+		}
+	})
+
+	t.Run("one habit", func(t *testing.T) {
+		heatmap, err := service.GetHeatmap(ctx, "user-1", HeatmapReq{From: "2026-02-02", To: "2026-02-03", HabitId: "run"})
+		if err != nil {
+			t.Fatalf("GetHeatmap failed: %v", err)
+		}
+
+		want := []HeatmapDay{
+			{Date: "2026-02-02", Count: 0, Scheduled: 0, Ratio: 0},
+			{Date: "2026-02-03", Count: 1, Scheduled: 1, Ratio: 1},
+		}
+		if !reflect.DeepEqual(heatmap.Days, want) {
+			t.Errorf("expected days %+v, got %+v", want, heatmap.Days)
+		}
+	})
+
+	t.Run("unknown habit", func(t *testing.T) {
+		_, err := service.GetHeatmap(ctx, "user-1", HeatmapReq{From: "2026-02-01", To: "2026-02-03", HabitId: "gone"})
+		if !errors.Is(err, ErrHabitNotFound) {
+			t.Errorf("expected ErrHabitNotFound, got %v", err)
+		}
+	})
+}
+
+func TestServiceGetDay(t *testing.T) {
+	storage := setupTestDB(t)
+	service := NewHabitService(storage, utcSettings, nil)
+	ctx := context.Background()
+
+	created := func(date string) int64 {
+		day, _ := time.Parse(time.DateOnly, date)
+		return day.Unix()
+	}
+
+	read := makeHabit("read", "Read")
+	read.CreatedAt = created("2026-02-01")
+	run := makeHabit("run", "Run")
+	run.CreatedAt = created("2026-02-01")
+	swim := makeHabit("swim", "Swim")
+	swim.CreatedAt = created("2026-02-10")
+	storage.CreateHabit(ctx, "user-1", read)
+	storage.CreateHabit(ctx, "user-1", run)
+	storage.CreateHabit(ctx, "user-1", swim)
+
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l1", "read", "2026-02-08"))
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l2", "read", "2026-02-07"))
+	// Deleted habit
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l3", "gone", "2026-02-08"))
+
+	day, err := service.GetDay(ctx, "user-1", "2026-02-08")
+	if err != nil {
+		t.Fatalf("GetDay failed: %v", err)
+	}
+
+	if day.Date != "2026-02-08" || len(day.Habits) != 3 {
+		t.Fatalf("expected three habits on 2026-02-08, got %+v", day)
+	}
+
+	byId := map[string]DayHabit{}
+	for _, habit := range day.Habits {
+		byId[habit.Habit.ID] = habit
+	}
+	if h := byId["read"]; !h.Done || !h.Scheduled || len(h.Logs) != 1 || h.Logs[0].Note != "test note" {
+		t.Errorf("expected read to be done with its note, got %+v", h)
+	}
+	if h := byId["run"]; h.Done || !h.Scheduled || h.Logs == nil {
+		t.Errorf("expected run to be scheduled and not done, got %+v", h)
+	}
+	if h := byId["swim"]; h.Done || h.Scheduled {
+		t.Errorf("expected swim not to be scheduled before it was created, got %+v", h)
+	}
+
+	if day.Scheduled != 2 || day.Completed != 1 || day.Progress != 0.5 {
+		t.Errorf("expected 1 of 2 done, got %d of %d (%v)", day.Completed, day.Scheduled, day.Progress)
+	}
+}
+
+func TestServiceUsesTimeZone(t *testing.T) {
+	storage := setupTestDB(t)
+	auckland := user.DefaultSettings()
+	auckland.TimeZone = "Pacific/Auckland"
+	auckland.WeekStart = "sunday"
+	service := NewHabitService(storage, fixedSettings(auckland), nil)
+	ctx := context.Background()
+
+	// Already Monday 9 February in Auckland
+	now := time.Date(2026, 2, 8, 20, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	habit := makeHabit("read", "Read")
+	habit.CreatedAt = now.Unix()
+	storage.CreateHabit(ctx, "user-1", habit)
+	storage.CreateHabitLog(ctx, "user-1", makeLog("l1", "read", "2026-02-09"))
+
+	t.Run("today", func(t *testing.T) {
+		day, err := service.GetDay(ctx, "user-1", "")
+		if err != nil {
+			t.Fatalf("GetDay failed: %v", err)
+		}
+		if day.Date != "2026-02-09" || day.Completed != 1 || !day.Habits[0].Scheduled {
+			t.Errorf("expected 2026-02-09 to be done, got %+v", day)
+		}
+	})
+
+	t.Run("created on", func(t *testing.T) {
+		day, err := service.GetDay(ctx, "user-1", "2026-02-08")
+		if err != nil {
+			t.Fatalf("GetDay failed: %v", err)
+		}
+		if day.Scheduled != 0 {
+			t.Errorf("expected the habit to be created on 2026-02-09 in Auckland, got %+v", day)
+		}
+	})
+
+	t.Run("heatmap weeks", func(t *testing.T) {
+		heatmap, err := service.GetHeatmap(ctx, "user-1", HeatmapReq{})
+		if err != nil {
+			t.Fatalf("GetHeatmap failed: %v", err)
+		}
+		// Sunday 8 February starts the week, 52 weeks earlier is 9 February 2025
+		if heatmap.From != "2025-02-09" || heatmap.To != "2026-02-09" || len(heatmap.Days) != 366 {
+			t.Errorf("expected 2025-02-09 to 2026-02-09, got %s to %s with %d days", heatmap.From, heatmap.To, len(heatmap.Days))
+		}
+	})
+
+	t.Run("defaults make a backwards range", func(t *testing.T) {
+		_, err := service.GetHeatmap(ctx, "user-1", HeatmapReq{From: "2026-03-01"})
+		if !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("expected ErrInvalidRange, got %v", err)
+		}
+	})
+}
+
+// recordedEvents collects the events a service publishes.
+type recordedEvents []events.Event
+
+func (r *recordedEvents) Prepare(_ context.Context, userId string, event events.Event) (events.Envelope, []types.TransactWriteItem, error) {
+	if userId != "user-1" {
+		return events.Envelope{}, nil, errors.New("unexpected user " + userId)
+	}
+	return events.Envelope{UserId: userId, Type: event.EventType(), Event: event}, nil, nil
+}
+
+func (r *recordedEvents) Publish(_ context.Context, env events.Envelope) error {
+	*r = append(*r, env.Event)
+	return nil
+}
+
+func TestServicePublishesEvents(t *testing.T) {
+	storage := setupTestDB(t)
+	published := &recordedEvents{}
+	service := NewHabitService(storage, utcSettings, published)
+	ctx := context.Background()
+
+	habit, _ := service.CreateHabit(ctx, "user-1", HabitReq{Name: "Read"})
+	updated, _ := service.UpdateHabit(ctx, "user-1", habit.ID, HabitReq{Name: "Read more"})
+	log, _ := service.CreateHabitLog(ctx, "user-1", HabitLogReq{HabitId: habit.ID, Date: "2026-03-02"})
+	service.DeleteHabitLog(ctx, "user-1", log.ID)
+	service.DeleteHabit(ctx, "user-1", habit.ID)
+
+	// Failed changes publish nothing
+	service.DeleteHabit(ctx, "user-1", habit.ID)
+	service.DeleteHabitLog(ctx, "user-1", log.ID)
+
+	want := []events.Event{
+		HabitCreated{HabitModel: habit},
+		HabitUpdated{HabitModel: updated},
+		LogCreated{HabitLogModel: log},
+		LogDeleted{ID: log.ID, HabitId: habit.ID},
+		HabitDeleted{ID: habit.ID},
+	}
+	if !reflect.DeepEqual([]events.Event(*published), want) {
+		t.Errorf("expected %+v, got %+v", want, *published)
+	}
+}
+
+func TestServiceRecordsEventsWithChanges(t *testing.T) {
+	storage := setupTestDB(t)
+	outbox := events.NewOutboxStorage(storage.db, storage.cfg)
+	bus := events.NewBus(outbox)
+	bus.SubscribeAsync("failing", events.AllEvents, func(context.Context, events.Envelope) error {
+		return errors.New("subscriber failed")
+	})
+	service := NewHabitService(storage, utcSettings, bus)
+	ctx := context.Background()
+
+	habit, err := service.CreateHabit(ctx, "user-1", HabitReq{Name: "Read"})
+	if err != nil {
+		t.Fatalf("CreateHabit failed: %v", err)
+	}
+	if err := service.DeleteHabit(ctx, "user-1", "missing"); !errors.Is(err, ErrHabitNotFound) {
+		t.Errorf("expected ErrHabitNotFound, got %v", err)
+	}
+	if err := service.DeleteHabitLog(ctx, "user-1", "missing"); !errors.Is(err, ErrHabitLogNotFound) {
+		t.Errorf("expected ErrHabitLogNotFound, got %v", err)
+	}
+	bus.Wait()
+
+	// The failed deletes left no records behind for the relay
+	records, err := outbox.GetRecords(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if len(records) != 1 || records[0].Type != EventHabitCreated || records[0].UserId != "user-1" {
+		t.Errorf("expected the habit.created record, got %+v", records)
+	}
+
+	if err := service.DeleteHabit(ctx, "user-1", habit.ID); err != nil {
+		t.Fatalf("DeleteHabit failed: %v", err)
+	}
+	if _, err := storage.FindHabitById(ctx, "user-1", habit.ID); !errors.Is(err, ErrHabitNotFound) {
+		t.Errorf("expected the habit to be deleted with its record, got %v", err)
+	}
+}
+
+func TestEventsEncodeAsModels(t *testing.T) {
+	habit := HabitModel{ID: "1", Name: "Read", CreatedAt: 1, UpdatedAt: 2}
+	event, _ := json.Marshal(HabitCreated{HabitModel: habit})
+	model, _ := json.Marshal(habit)
+	if string(event) != string(model) {
+		t.Errorf("expected %s, got %s", model, event)
+	}
+
+	for _, event := range Events {
+		if !slices.Contains(EventTypes, event.EventType()) {
+			t.Errorf("%T: %s is missing from EventTypes", event, event.EventType())
+		}
+	}
+}
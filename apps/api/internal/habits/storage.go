@@ -1,82 +1,131 @@
 package habits
 
 import (
+	"context"
 	"errors"
 	"log/slog"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/migrations"
 )
 
 const (
 	itemPrefixHabit    = "habit#"
 	itemPrefixHabitLog = "habit-log#"
+
+	// IndexByDate is a sparse index on (userId, Date). Only habit logs have a
+	// Date, so it holds just the logs, ordered by day. It projects HabitId.
+	IndexByDate = "byDate"
+
+	// maxBatchGetKeys is the most keys DynamoDB accepts in one BatchGetItem.
+	maxBatchGetKeys        = 100
+	maxUnprocessedAttempts = 5
+)
+
+var (
+	ErrHabitNotFound    = errors.New("could not find a habit with that ID")
+	ErrHabitLogNotFound = errors.New("could not find a habit log with that ID")
+	// ErrInvalidRange is returned for a date range that is backwards or too
+	// long once its defaults are filled in.
+	ErrInvalidRange = errors.New("date range is invalid")
+
+	errUnprocessedKeys = errors.New("batch get still has unprocessed keys after retries")
 )
 
 type HabitStorage struct {
-	db  *dynamodb.DynamoDB
-	cfg *config.Config
+	db       *dynamodb.Client
+	cfg      *config.Config
+	migrator *migrations.Migrator
 }
 
-func NewHabitStorage(db *dynamodb.DynamoDB, cfg *config.Config) *HabitStorage {
+func NewHabitStorage(db *dynamodb.Client, cfg *config.Config) *HabitStorage {
 	return &HabitStorage{
-		db:  db,
-		cfg: cfg,
+		db:       db,
+		cfg:      cfg,
+		migrator: migrations.NewMigrator(migrations.All),
 	}
 }
 
-func (s *HabitStorage) CreateHabit(userId string, habit HabitModel) error {
-	newItem := habitItem{
-		UserId:     userId,
-		ItemId:     itemPrefixHabit + habit.ID,
-		HabitModel: habit,
-	}
-
-	attributeValue, err := dynamodbattribute.MarshalMap(newItem)
+// upgrade applies pending migrations to an item read from the table, in
+// memory only. Stored items are rewritten by the migration runner, so a read
+// never writes.
+func (s *HabitStorage) upgrade(ctx context.Context, item migrations.Item) error {
+	_, err := s.migrator.Upgrade(item)
 	if err != nil {
-		slog.Error("Failed to marshal habit", "error", err)
+		slog.ErrorContext(ctx, "Failed to migrate item", "error", err)
 		return err
 	}
+	return nil
+}
 
-	input := &dynamodb.PutItemInput{
-		TableName: aws.String(s.cfg.TABLE_NAME),
-		Item:      attributeValue,
+func (s *HabitStorage) upgradeAll(ctx context.Context, items []migrations.Item) error {
+	for _, item := range items {
+		err := s.upgrade(ctx, item)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	slog.Debug("Writing to DynamoDB", "table", s.cfg.TABLE_NAME, "userId", newItem.UserId, "itemId", newItem.ItemId)
+// CreateHabit saves a new habit, with outbox, the outbox records of the
+// event it causes, in the same transaction.
+func (s *HabitStorage) CreateHabit(ctx context.Context, userId string, habit HabitModel, outbox ...types.TransactWriteItem) error {
+	newItem := habitItem{
+		UserId:        userId,
+		ItemId:        itemPrefixHabit + habit.ID,
+		SchemaVersion: s.migrator.Latest(),
+		HabitModel:    habit,
+	}
 
-	_, err = s.db.PutItem(input)
+	attributeValue, err := attributevalue.MarshalMap(newItem)
 	if err != nil {
-		slog.Error("DynamoDB PutItem failed", "error", err, "table", s.cfg.TABLE_NAME)
+		slog.ErrorContext(ctx, "Failed to marshal habit", "error", err)
 		return err
 	}
 
-	return nil
+	slog.DebugContext(ctx, "Writing to DynamoDB", "table", s.cfg.TableName, "itemId", newItem.ItemId)
+
+	_, err = s.write(ctx, types.TransactWriteItem{Put: &types.Put{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      attributeValue,
+	}}, outbox)
+	return err
 }
 
-func (s *HabitStorage) GetAllHabits(userId string) ([]HabitModel, error) {
+func (s *HabitStorage) GetAllHabits(ctx context.Context, userId string) ([]HabitModel, error) {
 	var items []habitItem
 
 	input := &dynamodb.QueryInput{
-		TableName:              aws.String(s.cfg.TABLE_NAME),
+		TableName:              aws.String(s.cfg.TableName),
 		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
-		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-			":userId": {S: aws.String(userId)},
-			":itemId": {S: aws.String(itemPrefixHabit)},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixHabit},
 		},
 	}
 
-	result, err := s.db.Query(input)
+	result, err := s.db.Query(ctx, input)
 	if err != nil {
-		slog.Error("DynamoDB Query failed", "error", err, "userId", userId)
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err)
 		return nil, err
 	}
 
-	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &items)
+	err = s.upgradeAll(ctx, result.Items)
 	if err != nil {
-		slog.Error("Failed to unmarshal habits", "error", err)
+		return nil, err
+	}
+
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal habits", "error", err)
 		return nil, err
 	}
 
@@ -88,139 +137,138 @@ func (s *HabitStorage) GetAllHabits(userId string) ([]HabitModel, error) {
 	return habits, nil
 }
 
-func (s *HabitStorage) FindHabitById(userId, habitId string) (HabitModel, error) {
+func (s *HabitStorage) FindHabitById(ctx context.Context, userId, habitId string) (HabitModel, error) {
 	var item habitItem
 
 	input := &dynamodb.GetItemInput{
-		TableName: aws.String(s.cfg.TABLE_NAME),
-		Key: map[string]*dynamodb.AttributeValue{
-			"userId": {S: aws.String(userId)},
-			"itemId": {S: aws.String(itemPrefixHabit + habitId)},
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixHabit + habitId},
 		},
 	}
 
-	result, err := s.db.GetItem(input)
+	result, err := s.db.GetItem(ctx, input)
 	if err != nil {
-		slog.Error("DynamoDB GetItem failed", "error", err, "userId", userId, "habitId", habitId)
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err, "habitId", habitId)
 		return HabitModel{}, err
 	}
 
 	if result.Item == nil {
-		return HabitModel{}, errors.New("could not find a habit with that ID")
+		return HabitModel{}, ErrHabitNotFound
+	}
+
+	err = s.upgrade(ctx, result.Item)
+	if err != nil {
+		return HabitModel{}, err
 	}
 
-	err = dynamodbattribute.UnmarshalMap(result.Item, &item)
+	err = attributevalue.UnmarshalMap(result.Item, &item)
 	if err != nil {
-		slog.Error("Failed to unmarshal habit", "error", err)
+		slog.ErrorContext(ctx, "Failed to unmarshal habit", "error", err)
 		return HabitModel{}, err
 	}
 
 	return item.HabitModel, nil
 }
 
-func (s *HabitStorage) DeleteHabit(userId, habitId string) error {
+func (s *HabitStorage) DeleteHabit(ctx context.Context, userId, habitId string, outbox ...types.TransactWriteItem) error {
 	// TODO: Delete all habit logs related to the habit
-	input := &dynamodb.DeleteItemInput{
-		TableName: aws.String(s.cfg.TABLE_NAME),
-		Key: map[string]*dynamodb.AttributeValue{
-			"userId": {S: aws.String(userId)},
-			"itemId": {S: aws.String(itemPrefixHabit + habitId)},
+	found, err := s.write(ctx, types.TransactWriteItem{Delete: &types.Delete{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixHabit + habitId},
 		},
-		ReturnValues: aws.String("ALL_OLD"),
-	}
-
-	result, err := s.db.DeleteItem(input)
+		ConditionExpression: aws.String("attribute_exists(itemId)"),
+	}}, outbox)
 	if err != nil {
-		slog.Error("DynamoDB DeleteItem failed", "error", err, "userId", userId, "habitId", habitId)
 		return err
 	}
 
-	if result.Attributes == nil {
-		return errors.New("could not find a habit with that ID")
+	if !found {
+		return ErrHabitNotFound
 	}
 
-	slog.Info("Habit deleted", "habitId", habitId, "userId", userId)
+	slog.InfoContext(ctx, "Habit deleted", "habitId", habitId)
 	return nil
 }
 
-func (s *HabitStorage) UpdateHabit(userId, habitId string, habit HabitModel) error {
+func (s *HabitStorage) UpdateHabit(ctx context.Context, userId, habitId string, habit HabitModel, outbox ...types.TransactWriteItem) error {
 	item := habitItem{
-		UserId:     userId,
-		ItemId:     itemPrefixHabit + habitId,
-		HabitModel: habit,
+		UserId:        userId,
+		ItemId:        itemPrefixHabit + habitId,
+		SchemaVersion: s.migrator.Latest(),
+		HabitModel:    habit,
 	}
 
-	attributeValue, err := dynamodbattribute.MarshalMap(item)
+	attributeValue, err := attributevalue.MarshalMap(item)
 	if err != nil {
-		slog.Error("Failed to marshal habit", "error", err)
+		slog.ErrorContext(ctx, "Failed to marshal habit", "error", err)
 		return err
 	}
 
-	input := &dynamodb.PutItemInput{
-		TableName: aws.String(s.cfg.TABLE_NAME),
+	_, err = s.write(ctx, types.TransactWriteItem{Put: &types.Put{
+		TableName: aws.String(s.cfg.TableName),
 		Item:      attributeValue,
-	}
-
-	_, err = s.db.PutItem(input)
+	}}, outbox)
 	if err != nil {
-		slog.Error("DynamoDB PutItem failed", "error", err, "userId", userId, "habitId", habitId)
 		return err
 	}
 
-	slog.Info("Habit updated", "habitId", habitId)
+	slog.InfoContext(ctx, "Habit updated", "habitId", habitId)
 	return nil
 }
 
-func (s *HabitStorage) CreateHabitLog(userId string, log HabitLogModel) error {
+func (s *HabitStorage) CreateHabitLog(ctx context.Context, userId string, log HabitLogModel, outbox ...types.TransactWriteItem) error {
 	newItem := habitLogItem{
 		UserId:        userId,
 		ItemId:        itemPrefixHabitLog + log.ID,
+		SchemaVersion: s.migrator.Latest(),
 		HabitLogModel: log,
 	}
 
-	attributeValue, err := dynamodbattribute.MarshalMap(newItem)
+	attributeValue, err := attributevalue.MarshalMap(newItem)
 	if err != nil {
-		slog.Error("Failed to marshal habit log", "error", err)
+		slog.ErrorContext(ctx, "Failed to marshal habit log", "error", err)
 		return err
 	}
 
-	input := &dynamodb.PutItemInput{
-		TableName: aws.String(s.cfg.TABLE_NAME),
-		Item:      attributeValue,
-	}
-
-	slog.Debug("Writing habit log to DynamoDB", "table", s.cfg.TABLE_NAME, "userId", newItem.UserId, "itemId", newItem.ItemId)
-
-	_, err = s.db.PutItem(input)
-	if err != nil {
-		slog.Error("DynamoDB PutItem failed", "error", err, "table", s.cfg.TABLE_NAME)
-		return err
-	}
+	slog.DebugContext(ctx, "Writing habit log to DynamoDB", "table", s.cfg.TableName, "itemId", newItem.ItemId)
 
-	return nil
+	_, err = s.write(ctx, types.TransactWriteItem{Put: &types.Put{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      attributeValue,
+	}}, outbox)
+	return err
 }
 
-func (s *HabitStorage) GetAllHabitLogs(userId string) ([]HabitLogModel, error) {
+func (s *HabitStorage) GetAllHabitLogs(ctx context.Context, userId string) ([]HabitLogModel, error) {
 	var items []habitLogItem
 
 	input := &dynamodb.QueryInput{
-		TableName:              aws.String(s.cfg.TABLE_NAME),
+		TableName:              aws.String(s.cfg.TableName),
 		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
-		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-			":userId": {S: aws.String(userId)},
-			":itemId": {S: aws.String(itemPrefixHabitLog)},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixHabitLog},
 		},
 	}
 
-	result, err := s.db.Query(input)
+	result, err := s.db.Query(ctx, input)
 	if err != nil {
-		slog.Error("DynamoDB Query failed", "error", err, "userId", userId)
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err)
 		return nil, err
 	}
 
-	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &items)
+	err = s.upgradeAll(ctx, result.Items)
 	if err != nil {
-		slog.Error("Failed to unmarshal habit logs", "error", err)
+		return nil, err
+	}
+
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal habit logs", "error", err)
 		return nil, err
 	}
 
@@ -232,84 +280,276 @@ func (s *HabitStorage) GetAllHabitLogs(userId string) ([]HabitLogModel, error) {
 	return logs, nil
 }
 
-func (s *HabitStorage) FindHabitLogById(userId, logId string) (HabitLogModel, error) {
+// GetHabitLogsInRange returns the logs dated from to to, both included,
+// optionally for one habit. Logs come from IndexByDate, which only projects
+// the keys, Date and HabitId, so the other fields are empty.
+func (s *HabitStorage) GetHabitLogsInRange(ctx context.Context, userId, from, to, habitId string) ([]HabitLogModel, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		IndexName:              aws.String(IndexByDate),
+		KeyConditionExpression: aws.String("userId = :userId AND #date BETWEEN :from AND :to"),
+		ExpressionAttributeNames: map[string]string{
+			"#date": "Date",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":from":   &types.AttributeValueMemberS{Value: from},
+			":to":     &types.AttributeValueMemberS{Value: to},
+		},
+	}
+	if habitId != "" {
+		input.FilterExpression = aws.String("HabitId = :habitId")
+		input.ExpressionAttributeValues[":habitId"] = &types.AttributeValueMemberS{Value: habitId}
+	}
+
+	// Not upgraded like other reads, migrations expect the full item and the
+	// index leaves attributes out
+	var logs []HabitLogModel
+	paginator := dynamodb.NewQueryPaginator(s.db, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err, "index", IndexByDate)
+			return nil, err
+		}
+
+		var items []habitLogItem
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to unmarshal habit logs", "error", err)
+			return nil, err
+		}
+		for _, item := range items {
+			logs = append(logs, item.HabitLogModel)
+		}
+	}
+
+	return logs, nil
+}
+
+// GetHabitLogsOnDate returns the full logs dated date. IndexByDate finds
+// their keys and the logs are then read from the table.
+func (s *HabitStorage) GetHabitLogsOnDate(ctx context.Context, userId, date string) ([]HabitLogModel, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		IndexName:              aws.String(IndexByDate),
+		KeyConditionExpression: aws.String("userId = :userId AND #date = :date"),
+		ExpressionAttributeNames: map[string]string{
+			"#date": "Date",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":date":   &types.AttributeValueMemberS{Value: date},
+		},
+		ProjectionExpression: aws.String("userId, itemId"),
+	}
+
+	var keys []map[string]types.AttributeValue
+	paginator := dynamodb.NewQueryPaginator(s.db, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err, "index", IndexByDate)
+			return nil, err
+		}
+		keys = append(keys, page.Items...)
+	}
+
+	var logs []HabitLogModel
+	for start := 0; start < len(keys); start += maxBatchGetKeys {
+		end := min(start+maxBatchGetKeys, len(keys))
+		items, err := s.batchGet(ctx, keys[start:end])
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.upgradeAll(ctx, items)
+		if err != nil {
+			return nil, err
+		}
+
+		var logItems []habitLogItem
+		err = attributevalue.UnmarshalListOfMaps(items, &logItems)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to unmarshal habit logs", "error", err)
+			return nil, err
+		}
+		for _, item := range logItems {
+			logs = append(logs, item.HabitLogModel)
+		}
+	}
+
+	// BatchGetItem returns items in no particular order
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].CreatedAt < logs[j].CreatedAt
+	})
+
+	return logs, nil
+}
+
+// batchGet reads up to maxBatchGetKeys items, retrying unprocessed keys with
+// exponential backoff.
+func (s *HabitStorage) batchGet(ctx context.Context, keys []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	pending := map[string]types.KeysAndAttributes{s.cfg.TableName: {Keys: keys}}
+	backoff := 50 * time.Millisecond
+
+	var items []map[string]types.AttributeValue
+	for attempt := 1; ; attempt++ {
+		result, err := s.db.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: pending})
+		if err != nil {
+			slog.ErrorContext(ctx, "DynamoDB BatchGetItem failed", "error", err, "table", s.cfg.TableName)
+			return nil, err
+		}
+		items = append(items, result.Responses[s.cfg.TableName]...)
+
+		if len(result.UnprocessedKeys) == 0 {
+			return items, nil
+		}
+		if attempt == maxUnprocessedAttempts {
+			return nil, errUnprocessedKeys
+		}
+
+		pending = result.UnprocessedKeys
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *HabitStorage) FindHabitLogById(ctx context.Context, userId, logId string) (HabitLogModel, error) {
 	var item habitLogItem
 
 	input := &dynamodb.GetItemInput{
-		TableName: aws.String(s.cfg.TABLE_NAME),
-		Key: map[string]*dynamodb.AttributeValue{
-			"userId": {S: aws.String(userId)},
-			"itemId": {S: aws.String(itemPrefixHabitLog + logId)},
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixHabitLog + logId},
 		},
 	}
 
-	result, err := s.db.GetItem(input)
+	result, err := s.db.GetItem(ctx, input)
 	if err != nil {
-		slog.Error("DynamoDB GetItem failed", "error", err, "userId", userId, "logId", logId)
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err, "logId", logId)
 		return HabitLogModel{}, err
 	}
 
 	if result.Item == nil {
-		return HabitLogModel{}, errors.New("could not find a habit log with that ID")
+		return HabitLogModel{}, ErrHabitLogNotFound
+	}
+
+	err = s.upgrade(ctx, result.Item)
+	if err != nil {
+		return HabitLogModel{}, err
 	}
 
-	err = dynamodbattribute.UnmarshalMap(result.Item, &item)
+	err = attributevalue.UnmarshalMap(result.Item, &item)
 	if err != nil {
-		slog.Error("Failed to unmarshal habit log", "error", err)
+		slog.ErrorContext(ctx, "Failed to unmarshal habit log", "error", err)
 		return HabitLogModel{}, err
 	}
 
 	return item.HabitLogModel, nil
 }
 
-func (s *HabitStorage) DeleteHabitLog(userId, logId string) error {
-	input := &dynamodb.DeleteItemInput{
-		TableName: aws.String(s.cfg.TABLE_NAME),
-		Key: map[string]*dynamodb.AttributeValue{
-			"userId": {S: aws.String(userId)},
-			"itemId": {S: aws.String(itemPrefixHabitLog + logId)},
+func (s *HabitStorage) DeleteHabitLog(ctx context.Context, userId, logId string, outbox ...types.TransactWriteItem) error {
+	found, err := s.write(ctx, types.TransactWriteItem{Delete: &types.Delete{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixHabitLog + logId},
 		},
-		ReturnValues: aws.String("ALL_OLD"),
-	}
-
-	result, err := s.db.DeleteItem(input)
+		ConditionExpression: aws.String("attribute_exists(itemId)"),
+	}}, outbox)
 	if err != nil {
-		slog.Error("DynamoDB DeleteItem failed", "error", err, "userId", userId, "logId", logId)
 		return err
 	}
 
-	if result.Attributes == nil {
-		return errors.New("could not find a habit log with that ID")
+	if !found {
+		return ErrHabitLogNotFound
 	}
 
-	slog.Info("Habit log deleted", "logId", logId, "userId", userId)
+	slog.InfoContext(ctx, "Habit log deleted", "logId", logId)
 	return nil
 }
 
-func (s *HabitStorage) UpdateHabitLog(userId, logId string, log HabitLogModel) error {
+func (s *HabitStorage) UpdateHabitLog(ctx context.Context, userId, logId string, log HabitLogModel) error {
 	item := habitLogItem{
 		UserId:        userId,
 		ItemId:        itemPrefixHabitLog + logId,
+		SchemaVersion: s.migrator.Latest(),
 		HabitLogModel: log,
 	}
 
-	attributeValue, err := dynamodbattribute.MarshalMap(item)
+	attributeValue, err := attributevalue.MarshalMap(item)
 	if err != nil {
-		slog.Error("Failed to marshal habit log", "error", err)
+		slog.ErrorContext(ctx, "Failed to marshal habit log", "error", err)
 		return err
 	}
 
 	input := &dynamodb.PutItemInput{
-		TableName: aws.String(s.cfg.TABLE_NAME),
+		TableName: aws.String(s.cfg.TableName),
 		Item:      attributeValue,
 	}
 
-	_, err = s.db.PutItem(input)
+	_, err = s.db.PutItem(ctx, input)
 	if err != nil {
-		slog.Error("DynamoDB PutItem failed", "error", err, "userId", userId, "logId", logId)
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "logId", logId)
 		return err
 	}
 
-	slog.Info("Habit log updated", "logId", logId)
+	slog.InfoContext(ctx, "Habit log updated", "logId", logId)
 	return nil
 }
+
+// write saves change, a Put or a Delete, with the outbox records of the
+// event it causes in one transaction, so the event is recorded exactly when
+// the change is. Without records it is a single write. It reports false
+// when the condition of change failed.
+func (s *HabitStorage) write(ctx context.Context, change types.TransactWriteItem, outbox []types.TransactWriteItem) (bool, error) {
+	var err error
+	switch {
+	case len(outbox) > 0:
+		_, err = s.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: append([]types.TransactWriteItem{change}, outbox...),
+		})
+		// Only change has a condition, so a failed one is always its
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && canceledByCondition(canceled) {
+			return false, nil
+		}
+	case change.Put != nil:
+		_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:           change.Put.TableName,
+			Item:                change.Put.Item,
+			ConditionExpression: change.Put.ConditionExpression,
+		})
+	case change.Delete != nil:
+		_, err = s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:           change.Delete.TableName,
+			Key:                 change.Delete.Key,
+			ConditionExpression: change.Delete.ConditionExpression,
+		})
+	}
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return false, nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB write failed", "error", err, "table", s.cfg.TableName, "outboxRecords", len(outbox))
+		return false, err
+	}
+
+	return true, nil
+}
+
+// canceledByCondition reports whether a transaction was canceled because a
+// condition failed.
+func canceledByCondition(canceled *types.TransactionCanceledException) bool {
+	return slices.ContainsFunc(canceled.CancellationReasons, func(reason types.CancellationReason) bool {
+		return aws.ToString(reason.Code) == "ConditionalCheckFailed"
+	})
+}
@@ -1,14 +1,16 @@
 package habits
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/jimvid/sidekick/internal/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+	"github.com/jimvid/sidekick/internal/migrations"
 )
 
 const testTableName = "test-habits"
@@ -16,38 +18,7 @@ const testTableName = "test-habits"
 func setupTestDB(t *testing.T) *HabitStorage {
 	t.Helper()
 
-	sess := session.Must(session.NewSession(&aws.Config{
-		Region:      aws.String("us-east-1"),
-		Endpoint:    aws.String("http://localhost:8000"),
-		Credentials: credentials.NewStaticCredentials("fake", "fake", ""),
-	}))
-	db := dynamodb.New(sess)
-
-	// Create table
-	_, err := db.CreateTable(&dynamodb.CreateTableInput{
-		TableName: aws.String(testTableName),
-		KeySchema: []*dynamodb.KeySchemaElement{
-			{AttributeName: aws.String("userId"), KeyType: aws.String("HASH")},
-			{AttributeName: aws.String("itemId"), KeyType: aws.String("RANGE")},
-		},
-		AttributeDefinitions: []*dynamodb.AttributeDefinition{
-			{AttributeName: aws.String("userId"), AttributeType: aws.String("S")},
-			{AttributeName: aws.String("itemId"), AttributeType: aws.String("S")},
-		},
-		BillingMode: aws.String("PAY_PER_REQUEST"),
-	})
-	if err != nil {
-		t.Fatalf("failed to create test table: %v", err)
-	}
-
-	t.Cleanup(func() {
-		db.DeleteTable(&dynamodb.DeleteTableInput{
-			TableName: aws.String(testTableName),
-		})
-	})
-
-	cfg := &config.Config{TABLE_NAME: testTableName}
-	return NewHabitStorage(db, cfg)
+	return NewHabitStorage(dynamotest.NewTable(t, testTableName))
 }
 
 func makeHabit(id, name string) HabitModel {
@@ -75,13 +46,13 @@ func TestStorageCreateHabit(t *testing.T) {
 	storage := setupTestDB(t)
 	habit := makeHabit("habit-1", "Exercise")
 
-	err := storage.CreateHabit("user-1", habit)
+	err := storage.CreateHabit(context.Background(), "user-1", habit)
 	if err != nil {
 		t.Fatalf("CreateHabit failed: %v", err)
 	}
 
 	// Verify it was persisted
-	result, err := storage.FindHabitById("user-1", "habit-1")
+	result, err := storage.FindHabitById(context.Background(), "user-1", "habit-1")
 	if err != nil {
 		t.Fatalf("FindHabitById after CreateHabit failed: %v", err)
 	}
@@ -93,10 +64,10 @@ func TestStorageCreateHabit(t *testing.T) {
 func TestStorageFindHabitById(t *testing.T) {
 	storage := setupTestDB(t)
 	habit := makeHabit("habit-1", "Read")
-	storage.CreateHabit("user-1", habit)
+	storage.CreateHabit(context.Background(), "user-1", habit)
 
 	t.Run("existing habit", func(t *testing.T) {
-		result, err := storage.FindHabitById("user-1", "habit-1")
+		result, err := storage.FindHabitById(context.Background(), "user-1", "habit-1")
 		if err != nil {
 			t.Fatalf("FindHabitById failed: %v", err)
 		}
@@ -112,14 +83,14 @@ func TestStorageFindHabitById(t *testing.T) {
 	})
 
 	t.Run("non-existent habit", func(t *testing.T) {
-		_, err := storage.FindHabitById("user-1", "does-not-exist")
+		_, err := storage.FindHabitById(context.Background(), "user-1", "does-not-exist")
 		if err == nil {
 			t.Fatal("expected error for non-existent habit, got nil")
 		}
 	})
 
 	t.Run("wrong user", func(t *testing.T) {
-		_, err := storage.FindHabitById("user-999", "habit-1")
+		_, err := storage.FindHabitById(context.Background(), "user-999", "habit-1")
 		if err == nil {
 			t.Fatal("expected error when querying with wrong user, got nil")
 		}
@@ -130,7 +101,7 @@ func TestStorageGetAllHabits(t *testing.T) {
 	storage := setupTestDB(t)
 
 	t.Run("empty result", func(t *testing.T) {
-		habits, err := storage.GetAllHabits("user-1")
+		habits, err := storage.GetAllHabits(context.Background(), "user-1")
 		if err != nil {
 			t.Fatalf("GetAllHabits failed: %v", err)
 		}
@@ -140,11 +111,11 @@ func TestStorageGetAllHabits(t *testing.T) {
 	})
 
 	t.Run("returns only habits for the given user", func(t *testing.T) {
-		storage.CreateHabit("user-1", makeHabit("h1", "Exercise"))
-		storage.CreateHabit("user-1", makeHabit("h2", "Read"))
-		storage.CreateHabit("user-2", makeHabit("h3", "Meditate"))
+		storage.CreateHabit(context.Background(), "user-1", makeHabit("h1", "Exercise"))
+		storage.CreateHabit(context.Background(), "user-1", makeHabit("h2", "Read"))
+		storage.CreateHabit(context.Background(), "user-2", makeHabit("h3", "Meditate"))
 
-		habits, err := storage.GetAllHabits("user-1")
+		habits, err := storage.GetAllHabits(context.Background(), "user-1")
 		if err != nil {
 			t.Fatalf("GetAllHabits failed: %v", err)
 		}
@@ -152,7 +123,7 @@ func TestStorageGetAllHabits(t *testing.T) {
 			t.Fatalf("expected 2 habits for user-1, got %d", len(habits))
 		}
 
-		habits2, err := storage.GetAllHabits("user-2")
+		habits2, err := storage.GetAllHabits(context.Background(), "user-2")
 		if err != nil {
 			t.Fatalf("GetAllHabits failed: %v", err)
 		}
@@ -164,31 +135,31 @@ func TestStorageGetAllHabits(t *testing.T) {
 
 func TestStorageDeleteHabit(t *testing.T) {
 	storage := setupTestDB(t)
-	storage.CreateHabit("user-1", makeHabit("habit-1", "Exercise"))
+	storage.CreateHabit(context.Background(), "user-1", makeHabit("habit-1", "Exercise"))
 
 	t.Run("existing habit", func(t *testing.T) {
-		err := storage.DeleteHabit("user-1", "habit-1")
+		err := storage.DeleteHabit(context.Background(), "user-1", "habit-1")
 		if err != nil {
 			t.Fatalf("DeleteHabit failed: %v", err)
 		}
 
-		_, err = storage.FindHabitById("user-1", "habit-1")
+		_, err = storage.FindHabitById(context.Background(), "user-1", "habit-1")
 		if err == nil {
 			t.Fatal("expected error after delete, got nil")
 		}
 	})
 
 	t.Run("non-existent habit", func(t *testing.T) {
-		err := storage.DeleteHabit("user-1", "does-not-exist")
+		err := storage.DeleteHabit(context.Background(), "user-1", "does-not-exist")
 		if err == nil {
 			t.Fatal("expected error for non-existent habit, got nil")
 		}
 	})
 
 	t.Run("wrong user", func(t *testing.T) {
-		storage.CreateHabit("user-1", makeHabit("habit-2", "Read"))
+		storage.CreateHabit(context.Background(), "user-1", makeHabit("habit-2", "Read"))
 
-		err := storage.DeleteHabit("user-999", "habit-2")
+		err := storage.DeleteHabit(context.Background(), "user-999", "habit-2")
 		if err == nil {
 			t.Fatal("expected error when deleting with wrong user, got nil")
 		}
@@ -198,19 +169,19 @@ func TestStorageDeleteHabit(t *testing.T) {
 func TestStorageUpdateHabit(t *testing.T) {
 	storage := setupTestDB(t)
 	original := makeHabit("habit-1", "Exercise")
-	storage.CreateHabit("user-1", original)
+	storage.CreateHabit(context.Background(), "user-1", original)
 
 	updated := original
 	updated.Name = "Morning Exercise"
 	updated.Color = "#00ff00"
 	updated.UpdatedAt = time.Now().Unix() + 100
 
-	err := storage.UpdateHabit("user-1", "habit-1", updated)
+	err := storage.UpdateHabit(context.Background(), "user-1", "habit-1", updated)
 	if err != nil {
 		t.Fatalf("UpdateHabit failed: %v", err)
 	}
 
-	result, err := storage.FindHabitById("user-1", "habit-1")
+	result, err := storage.FindHabitById(context.Background(), "user-1", "habit-1")
 	if err != nil {
 		t.Fatalf("FindHabitById after UpdateHabit failed: %v", err)
 	}
@@ -234,12 +205,12 @@ func TestStorageCreateHabitLog(t *testing.T) {
 	storage := setupTestDB(t)
 	log := makeLog("log-1", "habit-1", "2026-02-08")
 
-	err := storage.CreateHabitLog("user-1", log)
+	err := storage.CreateHabitLog(context.Background(), "user-1", log)
 	if err != nil {
 		t.Fatalf("CreateHabitLog failed: %v", err)
 	}
 
-	result, err := storage.FindHabitLogById("user-1", "log-1")
+	result, err := storage.FindHabitLogById(context.Background(), "user-1", "log-1")
 	if err != nil {
 		t.Fatalf("FindHabitLogById after CreateHabitLog failed: %v", err)
 	}
@@ -253,10 +224,10 @@ func TestStorageCreateHabitLog(t *testing.T) {
 
 func TestStorageFindHabitLogById(t *testing.T) {
 	storage := setupTestDB(t)
-	storage.CreateHabitLog("user-1", makeLog("log-1", "habit-1", "2026-02-08"))
+	storage.CreateHabitLog(context.Background(), "user-1", makeLog("log-1", "habit-1", "2026-02-08"))
 
 	t.Run("existing log", func(t *testing.T) {
-		result, err := storage.FindHabitLogById("user-1", "log-1")
+		result, err := storage.FindHabitLogById(context.Background(), "user-1", "log-1")
 		if err != nil {
 			t.Fatalf("FindHabitLogById failed: %v", err)
 		}
@@ -269,14 +240,14 @@ func TestStorageFindHabitLogById(t *testing.T) {
 	})
 
 	t.Run("non-existent log", func(t *testing.T) {
-		_, err := storage.FindHabitLogById("user-1", "does-not-exist")
+		_, err := storage.FindHabitLogById(context.Background(), "user-1", "does-not-exist")
 		if err == nil {
 			t.Fatal("expected error for non-existent log, got nil")
 		}
 	})
 
 	t.Run("wrong user", func(t *testing.T) {
-		_, err := storage.FindHabitLogById("user-999", "log-1")
+		_, err := storage.FindHabitLogById(context.Background(), "user-999", "log-1")
 		if err == nil {
 			t.Fatal("expected error when querying with wrong user, got nil")
 		}
@@ -287,7 +258,7 @@ func TestStorageGetAllHabitLogs(t *testing.T) {
 	storage := setupTestDB(t)
 
 	t.Run("empty result", func(t *testing.T) {
-		logs, err := storage.GetAllHabitLogs("user-1")
+		logs, err := storage.GetAllHabitLogs(context.Background(), "user-1")
 		if err != nil {
 			t.Fatalf("GetAllHabitLogs failed: %v", err)
 		}
@@ -297,11 +268,11 @@ func TestStorageGetAllHabitLogs(t *testing.T) {
 	})
 
 	t.Run("returns only logs for the given user", func(t *testing.T) {
-		storage.CreateHabitLog("user-1", makeLog("l1", "habit-1", "2026-02-08"))
-		storage.CreateHabitLog("user-1", makeLog("l2", "habit-1", "2026-02-09"))
-		storage.CreateHabitLog("user-2", makeLog("l3", "habit-2", "2026-02-08"))
+		storage.CreateHabitLog(context.Background(), "user-1", makeLog("l1", "habit-1", "2026-02-08"))
+		storage.CreateHabitLog(context.Background(), "user-1", makeLog("l2", "habit-1", "2026-02-09"))
+		storage.CreateHabitLog(context.Background(), "user-2", makeLog("l3", "habit-2", "2026-02-08"))
 
-		logs, err := storage.GetAllHabitLogs("user-1")
+		logs, err := storage.GetAllHabitLogs(context.Background(), "user-1")
 		if err != nil {
 			t.Fatalf("GetAllHabitLogs failed: %v", err)
 		}
@@ -309,7 +280,7 @@ func TestStorageGetAllHabitLogs(t *testing.T) {
 			t.Fatalf("expected 2 logs for user-1, got %d", len(logs))
 		}
 
-		logs2, err := storage.GetAllHabitLogs("user-2")
+		logs2, err := storage.GetAllHabitLogs(context.Background(), "user-2")
 		if err != nil {
 			t.Fatalf("GetAllHabitLogs failed: %v", err)
 		}
@@ -319,33 +290,98 @@ func TestStorageGetAllHabitLogs(t *testing.T) {
 	})
 }
 
+func TestStorageGetHabitLogsInRange(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	storage.CreateHabitLog(ctx, "user-1", makeLog("log-1", "h1", "2026-01-31"))
+	storage.CreateHabitLog(ctx, "user-1", makeLog("log-2", "h1", "2026-02-01"))
+	storage.CreateHabitLog(ctx, "user-1", makeLog("log-3", "h2", "2026-02-14"))
+	storage.CreateHabitLog(ctx, "user-1", makeLog("log-4", "h1", "2026-03-01"))
+	storage.CreateHabitLog(ctx, "user-2", makeLog("log-5", "h1", "2026-02-10"))
+	storage.CreateHabit(ctx, "user-1", makeHabit("h1", "Exercise"))
+
+	t.Run("range is inclusive", func(t *testing.T) {
+		logs, err := storage.GetHabitLogsInRange(ctx, "user-1", "2026-02-01", "2026-02-28", "")
+		if err != nil {
+			t.Fatalf("GetHabitLogsInRange failed: %v", err)
+		}
+
+		dates := map[string]string{}
+		for _, log := range logs {
+			dates[log.Date] = log.HabitId
+		}
+		want := map[string]string{"2026-02-01": "h1", "2026-02-14": "h2"}
+		if len(dates) != len(want) || dates["2026-02-01"] != "h1" || dates["2026-02-14"] != "h2" {
+			t.Errorf("expected %v, got %v", want, dates)
+		}
+	})
+
+	t.Run("filters by habit", func(t *testing.T) {
+		logs, err := storage.GetHabitLogsInRange(ctx, "user-1", "2026-01-01", "2026-12-31", "h2")
+		if err != nil {
+			t.Fatalf("GetHabitLogsInRange failed: %v", err)
+		}
+		if len(logs) != 1 || logs[0].Date != "2026-02-14" {
+			t.Errorf("expected only the h2 log, got %+v", logs)
+		}
+	})
+}
+
+func TestStorageGetHabitLogsOnDate(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	later := makeLog("log-1", "h1", "2026-02-08")
+	later.CreatedAt = 20
+	earlier := makeLog("log-2", "h2", "2026-02-08")
+	earlier.CreatedAt = 10
+	storage.CreateHabitLog(ctx, "user-1", later)
+	storage.CreateHabitLog(ctx, "user-1", earlier)
+	storage.CreateHabitLog(ctx, "user-1", makeLog("log-3", "h1", "2026-02-07"))
+	storage.CreateHabitLog(ctx, "user-2", makeLog("log-4", "h1", "2026-02-08"))
+
+	logs, err := storage.GetHabitLogsOnDate(ctx, "user-1", "2026-02-08")
+	if err != nil {
+		t.Fatalf("GetHabitLogsOnDate failed: %v", err)
+	}
+
+	if len(logs) != 2 || logs[0].ID != "log-2" || logs[1].ID != "log-1" {
+		t.Fatalf("expected log-2 then log-1, got %+v", logs)
+	}
+	// Read from the table, so fields the index leaves out are there
+	if logs[0].Note != "test note" {
+		t.Errorf("expected the note to be read, got %q", logs[0].Note)
+	}
+}
+
 func TestStorageDeleteHabitLog(t *testing.T) {
 	storage := setupTestDB(t)
-	storage.CreateHabitLog("user-1", makeLog("log-1", "habit-1", "2026-02-08"))
+	storage.CreateHabitLog(context.Background(), "user-1", makeLog("log-1", "habit-1", "2026-02-08"))
 
 	t.Run("existing log", func(t *testing.T) {
-		err := storage.DeleteHabitLog("user-1", "log-1")
+		err := storage.DeleteHabitLog(context.Background(), "user-1", "log-1")
 		if err != nil {
 			t.Fatalf("DeleteHabitLog failed: %v", err)
 		}
 
-		_, err = storage.FindHabitLogById("user-1", "log-1")
+		_, err = storage.FindHabitLogById(context.Background(), "user-1", "log-1")
 		if err == nil {
 			t.Fatal("expected error after delete, got nil")
 		}
 	})
 
 	t.Run("non-existent log", func(t *testing.T) {
-		err := storage.DeleteHabitLog("user-1", "does-not-exist")
+		err := storage.DeleteHabitLog(context.Background(), "user-1", "does-not-exist")
 		if err == nil {
 			t.Fatal("expected error for non-existent log, got nil")
 		}
 	})
 
 	t.Run("wrong user", func(t *testing.T) {
-		storage.CreateHabitLog("user-1", makeLog("log-2", "habit-1", "2026-02-09"))
+		storage.CreateHabitLog(context.Background(), "user-1", makeLog("log-2", "habit-1", "2026-02-09"))
 
-		err := storage.DeleteHabitLog("user-999", "log-2")
+		err := storage.DeleteHabitLog(context.Background(), "user-999", "log-2")
 		if err == nil {
 			t.Fatal("expected error when deleting with wrong user, got nil")
 		}
@@ -355,18 +391,18 @@ func TestStorageDeleteHabitLog(t *testing.T) {
 func TestStorageUpdateHabitLog(t *testing.T) {
 	storage := setupTestDB(t)
 	original := makeLog("log-1", "habit-1", "2026-02-08")
-	storage.CreateHabitLog("user-1", original)
+	storage.CreateHabitLog(context.Background(), "user-1", original)
 
 	updated := original
 	updated.Date = "2026-02-09"
 	updated.Note = "updated note"
 
-	err := storage.UpdateHabitLog("user-1", "log-1", updated)
+	err := storage.UpdateHabitLog(context.Background(), "user-1", "log-1", updated)
 	if err != nil {
 		t.Fatalf("UpdateLog failed: %v", err)
 	}
 
-	result, err := storage.FindHabitLogById("user-1", "log-1")
+	result, err := storage.FindHabitLogById(context.Background(), "user-1", "log-1")
 	if err != nil {
 		t.Fatalf("FindHabitLogById after UpdateLog failed: %v", err)
 	}
@@ -380,3 +416,55 @@ func TestStorageUpdateHabitLog(t *testing.T) {
 		t.Errorf("expected habitId %q, got %q", "habit-1", result.HabitId)
 	}
 }
+
+func TestStorageMigratesOnRead(t *testing.T) {
+	storage := setupTestDB(t)
+	storage.CreateHabit(context.Background(), "user-1", makeHabit("habit-1", "Exercise"))
+
+	storage.migrator = migrations.NewMigrator([]migrations.Migration{{
+		Version:    1,
+		Name:       "uppercase-name",
+		ItemPrefix: itemPrefixHabit,
+		Transform: func(item migrations.Item) error {
+			name := item["Name"].(*types.AttributeValueMemberS).Value
+			item["Name"] = &types.AttributeValueMemberS{Value: strings.ToUpper(name)}
+			return nil
+		},
+	}})
+
+	habits, err := storage.GetAllHabits(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("GetAllHabits failed: %v", err)
+	}
+	if len(habits) != 1 || habits[0].Name != "EXERCISE" {
+		t.Fatalf("expected migrated habit on read, got %+v", habits)
+	}
+
+	// Reads don't write, the stored item is left to the migration runner
+	result, err := storage.db.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName: aws.String(testTableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: "user-1"},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixHabit + "habit-1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("GetItem failed: %v", err)
+	}
+	if migrations.ItemVersion(result.Item) != 0 {
+		t.Errorf("expected stored version 0, got %d", migrations.ItemVersion(result.Item))
+	}
+	if name := result.Item["Name"].(*types.AttributeValueMemberS).Value; name != "Exercise" {
+		t.Errorf("expected stored name to be untouched, got %q", name)
+	}
+
+	// New items are written at the latest version and not migrated again
+	storage.CreateHabit(context.Background(), "user-1", makeHabit("habit-2", "Read"))
+	habit, err := storage.FindHabitById(context.Background(), "user-1", "habit-2")
+	if err != nil {
+		t.Fatalf("FindHabitById failed: %v", err)
+	}
+	if habit.Name != "Read" {
+		t.Errorf("expected new habit to be untouched, got %q", habit.Name)
+	}
+}
@@ -0,0 +1,50 @@
+package health
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const DefaultTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+func NewHealthHandler(checks ...Check) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: DefaultTimeout,
+	}
+}
+
+func (h *HealthHandler) writeReport(w http.ResponseWriter, report Report) {
+	statusCode := http.StatusOK
+	if report.Status != StatusOK {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Live reports that the process is up and serving requests. It never touches
+// dependencies, so a DynamoDB outage does not get the function restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	h.writeReport(w, Report{Status: StatusOK, Build: ReadBuildInfo()})
+}
+
+// Ready runs every check and responds with 503 when any of them fails.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := Run(r.Context(), h.checks, h.timeout)
+	if report.Status != StatusOK {
+		slog.WarnContext(r.Context(), "Readiness check failed", "checks", report.Checks)
+	}
+
+	h.writeReport(w, report)
+}
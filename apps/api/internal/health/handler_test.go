@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-health"
+
+func setupTestDB(t *testing.T) *dynamodb.Client {
+	t.Helper()
+
+	db, _ := dynamotest.NewTable(t, testTableName)
+	return db
+}
+
+func serveReady(t *testing.T, handler *HealthHandler) (int, Report) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	handler.Ready(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	var report Report
+	err := json.NewDecoder(w.Body).Decode(&report)
+	if err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	return w.Code, report
+}
+
+func TestReady(t *testing.T) {
+	db := setupTestDB(t)
+
+	t.Run("all checks pass", func(t *testing.T) {
+		handler := NewHealthHandler(
+			DynamoDBCheck(db, testTableName),
+			AuthCheck("sk_test_abc"),
+		)
+
+		status, report := serveReady(t, handler)
+		if status != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, status)
+		}
+		if report.Status != StatusOK || len(report.Checks) != 2 {
+			t.Errorf("unexpected report %+v", report)
+		}
+		if report.Checks["dynamodb"].Status != StatusOK {
+			t.Errorf("expected dynamodb check to pass, got %+v", report.Checks["dynamodb"])
+		}
+	})
+
+	t.Run("missing table", func(t *testing.T) {
+		handler := NewHealthHandler(
+			DynamoDBCheck(db, "does-not-exist"),
+			AuthCheck("sk_test_abc"),
+		)
+
+		status, report := serveReady(t, handler)
+		if status != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, status)
+		}
+		if report.Checks["dynamodb"].Status != StatusFail || report.Checks["dynamodb"].Error == "" {
+			t.Errorf("expected dynamodb check to fail with an error, got %+v", report.Checks["dynamodb"])
+		}
+		if report.Checks["auth"].Status != StatusOK {
+			t.Errorf("expected auth check to pass, got %+v", report.Checks["auth"])
+		}
+	})
+
+	t.Run("invalid auth config", func(t *testing.T) {
+		status, report := serveReady(t, NewHealthHandler(AuthCheck("not-a-key")))
+		if status != http.StatusServiceUnavailable || report.Checks["auth"].Status != StatusFail {
+			t.Errorf("expected auth check to fail, got %d %+v", status, report)
+		}
+	})
+
+	t.Run("check timeout", func(t *testing.T) {
+		handler := NewHealthHandler(Check{
+			Name: "slow",
+			Run: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+		handler.timeout = 10 * time.Millisecond
+
+		status, report := serveReady(t, handler)
+		if status != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, status)
+		}
+		if report.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+			t.Errorf("expected deadline error, got %+v", report.Checks["slow"])
+		}
+	})
+}
+
+func TestLive(t *testing.T) {
+	handler := NewHealthHandler(Check{
+		Name: "failing",
+		Run:  func(ctx context.Context) error { return errors.New("down") },
+	})
+
+	w := httptest.NewRecorder()
+	handler.Live(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected liveness to ignore checks, got status %d", w.Code)
+	}
+}
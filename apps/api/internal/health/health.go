@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Version is stamped at build time with -ldflags "-X .../health.Version=...".
+var Version = "dev"
+
+// Check is a single readiness probe. Run returns nil when the dependency is
+// usable.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type TableDescriber interface {
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+}
+
+// DynamoDBCheck verifies the table is reachable and active.
+func DynamoDBCheck(db TableDescriber, tableName string) Check {
+	return Check{
+		Name: "dynamodb",
+		Run: func(ctx context.Context) error {
+			out, err := db.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+				TableName: aws.String(tableName),
+			})
+			if err != nil {
+				return err
+			}
+			if out.Table == nil || out.Table.TableStatus != types.TableStatusActive {
+				return fmt.Errorf("table %s is not active", tableName)
+			}
+			return nil
+		},
+	}
+}
+
+// AuthCheck verifies the Clerk secret key looks usable, without calling Clerk.
+func AuthCheck(secretKey string) Check {
+	return Check{
+		Name: "auth",
+		Run: func(ctx context.Context) error {
+			if secretKey == "" {
+				return errors.New("clerk secret key is not set")
+			}
+			if !strings.HasPrefix(secretKey, "sk_test_") && !strings.HasPrefix(secretKey, "sk_live_") {
+				return errors.New("clerk secret key has an unexpected format")
+			}
+			return nil
+		},
+	}
+}
+
+// BuildCheck verifies the binary carries build information.
+func BuildCheck() Check {
+	return Check{
+		Name: "build",
+		Run: func(ctx context.Context) error {
+			if _, ok := debug.ReadBuildInfo(); !ok {
+				return errors.New("build info is not available")
+			}
+			return nil
+		},
+	}
+}
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{Version: Version}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.GoVersion = build.GoVersion
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Build  BuildInfo              `json:"build"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Run executes every check concurrently, each bounded by timeout.
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	report := Report{
+		Status: StatusOK,
+		Build:  ReadBuildInfo(),
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	results := make([]CheckResult, len(checks))
+	done := make(chan int)
+
+	for i, check := range checks {
+		go func() {
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(checkCtx)
+
+			result := CheckResult{
+				Status:    StatusOK,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+			results[i] = result
+			done <- i
+		}()
+	}
+
+	for range checks {
+		i := <-done
+		report.Checks[checks[i].Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+type accessKey struct{}
+
+// accessEntry collects attributes added further down the middleware chain,
+// such as the user ID set after authentication, for the access log.
+type accessEntry struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// AddAccessAttrs adds attributes to the access log of the request handled
+// with ctx. It does nothing outside AccessLog.
+func AddAccessAttrs(ctx context.Context, attrs ...slog.Attr) {
+	entry, ok := ctx.Value(accessKey{}).(*accessEntry)
+	if !ok {
+		return
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	entry.attrs = append(entry.attrs, attrs...)
+}
+
+// AccessLog writes one structured log record per request and carries the
+// logger in the request context.
+func AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry := &accessEntry{}
+
+			ctx := NewContext(r.Context(), logger)
+			ctx = context.WithValue(ctx, accessKey{}, entry)
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case status >= 500:
+				level = slog.LevelError
+			case status >= 400:
+				level = slog.LevelWarn
+			}
+
+			route := ""
+			if rctx := chi.RouteContext(ctx); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", route),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Float64("latencyMs", float64(time.Since(start).Microseconds())/1000),
+				slog.String("userAgent", r.UserAgent()),
+			}
+			entry.mu.Lock()
+			attrs = append(attrs, entry.attrs...)
+			entry.mu.Unlock()
+
+			logger.LogAttrs(r.Context(), level, "Request handled", attrs...)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	r := chi.NewRouter()
+	r.Use(chimiddleware.RequestID)
+	r.Use(RequestAttrs)
+	r.Use(AccessLog(logger))
+	r.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			AddAccessAttrs(r.Context(), slog.String("userId", "user-1"))
+			// Only in the handler's own logs
+			next.ServeHTTP(w, r.WithContext(WithAttrs(r.Context(), slog.String("habitName", "Exercise"))))
+		})
+	}).Get("/habits/{habitId}", func(w http.ResponseWriter, r *http.Request) {
+		if FromContext(r.Context()) != logger {
+			t.Error("expected access logger in request context")
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/habits/abc", nil)
+	req.Header.Set(chimiddleware.RequestIDHeader, "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	err := json.Unmarshal(buf.Bytes(), &entry)
+	if err != nil {
+		t.Fatalf("expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+
+	expected := map[string]any{
+		"msg":       "Request handled",
+		"level":     "WARN",
+		"method":    "GET",
+		"path":      "/habits/abc",
+		"route":     "/habits/{habitId}",
+		"status":    float64(http.StatusNotFound),
+		"requestId": "req-1",
+		"userId":    "user-1",
+	}
+	for key, want := range expected {
+		if entry[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, entry[key])
+		}
+	}
+	if _, ok := entry["habitName"]; ok {
+		t.Error("expected WithAttrs to leave the access log alone")
+	}
+	if _, ok := entry["latencyMs"]; !ok {
+		t.Error("expected latencyMs in access log")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("DEBUG")
+	if err != nil || level != slog.LevelDebug {
+		t.Errorf("expected debug level, got %v (%v)", level, err)
+	}
+
+	_, err = ParseLevel("verbose")
+	if err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
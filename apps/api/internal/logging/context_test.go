@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewTextHandler(&buf, nil)))
+
+	ctx := WithAttrs(context.Background(), slog.String("requestId", "req-1"))
+	ctx = WithAttrs(ctx, slog.String("userId", "user-1"))
+
+	logger.With("component", "test").InfoContext(ctx, "hello")
+
+	out := buf.String()
+	for _, want := range []string{"requestId=req-1", "userId=user-1", "component=test"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in log output %q", want, out)
+		}
+	}
+
+	buf.Reset()
+	logger.InfoContext(context.Background(), "no attrs")
+	if strings.Contains(buf.String(), "requestId") {
+		t.Errorf("expected no request attributes, got %q", buf.String())
+	}
+}
+
+func TestRequestAttrs(t *testing.T) {
+	var attrs []slog.Attr
+	handler := chimiddleware.RequestID(RequestAttrs(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attrs = Attrs(r.Context())
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/habits", nil)
+	req.Header.Set(chimiddleware.RequestIDHeader, "req-abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(attrs) != 1 || attrs[0].Key != "requestId" || attrs[0].Value.String() != "req-abc" {
+		t.Errorf("expected requestId attribute, got %v", attrs)
+	}
+}
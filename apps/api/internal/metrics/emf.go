@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Namespace is the CloudWatch namespace EMF documents are published under.
+const Namespace = "Sidekick"
+
+// EMF writes each measurement as a CloudWatch embedded metric format
+// document. In Lambda, stdout is shipped to CloudWatch Logs, which extracts
+// the metrics without any API calls from the function.
+type EMF struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+func NewEMF(w io.Writer) *EMF {
+	return &EMF{
+		w:   w,
+		now: time.Now,
+	}
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+func (e *EMF) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	e.write(map[string]any{
+		"Route":       route,
+		"Method":      method,
+		"StatusClass": fmt.Sprintf("%dxx", status/100),
+		"Status":      status,
+		"Requests":    1,
+		"Latency":     float64(duration.Microseconds()) / 1000,
+	}, []string{"Route", "Method", "StatusClass"}, []emfMetric{
+		{Name: "Requests", Unit: "Count"},
+		{Name: "Latency", Unit: "Milliseconds"},
+	})
+}
+
+func (e *EMF) ObserveDynamoDBCall(operation, outcome string, duration time.Duration, capacity float64) {
+	e.write(map[string]any{
+		"Operation":        operation,
+		"Outcome":          outcome,
+		"DynamoDBCalls":    1,
+		"DynamoDBLatency":  float64(duration.Microseconds()) / 1000,
+		"ConsumedCapacity": capacity,
+	}, []string{"Operation", "Outcome"}, []emfMetric{
+		{Name: "DynamoDBCalls", Unit: "Count"},
+		{Name: "DynamoDBLatency", Unit: "Milliseconds"},
+		{Name: "ConsumedCapacity", Unit: "None"},
+	})
+}
+
+func (e *EMF) write(doc map[string]any, dimensions []string, metrics []emfMetric) {
+	doc["_aws"] = emfMetadata{
+		Timestamp: e.now().UnixMilli(),
+		CloudWatchMetrics: []emfDirective{{
+			Namespace:  Namespace,
+			Dimensions: [][]string{dimensions},
+			Metrics:    metrics,
+		}},
+	}
+
+	line, err := json.Marshal(doc)
+	if err != nil {
+		slog.Error("Failed to marshal EMF document", "error", err)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.w.Write(append(line, '\n'))
+}
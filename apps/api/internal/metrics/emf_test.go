@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestEMFObserveRequest(t *testing.T) {
+	var buf bytes.Buffer
+	emf := NewEMF(&buf)
+	emf.now = func() time.Time { return time.UnixMilli(1700000000000) }
+
+	emf.ObserveRequest("POST", "/habit-logs", 201, 12*time.Millisecond)
+
+	var doc map[string]any
+	err := json.Unmarshal(buf.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("expected a JSON document, got %q: %v", buf.String(), err)
+	}
+
+	if doc["Route"] != "/habit-logs" || doc["StatusClass"] != "2xx" || doc["Latency"] != float64(12) {
+		t.Errorf("unexpected document %v", doc)
+	}
+
+	meta := doc["_aws"].(map[string]any)
+	if meta["Timestamp"] != float64(1700000000000) {
+		t.Errorf("unexpected timestamp %v", meta["Timestamp"])
+	}
+	directive := meta["CloudWatchMetrics"].([]any)[0].(map[string]any)
+	if directive["Namespace"] != Namespace {
+		t.Errorf("expected namespace %q, got %v", Namespace, directive["Namespace"])
+	}
+	dims := directive["Dimensions"].([]any)[0].([]any)
+	if len(dims) != 3 {
+		t.Errorf("expected 3 dimensions, got %v", dims)
+	}
+}
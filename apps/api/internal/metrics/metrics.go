@@ -0,0 +1,28 @@
+package metrics
+
+import "time"
+
+// Recorder receives measurements from the HTTP middleware and the DynamoDB
+// client. Registry serves them in Prometheus text format for the standalone
+// server, EMF writes CloudWatch embedded metric documents in Lambda.
+type Recorder interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+	ObserveDynamoDBCall(operation, outcome string, duration time.Duration, capacity float64)
+}
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// unmatchedRoute keeps requests for unknown paths from creating a label
+// value per path.
+const unmatchedRoute = "unmatched"
+
+// Nop discards every measurement.
+type Nop struct{}
+
+func (Nop) ObserveRequest(method, route string, status int, duration time.Duration) {}
+
+func (Nop) ObserveDynamoDBCall(operation, outcome string, duration time.Duration, capacity float64) {
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// HTTPMiddleware records latency and status for every request, labelled
+// with the chi route pattern rather than the raw path.
+func HTTPMiddleware(recorder Recorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			route := ""
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+
+			recorder.ObserveRequest(r.Method, route, status, time.Since(start))
+		})
+	}
+}
+
+// DynamoDBOptions instruments every call made by a DynamoDB client and asks
+// DynamoDB to report the capacity each call consumed.
+func DynamoDBOptions(recorder Recorder) func(*dynamodb.Options) {
+	return func(o *dynamodb.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("SidekickMetrics", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				requestCapacity(in.Parameters)
+
+				start := time.Now()
+				out, metadata, err := next.HandleInitialize(ctx, in)
+
+				outcome := OutcomeSuccess
+				if err != nil {
+					outcome = OutcomeError
+				}
+				recorder.ObserveDynamoDBCall(awsmiddleware.GetOperationName(ctx), outcome, time.Since(start), consumedCapacity(out.Result))
+
+				return out, metadata, err
+			}), middleware.After)
+		})
+	}
+}
+
+func requestCapacity(params any) {
+	total := types.ReturnConsumedCapacityTotal
+
+	switch in := params.(type) {
+	case *dynamodb.GetItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			in.ReturnConsumedCapacity = total
+		}
+	case *dynamodb.PutItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			in.ReturnConsumedCapacity = total
+		}
+	case *dynamodb.DeleteItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			in.ReturnConsumedCapacity = total
+		}
+	case *dynamodb.UpdateItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			in.ReturnConsumedCapacity = total
+		}
+	case *dynamodb.QueryInput:
+		if in.ReturnConsumedCapacity == "" {
+			in.ReturnConsumedCapacity = total
+		}
+	case *dynamodb.ScanInput:
+		if in.ReturnConsumedCapacity == "" {
+			in.ReturnConsumedCapacity = total
+		}
+	case *dynamodb.BatchGetItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			in.ReturnConsumedCapacity = total
+		}
+	case *dynamodb.BatchWriteItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			in.ReturnConsumedCapacity = total
+		}
+	case *dynamodb.TransactWriteItemsInput:
+		if in.ReturnConsumedCapacity == "" {
+			in.ReturnConsumedCapacity = total
+		}
+	}
+}
+
+func consumedCapacity(result any) float64 {
+	switch out := result.(type) {
+	case *dynamodb.GetItemOutput:
+		return capacityUnits(out.ConsumedCapacity)
+	case *dynamodb.PutItemOutput:
+		return capacityUnits(out.ConsumedCapacity)
+	case *dynamodb.DeleteItemOutput:
+		return capacityUnits(out.ConsumedCapacity)
+	case *dynamodb.UpdateItemOutput:
+		return capacityUnits(out.ConsumedCapacity)
+	case *dynamodb.QueryOutput:
+		return capacityUnits(out.ConsumedCapacity)
+	case *dynamodb.ScanOutput:
+		return capacityUnits(out.ConsumedCapacity)
+	case *dynamodb.BatchGetItemOutput:
+		return totalCapacityUnits(out.ConsumedCapacity)
+	case *dynamodb.BatchWriteItemOutput:
+		return totalCapacityUnits(out.ConsumedCapacity)
+	case *dynamodb.TransactWriteItemsOutput:
+		return totalCapacityUnits(out.ConsumedCapacity)
+	}
+	return 0
+}
+
+func capacityUnits(capacity *types.ConsumedCapacity) float64 {
+	if capacity == nil || capacity.CapacityUnits == nil {
+		return 0
+	}
+	return *capacity.CapacityUnits
+}
+
+func totalCapacityUnits(capacities []types.ConsumedCapacity) float64 {
+	total := 0.0
+	for i := range capacities {
+		total += capacityUnits(&capacities[i])
+	}
+	return total
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/go-chi/chi/v5"
+)
+
+type call struct {
+	method, route, operation, outcome string
+	status                            int
+	capacity                          float64
+}
+
+type fakeRecorder struct {
+	mu    sync.Mutex
+	calls []call
+}
+
+func (f *fakeRecorder) ObserveRequest(method, route string, status int, duration time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call{method: method, route: route, status: status})
+}
+
+func (f *fakeRecorder) ObserveDynamoDBCall(operation, outcome string, duration time.Duration, capacity float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call{operation: operation, outcome: outcome, capacity: capacity})
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	recorder := &fakeRecorder{}
+
+	r := chi.NewRouter()
+	r.Use(HTTPMiddleware(recorder))
+	r.Get("/habits/{habitId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/habits/abc", nil))
+
+	if len(recorder.calls) != 1 {
+		t.Fatalf("expected 1 observation, got %d", len(recorder.calls))
+	}
+	got := recorder.calls[0]
+	if got.route != "/habits/{habitId}" || got.status != http.StatusNotFound || got.method != "GET" {
+		t.Errorf("unexpected observation %+v", got)
+	}
+}
+
+func TestDynamoDBOptions(t *testing.T) {
+	recorder := &fakeRecorder{}
+	db := dynamodb.New(dynamodb.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String("http://localhost:8000"),
+		Credentials:  credentials.NewStaticCredentialsProvider("fake", "fake", ""),
+	}, DynamoDBOptions(recorder))
+
+	ctx := context.Background()
+	_, err := db.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("test-metrics"),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("userId"), KeyType: types.KeyTypeHash},
+		},
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("userId"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		t.Fatalf("failed to create test table: %v", err)
+	}
+	t.Cleanup(func() {
+		db.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: aws.String("test-metrics")})
+	})
+
+	_, err = db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String("test-metrics"),
+		Item:      map[string]types.AttributeValue{"userId": &types.AttributeValueMemberS{Value: "user-1"}},
+	})
+	if err != nil {
+		t.Fatalf("PutItem failed: %v", err)
+	}
+
+	db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("does-not-exist"),
+		Key:       map[string]types.AttributeValue{"userId": &types.AttributeValueMemberS{Value: "user-1"}},
+	})
+
+	var put, get *call
+	for i := range recorder.calls {
+		switch recorder.calls[i].operation {
+		case "PutItem":
+			put = &recorder.calls[i]
+		case "GetItem":
+			get = &recorder.calls[i]
+		}
+	}
+
+	if put == nil || put.outcome != OutcomeSuccess || put.capacity <= 0 {
+		t.Errorf("expected successful PutItem with consumed capacity, got %+v", put)
+	}
+	if get == nil || get.outcome != OutcomeError {
+		t.Errorf("expected failed GetItem, got %+v", get)
+	}
+}
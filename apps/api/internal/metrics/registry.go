@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type counter struct {
+	labels []string
+	value  float64
+}
+
+type histogram struct {
+	labels []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type family struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	counters   map[string]*counter
+	histograms map[string]*histogram
+}
+
+// Registry aggregates measurements in memory and serves them in the
+// Prometheus text exposition format.
+type Registry struct {
+	mu       sync.Mutex
+	buckets  []float64
+	families []*family
+
+	requests         *family
+	requestDuration  *family
+	dynamoCalls      *family
+	dynamoDuration   *family
+	consumedCapacity *family
+}
+
+func NewRegistry() *Registry {
+	r := &Registry{buckets: defaultBuckets}
+
+	r.requests = r.add("http_requests_total", "Total HTTP requests by route and status.", "counter", "method", "route", "status")
+	r.requestDuration = r.add("http_request_duration_seconds", "HTTP request latency by route.", "histogram", "method", "route")
+	r.dynamoCalls = r.add("dynamodb_calls_total", "Total DynamoDB calls by operation and outcome.", "counter", "operation", "outcome")
+	r.dynamoDuration = r.add("dynamodb_call_duration_seconds", "DynamoDB call latency by operation.", "histogram", "operation")
+	r.consumedCapacity = r.add("dynamodb_consumed_capacity_units_total", "Capacity units consumed by DynamoDB calls.", "counter", "operation")
+
+	return r
+}
+
+func (r *Registry) add(name, help, kind string, labelNames ...string) *family {
+	f := &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		counters:   map[string]*counter{},
+		histograms: map[string]*histogram{},
+	}
+	r.families = append(r.families, f)
+	return f
+}
+
+func (r *Registry) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inc(r.requests, 1, method, route, strconv.Itoa(status))
+	r.observe(r.requestDuration, duration.Seconds(), method, route)
+}
+
+func (r *Registry) ObserveDynamoDBCall(operation, outcome string, duration time.Duration, capacity float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inc(r.dynamoCalls, 1, operation, outcome)
+	r.observe(r.dynamoDuration, duration.Seconds(), operation)
+	if capacity > 0 {
+		r.inc(r.consumedCapacity, capacity, operation)
+	}
+}
+
+func (r *Registry) inc(f *family, delta float64, labels ...string) {
+	key := strings.Join(labels, "\x00")
+	c, ok := f.counters[key]
+	if !ok {
+		c = &counter{labels: labels}
+		f.counters[key] = c
+	}
+	c.value += delta
+}
+
+func (r *Registry) observe(f *family, value float64, labels ...string) {
+	key := strings.Join(labels, "\x00")
+	h, ok := f.histograms[key]
+	if !ok {
+		h = &histogram{labels: labels, counts: make([]uint64, len(r.buckets))}
+		f.histograms[key] = h
+	}
+
+	for i, bound := range r.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// WriteTo writes every metric family in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	for _, f := range r.families {
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.kind)
+
+		switch f.kind {
+		case "counter":
+			for _, key := range sortedKeys(f.counters) {
+				c := f.counters[key]
+				fmt.Fprintf(&b, "%s%s %s\n", f.name, formatLabels(f.labelNames, c.labels, ""), formatFloat(c.value))
+			}
+		case "histogram":
+			for _, key := range sortedKeys(f.histograms) {
+				h := f.histograms[key]
+				for i, bound := range r.buckets {
+					fmt.Fprintf(&b, "%s_bucket%s %d\n", f.name, formatLabels(f.labelNames, h.labels, formatFloat(bound)), h.counts[i])
+				}
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", f.name, formatLabels(f.labelNames, h.labels, "+Inf"), h.count)
+				fmt.Fprintf(&b, "%s_sum%s %s\n", f.name, formatLabels(f.labelNames, h.labels, ""), formatFloat(h.sum))
+				fmt.Fprintf(&b, "%s_count%s %d\n", f.name, formatLabels(f.labelNames, h.labels, ""), h.count)
+			}
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP exposes the registry as the /metrics endpoint.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	r.WriteTo(w)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names, values []string, le string) string {
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(values[i])))
+	}
+	if le != "" {
+		pairs = append(pairs, fmt.Sprintf(`le="%s"`, le))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistryExposition(t *testing.T) {
+	registry := NewRegistry()
+	registry.ObserveRequest("GET", "/habits", 200, 20*time.Millisecond)
+	registry.ObserveRequest("GET", "/habits", 200, 2*time.Second)
+	registry.ObserveRequest("GET", "", 404, time.Millisecond)
+	registry.ObserveDynamoDBCall("Query", OutcomeSuccess, 5*time.Millisecond, 0.5)
+	registry.ObserveDynamoDBCall("Query", OutcomeSuccess, 5*time.Millisecond, 1)
+
+	w := httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/habits",status="200"} 2`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/habits",le="0.025"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/habits",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/habits"} 2`,
+		`dynamodb_calls_total{operation="Query",outcome="success"} 2`,
+		`dynamodb_consumed_capacity_units_total{operation="Query"} 1.5`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+}
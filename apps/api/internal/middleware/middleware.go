@@ -1,10 +1,119 @@
 package middleware
 
 import (
-	clerkhttp "github.com/clerk/clerk-sdk-go/v2/http"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strings"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	clerkhttp "github.com/clerk/clerk-sdk-go/v2/http"
+	"github.com/jimvid/sidekick/internal/logging"
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/tokens"
+	"github.com/jimvid/sidekick/internal/tracing"
 )
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return clerkhttp.RequireHeaderAuthorization()(next)
+// TokenVerifier returns the ID of the user a personal API token belongs to
+// and the token's ID, see tokens.TokenService.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (string, string, error)
+}
+
+// AuthMiddleware accepts a Clerk session token, or a personal API token,
+// which is checked by verifier.
+func AuthMiddleware(verifier TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var (
+				claims  *clerk.SessionClaims
+				tokenId string
+				ok      bool
+			)
+			token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if strings.HasPrefix(token, tokens.Prefix) {
+				claims, tokenId, ok = verifyAPIToken(w, r, verifier, token)
+			} else {
+				claims, ok = verifySession(w, r)
+			}
+			if !ok {
+				return
+			}
+
+			attr := slog.String("userId", claims.Subject)
+			logging.AddAccessAttrs(r.Context(), attr)
+
+			ctx := clerk.ContextWithSessionClaims(r.Context(), claims)
+			ctx = logging.WithAttrs(ctx, attr)
+			if tokenId != "" {
+				ctx = tokens.WithTokenId(ctx, tokenId)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// SessionOnly refuses requests signed in with a personal API token, for
+// routes a leaked token must not reach, like minting more tokens or
+// deleting the account. It runs after AuthMiddleware.
+func SessionOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := tokens.TokenIdFromContext(r.Context()); ok {
+			problem.Write(w, r, problem.Forbidden("This route needs a signed in session, API tokens cannot use it"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// verifyAPIToken checks a personal API token. Its user is returned as
+// session claims, so handlers read it the same way for both kinds of token,
+// along with the token's ID.
+func verifyAPIToken(w http.ResponseWriter, r *http.Request, verifier TokenVerifier, token string) (*clerk.SessionClaims, string, bool) {
+	ctx, span := tracing.Tracer().Start(r.Context(), "auth.verify")
+	defer span.End()
+
+	userId, tokenId, err := verifier.Verify(ctx, token)
+	if errors.Is(err, tokens.ErrInvalidToken) {
+		problem.Write(w, r, problem.Unauthenticated())
+		return nil, "", false
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to verify API token", "error", err)
+		problem.Write(w, r, problem.Internal("Could not verify API token"))
+		return nil, "", false
+	}
+
+	return &clerk.SessionClaims{RegisteredClaims: clerk.RegisteredClaims{Subject: userId}}, tokenId, true
+}
+
+// verifySession runs Clerk's token verification in its own span, so the rest
+// of the request is traced as a sibling rather than a child. When there is no
+// valid session it writes the problem response and returns false.
+func verifySession(w http.ResponseWriter, r *http.Request) (*clerk.SessionClaims, bool) {
+	ctx, span := tracing.Tracer().Start(r.Context(), "auth.verify")
+	defer span.End()
+
+	var (
+		claims  *clerk.SessionClaims
+		reached bool
+	)
+	clerkhttp.WithHeaderAuthorization(
+		clerkhttp.AuthorizationFailureHandler(problem.Handler(problem.Unauthenticated())),
+	)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		reached = true
+		claims, _ = clerk.SessionClaimsFromContext(r.Context())
+	})).ServeHTTP(w, r.WithContext(ctx))
+
+	if !reached {
+		// Clerk's failure handler has answered
+		return nil, false
+	}
+	if claims == nil {
+		// Clerk passes on a missing or malformed token without a session
+		problem.Write(w, r, problem.Unauthenticated())
+		return nil, false
+	}
+	return claims, true
 }
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimvid/sidekick/internal/tokens"
+	"github.com/jimvid/sidekick/internal/user"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeVerifier knows a single API token.
+type fakeVerifier struct {
+	token  string
+	userId string
+	err    error
+}
+
+func (f fakeVerifier) Verify(_ context.Context, token string) (string, string, error) {
+	if f.err != nil {
+		return "", "", f.err
+	}
+	if token != f.token {
+		return "", "", tokens.ErrInvalidToken
+	}
+	return f.userId, "token-1", nil
+}
+
+func TestAuthMiddlewareRejects(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	handler := AuthMiddleware(fakeVerifier{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected the request to be rejected")
+	}))
+
+	for name, header := range map[string]string{
+		"missing token":   "",
+		"malformed token": "Bearer not-a-jwt",
+		"unknown token":   "Bearer " + tokens.Prefix + "unknown",
+	} {
+		t.Run(name, func(t *testing.T) {
+			recorder.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, "/habits", nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("expected status 401, got %d", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("expected a problem response, got %q", ct)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 1 || spans[0].Name() != "auth.verify" {
+				t.Errorf("expected the auth.verify span to end once, got %d spans", len(spans))
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareAPIToken(t *testing.T) {
+	verifier := fakeVerifier{token: tokens.Prefix + "secret", userId: "user-1"}
+
+	var userId, tokenId string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId, _ = user.GetUserId(r)
+		tokenId, _ = tokens.TokenIdFromContext(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/habits", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.Prefix+"secret")
+	rec := httptest.NewRecorder()
+	AuthMiddleware(verifier)(next).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || userId != "user-1" || tokenId != "token-1" {
+		t.Errorf("expected the token to sign in user-1, got status %d, user %q and token %q", rec.Code, userId, tokenId)
+	}
+
+	verifier.err = errors.New("table unavailable")
+	rec = httptest.NewRecorder()
+	AuthMiddleware(verifier)(next).ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500 when the token cannot be checked, got %d", rec.Code)
+	}
+}
+
+func TestSessionOnly(t *testing.T) {
+	handler := SessionOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/me/tokens", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected a session to pass, got status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req.WithContext(tokens.WithTokenId(req.Context(), "token-1")))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected an API token to be refused, got status %d", rec.Code)
+	}
+}
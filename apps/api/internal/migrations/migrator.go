@@ -0,0 +1,126 @@
+package migrations
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// VersionAttribute holds the version of the last migration applied to an item.
+const VersionAttribute = "SchemaVersion"
+
+type Item = map[string]types.AttributeValue
+
+// Migration is a Go-defined transform applied to every item whose itemId
+// starts with ItemPrefix. Transforms must be deterministic and safe to run
+// on an item that already has the new shape.
+type Migration struct {
+	Version    int
+	Name       string
+	ItemPrefix string
+	Transform  func(item Item) error
+}
+
+// TransformError is returned when a migration's transform rejects an item.
+type TransformError struct {
+	Version int
+	Name    string
+	ItemId  string
+	Err     error
+}
+
+func (e *TransformError) Error() string {
+	return fmt.Sprintf("migration %d (%s) on %s: %v", e.Version, e.Name, e.ItemId, e.Err)
+}
+
+func (e *TransformError) Unwrap() error {
+	return e.Err
+}
+
+type Migrator struct {
+	migrations []Migration
+}
+
+func NewMigrator(migrations []Migration) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version < 1 {
+			panic(fmt.Sprintf("migration %q must have a positive version", m.Name))
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			panic(fmt.Sprintf("duplicate migration version %d", m.Version))
+		}
+	}
+
+	return &Migrator{
+		migrations: sorted,
+	}
+}
+
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest returns the highest known migration version, which new items are
+// stamped with when written.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Upgrade applies every pending migration to the item in place and reports
+// whether anything changed.
+func (m *Migrator) Upgrade(item Item) (bool, error) {
+	version := ItemVersion(item)
+	id := itemId(item)
+
+	applied := version
+	for _, migration := range m.migrations {
+		if migration.Version <= version || !strings.HasPrefix(id, migration.ItemPrefix) {
+			continue
+		}
+
+		err := migration.Transform(item)
+		if err != nil {
+			return false, &TransformError{Version: migration.Version, Name: migration.Name, ItemId: id, Err: err}
+		}
+		applied = migration.Version
+	}
+
+	if applied == version {
+		return false, nil
+	}
+
+	item[VersionAttribute] = &types.AttributeValueMemberN{Value: strconv.Itoa(applied)}
+	return true, nil
+}
+
+// ItemVersion returns the item's schema version, 0 when it was never migrated.
+func ItemVersion(item Item) int {
+	av, ok := item[VersionAttribute].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0
+	}
+
+	version, err := strconv.Atoi(av.Value)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+func itemId(item Item) string {
+	av, ok := item["itemId"].(*types.AttributeValueMemberS)
+	if !ok {
+		return ""
+	}
+	return av.Value
+}
@@ -0,0 +1,5 @@
+package migrations
+
+// All lists the item migrations for the table. Append new migrations with
+// the next version number; never reorder or remove applied ones.
+var All = []Migration{}
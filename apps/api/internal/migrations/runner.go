@@ -0,0 +1,283 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// TablePartition holds the records of whole-table migration runs
+	TablePartition      = "migrations"
+	itemPrefixMigration = "migration#"
+)
+
+type recordItem struct {
+	UserId string `dynamodbav:"userId"`
+	ItemId string `dynamodbav:"itemId"`
+	Record
+}
+
+// Record marks a migration as applied to a scope, either the whole table or
+// a single user's partition.
+type Record struct {
+	Version       int    `dynamodbav:"Version"`
+	Name          string `dynamodbav:"Name"`
+	Scope         string `dynamodbav:"Scope"`
+	ItemsScanned  int    `dynamodbav:"ItemsScanned"`
+	ItemsMigrated int    `dynamodbav:"ItemsMigrated"`
+	AppliedAt     int64  `dynamodbav:"AppliedAt"`
+}
+
+type Result struct {
+	Scope     string
+	Scanned   int
+	Migrated  int
+	Conflicts int
+	Skipped   bool
+}
+
+type Runner struct {
+	db       *dynamodb.Client
+	table    string
+	migrator *Migrator
+	dryRun   bool
+}
+
+func NewRunner(db *dynamodb.Client, table string, migrator *Migrator, dryRun bool) *Runner {
+	return &Runner{
+		db:       db,
+		table:    table,
+		migrator: migrator,
+		dryRun:   dryRun,
+	}
+}
+
+// RunUser migrates every item in a single user's partition.
+func (r *Runner) RunUser(ctx context.Context, userId string) (Result, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.table),
+		KeyConditionExpression: aws.String("userId = :userId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+		},
+	}
+
+	return r.run(ctx, "user:"+userId, userId, func(handle func([]Item) error) error {
+		paginator := dynamodb.NewQueryPaginator(r.db, input)
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err, "userId", userId)
+				return err
+			}
+			err = handle(page.Items)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RunTable migrates every item in the table.
+func (r *Runner) RunTable(ctx context.Context) (Result, error) {
+	input := &dynamodb.ScanInput{
+		TableName: aws.String(r.table),
+	}
+
+	return r.run(ctx, "table", TablePartition, func(handle func([]Item) error) error {
+		paginator := dynamodb.NewScanPaginator(r.db, input)
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "DynamoDB Scan failed", "error", err, "table", r.table)
+				return err
+			}
+			err = handle(page.Items)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Runner) run(ctx context.Context, scope, recordPartition string, iterate func(func([]Item) error) error) (Result, error) {
+	result := Result{Scope: scope}
+
+	latest := r.migrator.Latest()
+	if latest == 0 {
+		result.Skipped = true
+		return result, nil
+	}
+
+	applied, err := r.Applied(ctx, recordPartition)
+	if err != nil {
+		return result, err
+	}
+	if len(applied) > 0 && applied[len(applied)-1].Version >= latest {
+		slog.InfoContext(ctx, "Migrations already applied", "scope", scope, "version", latest)
+		result.Skipped = true
+		return result, nil
+	}
+
+	err = iterate(func(items []Item) error {
+		for _, item := range items {
+			// Migration records are bookkeeping, not data
+			if strings.HasPrefix(itemId(item), itemPrefixMigration) {
+				continue
+			}
+
+			result.Scanned++
+			changed, err := r.UpgradeAndSave(ctx, item)
+			if errors.Is(err, ErrConcurrentUpdate) {
+				result.Conflicts++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if changed {
+				result.Migrated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	slog.InfoContext(ctx, "Migration run finished", "scope", scope, "scanned", result.Scanned, "migrated", result.Migrated, "conflicts", result.Conflicts, "dryRun", r.dryRun)
+
+	// Leave the scope unrecorded so a re-run picks up conflicting items
+	if r.dryRun || result.Conflicts > 0 {
+		return result, nil
+	}
+
+	return result, r.record(ctx, recordPartition, scope, applied, result)
+}
+
+var ErrConcurrentUpdate = errors.New("item changed while being migrated")
+
+// UpgradeAndSave applies pending migrations to the item and writes it back,
+// guarded so a concurrent writer's newer version is never overwritten.
+func (r *Runner) UpgradeAndSave(ctx context.Context, item Item) (bool, error) {
+	previous := ItemVersion(item)
+
+	changed, err := r.migrator.Upgrade(item)
+	if err != nil || !changed || r.dryRun {
+		return changed, err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(r.table),
+		Item:      item,
+	}
+	if previous == 0 {
+		input.ConditionExpression = aws.String("attribute_exists(itemId) AND attribute_not_exists(#version)")
+		input.ExpressionAttributeNames = map[string]string{"#version": VersionAttribute}
+	} else {
+		input.ConditionExpression = aws.String("#version = :previous")
+		input.ExpressionAttributeNames = map[string]string{"#version": VersionAttribute}
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":previous": &types.AttributeValueMemberN{Value: strconv.Itoa(previous)},
+		}
+	}
+
+	_, err = r.db.PutItem(ctx, input)
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return false, ErrConcurrentUpdate
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "table", r.table)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Applied returns the migration records for a partition, oldest first.
+func (r *Runner) Applied(ctx context.Context, partition string) ([]Record, error) {
+	var items []recordItem
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.table),
+		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: partition},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixMigration},
+		},
+	}
+
+	result, err := r.db.Query(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err, "partition", partition)
+		return nil, err
+	}
+
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal migration records", "error", err)
+		return nil, err
+	}
+
+	records := make([]Record, len(items))
+	for i, item := range items {
+		records[i] = item.Record
+	}
+
+	return records, nil
+}
+
+func (r *Runner) record(ctx context.Context, partition, scope string, applied []Record, result Result) error {
+	done := make(map[int]bool, len(applied))
+	for _, record := range applied {
+		done[record.Version] = true
+	}
+
+	for _, migration := range r.migrator.Migrations() {
+		if done[migration.Version] {
+			continue
+		}
+
+		item, err := attributevalue.MarshalMap(recordItem{
+			UserId: partition,
+			// Zero padded so records sort by version
+			ItemId: fmt.Sprintf("%s%06d", itemPrefixMigration, migration.Version),
+			Record: Record{
+				Version:       migration.Version,
+				Name:          migration.Name,
+				Scope:         scope,
+				ItemsScanned:  result.Scanned,
+				ItemsMigrated: result.Migrated,
+				AppliedAt:     time.Now().Unix(),
+			},
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to marshal migration record", "error", err)
+			return err
+		}
+
+		_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(r.table),
+			Item:      item,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "table", r.table)
+			return err
+		}
+	}
+
+	return nil
+}
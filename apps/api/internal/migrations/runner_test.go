@@ -0,0 +1,245 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-migrations"
+
+func setupTestDB(t *testing.T) *dynamodb.Client {
+	t.Helper()
+
+	db, _ := dynamotest.NewTable(t, testTableName)
+	return db
+}
+
+var testMigrations = []Migration{
+	{
+		Version:    2,
+		Name:       "backfill-archived",
+		ItemPrefix: "habit#",
+		Transform: func(item Item) error {
+			if _, ok := item["Archived"]; !ok {
+				item["Archived"] = &types.AttributeValueMemberBOOL{Value: false}
+			}
+			return nil
+		},
+	},
+	{
+		Version:    1,
+		Name:       "default-color",
+		ItemPrefix: "habit#",
+		Transform: func(item Item) error {
+			if _, ok := item["Color"]; !ok {
+				item["Color"] = &types.AttributeValueMemberS{Value: "#000000"}
+			}
+			return nil
+		},
+	},
+}
+
+func putItem(t *testing.T, db *dynamodb.Client, userId, itemId string) {
+	t.Helper()
+
+	_, err := db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(testTableName),
+		Item: Item{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemId},
+			"Name":   &types.AttributeValueMemberS{Value: "test"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to put item: %v", err)
+	}
+}
+
+func getItem(t *testing.T, db *dynamodb.Client, userId, itemId string) Item {
+	t.Helper()
+
+	result, err := db.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName: aws.String(testTableName),
+		Key: Item{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemId},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to get item: %v", err)
+	}
+	return result.Item
+}
+
+func TestMigratorUpgrade(t *testing.T) {
+	migrator := NewMigrator(testMigrations)
+
+	t.Run("applies pending migrations in order", func(t *testing.T) {
+		item := Item{"itemId": &types.AttributeValueMemberS{Value: "habit#1"}}
+
+		changed, err := migrator.Upgrade(item)
+		if err != nil {
+			t.Fatalf("Upgrade failed: %v", err)
+		}
+		if !changed {
+			t.Fatal("expected item to change")
+		}
+		if ItemVersion(item) != 2 {
+			t.Errorf("expected version 2, got %d", ItemVersion(item))
+		}
+		color, _ := item["Color"].(*types.AttributeValueMemberS)
+		if color == nil || color.Value != "#000000" || item["Archived"] == nil {
+			t.Errorf("expected both migrations applied, got %v", item)
+		}
+	})
+
+	t.Run("skips applied migrations", func(t *testing.T) {
+		item := Item{
+			"itemId":         &types.AttributeValueMemberS{Value: "habit#1"},
+			VersionAttribute: &types.AttributeValueMemberN{Value: "2"},
+		}
+
+		changed, err := migrator.Upgrade(item)
+		if err != nil {
+			t.Fatalf("Upgrade failed: %v", err)
+		}
+		if changed {
+			t.Error("expected no change for an up to date item")
+		}
+	})
+
+	t.Run("skips other item types", func(t *testing.T) {
+		item := Item{"itemId": &types.AttributeValueMemberS{Value: "habit-log#1"}}
+
+		changed, _ := migrator.Upgrade(item)
+		if changed {
+			t.Error("expected habit log to be left alone")
+		}
+		if _, ok := item[VersionAttribute]; ok {
+			t.Error("expected no version stamp on untouched item")
+		}
+	})
+
+	t.Run("transform error", func(t *testing.T) {
+		failing := NewMigrator([]Migration{{
+			Version:   1,
+			Name:      "fail",
+			Transform: func(item Item) error { return errors.New("boom") },
+		}})
+
+		_, err := failing.Upgrade(Item{"itemId": &types.AttributeValueMemberS{Value: "habit#1"}})
+		var transformErr *TransformError
+		if !errors.As(err, &transformErr) {
+			t.Fatalf("expected TransformError, got %v", err)
+		}
+	})
+}
+
+func TestRunnerRunUser(t *testing.T) {
+	db := setupTestDB(t)
+	putItem(t, db, "user-1", "habit#1")
+	putItem(t, db, "user-1", "habit#2")
+	putItem(t, db, "user-1", "habit-log#1")
+	putItem(t, db, "user-2", "habit#3")
+
+	runner := NewRunner(db, testTableName, NewMigrator(testMigrations), false)
+
+	result, err := runner.RunUser(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("RunUser failed: %v", err)
+	}
+	if result.Scanned != 3 || result.Migrated != 2 {
+		t.Errorf("expected 3 scanned and 2 migrated, got %+v", result)
+	}
+	if ItemVersion(getItem(t, db, "user-1", "habit#1")) != 2 {
+		t.Error("expected habit#1 to be at version 2")
+	}
+	if ItemVersion(getItem(t, db, "user-2", "habit#3")) != 0 {
+		t.Error("expected other users to be untouched")
+	}
+
+	records, err := runner.Applied(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Applied failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Version != 1 || records[1].Version != 2 {
+		t.Errorf("expected records for versions 1 and 2, got %+v", records)
+	}
+
+	// A second run is a no-op
+	result, err = runner.RunUser(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("second RunUser failed: %v", err)
+	}
+	if !result.Skipped {
+		t.Errorf("expected second run to be skipped, got %+v", result)
+	}
+}
+
+func TestRunnerRunTable(t *testing.T) {
+	db := setupTestDB(t)
+	putItem(t, db, "user-1", "habit#1")
+	putItem(t, db, "user-2", "habit#2")
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		runner := NewRunner(db, testTableName, NewMigrator(testMigrations), true)
+
+		result, err := runner.RunTable(context.Background())
+		if err != nil {
+			t.Fatalf("RunTable failed: %v", err)
+		}
+		if result.Migrated != 2 {
+			t.Errorf("expected 2 items to need migration, got %d", result.Migrated)
+		}
+		if ItemVersion(getItem(t, db, "user-1", "habit#1")) != 0 {
+			t.Error("expected dry run to leave items untouched")
+		}
+	})
+
+	t.Run("migrates every partition", func(t *testing.T) {
+		runner := NewRunner(db, testTableName, NewMigrator(testMigrations), false)
+
+		result, err := runner.RunTable(context.Background())
+		if err != nil {
+			t.Fatalf("RunTable failed: %v", err)
+		}
+		if result.Migrated != 2 {
+			t.Errorf("expected 2 migrated items, got %d", result.Migrated)
+		}
+		if ItemVersion(getItem(t, db, "user-2", "habit#2")) != 2 {
+			t.Error("expected habit#2 to be at version 2")
+		}
+
+		records, err := runner.Applied(context.Background(), TablePartition)
+		if err != nil {
+			t.Fatalf("Applied failed: %v", err)
+		}
+		if len(records) != 2 {
+			t.Errorf("expected 2 table records, got %d", len(records))
+		}
+	})
+}
+
+func TestRunnerUpgradeAndSaveConflict(t *testing.T) {
+	db := setupTestDB(t)
+	putItem(t, db, "user-1", "habit#1")
+	runner := NewRunner(db, testTableName, NewMigrator(testMigrations), false)
+
+	stale := getItem(t, db, "user-1", "habit#1")
+
+	// Another writer migrates the item first
+	if _, err := runner.UpgradeAndSave(context.Background(), getItem(t, db, "user-1", "habit#1")); err != nil {
+		t.Fatalf("UpgradeAndSave failed: %v", err)
+	}
+
+	_, err := runner.UpgradeAndSave(context.Background(), stale)
+	if !errors.Is(err, ErrConcurrentUpdate) {
+		t.Fatalf("expected ErrConcurrentUpdate, got %v", err)
+	}
+}
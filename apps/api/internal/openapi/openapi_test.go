@@ -0,0 +1,138 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite openapi.json from Build")
+
+// TestSpecUpToDate fails when a route or model changed without openapi.json
+// being regenerated with: go test ./internal/openapi -update. The web app's
+// types are generated from it in turn, with npm run types in apps/web.
+func TestSpecUpToDate(t *testing.T) {
+	got, err := Marshal(Build())
+	if err != nil {
+		t.Fatalf("failed to marshal document: %v", err)
+	}
+
+	if *update {
+		err = os.WriteFile("openapi.json", got, 0o644)
+		if err != nil {
+			t.Fatalf("failed to write openapi.json: %v", err)
+		}
+		return
+	}
+
+	if !bytes.Equal(got, spec) {
+		t.Error("openapi.json is out of date, regenerate it with: go test ./internal/openapi -update")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	doc := Build()
+
+	t.Run("operation IDs are unique", func(t *testing.T) {
+		seen := map[string]bool{}
+		for _, route := range Routes {
+			if seen[route.ID] {
+				t.Errorf("duplicate operation ID %q", route.ID)
+			}
+			seen[route.ID] = true
+		}
+	})
+
+	t.Run("refs resolve", func(t *testing.T) {
+		raw, _ := json.Marshal(doc)
+		var refs []string
+		collectRefs(t, raw, &refs)
+
+		for _, ref := range refs {
+			name := ref[len(schemaRefPrefix):]
+			if _, ok := doc.Components.Schemas[name]; !ok {
+				t.Errorf("ref %q has no schema", ref)
+			}
+		}
+	})
+
+	t.Run("path parameters", func(t *testing.T) {
+		op := doc.Paths["/habits/{habitId}"]["get"]
+		if op == nil {
+			t.Fatal("expected GET /habits/{habitId}")
+		}
+		if len(op.Parameters) != 1 || op.Parameters[0].Name != "habitId" || op.Parameters[0].In != "path" {
+			t.Errorf("expected habitId path parameter, got %+v", op.Parameters)
+		}
+	})
+
+	t.Run("authed routes", func(t *testing.T) {
+		op := doc.Paths["/habits"]["post"]
+		if len(op.Security) != 1 {
+			t.Errorf("expected bearer security, got %v", op.Security)
+		}
+		for _, status := range []string{"201", "400", "401", "413", "415", "429", "default"} {
+			if _, ok := op.Responses[status]; !ok {
+				t.Errorf("expected %s response", status)
+			}
+		}
+	})
+
+	t.Run("public routes", func(t *testing.T) {
+		op := doc.Paths["/health/live"]["get"]
+		if len(op.Security) != 0 {
+			t.Errorf("expected no security, got %v", op.Security)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	Handler(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON, got %q", w.Header().Get("Content-Type"))
+	}
+
+	var doc Document
+	err := json.NewDecoder(w.Body).Decode(&doc)
+	if err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("expected OpenAPI 3.1.0, got %q", doc.OpenAPI)
+	}
+}
+
+func collectRefs(t *testing.T, raw json.RawMessage, refs *[]string) {
+	t.Helper()
+
+	var value any
+	json.Unmarshal(raw, &value)
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for key, child := range v {
+				if ref, ok := child.(string); ok && key == "$ref" {
+					*refs = append(*refs, ref)
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(value)
+}
@@ -0,0 +1,336 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jimvid/sidekick/internal/account"
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/health"
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/push"
+	"github.com/jimvid/sidekick/internal/reminders"
+	"github.com/jimvid/sidekick/internal/tokens"
+	"github.com/jimvid/sidekick/internal/user"
+	"github.com/jimvid/sidekick/internal/webhooks"
+)
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeText = "text/plain"
+
+	bearerAuth = "bearerAuth"
+)
+
+// Route documents one route registered in router.NewRouter.
+type Route struct {
+	Method  string
+	Path    string
+	ID      string
+	Summary string
+	Tag     string
+	// Auth routes need a Clerk session token or a personal API token and are
+	// rate limited per user, other routes per IP.
+	Auth bool
+	// SessionOnly routes refuse personal API tokens with 403.
+	SessionOnly bool
+	// Body is a zero value of the request body type, nil when there is none.
+	Body any
+	// AllowUnknown is set for bodies that are not read with
+	// request.DecodeJSON, so unknown fields are ignored rather than rejected.
+	AllowUnknown bool
+	// Query lists the optional query parameters the route reads.
+	Query  []QueryParam
+	Status int
+	// Response is a zero value of the response type. A string means a plain
+	// text response.
+	Response    any
+	Description string
+	// Errors are the problem statuses the route returns besides the ones
+	// implied by Auth and Body.
+	Errors []int
+}
+
+// QueryParam is an optional string query parameter.
+type QueryParam struct {
+	Name        string
+	Format      string
+	Description string
+}
+
+// Message is the body of responses that only confirm an action.
+type Message struct {
+	Message string `json:"message"`
+}
+
+// Routes lists every route the API serves. TestRoutesDocumented in the router
+// package fails when this and the router disagree.
+var Routes = []Route{
+	// Health
+	{Method: http.MethodGet, Path: "/health", ID: "getHealth", Summary: "Plain text liveness probe", Tag: "Health", Status: http.StatusOK, Response: ""},
+	{Method: http.MethodGet, Path: "/health/live", ID: "getHealthLive", Summary: "Report that the process is up", Tag: "Health", Status: http.StatusOK, Response: health.Report{}},
+	{Method: http.MethodGet, Path: "/health/ready", ID: "getHealthReady", Summary: "Check dependencies the API needs to serve requests", Tag: "Health", Status: http.StatusOK, Response: health.Report{},
+		Description: "Responds 503 with the same report when any check fails."},
+	{Method: http.MethodGet, Path: "/metrics", ID: "getMetrics", Summary: "Prometheus metrics, only served outside Lambda", Tag: "Health", Status: http.StatusOK, Response: ""},
+	{Method: http.MethodGet, Path: "/openapi.json", ID: "getOpenAPI", Summary: "This document", Tag: "Health", Status: http.StatusOK, Response: map[string]any{}},
+
+	// Habits
+	{Method: http.MethodPost, Path: "/habits", ID: "createHabit", Summary: "Create a habit", Tag: "Habits", Auth: true, Body: habits.HabitReq{}, Status: http.StatusCreated, Response: habits.HabitModel{}},
+	{Method: http.MethodGet, Path: "/habits", ID: "listHabits", Summary: "List the user's habits", Tag: "Habits", Auth: true, Status: http.StatusOK, Response: []habits.HabitModel{}},
+	{Method: http.MethodGet, Path: "/habits/{habitId}", ID: "getHabit", Summary: "Get a habit", Tag: "Habits", Auth: true, Status: http.StatusOK, Response: habits.HabitModel{},
+		Errors: []int{http.StatusNotFound}},
+	{Method: http.MethodDelete, Path: "/habits/{habitId}", ID: "deleteHabit", Summary: "Delete a habit", Tag: "Habits", Auth: true, Status: http.StatusOK, Response: Message{},
+		Errors: []int{http.StatusNotFound}},
+	{Method: http.MethodPut, Path: "/habits/{habitId}", ID: "updateHabit", Summary: "Update a habit", Tag: "Habits", Auth: true, Body: habits.HabitReq{}, Status: http.StatusOK, Response: habits.HabitModel{},
+		Errors: []int{http.StatusNotFound}},
+
+	// Logs
+	{Method: http.MethodPost, Path: "/habit-logs", ID: "createHabitLog", Summary: "Log a habit for a day", Tag: "Habit logs", Auth: true, Body: habits.HabitLogReq{}, Status: http.StatusCreated, Response: habits.HabitLogModel{}},
+	{Method: http.MethodGet, Path: "/habit-logs", ID: "listHabitLogs", Summary: "List the user's habit logs", Tag: "Habit logs", Auth: true, Status: http.StatusOK, Response: []habits.HabitLogModel{}},
+	{Method: http.MethodGet, Path: "/habit-logs/{id}", ID: "getHabitLog", Summary: "Get a habit log", Tag: "Habit logs", Auth: true, Status: http.StatusOK, Response: habits.HabitLogModel{},
+		Errors: []int{http.StatusNotFound}},
+	{Method: http.MethodDelete, Path: "/habit-logs/{id}", ID: "deleteHabitLog", Summary: "Delete a habit log", Tag: "Habit logs", Auth: true, Status: http.StatusOK, Response: Message{},
+		Errors: []int{http.StatusNotFound}},
+	{Method: http.MethodPut, Path: "/habit-logs/{id}", ID: "updateHabitLog", Summary: "Update a habit log", Tag: "Habit logs", Auth: true, Body: habits.HabitLogReq{}, Status: http.StatusOK, Response: habits.HabitLogModel{},
+		Errors: []int{http.StatusNotFound}},
+
+	// Days
+	{Method: http.MethodGet, Path: "/days/{date}", ID: "getDay", Summary: "Get each habit with its logs for a day", Tag: "Days", Auth: true, Status: http.StatusOK, Response: habits.DayModel{},
+		Description: "The date is YYYY-MM-DD, or today for the current date in the user's time zone.",
+		Errors:      []int{http.StatusBadRequest}},
+
+	// Stats
+	{Method: http.MethodGet, Path: "/heatmap", ID: "getHeatmap", Summary: "Count completed habits per day", Tag: "Stats", Auth: true, Status: http.StatusOK, Response: habits.HeatmapModel{},
+		Description: "Every day in the range is returned, with how many habits were done and the ratio of done to scheduled habits. The range is at most 371 days, 53 weeks.",
+		Query: []QueryParam{
+			{Name: "from", Format: "date", Description: "First day, defaults to the start of the week 52 weeks before to"},
+			{Name: "to", Format: "date", Description: "Last day, defaults to today in the user's time zone"},
+			{Name: "habitId", Description: "Only count this habit"},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+
+	// Reminders
+	{Method: http.MethodGet, Path: "/reminders", ID: "listReminders", Summary: "List the user's reminders", Tag: "Reminders", Auth: true, Status: http.StatusOK, Response: []reminders.ReminderModel{}},
+	{Method: http.MethodGet, Path: "/habits/{habitId}/reminders", ID: "listHabitReminders", Summary: "List a habit's reminders", Tag: "Reminders", Auth: true, Status: http.StatusOK, Response: []reminders.ReminderModel{},
+		Errors: []int{http.StatusNotFound}},
+	{Method: http.MethodPost, Path: "/habits/{habitId}/reminders", ID: "createReminder", Summary: "Remind the user of a habit at a time of day", Tag: "Reminders", Auth: true, Body: reminders.ReminderReq{}, Status: http.StatusCreated, Response: reminders.ReminderModel{},
+		Description: "The time is HH:MM in the user's time zone. Days are lower case weekdays, empty for every day. A reminder fires at most once a day, and not once its habit is logged that day.",
+		Errors:      []int{http.StatusNotFound}},
+	{Method: http.MethodPut, Path: "/reminders/{reminderId}", ID: "updateReminder", Summary: "Update a reminder", Tag: "Reminders", Auth: true, Body: reminders.ReminderReq{}, Status: http.StatusOK, Response: reminders.ReminderModel{},
+		Description: "Moving a reminder to a new time lets it fire again the same day.",
+		Errors:      []int{http.StatusNotFound}},
+	{Method: http.MethodDelete, Path: "/reminders/{reminderId}", ID: "deleteReminder", Summary: "Delete a reminder", Tag: "Reminders", Auth: true, Status: http.StatusOK, Response: Message{},
+		Errors: []int{http.StatusNotFound}},
+
+	// Push
+	{Method: http.MethodGet, Path: "/push/public-key", ID: "getPushPublicKey", Summary: "Get the VAPID key to subscribe to push with", Tag: "Push", Auth: true, Status: http.StatusOK, Response: push.PublicKeyModel{},
+		Description: "Pass it as applicationServerKey to PushManager.subscribe.",
+		Errors:      []int{http.StatusServiceUnavailable}},
+	{Method: http.MethodGet, Path: "/me/push-subscriptions", ID: "listPushSubscriptions", Summary: "List the devices push notifications are sent to", Tag: "Push", Auth: true, Status: http.StatusOK, Response: []push.SubscriptionModel{}},
+	{Method: http.MethodPost, Path: "/me/push-subscriptions", ID: "createPushSubscription", Summary: "Send push notifications to a device", Tag: "Push", Auth: true, Body: push.SubscriptionReq{}, Status: http.StatusCreated, Response: push.SubscriptionModel{},
+		Description: "The body is the JSON of the browser's PushSubscription. The endpoint must be on a known push service: Firebase Cloud Messaging, Apple, Mozilla or Windows. Subscribing a device again updates it and responds 200."},
+	{Method: http.MethodPost, Path: "/me/push-subscriptions/test", ID: "testPushSubscriptions", Summary: "Send a test notification to every device", Tag: "Push", Auth: true, Status: http.StatusOK, Response: push.TestResult{},
+		Description: "Devices the push service no longer knows are removed.",
+		Errors:      []int{http.StatusServiceUnavailable}},
+	{Method: http.MethodDelete, Path: "/me/push-subscriptions/{subscriptionId}", ID: "deletePushSubscription", Summary: "Stop sending push notifications to a device", Tag: "Push", Auth: true, Status: http.StatusOK, Response: Message{},
+		Errors: []int{http.StatusNotFound}},
+
+	// Webhooks
+	{Method: http.MethodGet, Path: "/me/webhooks", ID: "listWebhooks", Summary: "List the user's webhooks", Tag: "Webhooks", Auth: true, Status: http.StatusOK, Response: []webhooks.WebhookModel{}},
+	{Method: http.MethodPost, Path: "/me/webhooks", ID: "createWebhook", Summary: "Send habit and log events to a URL", Tag: "Webhooks", Auth: true, Body: webhooks.WebhookReq{}, Status: http.StatusCreated, Response: webhooks.WebhookWithSecretModel{},
+		Description: "Events are habit.created, habit.updated, habit.deleted, log.created and log.deleted. Each is posted as JSON and signed like Standard Webhooks, with the webhook-id, webhook-timestamp and webhook-signature headers and the webhook's secret. The secret is only in this response, rotateWebhookSecret replaces it. Failed deliveries are retried 5 times, after 1, 2, 4, 8 and 16 minutes. Deliveries to a URL resolving to a private, loopback or link-local address fail. A user can have 10 webhooks.",
+		Errors:      []int{http.StatusBadRequest}},
+	{Method: http.MethodGet, Path: "/me/webhooks/{webhookId}", ID: "getWebhook", Summary: "Get a webhook", Tag: "Webhooks", Auth: true, Status: http.StatusOK, Response: webhooks.WebhookModel{},
+		Errors: []int{http.StatusNotFound}},
+	{Method: http.MethodPut, Path: "/me/webhooks/{webhookId}", ID: "updateWebhook", Summary: "Update a webhook", Tag: "Webhooks", Auth: true, Body: webhooks.WebhookReq{}, Status: http.StatusOK, Response: webhooks.WebhookModel{},
+		Description: "The secret is kept. Pending retries of an inactive webhook fail.",
+		Errors:      []int{http.StatusNotFound}},
+	{Method: http.MethodDelete, Path: "/me/webhooks/{webhookId}", ID: "deleteWebhook", Summary: "Delete a webhook", Tag: "Webhooks", Auth: true, Status: http.StatusOK, Response: Message{},
+		Errors: []int{http.StatusNotFound}},
+	{Method: http.MethodGet, Path: "/me/webhooks/{webhookId}/deliveries", ID: "listWebhookDeliveries", Summary: "List a webhook's latest deliveries", Tag: "Webhooks", Auth: true, Status: http.StatusOK, Response: []webhooks.DeliveryModel{},
+		Description: "The 50 newest deliveries of the last 30 days, newest first.",
+		Errors:      []int{http.StatusNotFound}},
+	{Method: http.MethodPost, Path: "/me/webhooks/{webhookId}/test", ID: "testWebhook", Summary: "Send a webhook.test event to a webhook", Tag: "Webhooks", Auth: true, Status: http.StatusOK, Response: webhooks.DeliveryModel{},
+		Description: "Sent once, even to an inactive webhook, and not retried. The delivery's status says whether the URL accepted it.",
+		Errors:      []int{http.StatusNotFound}},
+	{Method: http.MethodPost, Path: "/me/webhooks/{webhookId}/rotate-secret", ID: "rotateWebhookSecret", Summary: "Replace a webhook's secret", Tag: "Webhooks", Auth: true, Status: http.StatusOK, Response: webhooks.WebhookWithSecretModel{},
+		Description: "The new secret is only in this response. Deliveries are signed with it right away, retries included.",
+		Errors:      []int{http.StatusNotFound}},
+
+	// Account
+	{Method: http.MethodGet, Path: "/me", ID: "getMe", Summary: "Get the signed in user's profile and settings", Tag: "Account", Auth: true, Status: http.StatusOK, Response: user.UserModel{}},
+	{Method: http.MethodDelete, Path: "/me", ID: "deleteMe", Summary: "Delete the signed in user's account and data", Tag: "Account", Auth: true, SessionOnly: true, Status: http.StatusOK, Response: Message{},
+		Description: "Needs a session token, personal API tokens are refused."},
+	{Method: http.MethodGet, Path: "/me/profile", ID: "getProfile", Summary: "Get the signed in user's profile", Tag: "Account", Auth: true, Status: http.StatusOK, Response: user.ProfileModel{},
+		Description: "A default profile is returned until the user saves one."},
+	{Method: http.MethodPut, Path: "/me/profile", ID: "updateProfile", Summary: "Replace the display name and notification preferences", Tag: "Account", Auth: true, Body: user.ProfileReq{}, Status: http.StatusOK, Response: user.ProfileModel{},
+		Description: "Onboarding progress is kept. With streakAlerts on, a reminder for a habit done at least the last two days says its streak ends today, and with reminders off only those reminders are sent."},
+	{Method: http.MethodPut, Path: "/me/onboarding/{step}", ID: "completeOnboardingStep", Summary: "Mark an onboarding step done", Tag: "Account", Auth: true, Status: http.StatusOK, Response: user.ProfileModel{},
+		Description: "Steps are create-habit, log-habit, set-time-zone and enable-notifications. Completing a step again has no effect.",
+		Errors:      []int{http.StatusBadRequest}},
+	{Method: http.MethodGet, Path: "/me/settings", ID: "getSettings", Summary: "Get the signed in user's settings", Tag: "Account", Auth: true, Status: http.StatusOK, Response: user.SettingsModel{},
+		Description: "Defaults are returned until the user saves settings."},
+	{Method: http.MethodPut, Path: "/me/settings", ID: "updateSettings", Summary: "Replace the signed in user's settings", Tag: "Account", Auth: true, Body: user.SettingsReq{}, Status: http.StatusOK, Response: user.SettingsModel{},
+		Description: "Dates such as today, and the weeks of the heatmap, follow the time zone and week start. Empty fields reset to the defaults."},
+	{Method: http.MethodGet, Path: "/me/tokens", ID: "listTokens", Summary: "List the user's personal API tokens", Tag: "Account", Auth: true, SessionOnly: true, Status: http.StatusOK, Response: []tokens.TokenModel{},
+		Description: "Needs a session token, personal API tokens are refused."},
+	{Method: http.MethodPost, Path: "/me/tokens", ID: "createToken", Summary: "Create a personal API token", Tag: "Account", Auth: true, SessionOnly: true, Body: tokens.TokenReq{}, Status: http.StatusCreated, Response: tokens.CreatedTokenModel{},
+		Description: "The token is only returned here. It is sent as a bearer token like a session token and does not expire unless expiresInDays is set, up to 365. A user can have 20 tokens. Needs a session token, personal API tokens are refused.",
+		Errors:      []int{http.StatusBadRequest}},
+	{Method: http.MethodDelete, Path: "/me/tokens/{tokenId}", ID: "deleteToken", Summary: "Revoke a personal API token", Tag: "Account", Auth: true, Status: http.StatusOK, Response: Message{},
+		Description: "A personal API token can only revoke itself, other tokens need a session token.",
+		Errors:      []int{http.StatusForbidden, http.StatusNotFound}},
+	{Method: http.MethodPost, Path: "/webhooks/clerk", ID: "clerkWebhook", Summary: "Receive Clerk user events", Tag: "Account", Body: account.ClerkWebhookEvent{}, AllowUnknown: true, Status: http.StatusOK, Response: Message{},
+		Description: "Signed by Clerk with Svix headers. Only user.deleted events are acted on.",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusServiceUnavailable}},
+}
+
+// Enum is a string schema that only takes a few values, so clients can type
+// it as a union.
+type Enum struct {
+	Name   string
+	Values []string
+	// Properties use the enum, as Component.property.
+	Properties []string
+}
+
+var Enums = []Enum{
+	{Name: "WeekStart", Values: user.Weekdays, Properties: []string{"Settings.weekStart", "SettingsReq.weekStart", "Reminder.days", "ReminderReq.days"}},
+	{Name: "OnboardingStep", Values: user.OnboardingSteps, Properties: []string{"Onboarding.completedSteps"}},
+	{Name: "WebhookEvent", Values: habits.EventTypes, Properties: []string{"Webhook.events", "WebhookReq.events"}},
+	{Name: "DeliveryEvent", Values: append(slices.Clone(habits.EventTypes), webhooks.EventTest), Properties: []string{"Delivery.event"}},
+	{Name: "DeliveryStatus", Values: webhooks.Statuses, Properties: []string{"Delivery.status"}},
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Build generates the document from Routes.
+func Build() *Document {
+	s := newSchemas()
+	problemSchema := s.ref(problem.Problem{})
+
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info: Info{
+			Title:       "Sidekick API",
+			Description: "Habit tracking API. Errors are RFC 7807 problem details.",
+			Version:     Version,
+		},
+		Servers: []Server{
+			{URL: "https://api.sidekick.jimvid.xyz", Description: "Production"},
+			{URL: "https://dev.api.sidekick.jimvid.xyz", Description: "Development"},
+		},
+		Paths: map[string]PathItem{},
+		Components: Components{
+			Schemas: s.components,
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "Clerk session token, or a personal API token from createToken",
+				},
+			},
+		},
+	}
+
+	for _, route := range Routes {
+		op := &Operation{
+			OperationID: route.ID,
+			Summary:     route.Summary,
+			Description: route.Description,
+			Tags:        []string{route.Tag},
+			Responses:   map[string]Response{},
+		}
+
+		for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+		for _, param := range route.Query {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:        param.Name,
+				In:          "query",
+				Description: param.Description,
+				Schema:      &Schema{Type: "string", Format: param.Format},
+			})
+		}
+
+		// Every route is rate limited, per user or per IP
+		statuses := append([]int{}, route.Errors...)
+		statuses = append(statuses, http.StatusTooManyRequests)
+		if route.Auth {
+			op.Security = []map[string][]string{{bearerAuth: {}}}
+			statuses = append(statuses, http.StatusUnauthorized)
+		}
+		if route.SessionOnly {
+			statuses = append(statuses, http.StatusForbidden)
+		}
+		if route.Body != nil {
+			body := s.ref(route.Body)
+			if !route.AllowUnknown {
+				s.closeRequest(body)
+				statuses = append(statuses, http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType)
+			}
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{contentTypeJSON: {Schema: body}},
+			}
+		}
+
+		op.Responses[strconv.Itoa(route.Status)] = successResponse(s, route)
+		for _, status := range statuses {
+			op.Responses[strconv.Itoa(status)] = problemResponse(problemSchema, status)
+		}
+		op.Responses["default"] = problemResponse(problemSchema, http.StatusInternalServerError)
+
+		item, ok := doc.Paths[route.Path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	for _, enum := range Enums {
+		s.enum(enum.Name, enum.Values, enum.Properties...)
+	}
+
+	return doc
+}
+
+func successResponse(s *schemas, route Route) Response {
+	description := http.StatusText(route.Status)
+
+	if _, ok := route.Response.(string); ok {
+		return Response{
+			Description: description,
+			Content:     map[string]MediaType{contentTypeText: {Schema: &Schema{Type: "string"}}},
+		}
+	}
+
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{contentTypeJSON: {Schema: s.ref(route.Response)}},
+	}
+}
+
+func problemResponse(schema *Schema, status int) Response {
+	response := Response{
+		Description: http.StatusText(status),
+		Content:     map[string]MediaType{problem.ContentType: {Schema: schema}},
+	}
+
+	if status == http.StatusTooManyRequests {
+		response.Headers = map[string]Header{
+			"Retry-After": {Description: "Seconds until a request will be allowed", Schema: &Schema{Type: "integer"}},
+		}
+	}
+	return response
+}
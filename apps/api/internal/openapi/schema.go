@@ -0,0 +1,180 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema 2020-12 the API models need.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+}
+
+const schemaRefPrefix = "#/components/schemas/"
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// schemas derives component schemas from Go types using their json tags, the
+// same way encoding/json sees them.
+type schemas struct {
+	components map[string]*Schema
+	types      map[string]reflect.Type
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: map[string]*Schema{},
+		types:      map[string]reflect.Type{},
+	}
+}
+
+// componentName is the type name without the Model suffix the storage
+// packages use, so HabitModel is published as Habit.
+func componentName(t reflect.Type) string {
+	return strings.TrimSuffix(t.Name(), "Model")
+}
+
+// ref returns a schema for v, adding named struct types to the components.
+func (s *schemas) ref(v any) *Schema {
+	return s.schemaFor(reflect.TypeOf(v))
+}
+
+func (s *schemas) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == rawMessageType {
+		// Encoded as is, any JSON value
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.schemaFor(t.Elem())}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			panic(fmt.Sprintf("openapi: map key of %s must be a string", t))
+		}
+		return &Schema{Type: "object", AdditionalProperties: s.schemaFor(t.Elem())}
+	case reflect.Interface:
+		// Any JSON value
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+	default:
+		panic(fmt.Sprintf("openapi: unsupported type %s", t))
+	}
+
+	name := componentName(t)
+	if existing, ok := s.types[name]; ok {
+		if existing != t {
+			panic(fmt.Sprintf("openapi: %s and %s both map to schema %s", existing, t, name))
+		}
+		return &Schema{Ref: schemaRefPrefix + name}
+	}
+
+	// Registered before the fields are walked so recursive types terminate
+	s.types[name] = t
+	s.components[name] = nil
+	s.components[name] = s.object(t)
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+// object describes a struct. Fields without omitempty are always encoded, so
+// they are required.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			s.addFields(schema, embedded)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = s.schemaFor(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// enum adds a string component limited to values and points properties,
+// given as component.property, at it. Array properties get it as their
+// items.
+func (s *schemas) enum(name string, values []string, properties ...string) {
+	if _, ok := s.components[name]; ok {
+		panic(fmt.Sprintf("openapi: enum %s clashes with a schema", name))
+	}
+	s.components[name] = &Schema{Type: "string", Enum: values}
+
+	for _, key := range properties {
+		component, property, _ := strings.Cut(key, ".")
+		schema, ok := s.components[component]
+		if !ok || schema.Properties[property] == nil {
+			panic(fmt.Sprintf("openapi: enum %s for unknown property %s", name, key))
+		}
+
+		target := schema.Properties[property]
+		if target.Type == "array" {
+			target = target.Items
+		}
+		if target.Type != "string" {
+			panic(fmt.Sprintf("openapi: enum %s for %s, which is not a string", name, key))
+		}
+		*target = Schema{Ref: schemaRefPrefix + name}
+	}
+}
+
+// closeRequest marks a request body schema as the decoder treats it: unknown
+// fields are rejected and missing fields decode to their zero value.
+func (s *schemas) closeRequest(ref *Schema) {
+	schema, ok := s.components[strings.TrimPrefix(ref.Ref, schemaRefPrefix)]
+	if !ok {
+		return
+	}
+	schema.Required = nil
+	schema.AdditionalProperties = false
+}
@@ -0,0 +1,97 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+type embeddedModel struct {
+	ID string `json:"id"`
+}
+
+type testModel struct {
+	Hidden string `json:"-"`
+	embeddedModel
+	Name    string            `json:"name"`
+	Count   int64             `json:"count"`
+	Tags    []string          `json:"tags,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Child   *testChild        `json:"child,omitempty"`
+	private string
+}
+
+type testChild struct {
+	Score float64 `json:"score"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	s := newSchemas()
+	ref := s.ref(testModel{})
+
+	if ref.Ref != schemaRefPrefix+"test" {
+		t.Fatalf("expected ref to test, got %q", ref.Ref)
+	}
+
+	schema := s.components["test"]
+	var names []string
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	want := map[string]string{"id": "string", "name": "string", "count": "integer", "tags": "array", "labels": "object", "child": ""}
+	if len(schema.Properties) != len(want) {
+		t.Fatalf("expected properties %v, got %v", want, names)
+	}
+	for name, typ := range want {
+		if schema.Properties[name].Type != typ {
+			t.Errorf("expected %s to be %q, got %q", name, typ, schema.Properties[name].Type)
+		}
+	}
+
+	if !reflect.DeepEqual(schema.Required, []string{"id", "name", "count"}) {
+		t.Errorf("expected omitempty fields to be optional, got required %v", schema.Required)
+	}
+	if schema.Properties["child"].Ref != schemaRefPrefix+"testChild" {
+		t.Errorf("expected child to reference testChild, got %q", schema.Properties["child"].Ref)
+	}
+	if _, ok := s.components["testChild"]; !ok {
+		t.Error("expected testChild component")
+	}
+}
+
+func TestCloseRequest(t *testing.T) {
+	s := newSchemas()
+	s.closeRequest(s.ref(testChild{}))
+
+	schema := s.components["testChild"]
+	if schema.Required != nil {
+		t.Errorf("expected no required fields, got %v", schema.Required)
+	}
+	if schema.AdditionalProperties != false {
+		t.Errorf("expected additionalProperties false, got %v", schema.AdditionalProperties)
+	}
+}
+
+func TestEnum(t *testing.T) {
+	s := newSchemas()
+	s.ref(testModel{})
+
+	s.enum("Kind", []string{"a", "b"}, "test.name", "test.tags")
+
+	if !reflect.DeepEqual(s.components["Kind"], &Schema{Type: "string", Enum: []string{"a", "b"}}) {
+		t.Errorf("expected a Kind component, got %+v", s.components["Kind"])
+	}
+	schema := s.components["test"]
+	if schema.Properties["name"].Ref != schemaRefPrefix+"Kind" {
+		t.Errorf("expected name to reference Kind, got %+v", schema.Properties["name"])
+	}
+	if schema.Properties["tags"].Type != "array" || schema.Properties["tags"].Items.Ref != schemaRefPrefix+"Kind" {
+		t.Errorf("expected tags items to reference Kind, got %+v", schema.Properties["tags"])
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a property that is not a string")
+		}
+	}()
+	s.enum("Count", []string{"1"}, "test.count")
+}
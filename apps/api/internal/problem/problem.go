@@ -0,0 +1,144 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/jimvid/sidekick/internal/request"
+)
+
+const ContentType = "application/problem+json"
+
+// TypeBase prefixes every problem type, followed by the error code.
+const TypeBase = "https://sidekick.jimvid.xyz/problems/"
+
+// Error codes are stable identifiers clients can switch on, unlike detail
+// which is meant for people.
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeInvalidBody          = "invalid_body"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeUnauthenticated      = "unauthenticated"
+	CodeInvalidSignature     = "invalid_signature"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeRateLimited          = "rate_limited"
+	CodeUnavailable          = "unavailable"
+	CodeInternal             = "internal_error"
+)
+
+var titles = map[string]string{
+	CodeInvalidRequest:       "Invalid request",
+	CodeInvalidBody:          "Invalid request body",
+	CodeValidationFailed:     "Validation failed",
+	CodeUnsupportedMediaType: "Unsupported media type",
+	CodeBodyTooLarge:         "Request body too large",
+	CodeUnauthenticated:      "Authentication required",
+	CodeInvalidSignature:     "Invalid signature",
+	CodeForbidden:            "Forbidden",
+	CodeNotFound:             "Resource not found",
+	CodeMethodNotAllowed:     "Method not allowed",
+	CodeRateLimited:          "Too many requests",
+	CodeUnavailable:          "Service unavailable",
+	CodeInternal:             "Internal server error",
+}
+
+// InvalidParam describes one field that failed validation.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Problem is an RFC 7807 problem details object with an error code and
+// invalid-params extension members.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	Code          string         `json:"code"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+func New(status int, code, detail string) Problem {
+	title, ok := titles[code]
+	if !ok {
+		title = http.StatusText(status)
+	}
+
+	return Problem{
+		Type:   TypeBase + code,
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func BadRequest(detail string) Problem {
+	return New(http.StatusBadRequest, CodeInvalidRequest, detail)
+}
+
+func Unauthenticated() Problem {
+	return New(http.StatusUnauthorized, CodeUnauthenticated, "A valid session token is required")
+}
+
+func Forbidden(detail string) Problem {
+	return New(http.StatusForbidden, CodeForbidden, detail)
+}
+
+func NotFound(detail string) Problem {
+	return New(http.StatusNotFound, CodeNotFound, detail)
+}
+
+func Internal(detail string) Problem {
+	return New(http.StatusInternalServerError, CodeInternal, detail)
+}
+
+func Validation(params []InvalidParam) Problem {
+	p := New(http.StatusBadRequest, CodeValidationFailed, "One or more fields are invalid")
+	p.InvalidParams = params
+	return p
+}
+
+// FromRequestError converts an error from request.DecodeJSON.
+func FromRequestError(err error) Problem {
+	var p Problem
+	switch request.StatusCode(err) {
+	case http.StatusUnsupportedMediaType:
+		p = New(http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, err.Error())
+	case http.StatusRequestEntityTooLarge:
+		p = New(http.StatusRequestEntityTooLarge, CodeBodyTooLarge, err.Error())
+	default:
+		p = New(http.StatusBadRequest, CodeInvalidBody, err.Error())
+	}
+
+	if field := request.Field(err); field != "" {
+		p.InvalidParams = []InvalidParam{{Name: field, Reason: err.Error()}}
+	}
+	return p
+}
+
+// Write sends the problem, using the request ID as its instance.
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Instance == "" {
+		p.Instance = chimiddleware.GetReqID(r.Context())
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Handler responds with the problem to every request, for use as a chi
+// NotFound or MethodNotAllowed handler or a Clerk failure handler.
+func Handler(p Problem) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, p)
+	}
+}
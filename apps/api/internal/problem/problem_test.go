@@ -0,0 +1,91 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/jimvid/sidekick/internal/request"
+)
+
+func TestWrite(t *testing.T) {
+	handler := chimiddleware.RequestID(Handler(NotFound("Could not find habit by ID")))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/habits/abc", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if w.Header().Get("Content-Type") != ContentType {
+		t.Errorf("expected content type %q, got %q", ContentType, w.Header().Get("Content-Type"))
+	}
+
+	var body map[string]any
+	json.NewDecoder(w.Body).Decode(&body)
+
+	want := map[string]any{
+		"type":   TypeBase + CodeNotFound,
+		"title":  "Resource not found",
+		"status": float64(http.StatusNotFound),
+		"detail": "Could not find habit by ID",
+		"code":   CodeNotFound,
+	}
+	for key, value := range want {
+		if body[key] != value {
+			t.Errorf("expected %s %v, got %v", key, value, body[key])
+		}
+	}
+	if instance, _ := body["instance"].(string); instance == "" {
+		t.Error("expected instance to be the request ID")
+	}
+	if _, ok := body["invalid-params"]; ok {
+		t.Error("expected invalid-params to be omitted")
+	}
+}
+
+func TestFromRequestError(t *testing.T) {
+	decode := func(contentType, body string) error {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		var dst struct {
+			Name string `json:"name"`
+		}
+		return request.DecodeJSON(httptest.NewRecorder(), req, &dst)
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantParam  string
+	}{
+		{name: "media type", err: decode("text/plain", `{}`), wantStatus: http.StatusUnsupportedMediaType, wantCode: CodeUnsupportedMediaType},
+		{name: "too large", err: decode("application/json", `{"name":"`+strings.Repeat("a", request.MaxBodyBytes)+`"}`), wantStatus: http.StatusRequestEntityTooLarge, wantCode: CodeBodyTooLarge},
+		{name: "unknown field", err: decode("application/json", `{"nmae":"x"}`), wantStatus: http.StatusBadRequest, wantCode: CodeInvalidBody, wantParam: "nmae"},
+		{name: "wrong type", err: decode("application/json", `{"name":1}`), wantStatus: http.StatusBadRequest, wantCode: CodeInvalidBody, wantParam: "name"},
+		{name: "other error", err: errors.New("boom"), wantStatus: http.StatusBadRequest, wantCode: CodeInvalidBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FromRequestError(tt.err)
+			if p.Status != tt.wantStatus || p.Code != tt.wantCode {
+				t.Errorf("expected %d %s, got %d %s", tt.wantStatus, tt.wantCode, p.Status, p.Code)
+			}
+
+			gotParam := ""
+			if len(p.InvalidParams) > 0 {
+				gotParam = p.InvalidParams[0].Name
+			}
+			if gotParam != tt.wantParam {
+				t.Errorf("expected invalid param %q, got %q", tt.wantParam, gotParam)
+			}
+		})
+	}
+}
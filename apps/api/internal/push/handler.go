@@ -0,0 +1,163 @@
+package push
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/request"
+	"github.com/jimvid/sidekick/internal/tracing"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+type PushHandler struct {
+	service   *PushService
+	getUserId func(r *http.Request) (string, error)
+}
+
+func NewPushHandler(service *PushService) *PushHandler {
+	return &PushHandler{
+		service:   service,
+		getUserId: user.GetUserId,
+	}
+}
+
+func (h *PushHandler) writeErrorResponse(w http.ResponseWriter, r *http.Request, p problem.Problem) {
+	problem.Write(w, r, p)
+}
+
+func (h *PushHandler) writeSuccessResponse(w http.ResponseWriter, r *http.Request, statusCode int, data any) {
+	_, span := tracing.Tracer().Start(r.Context(), "json.encode")
+	defer span.End()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+func disabledProblem() problem.Problem {
+	return problem.New(http.StatusServiceUnavailable, problem.CodeUnavailable, "Push notifications are not configured")
+}
+
+func (h *PushHandler) GetPublicKey(w http.ResponseWriter, r *http.Request) {
+	publicKey, err := h.service.PublicKey()
+	if err != nil {
+		h.writeErrorResponse(w, r, disabledProblem())
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, PublicKeyModel{PublicKey: publicKey})
+}
+
+func (h *PushHandler) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	subscriptions, err := h.service.GetSubscriptions(r.Context(), userId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get push subscriptions", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get push subscriptions"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, subscriptions)
+}
+
+func (h *PushHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	var subscriptionReq SubscriptionReq
+	err := request.DecodeJSON(w, r, &subscriptionReq)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := subscriptionReq.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	subscription, created, err := h.service.Subscribe(r.Context(), userId, subscriptionReq, r.UserAgent())
+	if err != nil {
+		if errors.Is(err, ErrUnknownService) {
+			h.writeErrorResponse(w, r, problem.Validation([]problem.InvalidParam{{Name: "endpoint", Reason: "Endpoint must be on a known push service"}}))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to save push subscription", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Could not save push subscription"))
+		return
+	}
+
+	if !created {
+		h.writeSuccessResponse(w, r, http.StatusOK, subscription)
+		return
+	}
+	slog.InfoContext(r.Context(), "Push subscription created", "subscriptionId", subscription.ID)
+	h.writeSuccessResponse(w, r, http.StatusCreated, subscription)
+}
+
+func (h *PushHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	subscriptionId := chi.URLParam(r, "subscriptionId")
+	if subscriptionId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	err = h.service.DeleteSubscription(r.Context(), userId, subscriptionId)
+	if err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find push subscription by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not delete push subscription", "error", err, "subscriptionId", subscriptionId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not delete push subscription"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, map[string]string{"message": "Successfully deleted push subscription"})
+}
+
+// SendTest sends a notification to every device of the user, to check that
+// push works end to end.
+func (h *PushHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	sent, err := h.service.SendToUser(r.Context(), userId, Message{
+		Title: "Sidekick",
+		Body:  "Notifications are working.",
+		Tag:   "test",
+	}, Options{TTL: DefaultTTL})
+	if errors.Is(err, ErrPushDisabled) {
+		h.writeErrorResponse(w, r, disabledProblem())
+		return
+	}
+	if err != nil && sent == 0 {
+		slog.ErrorContext(r.Context(), "Failed to send test push", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Could not send test notification"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, TestResult{Sent: sent})
+}
@@ -0,0 +1,182 @@
+package push
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/problem"
+)
+
+const testUserId = "test-user-1"
+
+func setupHandler(t *testing.T, sender *Sender) *chi.Mux {
+	t.Helper()
+
+	service := NewPushService(setupTestDB(t), sender)
+	handler := &PushHandler{
+		service: service,
+		getUserId: func(r *http.Request) (string, error) {
+			return testUserId, nil
+		},
+	}
+
+	r := chi.NewRouter()
+	r.Get("/push/public-key", handler.GetPublicKey)
+	r.Get("/me/push-subscriptions", handler.GetSubscriptions)
+	r.Post("/me/push-subscriptions", handler.Subscribe)
+	r.Delete("/me/push-subscriptions/{subscriptionId}", handler.DeleteSubscription)
+	r.Post("/me/push-subscriptions/test", handler.SendTest)
+
+	return r
+}
+
+func serve(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Firefox")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlerSubscriptions(t *testing.T) {
+	sender, server := setupSender(t)
+	router := setupHandler(t, sender)
+
+	phone := server.Subscribe("phone")
+	body, _ := json.Marshal(phone)
+
+	var created SubscriptionModel
+	t.Run("subscribe", func(t *testing.T) {
+		w := serve(router, http.MethodPost, "/me/push-subscriptions", string(body))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), phone.Keys.Auth) {
+			t.Error("expected the keys to stay private")
+		}
+		json.NewDecoder(w.Body).Decode(&created)
+		if created.ID == "" || created.Endpoint != phone.Endpoint || created.UserAgent != "Firefox" {
+			t.Errorf("unexpected subscription %+v", created)
+		}
+	})
+
+	t.Run("subscribe again", func(t *testing.T) {
+		w := serve(router, http.MethodPost, "/me/push-subscriptions", string(body))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var again SubscriptionModel
+		json.NewDecoder(w.Body).Decode(&again)
+		if again.ID != created.ID || again.CreatedAt != created.CreatedAt {
+			t.Errorf("expected the same subscription, got %+v", again)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		w := serve(router, http.MethodPost, "/me/push-subscriptions", `{"endpoint":"http://push.example.com","keys":{"p256dh":"abc","auth":"abc"}}`)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", w.Code)
+		}
+		var p problem.Problem
+		json.NewDecoder(w.Body).Decode(&p)
+		if len(p.InvalidParams) != 3 {
+			t.Errorf("expected endpoint and both keys to be invalid, got %+v", p.InvalidParams)
+		}
+	})
+
+	t.Run("unknown push service", func(t *testing.T) {
+		internal := phone
+		internal.Endpoint = "https://169.254.169.254/latest/meta-data"
+		body, _ := json.Marshal(internal)
+		w := serve(router, http.MethodPost, "/me/push-subscriptions", string(body))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", w.Code)
+		}
+		var p problem.Problem
+		json.NewDecoder(w.Body).Decode(&p)
+		if len(p.InvalidParams) != 1 || p.InvalidParams[0].Name != "endpoint" {
+			t.Errorf("expected the endpoint to be invalid, got %+v", p.InvalidParams)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		w := serve(router, http.MethodGet, "/me/push-subscriptions", "")
+		var subscriptions []SubscriptionModel
+		json.NewDecoder(w.Body).Decode(&subscriptions)
+		if w.Code != http.StatusOK || len(subscriptions) != 1 {
+			t.Errorf("expected 1 subscription, got %d %+v", w.Code, subscriptions)
+		}
+	})
+
+	t.Run("test notification", func(t *testing.T) {
+		w := serve(router, http.MethodPost, "/me/push-subscriptions/test", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var result TestResult
+		json.NewDecoder(w.Body).Decode(&result)
+		if result.Sent != 1 {
+			t.Errorf("expected 1 sent, got %+v", result)
+		}
+
+		received := server.Received()
+		if len(received) != 1 {
+			t.Fatalf("expected the device to receive 1 message, got %d", len(received))
+		}
+		var msg Message
+		json.Unmarshal(received[0].Payload, &msg)
+		if msg.Title == "" || msg.Tag != "test" {
+			t.Errorf("unexpected message %+v", msg)
+		}
+	})
+
+	t.Run("gone devices are removed", func(t *testing.T) {
+		server.Unsubscribe("phone")
+		w := serve(router, http.MethodPost, "/me/push-subscriptions/test", "")
+		var result TestResult
+		json.NewDecoder(w.Body).Decode(&result)
+		if w.Code != http.StatusOK || result.Sent != 0 {
+			t.Errorf("expected nothing sent, got %d %+v", w.Code, result)
+		}
+
+		w = serve(router, http.MethodDelete, "/me/push-subscriptions/"+created.ID, "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected the gone subscription to be removed, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandlerPushDisabled(t *testing.T) {
+	router := setupHandler(t, nil)
+
+	tests := []struct {
+		method string
+		target string
+	}{
+		{http.MethodGet, "/push/public-key"},
+		{http.MethodPost, "/me/push-subscriptions/test"},
+	}
+	for _, tt := range tests {
+		w := serve(router, tt.method, tt.target, "")
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected 503, got %d", tt.method, tt.target, w.Code)
+		}
+	}
+}
+
+func TestHandlerPublicKey(t *testing.T) {
+	sender, _ := setupSender(t)
+	router := setupHandler(t, sender)
+
+	w := serve(router, http.MethodGet, "/push/public-key", "")
+	var key PublicKeyModel
+	json.NewDecoder(w.Body).Decode(&key)
+	if w.Code != http.StatusOK || key.PublicKey != sender.PublicKey() {
+		t.Errorf("expected the VAPID public key, got %d %+v", w.Code, key)
+	}
+}
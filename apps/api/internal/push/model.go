@@ -0,0 +1,86 @@
+package push
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"github.com/jimvid/sidekick/internal/problem"
+)
+
+type subscriptionItem struct {
+	UserId string `json:"-" dynamodbav:"userId"`
+	ItemId string `json:"-" dynamodbav:"itemId"`
+	// ExpiresAt lets the table's TTL remove subscriptions the browser said
+	// expire, in unix seconds.
+	ExpiresAt int64 `json:"-" dynamodbav:"ExpiresAt,omitempty"`
+	SubscriptionModel
+}
+
+// SubscriptionModel is a device the user allowed push notifications on.
+type SubscriptionModel struct {
+	// ID is derived from the endpoint, so subscribing a device again updates
+	// it rather than adding another.
+	ID       string `json:"id" dynamodbav:"ID"`
+	Endpoint string `json:"endpoint" dynamodbav:"Endpoint"`
+	// Keys encrypt messages to the device and are never sent back.
+	Keys      SubscriptionKeys `json:"-" dynamodbav:"Keys"`
+	UserAgent string           `json:"userAgent,omitempty" dynamodbav:"UserAgent,omitempty"`
+	CreatedAt int64            `json:"createdAt" dynamodbav:"CreatedAt"`
+	UpdatedAt int64            `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// SubscriptionKeys are the browser's P-256 public key and auth secret,
+// base64url encoded.
+type SubscriptionKeys struct {
+	P256dh string `json:"p256dh" dynamodbav:"P256dh"`
+	Auth   string `json:"auth" dynamodbav:"Auth"`
+}
+
+// SubscriptionReq is the JSON of the browser's PushSubscription.
+type SubscriptionReq struct {
+	Endpoint string `json:"endpoint"`
+	// ExpirationTime is in unix milliseconds, null when it does not expire.
+	ExpirationTime *int64           `json:"expirationTime"`
+	Keys           SubscriptionKeys `json:"keys"`
+}
+
+// Message is what the service worker shows as a notification.
+type Message struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Tag replaces an earlier notification with the same tag on the device.
+	Tag string `json:"tag,omitempty"`
+	// URL is opened when the notification is clicked.
+	URL string `json:"url,omitempty"`
+}
+
+type TestResult struct {
+	Sent int `json:"sent"`
+}
+
+type PublicKeyModel struct {
+	PublicKey string `json:"publicKey"`
+}
+
+// decodeKey accepts base64url with or without padding, browsers leave it out.
+func decodeKey(key string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(key, "="))
+}
+
+func (r SubscriptionReq) validate() []problem.InvalidParam {
+	var params []problem.InvalidParam
+
+	endpoint, err := url.Parse(r.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" || len(r.Endpoint) > 2048 {
+		params = append(params, problem.InvalidParam{Name: "endpoint", Reason: "Endpoint must be an https URL of at most 2048 characters"})
+	}
+	if key, err := decodeKey(r.Keys.P256dh); err != nil || len(key) != 65 || key[0] != 4 {
+		params = append(params, problem.InvalidParam{Name: "keys.p256dh", Reason: "P256dh must be an uncompressed P-256 public key, base64url encoded"})
+	}
+	if secret, err := decodeKey(r.Keys.Auth); err != nil || len(secret) != 16 {
+		params = append(params, problem.InvalidParam{Name: "keys.auth", Reason: "Auth must be a 16 byte secret, base64url encoded"})
+	}
+
+	return params
+}
@@ -0,0 +1,273 @@
+// Package pushtest is a fake Web Push service for tests and local runs. It
+// checks the VAPID token of every request and decrypts the messages it
+// receives, as a browser would.
+package pushtest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Received is a message the server decrypted.
+type Received struct {
+	// Device is the name the subscription was created with.
+	Device  string
+	Payload []byte
+	Header  http.Header
+	// Subject is the sub claim of the VAPID token.
+	Subject string
+}
+
+// Subscription is the JSON of a browser's PushSubscription.
+type Subscription struct {
+	Endpoint       string `json:"endpoint"`
+	ExpirationTime *int64 `json:"expirationTime"`
+	Keys           struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+type device struct {
+	key        *ecdh.PrivateKey
+	authSecret []byte
+	gone       bool
+}
+
+type Server struct {
+	*httptest.Server
+
+	// PublicKey is the VAPID key requests must be signed with, when set.
+	PublicKey string
+
+	mu       sync.Mutex
+	devices  map[string]*device
+	received []Received
+}
+
+// NewServer starts a push service, close it with Close.
+func NewServer() *Server {
+	s := &Server{devices: map[string]*device{}}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Host is the server's host name, for push.Sender.Services.
+func (s *Server) Host() string {
+	return strings.Split(strings.TrimPrefix(s.URL, "https://"), ":")[0]
+}
+
+// Subscribe creates a device and returns its subscription.
+func (s *Server) Subscribe(name string) Subscription {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	s.mu.Lock()
+	s.devices[name] = &device{key: key, authSecret: authSecret}
+	s.mu.Unlock()
+
+	var sub Subscription
+	sub.Endpoint = s.URL + "/push/" + name
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes())
+	sub.Keys.Auth = base64.RawURLEncoding.EncodeToString(authSecret)
+	return sub
+}
+
+// Unsubscribe makes the server answer 410 Gone for the device, as push
+// services do once the user revokes permission.
+func (s *Server) Unsubscribe(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.devices[name]; ok {
+		d.gone = true
+	}
+}
+
+// Received returns the messages delivered so far.
+func (s *Server) Received() []Received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Received(nil), s.received...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutPrefix(r.URL.Path, "/push/")
+	s.mu.Lock()
+	d := s.devices[name]
+	s.mu.Unlock()
+	if r.Method != http.MethodPost || !ok || d == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if d.gone {
+		http.Error(w, "subscription expired", http.StatusGone)
+		return
+	}
+
+	subject, err := s.verifyVAPID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+		http.Error(w, "expected aes128gcm content and a TTL", http.StatusBadRequest)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	payload, err := decrypt(body, d.key, d.authSecret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.received = append(s.received, Received{Device: name, Payload: payload, Header: r.Header.Clone(), Subject: subject})
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+}
+
+// verifyVAPID checks the ES256 token of RFC 8292 and returns its subject.
+func (s *Server) verifyVAPID(r *http.Request) (string, error) {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "vapid ")
+	if !ok {
+		return "", errors.New("missing vapid authorization")
+	}
+	var token, key string
+	for _, part := range strings.Split(auth, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "t":
+			token = value
+		case "k":
+			key = value
+		}
+	}
+	if s.PublicKey != "" && key != s.PublicKey {
+		return "", errors.New("unexpected VAPID key")
+	}
+
+	publicKey, err := base64.RawURLEncoding.DecodeString(key)
+	if err != nil || len(publicKey) != 65 {
+		return "", errors.New("invalid VAPID key")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("invalid VAPID token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		return "", errors.New("invalid VAPID signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	verifier := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(publicKey[1:33]),
+		Y:     new(big.Int).SetBytes(publicKey[33:]),
+	}
+	if !ecdsa.Verify(verifier, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+		return "", errors.New("VAPID signature does not verify")
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.New("invalid VAPID claims")
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return "", errors.New("invalid VAPID claims")
+	}
+	if claims.Aud != s.URL {
+		return "", fmt.Errorf("VAPID audience %q is not %q", claims.Aud, s.URL)
+	}
+	if claims.Exp < time.Now().Unix() || claims.Exp > time.Now().Add(24*time.Hour).Unix() {
+		return "", errors.New("VAPID token expired or valid for over 24 hours")
+	}
+
+	return claims.Sub, nil
+}
+
+// decrypt reverses the aes128gcm encryption of RFC 8291 with the device's
+// key and auth secret.
+func decrypt(body []byte, key *ecdh.PrivateKey, authSecret []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, errors.New("body too short")
+	}
+	salt := body[:16]
+	recordSize := binary.BigEndian.Uint32(body[16:20])
+	keyLength := int(body[20])
+	if len(body) < 21+keyLength || int(recordSize) < len(body)-21-keyLength {
+		return nil, errors.New("invalid aes128gcm header")
+	}
+	asPublicBytes := body[21 : 21+keyLength]
+	ciphertext := body[21+keyLength:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := key.ECDH(asPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), key.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+	ikm := make([]byte, 32)
+	io.ReadFull(hkdf.New(sha256.New, sharedSecret, authSecret, keyInfo), ikm)
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	cek := make([]byte, 16)
+	io.ReadFull(hkdf.Expand(sha256.New, prk, []byte("Content-Encoding: aes128gcm\x00")), cek)
+	nonce := make([]byte, 12)
+	io.ReadFull(hkdf.Expand(sha256.New, prk, []byte("Content-Encoding: nonce\x00")), nonce)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Strip padding up to the last record delimiter
+	end := len(plaintext) - 1
+	for end >= 0 && plaintext[end] == 0 {
+		end--
+	}
+	if end < 0 || plaintext[end] != 2 {
+		return nil, errors.New("missing last record delimiter")
+	}
+	return plaintext[:end], nil
+}
@@ -0,0 +1,156 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTTL is how long the push service keeps a message for a device that
+// is offline. Reminders are stale after a few hours.
+const DefaultTTL = 4 * time.Hour
+
+// ErrSubscriptionGone means the push service no longer knows the
+// subscription, it was unsubscribed or expired and should be removed.
+var ErrSubscriptionGone = errors.New("push subscription is gone")
+
+// ErrUnknownService means the endpoint is not on one of Sender.Services.
+var ErrUnknownService = errors.New("push endpoint is not on a known push service")
+
+// DefaultServices are the hosts of the push services browsers subscribe
+// with. Endpoints are posted to from inside the network, so any other host
+// could point at a service that should not be reachable.
+var DefaultServices = []string{
+	"fcm.googleapis.com",
+	"push.apple.com",
+	"push.services.mozilla.com",
+	"notify.windows.com",
+}
+
+// Options are the headers of RFC 8030 that shape delivery.
+type Options struct {
+	TTL time.Duration
+	// Urgency is very-low, low, normal or high.
+	Urgency string
+	// Topic replaces an undelivered message with the same topic.
+	Topic string
+}
+
+// Sender delivers encrypted messages to push services, signed with the
+// application's VAPID key.
+type Sender struct {
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+	now       func() time.Time
+
+	// Client posts to push services, tests swap it for one trusting a fake.
+	Client *http.Client
+	// Services are the hosts endpoints may be on, each with its subdomains.
+	// Tests add their fake's.
+	Services []string
+}
+
+// NewSender takes the VAPID key pair, base64url encoded, and a mailto: or
+// https: subject push services can reach the operator at.
+func NewSender(publicKey, privateKey, subject string) (*Sender, error) {
+	key, err := parseVAPIDKey(publicKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sender{
+		key:       key,
+		publicKey: publicKey,
+		subject:   subject,
+		now:       time.Now,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+		Services:  DefaultServices,
+	}, nil
+}
+
+// PublicKey is the applicationServerKey browsers subscribe with.
+func (s *Sender) PublicKey() string {
+	return s.publicKey
+}
+
+// Allows reports whether endpoint is an https URL on one of Services.
+func (s *Sender) Allows(endpoint string) bool {
+	return allowsEndpoint(s.Services, endpoint)
+}
+
+func allowsEndpoint(services []string, endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	host := u.Hostname()
+	for _, service := range services {
+		if host == service || strings.HasSuffix(host, "."+service) {
+			return true
+		}
+	}
+	return false
+}
+
+// Send encrypts payload for the subscription and posts it to its endpoint.
+// Endpoints not on Services are refused with ErrUnknownService.
+func (s *Sender) Send(ctx context.Context, subscription SubscriptionModel, payload []byte, opts Options) error {
+	if !s.Allows(subscription.Endpoint) {
+		return ErrUnknownService
+	}
+
+	body, err := encrypt(payload, subscription.Keys)
+	if err != nil {
+		return err
+	}
+
+	token, err := vapidToken(s.key, subscription.Endpoint, s.subject, s.now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	ttl := opts.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	req.Header.Set("Authorization", "vapid t="+token+", k="+s.publicKey)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	if opts.Urgency != "" {
+		req.Header.Set("Urgency", opts.Urgency)
+	}
+	if opts.Topic != "" {
+		req.Header.Set("Topic", opts.Topic)
+	}
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		return nil
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	default:
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("push service responded %s: %s", res.Status, bytes.TrimSpace(detail))
+	}
+}
@@ -0,0 +1,147 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/push/pushtest"
+)
+
+const testSubject = "mailto:test@sidekick.example"
+
+func setupSender(t *testing.T) (*Sender, *pushtest.Server) {
+	t.Helper()
+
+	publicKey, privateKey, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatalf("GenerateVAPIDKeys failed: %v", err)
+	}
+	sender, err := NewSender(publicKey, privateKey, testSubject)
+	if err != nil {
+		t.Fatalf("NewSender failed: %v", err)
+	}
+
+	server := pushtest.NewServer()
+	server.PublicKey = publicKey
+	t.Cleanup(server.Close)
+	sender.Client = server.Client()
+	sender.Services = append(sender.Services, server.Host())
+
+	return sender, server
+}
+
+// subscriptionReq converts a fake device's subscription to a request, as
+// the browser's JSON would be decoded.
+func subscriptionReq(t *testing.T, sub pushtest.Subscription) SubscriptionReq {
+	t.Helper()
+
+	data, _ := json.Marshal(sub)
+	var req SubscriptionReq
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("failed to decode subscription: %v", err)
+	}
+	return req
+}
+
+func subscriptionModel(t *testing.T, sub pushtest.Subscription) SubscriptionModel {
+	t.Helper()
+
+	req := subscriptionReq(t, sub)
+	return SubscriptionModel{ID: subscriptionId(req.Endpoint), Endpoint: req.Endpoint, Keys: req.Keys}
+}
+
+func TestSenderSend(t *testing.T) {
+	sender, server := setupSender(t)
+	ctx := context.Background()
+	phone := subscriptionModel(t, server.Subscribe("phone"))
+
+	err := sender.Send(ctx, phone, []byte(`{"title":"Read"}`), Options{TTL: time.Hour, Urgency: "high", Topic: "reminder"})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	received := server.Received()
+	if len(received) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(received))
+	}
+	got := received[0]
+	if string(got.Payload) != `{"title":"Read"}` {
+		t.Errorf("unexpected payload %q", got.Payload)
+	}
+	if got.Subject != testSubject {
+		t.Errorf("expected subject %q, got %q", testSubject, got.Subject)
+	}
+	if got.Header.Get("TTL") != "3600" || got.Header.Get("Urgency") != "high" || got.Header.Get("Topic") != "reminder" {
+		t.Errorf("unexpected headers %v", got.Header)
+	}
+
+	t.Run("largest payload", func(t *testing.T) {
+		err := sender.Send(ctx, phone, []byte(strings.Repeat("a", MaxPayload)), Options{})
+		if err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if last := server.Received()[len(server.Received())-1]; len(last.Payload) != MaxPayload {
+			t.Errorf("expected %d bytes, got %d", MaxPayload, len(last.Payload))
+		}
+	})
+
+	t.Run("payload too large", func(t *testing.T) {
+		err := sender.Send(ctx, phone, make([]byte, MaxPayload+1), Options{})
+		if !errors.Is(err, ErrPayloadTooLarge) {
+			t.Errorf("expected ErrPayloadTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("gone", func(t *testing.T) {
+		server.Unsubscribe("phone")
+		err := sender.Send(ctx, phone, []byte("{}"), Options{})
+		if !errors.Is(err, ErrSubscriptionGone) {
+			t.Errorf("expected ErrSubscriptionGone, got %v", err)
+		}
+	})
+}
+
+func TestSenderAllows(t *testing.T) {
+	sender := &Sender{Services: DefaultServices}
+
+	tests := []struct {
+		endpoint string
+		allowed  bool
+	}{
+		{"https://fcm.googleapis.com/fcm/send/abc", true},
+		{"https://web.push.apple.com/abc", true},
+		{"https://updates.push.services.mozilla.com/wpush/v2/abc", true},
+		{"https://wns2-db5p.notify.windows.com/w/?token=abc", true},
+		{"http://fcm.googleapis.com/fcm/send/abc", false},
+		{"https://fcm.googleapis.com.example.com/abc", false},
+		{"https://evilpush.apple.com.example.com/abc", false},
+		{"https://localhost/abc", false},
+		{"https://10.0.0.1/abc", false},
+	}
+	for _, tt := range tests {
+		if got := sender.Allows(tt.endpoint); got != tt.allowed {
+			t.Errorf("%s: expected allowed %v, got %v", tt.endpoint, tt.allowed, got)
+		}
+	}
+
+	err := sender.Send(context.Background(), SubscriptionModel{Endpoint: "https://10.0.0.1/abc"}, nil, Options{})
+	if !errors.Is(err, ErrUnknownService) {
+		t.Errorf("expected ErrUnknownService, got %v", err)
+	}
+}
+
+func TestNewSenderRejectsMismatchedKeys(t *testing.T) {
+	publicKey, _, _ := GenerateVAPIDKeys()
+	_, privateKey, _ := GenerateVAPIDKeys()
+
+	if _, err := NewSender(publicKey, privateKey, testSubject); err == nil {
+		t.Error("expected an error for keys of different pairs")
+	}
+	if _, err := NewSender(publicKey, "not a key", testSubject); err == nil {
+		t.Error("expected an error for a malformed private key")
+	}
+}
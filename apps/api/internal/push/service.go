@@ -0,0 +1,152 @@
+package push
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/tracing"
+)
+
+// ErrPushDisabled means no VAPID key is configured, so nothing can be sent.
+var ErrPushDisabled = errors.New("push notifications are not configured")
+
+type PushService struct {
+	storage *PushStorage
+	// sender is nil when push is disabled.
+	sender *Sender
+	now    func() time.Time
+}
+
+func NewPushService(storage *PushStorage, sender *Sender) *PushService {
+	return &PushService{
+		storage: storage,
+		sender:  sender,
+		now:     time.Now,
+	}
+}
+
+// subscriptionId is stable for an endpoint, each endpoint is one device.
+func subscriptionId(endpoint string) string {
+	sum := sha256.Sum256([]byte(endpoint))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (s *PushService) PublicKey() (string, error) {
+	if s.sender == nil {
+		return "", ErrPushDisabled
+	}
+	return s.sender.PublicKey(), nil
+}
+
+// allows checks endpoint against the sender's services, or the default ones
+// while push is disabled.
+func (s *PushService) allows(endpoint string) bool {
+	if s.sender == nil {
+		return allowsEndpoint(DefaultServices, endpoint)
+	}
+	return s.sender.Allows(endpoint)
+}
+
+// Subscribe saves the device, or updates it when it subscribed before. It
+// reports whether the subscription is new.
+func (s *PushService) Subscribe(ctx context.Context, userId string, req SubscriptionReq, userAgent string) (SubscriptionModel, bool, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PushService.Subscribe")
+	defer span.End()
+
+	if !s.allows(req.Endpoint) {
+		return SubscriptionModel{}, false, ErrUnknownService
+	}
+
+	now := s.now().Unix()
+	subscription := SubscriptionModel{
+		ID:        subscriptionId(req.Endpoint),
+		Endpoint:  req.Endpoint,
+		Keys:      req.Keys,
+		UserAgent: userAgent,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	existing, err := s.storage.FindSubscriptionById(ctx, userId, subscription.ID)
+	created := errors.Is(err, ErrSubscriptionNotFound)
+	if err != nil && !created {
+		return SubscriptionModel{}, false, err
+	}
+	if !created {
+		subscription.CreatedAt = existing.CreatedAt
+	}
+
+	var expiresAt int64
+	if req.ExpirationTime != nil {
+		expiresAt = *req.ExpirationTime / 1000
+	}
+
+	err = s.storage.PutSubscription(ctx, userId, subscription, expiresAt)
+	if err != nil {
+		return SubscriptionModel{}, false, err
+	}
+
+	return subscription, created, nil
+}
+
+func (s *PushService) GetSubscriptions(ctx context.Context, userId string) ([]SubscriptionModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PushService.GetSubscriptions")
+	defer span.End()
+
+	return s.storage.GetSubscriptions(ctx, userId)
+}
+
+func (s *PushService) DeleteSubscription(ctx context.Context, userId, subscriptionId string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "PushService.DeleteSubscription")
+	defer span.End()
+
+	return s.storage.DeleteSubscription(ctx, userId, subscriptionId)
+}
+
+// SendToUser sends msg to every device of the user and returns how many
+// accepted it. Subscriptions the push service has forgotten are removed.
+func (s *PushService) SendToUser(ctx context.Context, userId string, msg Message, opts Options) (int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PushService.SendToUser")
+	defer span.End()
+
+	if s.sender == nil {
+		return 0, ErrPushDisabled
+	}
+
+	subscriptions, err := s.storage.GetSubscriptions(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, subscription := range subscriptions {
+		err := s.sender.Send(ctx, subscription, payload, opts)
+		// Subscriptions saved before endpoints were checked are not kept either
+		if errors.Is(err, ErrSubscriptionGone) || errors.Is(err, ErrUnknownService) {
+			slog.InfoContext(ctx, "Removing gone push subscription", "subscriptionId", subscription.ID, "error", err)
+			if err := s.storage.DeleteSubscription(ctx, userId, subscription.ID); err != nil && !errors.Is(err, ErrSubscriptionNotFound) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Push failed", "error", err, "subscriptionId", subscription.ID)
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
@@ -0,0 +1,143 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/config"
+)
+
+const itemPrefixSubscription = "push-subscription#"
+
+var ErrSubscriptionNotFound = errors.New("could not find a push subscription with that ID")
+
+type PushStorage struct {
+	db  *dynamodb.Client
+	cfg *config.Config
+}
+
+func NewPushStorage(db *dynamodb.Client, cfg *config.Config) *PushStorage {
+	return &PushStorage{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// PutSubscription saves a subscription, expiresAt is in unix seconds or 0.
+func (s *PushStorage) PutSubscription(ctx context.Context, userId string, subscription SubscriptionModel, expiresAt int64) error {
+	item := subscriptionItem{
+		UserId:            userId,
+		ItemId:            itemPrefixSubscription + subscription.ID,
+		ExpiresAt:         expiresAt,
+		SubscriptionModel: subscription,
+	}
+
+	attributeValue, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal push subscription", "error", err)
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      attributeValue,
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "subscriptionId", subscription.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (s *PushStorage) GetSubscriptions(ctx context.Context, userId string) ([]SubscriptionModel, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixSubscription},
+		},
+	}
+
+	result, err := s.db.Query(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err)
+		return nil, err
+	}
+
+	var items []subscriptionItem
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal push subscriptions", "error", err)
+		return nil, err
+	}
+
+	subscriptions := make([]SubscriptionModel, len(items))
+	for i, item := range items {
+		subscriptions[i] = item.SubscriptionModel
+	}
+
+	return subscriptions, nil
+}
+
+func (s *PushStorage) FindSubscriptionById(ctx context.Context, userId, subscriptionId string) (SubscriptionModel, error) {
+	var item subscriptionItem
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixSubscription + subscriptionId},
+		},
+	}
+
+	result, err := s.db.GetItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err, "subscriptionId", subscriptionId)
+		return SubscriptionModel{}, err
+	}
+
+	if result.Item == nil {
+		return SubscriptionModel{}, ErrSubscriptionNotFound
+	}
+
+	err = attributevalue.UnmarshalMap(result.Item, &item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal push subscription", "error", err)
+		return SubscriptionModel{}, err
+	}
+
+	return item.SubscriptionModel, nil
+}
+
+func (s *PushStorage) DeleteSubscription(ctx context.Context, userId, subscriptionId string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixSubscription + subscriptionId},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	}
+
+	result, err := s.db.DeleteItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB DeleteItem failed", "error", err, "subscriptionId", subscriptionId)
+		return err
+	}
+
+	if result.Attributes == nil {
+		return ErrSubscriptionNotFound
+	}
+
+	slog.InfoContext(ctx, "Push subscription deleted", "subscriptionId", subscriptionId)
+	return nil
+}
@@ -0,0 +1,58 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-push"
+
+func setupTestDB(t *testing.T) *PushStorage {
+	t.Helper()
+
+	return NewPushStorage(dynamotest.NewTable(t, testTableName))
+}
+
+func TestStorageSubscriptions(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	phone := SubscriptionModel{
+		ID:        "phone",
+		Endpoint:  "https://push.example.com/phone",
+		Keys:      SubscriptionKeys{P256dh: "key", Auth: "secret"},
+		UserAgent: "Firefox",
+		CreatedAt: 1,
+		UpdatedAt: 1,
+	}
+	if err := storage.PutSubscription(ctx, "user-1", phone, 0); err != nil {
+		t.Fatalf("PutSubscription failed: %v", err)
+	}
+
+	subscriptions, err := storage.GetSubscriptions(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetSubscriptions failed: %v", err)
+	}
+	if !reflect.DeepEqual(subscriptions, []SubscriptionModel{phone}) {
+		t.Errorf("expected %+v, got %+v", phone, subscriptions)
+	}
+
+	others, _ := storage.GetSubscriptions(ctx, "user-2")
+	if len(others) != 0 {
+		t.Errorf("expected no subscriptions for another user, got %+v", others)
+	}
+
+	if err := storage.DeleteSubscription(ctx, "user-1", "phone"); err != nil {
+		t.Fatalf("DeleteSubscription failed: %v", err)
+	}
+	if _, err := storage.FindSubscriptionById(ctx, "user-1", "phone"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+	if err := storage.DeleteSubscription(ctx, "user-1", "phone"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound deleting twice, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	itemPrefix  = "ratelimit#"
+	maxAttempts = 3
+)
+
+// ErrContention is returned when a bucket kept changing underneath us.
+var ErrContention = errors.New("rate limit bucket is being updated concurrently")
+
+type bucketItem struct {
+	UserId    string  `dynamodbav:"userId"`
+	ItemId    string  `dynamodbav:"itemId"`
+	Tokens    float64 `dynamodbav:"Tokens"`
+	UpdatedAt int64   `dynamodbav:"UpdatedAt"` // Unix milliseconds
+	ExpiresAt int64   `dynamodbav:"ExpiresAt"` // DynamoDB TTL, Unix seconds
+}
+
+// DynamoStore keeps buckets in the table so every Lambda instance shares
+// them. A user's buckets live in their own partition; buckets for anonymous
+// clients are keyed by IP.
+type DynamoStore struct {
+	db    *dynamodb.Client
+	table string
+}
+
+func NewDynamoStore(db *dynamodb.Client, table string) *DynamoStore {
+	return &DynamoStore{
+		db:    db,
+		table: table,
+	}
+}
+
+func (s *DynamoStore) Take(ctx context.Context, subject, route string, limit Limit, now time.Time) (Result, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		result, err := s.take(ctx, subject, route, limit, now)
+		if !errors.Is(err, ErrContention) {
+			return result, err
+		}
+	}
+	return Result{}, ErrContention
+}
+
+func (s *DynamoStore) take(ctx context.Context, subject, route string, limit Limit, now time.Time) (Result, error) {
+	key := map[string]types.AttributeValue{
+		"userId": &types.AttributeValueMemberS{Value: subject},
+		"itemId": &types.AttributeValueMemberS{Value: itemPrefix + route},
+	}
+
+	existing, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err, "table", s.table)
+		return Result{}, err
+	}
+
+	b := fullBucket(limit, now)
+	var item bucketItem
+	if existing.Item != nil {
+		err = attributevalue.UnmarshalMap(existing.Item, &item)
+		if err != nil {
+			return Result{}, err
+		}
+		b = bucket{Tokens: item.Tokens, UpdatedAt: time.UnixMilli(item.UpdatedAt)}
+	}
+
+	result := b.take(limit, now)
+	if !result.Allowed {
+		// Nothing was taken, so there is nothing worth writing
+		return result, nil
+	}
+
+	av, err := attributevalue.MarshalMap(bucketItem{
+		UserId:    subject,
+		ItemId:    itemPrefix + route,
+		Tokens:    b.Tokens,
+		UpdatedAt: b.UpdatedAt.UnixMilli(),
+		ExpiresAt: now.Add(result.Reset).Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      av,
+	}
+	if existing.Item == nil {
+		input.ConditionExpression = aws.String("attribute_not_exists(itemId)")
+	} else {
+		// Tokens is compared too since two requests in the same millisecond
+		// leave UpdatedAt unchanged
+		input.ConditionExpression = aws.String("UpdatedAt = :updatedAt AND Tokens = :tokens")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":updatedAt": existing.Item["UpdatedAt"],
+			":tokens":    existing.Item["Tokens"],
+		}
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return Result{}, ErrContention
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "table", s.table)
+		return Result{}, err
+	}
+
+	return result, nil
+}
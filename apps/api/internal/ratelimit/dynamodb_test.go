@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-ratelimit"
+
+func setupTestDB(t *testing.T) *dynamodb.Client {
+	t.Helper()
+
+	db, _ := dynamotest.NewTable(t, testTableName)
+	return db
+}
+
+func TestDynamoStore(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewDynamoStore(db, testTableName)
+	limit := Limit{Requests: 3, Period: time.Minute}
+	now := time.Unix(1700000000, 0)
+	ctx := context.Background()
+
+	t.Run("shares a bucket across calls", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			result, err := store.Take(ctx, "user-1", "POST /habit-logs", limit, now)
+			if err != nil {
+				t.Fatalf("Take failed: %v", err)
+			}
+			if !result.Allowed || result.Remaining != 2-i {
+				t.Fatalf("expected request %d to be allowed, got %+v", i+1, result)
+			}
+		}
+
+		result, err := store.Take(ctx, "user-1", "POST /habit-logs", limit, now)
+		if err != nil {
+			t.Fatalf("Take failed: %v", err)
+		}
+		if result.Allowed || result.RetryAfter != 20*time.Second {
+			t.Errorf("expected denial with 20s retry, got %+v", result)
+		}
+
+		result, err = store.Take(ctx, "user-1", "POST /habit-logs", limit, now.Add(20*time.Second))
+		if err != nil || !result.Allowed {
+			t.Errorf("expected refilled token to be allowed, got %+v %v", result, err)
+		}
+	})
+
+	t.Run("stores the bucket in the user's partition", func(t *testing.T) {
+		out, err := db.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String(testTableName),
+			Key: map[string]types.AttributeValue{
+				"userId": &types.AttributeValueMemberS{Value: "user-1"},
+				"itemId": &types.AttributeValueMemberS{Value: "ratelimit#POST /habit-logs"},
+			},
+		})
+		if err != nil || out.Item == nil {
+			t.Fatalf("expected bucket item, got %v", err)
+		}
+		if _, ok := out.Item["ExpiresAt"]; !ok {
+			t.Error("expected bucket to carry a TTL")
+		}
+	})
+
+	t.Run("concurrent takes never overdraw", func(t *testing.T) {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		allowed := 0
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := store.Take(ctx, "user-2", "*", limit, now)
+				if err == nil && result.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if allowed > limit.Requests {
+			t.Errorf("expected at most %d allowed requests, got %d", limit.Requests, allowed)
+		}
+	})
+}
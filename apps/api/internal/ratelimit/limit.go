@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRoute is the Limits key used for routes without a limit of their own.
+const DefaultRoute = "*"
+
+// Limit allows Requests per Period, refilled continuously. Requests is also
+// the bucket size, so a client can burst up to it after being idle.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d;w=%d", l.Requests, int(l.Period.Seconds()))
+}
+
+// Limits maps "METHOD /route/{pattern}" keys, or DefaultRoute, to a limit.
+type Limits map[string]Limit
+
+// For returns the bucket name and limit for a route, and false when the
+// route is not limited.
+func (l Limits) For(method, pattern string) (string, Limit, bool) {
+	route := method + " " + pattern
+	if limit, ok := l[route]; ok {
+		return route, limit, true
+	}
+	if limit, ok := l[DefaultRoute]; ok {
+		return DefaultRoute, limit, true
+	}
+	return "", Limit{}, false
+}
+
+var periods = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseLimits parses entries such as "POST /habit-logs=30/m" or "*=120/m".
+func ParseLimits(entries []string) (Limits, error) {
+	limits := Limits{}
+
+	for _, entry := range entries {
+		route, value, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || route == "" {
+			return nil, fmt.Errorf("rate limit %q must look like \"POST /habit-logs=30/m\"", entry)
+		}
+		if route != DefaultRoute {
+			method, path, ok := strings.Cut(route, " ")
+			if !ok || method != strings.ToUpper(method) || !strings.HasPrefix(path, "/") {
+				return nil, fmt.Errorf("rate limit route %q must be * or a method and path like \"POST /habit-logs\"", route)
+			}
+		}
+
+		count, unit, ok := strings.Cut(strings.TrimSpace(value), "/")
+		requests, err := strconv.Atoi(count)
+		period, known := periods[unit]
+		if !ok || err != nil || requests < 1 || !known {
+			return nil, fmt.Errorf("rate limit %q must be a positive count per s, m or h like \"30/m\"", value)
+		}
+
+		limits[route] = Limit{Requests: requests, Period: period}
+	}
+
+	return limits, nil
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token, set when not allowed.
+	RetryAfter time.Duration
+}
+
+// bucket is a token bucket as stored by every Store.
+type bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+func fullBucket(limit Limit, now time.Time) bucket {
+	return bucket{Tokens: float64(limit.Requests), UpdatedAt: now}
+}
+
+// take refills the bucket for the time since it was last updated and removes
+// one token if there is one.
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	capacity := float64(limit.Requests)
+	rate := limit.rate()
+
+	elapsed := now.Sub(b.UpdatedAt).Seconds()
+	if elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed*rate)
+		b.UpdatedAt = now
+	}
+
+	result := Result{}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.Tokens) / rate)
+	}
+
+	result.Remaining = int(b.Tokens)
+	result.Reset = seconds((capacity - b.Tokens) / rate)
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
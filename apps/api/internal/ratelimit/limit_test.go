@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits([]string{"*=120/m", "POST /habit-logs=30/m", " GET /habits = 5/s "})
+	if err != nil {
+		t.Fatalf("ParseLimits failed: %v", err)
+	}
+
+	route, limit, ok := limits.For("POST", "/habit-logs")
+	if !ok || route != "POST /habit-logs" || limit != (Limit{Requests: 30, Period: time.Minute}) {
+		t.Errorf("unexpected limit for POST /habit-logs: %q %+v", route, limit)
+	}
+
+	route, limit, ok = limits.For("GET", "/habits")
+	if !ok || route != "GET /habits" || limit != (Limit{Requests: 5, Period: time.Second}) {
+		t.Errorf("unexpected limit for GET /habits: %q %+v", route, limit)
+	}
+
+	route, _, ok = limits.For("DELETE", "/me")
+	if !ok || route != DefaultRoute {
+		t.Errorf("expected default limit for DELETE /me, got %q", route)
+	}
+
+	for _, invalid := range []string{"POST /habit-logs", "post /habit-logs=1/m", "POST=1/m", "*=0/m", "*=10/d", "*=ten/m"} {
+		_, err := ParseLimits([]string{invalid})
+		if err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestBucketTake(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Minute}
+	now := time.Unix(1700000000, 0)
+	b := fullBucket(limit, now)
+
+	for i := 0; i < 2; i++ {
+		result := b.take(limit, now)
+		if !result.Allowed {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	result := b.take(limit, now)
+	if result.Allowed || result.Remaining != 0 {
+		t.Fatalf("expected empty bucket to deny, got %+v", result)
+	}
+	if result.RetryAfter != 30*time.Second || result.Reset != time.Minute {
+		t.Errorf("expected retry after 30s and reset after 1m, got %+v", result)
+	}
+
+	// One token refills every 30 seconds
+	result = b.take(limit, now.Add(30*time.Second))
+	if !result.Allowed || result.Remaining != 0 {
+		t.Errorf("expected refilled token to be allowed, got %+v", result)
+	}
+
+	// Idle time never overfills the bucket
+	result = b.take(limit, now.Add(time.Hour))
+	if !result.Allowed || result.Remaining != 1 {
+		t.Errorf("expected bucket capped at its size, got %+v", result)
+	}
+}
@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps a token bucket per key.
+type Store interface {
+	Take(ctx context.Context, subject, route string, limit Limit, now time.Time) (Result, error)
+}
+
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. It suits a single long-running
+// server, not Lambda where every instance would have its own buckets.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, subject, route string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	key := subject + "|" + route
+	b, ok := s.buckets[key]
+	if !ok {
+		full := fullBucket(limit, now)
+		b = &full
+		s.buckets[key] = b
+	}
+
+	return b.take(limit, now), nil
+}
+
+// sweep drops buckets idle long enough to have refilled completely, since
+// they are indistinguishable from new ones.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.UpdatedAt) > time.Hour {
+			delete(s.buckets, key)
+		}
+	}
+}
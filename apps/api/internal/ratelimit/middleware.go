@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/problem"
+)
+
+type Limiter struct {
+	store  Store
+	limits Limits
+	now    func() time.Time
+}
+
+func NewLimiter(store Store, limits Limits) *Limiter {
+	return &Limiter{
+		store:  store,
+		limits: limits,
+		now:    time.Now,
+	}
+}
+
+func (l *Limiter) writeErrorResponse(w http.ResponseWriter, r *http.Request, p problem.Problem) {
+	problem.Write(w, r, p)
+}
+
+// Middleware limits requests per user, or per IP for unauthenticated
+// requests. It needs the chi route pattern and, on signed in routes, the
+// session claims, so it must be added with r.With, after AuthMiddleware where
+// there is one, rather than r.Use.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pattern := ""
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			pattern = rctx.RoutePattern()
+		}
+
+		route, limit, ok := l.limits.For(r.Method, pattern)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := l.store.Take(r.Context(), subject(r), route, limit, l.now())
+		if err != nil {
+			// Fail open, an unavailable limiter should not take the API down
+			slog.WarnContext(r.Context(), "Rate limit check failed", "error", err, "route", route)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", limit.String())
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+
+		if !result.Allowed {
+			slog.InfoContext(r.Context(), "Rate limit exceeded", "route", route)
+			w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+			l.writeErrorResponse(w, r, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited, "Rate limit exceeded, retry after "+ceilSeconds(result.RetryAfter)+" seconds"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// subject identifies who a bucket belongs to.
+func subject(r *http.Request) string {
+	if claims, ok := clerk.SessionClaimsFromContext(r.Context()); ok {
+		return claims.Subject
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return "ip#" + ip
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, subject, route string, limit Limit, now time.Time) (Result, error) {
+	return Result{}, errors.New("unavailable")
+}
+
+func newTestRouter(limiter *Limiter) *chi.Mux {
+	r := chi.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r.With(limiter.Middleware).Post("/habit-logs", ok)
+	r.With(limiter.Middleware).Get("/habits", ok)
+	return r
+}
+
+func serve(r http.Handler, method, path, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLimiterMiddleware(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), Limits{
+		DefaultRoute:       {Requests: 10, Period: time.Minute},
+		"POST /habit-logs": {Requests: 2, Period: time.Minute},
+	})
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+	r := newTestRouter(limiter)
+
+	t.Run("sets headers", func(t *testing.T) {
+		w := serve(r, http.MethodPost, "/habit-logs", "10.0.0.1:1234")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "1" || w.Header().Get("RateLimit-Reset") != "30" {
+			t.Errorf("unexpected headers %v", w.Header())
+		}
+		if w.Header().Get("RateLimit-Policy") != "2;w=60" {
+			t.Errorf("unexpected policy %q", w.Header().Get("RateLimit-Policy"))
+		}
+	})
+
+	t.Run("rejects when exhausted", func(t *testing.T) {
+		serve(r, http.MethodPost, "/habit-logs", "10.0.0.1:1234")
+		w := serve(r, http.MethodPost, "/habit-logs", "10.0.0.1:1234")
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+		if w.Header().Get("Retry-After") != "30" {
+			t.Errorf("expected Retry-After 30, got %q", w.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("routes have separate buckets", func(t *testing.T) {
+		w := serve(r, http.MethodGet, "/habits", "10.0.0.1:1234")
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "10" {
+			t.Errorf("expected default limit for GET /habits, got %d %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("clients have separate buckets", func(t *testing.T) {
+		w := serve(r, http.MethodPost, "/habit-logs", "10.0.0.2:1234")
+		if w.Code != http.StatusOK {
+			t.Errorf("expected another IP to be allowed, got %d", w.Code)
+		}
+	})
+}
+
+func TestLimiterFailsOpen(t *testing.T) {
+	limiter := NewLimiter(failingStore{}, Limits{DefaultRoute: {Requests: 1, Period: time.Minute}})
+	r := newTestRouter(limiter)
+
+	w := serve(r, http.MethodGet, "/habits", "10.0.0.1:1234")
+	if w.Code != http.StatusOK {
+		t.Errorf("expected request to be allowed when the store fails, got %d", w.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package reminders
+
+import (
+	"slices"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+// DefaultWindow is how late a reminder may still fire, so a run that was
+// skipped or delayed catches up without reminding hours afterwards.
+const DefaultWindow = 2 * time.Hour
+
+const (
+	// MinStreak is how long a streak must be before a reminder warns that
+	// it is about to end.
+	MinStreak = 2
+	// StreakLookback is how many days of logs streaks are counted from.
+	StreakLookback = 366
+)
+
+// Evaluator decides which reminders are due.
+type Evaluator struct {
+	Window time.Duration
+}
+
+func NewEvaluator() *Evaluator {
+	return &Evaluator{Window: DefaultWindow}
+}
+
+// Due returns the reminders that should fire at now for a user with
+// settings. A reminder is due on its days, from its time until Window has
+// passed, unless it is paused, already fired today or its habit is in done,
+// the habits logged today.
+func (e *Evaluator) Due(now time.Time, settings user.SettingsModel, reminders []ReminderModel, done map[string]bool) []ReminderModel {
+	local := now.In(settings.Location())
+	today := local.Format(time.DateOnly)
+	weekday := local.Weekday()
+
+	var due []ReminderModel
+	for _, reminder := range reminders {
+		if reminder.Paused || reminder.LastSentOn == today || done[reminder.HabitId] {
+			continue
+		}
+		if !onDay(reminder.Days, weekday) {
+			continue
+		}
+
+		at, err := time.Parse("15:04", reminder.Time)
+		if err != nil {
+			continue
+		}
+		// Built from the date so days that skip or repeat an hour for
+		// daylight saving still land on a real instant
+		fireAt := time.Date(local.Year(), local.Month(), local.Day(), at.Hour(), at.Minute(), 0, 0, local.Location())
+		if local.Before(fireAt) || local.Sub(fireAt) >= e.Window {
+			continue
+		}
+
+		due = append(due, reminder)
+	}
+
+	return due
+}
+
+// Streaks returns how many days in a row each habit in logs was done,
+// counting back from the day before today. Habits without a streak are left
+// out. Today does not count, a reminder only fires while its habit is not
+// done, so that is when the streak is about to end.
+func Streaks(logs []habits.HabitLogModel, today string) map[string]int {
+	day, err := time.Parse(time.DateOnly, today)
+	if err != nil {
+		return nil
+	}
+
+	logged := map[string]map[string]bool{}
+	for _, log := range logs {
+		if logged[log.HabitId] == nil {
+			logged[log.HabitId] = map[string]bool{}
+		}
+		logged[log.HabitId][log.Date] = true
+	}
+
+	streaks := map[string]int{}
+	for habitId, dates := range logged {
+		streak := 0
+		for d := day.AddDate(0, 0, -1); dates[d.Format(time.DateOnly)]; d = d.AddDate(0, 0, -1) {
+			streak++
+		}
+		if streak > 0 {
+			streaks[habitId] = streak
+		}
+	}
+
+	return streaks
+}
+
+func onDay(days []string, weekday time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(days, func(day string) bool {
+		d, ok := user.ParseWeekday(day)
+		return ok && d == weekday
+	})
+}
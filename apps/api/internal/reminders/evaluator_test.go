@@ -0,0 +1,81 @@
+package reminders
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+func TestEvaluatorDue(t *testing.T) {
+	stockholm := user.SettingsModel{TimeZone: "Europe/Stockholm", WeekStart: "monday", Locale: "sv-SE"}
+	// Monday 2026-03-02 20:30 in Stockholm
+	now := time.Date(2026, 3, 2, 19, 30, 0, 0, time.UTC)
+
+	paused := makeReminder("paused", "habit-1", "20:00")
+	paused.Paused = true
+	sentToday := makeReminder("sent", "habit-1", "20:00")
+	sentToday.LastSentOn = "2026-03-02"
+	sentYesterday := makeReminder("sent-yesterday", "habit-1", "20:00")
+	sentYesterday.LastSentOn = "2026-03-01"
+	weekdays := makeReminder("weekdays", "habit-1", "20:00")
+	weekdays.Days = []string{"monday", "tuesday"}
+	weekend := makeReminder("weekend", "habit-1", "20:00")
+	weekend.Days = []string{"saturday", "sunday"}
+
+	tests := []struct {
+		name     string
+		reminder ReminderModel
+		done     map[string]bool
+		due      bool
+	}{
+		{"at its time", makeReminder("r", "habit-1", "20:30"), nil, true},
+		{"since its time", makeReminder("r", "habit-1", "19:00"), nil, true},
+		{"before its time", makeReminder("r", "habit-1", "21:00"), nil, false},
+		{"past the window", makeReminder("r", "habit-1", "18:30"), nil, false},
+		{"habit done", makeReminder("r", "habit-1", "20:00"), map[string]bool{"habit-1": true}, false},
+		{"other habit done", makeReminder("r", "habit-1", "20:00"), map[string]bool{"habit-2": true}, true},
+		{"paused", paused, nil, false},
+		{"sent today", sentToday, nil, false},
+		{"sent yesterday", sentYesterday, nil, true},
+		{"on its days", weekdays, nil, true},
+		{"not on its days", weekend, nil, false},
+	}
+
+	evaluator := NewEvaluator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due := evaluator.Due(now, stockholm, []ReminderModel{tt.reminder}, tt.done)
+			if got := len(due) == 1; got != tt.due {
+				t.Errorf("expected due %v, got %v", tt.due, got)
+			}
+		})
+	}
+
+	t.Run("uses the user's time zone", func(t *testing.T) {
+		reminder := makeReminder("r", "habit-1", "20:00")
+		if due := evaluator.Due(now, user.DefaultSettings(), []ReminderModel{reminder}, nil); len(due) != 0 {
+			t.Errorf("expected 19:30 UTC to be before 20:00, got %+v", due)
+		}
+	})
+}
+
+func TestStreaks(t *testing.T) {
+	logs := []habits.HabitLogModel{
+		{HabitId: "read", Date: "2026-03-01"},
+		{HabitId: "read", Date: "2026-02-28"},
+		{HabitId: "read", Date: "2026-02-26"},
+		{HabitId: "run", Date: "2026-03-02"},
+		{HabitId: "run", Date: "2026-03-01"},
+		{HabitId: "walk", Date: "2026-02-28"},
+	}
+
+	got := Streaks(logs, "2026-03-02")
+	// Today does not count and a missed day ends the streak
+	want := map[string]int{"read": 2, "run": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
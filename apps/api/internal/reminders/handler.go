@@ -0,0 +1,194 @@
+package reminders
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/request"
+	"github.com/jimvid/sidekick/internal/tracing"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+type ReminderHandler struct {
+	service   *ReminderService
+	getUserId func(r *http.Request) (string, error)
+}
+
+func NewReminderHandler(service *ReminderService) *ReminderHandler {
+	return &ReminderHandler{
+		service:   service,
+		getUserId: user.GetUserId,
+	}
+}
+
+func (h *ReminderHandler) writeErrorResponse(w http.ResponseWriter, r *http.Request, p problem.Problem) {
+	problem.Write(w, r, p)
+}
+
+func (h *ReminderHandler) writeSuccessResponse(w http.ResponseWriter, r *http.Request, statusCode int, data any) {
+	_, span := tracing.Tracer().Start(r.Context(), "json.encode")
+	defer span.End()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *ReminderHandler) GetAllReminders(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	reminders, err := h.service.GetReminders(r.Context(), userId, "")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get reminders", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get reminders"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, reminders)
+}
+
+func (h *ReminderHandler) GetHabitReminders(w http.ResponseWriter, r *http.Request) {
+	habitId := chi.URLParam(r, "habitId")
+	if habitId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	reminders, err := h.service.GetReminders(r.Context(), userId, habitId)
+	if err != nil {
+		if errors.Is(err, habits.ErrHabitNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find habit by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to get reminders", "error", err, "habitId", habitId)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get reminders"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, reminders)
+}
+
+func (h *ReminderHandler) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	var reminderReq ReminderReq
+	err := request.DecodeJSON(w, r, &reminderReq)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := reminderReq.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
+		return
+	}
+
+	habitId := chi.URLParam(r, "habitId")
+	if habitId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	reminder, err := h.service.CreateReminder(r.Context(), userId, habitId, reminderReq)
+	if err != nil {
+		if errors.Is(err, habits.ErrHabitNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find habit by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to create reminder", "error", err, "habitId", habitId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not create reminder"))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Reminder created", "reminderId", reminder.ID, "habitId", habitId)
+	h.writeSuccessResponse(w, r, http.StatusCreated, reminder)
+}
+
+func (h *ReminderHandler) UpdateReminder(w http.ResponseWriter, r *http.Request) {
+	var req ReminderReq
+	err := request.DecodeJSON(w, r, &req)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := req.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
+		return
+	}
+
+	reminderId := chi.URLParam(r, "reminderId")
+	if reminderId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	updated, err := h.service.UpdateReminder(r.Context(), userId, reminderId, req)
+	if err != nil {
+		if errors.Is(err, ErrReminderNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find reminder by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not update reminder", "error", err, "reminderId", reminderId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not update reminder"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, updated)
+}
+
+func (h *ReminderHandler) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	reminderId := chi.URLParam(r, "reminderId")
+	if reminderId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	err = h.service.DeleteReminder(r.Context(), userId, reminderId)
+	if err != nil {
+		if errors.Is(err, ErrReminderNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find reminder by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not delete reminder", "error", err, "reminderId", reminderId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not delete reminder"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, map[string]string{"message": "Successfully deleted reminder"})
+}
@@ -0,0 +1,128 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/problem"
+)
+
+const testUserId = "test-user-1"
+
+func setupHandler(t *testing.T) *chi.Mux {
+	t.Helper()
+
+	db, cfg := setupTestTable(t)
+	habitStorage := habits.NewHabitStorage(db, cfg)
+	habitStorage.CreateHabit(context.Background(), testUserId, habits.HabitModel{ID: "habit-1", Name: "Read"})
+
+	service := NewReminderService(NewReminderStorage(db, cfg), habitStorage)
+	handler := &ReminderHandler{
+		service: service,
+		getUserId: func(r *http.Request) (string, error) {
+			return testUserId, nil
+		},
+	}
+
+	r := chi.NewRouter()
+	r.Get("/reminders", handler.GetAllReminders)
+	r.Get("/habits/{habitId}/reminders", handler.GetHabitReminders)
+	r.Post("/habits/{habitId}/reminders", handler.CreateReminder)
+	r.Put("/reminders/{reminderId}", handler.UpdateReminder)
+	r.Delete("/reminders/{reminderId}", handler.DeleteReminder)
+
+	return r
+}
+
+func serve(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlerReminders(t *testing.T) {
+	router := setupHandler(t)
+
+	var created ReminderModel
+	t.Run("create", func(t *testing.T) {
+		w := serve(router, http.MethodPost, "/habits/habit-1/reminders", `{"time":"20:00","days":["monday"]}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		json.NewDecoder(w.Body).Decode(&created)
+		if created.ID == "" || created.HabitId != "habit-1" || created.Time != "20:00" {
+			t.Errorf("unexpected reminder %+v", created)
+		}
+	})
+
+	t.Run("create for unknown habit", func(t *testing.T) {
+		w := serve(router, http.MethodPost, "/habits/missing/reminders", `{"time":"20:00"}`)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		w := serve(router, http.MethodPost, "/habits/habit-1/reminders", `{"time":"8pm","days":["someday"]}`)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", w.Code)
+		}
+		var p problem.Problem
+		json.NewDecoder(w.Body).Decode(&p)
+		if len(p.InvalidParams) != 2 {
+			t.Errorf("expected time and days to be invalid, got %+v", p.InvalidParams)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		for _, target := range []string{"/reminders", "/habits/habit-1/reminders"} {
+			w := serve(router, http.MethodGet, target, "")
+			var reminders []ReminderModel
+			json.NewDecoder(w.Body).Decode(&reminders)
+			if w.Code != http.StatusOK || len(reminders) != 1 {
+				t.Errorf("%s: expected 1 reminder, got %d %+v", target, w.Code, reminders)
+			}
+		}
+
+		w := serve(router, http.MethodGet, "/habits/missing/reminders", "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for an unknown habit, got %d", w.Code)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		w := serve(router, http.MethodPut, "/reminders/"+created.ID, `{"time":"07:30","paused":true}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var updated ReminderModel
+		json.NewDecoder(w.Body).Decode(&updated)
+		if updated.Time != "07:30" || !updated.Paused || updated.Days == nil || len(updated.Days) != 0 {
+			t.Errorf("unexpected reminder %+v", updated)
+		}
+
+		w = serve(router, http.MethodPut, "/reminders/missing", `{"time":"07:30"}`)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w := serve(router, http.MethodDelete, "/reminders/"+created.ID, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		w = serve(router, http.MethodDelete, "/reminders/"+created.ID, "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package reminders
+
+import (
+	"regexp"
+	"slices"
+
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+type reminderItem struct {
+	UserId string `json:"-" dynamodbav:"userId"`
+	ItemId string `json:"-" dynamodbav:"itemId"`
+	// ReminderIndex puts the item in IndexReminders.
+	ReminderIndex string `json:"-" dynamodbav:"ReminderIndex"`
+	ReminderModel
+}
+
+// ReminderModel asks to be reminded of a habit at a time of day, in the
+// user's time zone, when it has not been logged yet that day.
+type ReminderModel struct {
+	ID      string `json:"id" dynamodbav:"ID"`
+	HabitId string `json:"habitId" dynamodbav:"HabitId"`
+	// Time is HH:MM on a 24 hour clock.
+	Time string `json:"time" dynamodbav:"Time"`
+	// Days are lower case weekdays, empty for every day.
+	Days   []string `json:"days" dynamodbav:"Days"`
+	Paused bool     `json:"paused" dynamodbav:"Paused"`
+	// LastSentOn is the user's date the reminder last fired, so it fires at
+	// most once a day.
+	LastSentOn string `json:"lastSentOn,omitempty" dynamodbav:"LastSentOn,omitempty"`
+	CreatedAt  int64  `json:"createdAt" dynamodbav:"CreatedAt"`
+	UpdatedAt  int64  `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+type ReminderReq struct {
+	Time   string   `json:"time"`
+	Days   []string `json:"days"`
+	Paused bool     `json:"paused"`
+}
+
+var clockTime = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+func (r ReminderReq) validate() []problem.InvalidParam {
+	var params []problem.InvalidParam
+
+	if !clockTime.MatchString(r.Time) {
+		params = append(params, problem.InvalidParam{Name: "time", Reason: "Time must be HH:MM on a 24 hour clock, like 20:00"})
+	}
+	for i, day := range r.Days {
+		if _, ok := user.ParseWeekday(day); !ok {
+			params = append(params, problem.InvalidParam{Name: "days", Reason: "Days must be lower case weekdays like monday"})
+			break
+		}
+		if slices.Contains(r.Days[:i], day) {
+			params = append(params, problem.InvalidParam{Name: "days", Reason: "Days must not repeat"})
+			break
+		}
+	}
+
+	return params
+}
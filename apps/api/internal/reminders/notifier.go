@@ -0,0 +1,143 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/push"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+// Notification is one reminder sent to a user.
+type Notification struct {
+	UserId     string `json:"userId"`
+	ReminderId string `json:"reminderId"`
+	HabitId    string `json:"habitId"`
+	HabitName  string `json:"habitName"`
+	// Date is the user's date the reminder is for.
+	Date  string `json:"date"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Streak is how many days in a row the habit was done before today,
+	// set when the user wants streak alerts and it is at least MinStreak.
+	Streak int `json:"streak,omitempty"`
+	// Preferences are the channels the user wants notifications on.
+	Preferences user.NotificationPreferences `json:"-"`
+}
+
+// Notifier delivers notifications, over Web Push, email, a webhook or
+// anything else. A reminder is only marked as sent when Notify succeeds.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotifierFunc lets a function be used as a Notifier.
+type NotifierFunc func(ctx context.Context, n Notification) error
+
+func (f NotifierFunc) Notify(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+// LogNotifier only logs notifications, for local runs and dry runs.
+type LogNotifier struct {
+	Logger *slog.Logger
+}
+
+func (l LogNotifier) Notify(ctx context.Context, n Notification) error {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.InfoContext(ctx, "Reminder", "userId", n.UserId, "habitId", n.HabitId, "reminderId", n.ReminderId, "title", n.Title)
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a URL.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: http.DefaultClient,
+	}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("reminder webhook responded %s", res.Status)
+	}
+	return nil
+}
+
+// PushNotifier sends notifications to the user's devices over Web Push,
+// when the user has push turned on.
+type PushNotifier struct {
+	push *push.PushService
+}
+
+func NewPushNotifier(push *push.PushService) *PushNotifier {
+	return &PushNotifier{push: push}
+}
+
+func (p *PushNotifier) Notify(ctx context.Context, n Notification) error {
+	if !n.Preferences.Push {
+		return nil
+	}
+
+	_, err := p.push.SendToUser(ctx, n.UserId, push.Message{
+		Title: n.Title,
+		Body:  n.Body,
+		// One reminder per habit stays on the device
+		Tag: "reminder-" + n.HabitId,
+		URL: "/dashboard",
+	}, push.Options{TTL: 2 * time.Hour})
+	return err
+}
+
+// MultiNotifier sends every notification with all of its notifiers. It
+// succeeds when any of them does, so that the reminder is marked as sent and
+// the channels that delivered it do not send it again on the next run. The
+// channels that failed are logged and not retried.
+type MultiNotifier []Notifier
+
+func (m MultiNotifier) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == len(m) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		slog.WarnContext(ctx, "Reminder channel failed", "error", err, "reminderId", n.ReminderId)
+	}
+	return nil
+}
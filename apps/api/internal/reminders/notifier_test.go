@@ -0,0 +1,100 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimvid/sidekick/internal/push"
+	"github.com/jimvid/sidekick/internal/push/pushtest"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Notification
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL)
+	n := Notification{UserId: "user-1", ReminderId: "r1", HabitId: "read", HabitName: "Read", Date: "2026-03-02", Title: "Read"}
+	if err := notifier.Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if got != n {
+		t.Errorf("expected %+v, got %+v", n, got)
+	}
+
+	status = http.StatusInternalServerError
+	if err := notifier.Notify(context.Background(), n); err == nil {
+		t.Error("expected an error for a failing webhook")
+	}
+}
+
+func TestPushNotifier(t *testing.T) {
+	db, cfg := setupTestTable(t)
+	ctx := context.Background()
+
+	publicKey, privateKey, _ := push.GenerateVAPIDKeys()
+	sender, err := push.NewSender(publicKey, privateKey, "mailto:test@sidekick.example")
+	if err != nil {
+		t.Fatalf("NewSender failed: %v", err)
+	}
+	server := pushtest.NewServer()
+	defer server.Close()
+	sender.Client = server.Client()
+	sender.Services = append(sender.Services, server.Host())
+
+	service := push.NewPushService(push.NewPushStorage(db, cfg), sender)
+	device := server.Subscribe("phone")
+	req := push.SubscriptionReq{Endpoint: device.Endpoint, Keys: push.SubscriptionKeys{P256dh: device.Keys.P256dh, Auth: device.Keys.Auth}}
+	if _, _, err := service.Subscribe(ctx, "user-1", req, ""); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	notifier := NewPushNotifier(service)
+	n := Notification{UserId: "user-1", HabitId: "read", Title: "Read", Body: "Time for Read"}
+
+	if err := notifier.Notify(ctx, n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(server.Received()) != 0 {
+		t.Error("expected nothing sent with push turned off")
+	}
+
+	n.Preferences = user.NotificationPreferences{Push: true}
+	if err := notifier.Notify(ctx, n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	received := server.Received()
+	if len(received) != 1 {
+		t.Fatalf("expected 1 push, got %d", len(received))
+	}
+	var msg push.Message
+	json.Unmarshal(received[0].Payload, &msg)
+	if msg.Title != "Read" || msg.Body != "Time for Read" || msg.Tag != "reminder-read" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+}
+
+func TestMultiNotifier(t *testing.T) {
+	var calls int
+	ok := NotifierFunc(func(context.Context, Notification) error { calls++; return nil })
+	failing := NotifierFunc(func(context.Context, Notification) error { calls++; return errors.New("down") })
+
+	err := MultiNotifier{failing, ok}.Notify(context.Background(), Notification{})
+	if err != nil || calls != 2 {
+		t.Errorf("expected every notifier to run and one delivery to be enough, got %v after %d calls", err, calls)
+	}
+
+	err = MultiNotifier{failing, failing}.Notify(context.Background(), Notification{})
+	if err == nil {
+		t.Error("expected an error when no notifier delivered")
+	}
+}
@@ -0,0 +1,205 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/logging"
+	"github.com/jimvid/sidekick/internal/tracing"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+// Runner sends the reminders that are due, meant to run every few minutes
+// from a schedule. See cmd/reminders.
+type Runner struct {
+	reminders *ReminderStorage
+	habits    *habits.HabitStorage
+	users     *user.UserStorage
+	notifier  Notifier
+	evaluator *Evaluator
+	// DryRun evaluates reminders without notifying or marking them sent.
+	DryRun bool
+}
+
+func NewRunner(reminders *ReminderStorage, habits *habits.HabitStorage, users *user.UserStorage, notifier Notifier, evaluator *Evaluator) *Runner {
+	return &Runner{
+		reminders: reminders,
+		habits:    habits,
+		users:     users,
+		notifier:  notifier,
+		evaluator: evaluator,
+	}
+}
+
+// RunResult counts what a run did.
+type RunResult struct {
+	Users  int `json:"users"`
+	Due    int `json:"due"`
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+}
+
+// Run sends the reminders due at now. A user whose reminders fail to load
+// or send is counted and logged, and the run goes on with the next user.
+func (r *Runner) Run(ctx context.Context, now time.Time) (RunResult, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Runner.Run")
+	defer span.End()
+
+	all, err := r.reminders.GetAllReminders(ctx)
+	if err != nil {
+		return RunResult{}, err
+	}
+
+	byUser := map[string][]ReminderModel{}
+	var userIds []string
+	for _, reminder := range all {
+		if byUser[reminder.UserId] == nil {
+			userIds = append(userIds, reminder.UserId)
+		}
+		byUser[reminder.UserId] = append(byUser[reminder.UserId], reminder.ReminderModel)
+	}
+
+	var result RunResult
+	for _, userId := range userIds {
+		// Storage leaves the user ID to the context, AuthMiddleware sets it in requests
+		userCtx := logging.WithAttrs(ctx, slog.String("userId", userId))
+
+		result.Users++
+		due, sent, err := r.runUser(userCtx, now, userId, byUser[userId])
+		result.Due += due
+		result.Sent += sent
+		if err != nil {
+			result.Failed += due - sent
+			slog.ErrorContext(userCtx, "Failed to send reminders", "error", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Reminders run", "users", result.Users, "due", result.Due, "sent", result.Sent, "failed", result.Failed, "dryRun", r.DryRun)
+	return result, nil
+}
+
+func (r *Runner) runUser(ctx context.Context, now time.Time, userId string, reminders []ReminderModel) (int, int, error) {
+	found, err := r.users.GetUser(ctx, userId)
+	if err != nil {
+		return 0, 0, err
+	}
+	prefs := found.Profile.Notifications
+	if !prefs.Reminders && !prefs.StreakAlerts {
+		return 0, 0, nil
+	}
+
+	settings := found.Settings
+	today := settings.Today(now)
+
+	logs, err := r.habits.GetHabitLogsInRange(ctx, userId, today, today, "")
+	if err != nil {
+		return 0, 0, err
+	}
+	done := map[string]bool{}
+	for _, log := range logs {
+		done[log.HabitId] = true
+	}
+
+	due := r.evaluator.Due(now, settings, reminders, done)
+	if len(due) == 0 {
+		return 0, 0, nil
+	}
+
+	var streaks map[string]int
+	if prefs.StreakAlerts {
+		streaks, err = r.streaks(ctx, userId, today)
+		if err != nil {
+			return len(due), 0, err
+		}
+	}
+	if !prefs.Reminders {
+		// Only the reminders warning about a streak are wanted
+		due = slices.DeleteFunc(due, func(reminder ReminderModel) bool {
+			return streaks[reminder.HabitId] < MinStreak
+		})
+		if len(due) == 0 {
+			return 0, 0, nil
+		}
+	}
+
+	userHabits, err := r.habits.GetAllHabits(ctx, userId)
+	if err != nil {
+		return len(due), 0, err
+	}
+	names := map[string]string{}
+	for _, habit := range userHabits {
+		names[habit.ID] = habit.Name
+	}
+
+	sent := 0
+	var errs []error
+	var orphaned int
+	for _, reminder := range due {
+		name, ok := names[reminder.HabitId]
+		if !ok {
+			// The habit was deleted, its reminders go with it
+			orphaned++
+			if err := r.reminders.DeleteReminder(ctx, userId, reminder.ID); err != nil && !errors.Is(err, ErrReminderNotFound) {
+				slog.WarnContext(ctx, "Failed to delete reminder of deleted habit", "error", err, "reminderId", reminder.ID)
+			}
+			continue
+		}
+		if r.DryRun {
+			slog.InfoContext(ctx, "Reminder due", "habitId", reminder.HabitId, "reminderId", reminder.ID, "streak", streaks[reminder.HabitId], "dryRun", true)
+			sent++
+			continue
+		}
+
+		notification := Notification{
+			UserId:      userId,
+			ReminderId:  reminder.ID,
+			HabitId:     reminder.HabitId,
+			HabitName:   name,
+			Date:        today,
+			Title:       name,
+			Body:        fmt.Sprintf("Time for %s, it has not been done yet today.", name),
+			Preferences: prefs,
+		}
+		if streak := streaks[reminder.HabitId]; streak >= MinStreak {
+			notification.Streak = streak
+			notification.Body = fmt.Sprintf("Your %d day streak of %s ends today unless it is done.", streak, name)
+		}
+
+		err := r.notifier.Notify(ctx, notification)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		err = r.reminders.MarkSent(ctx, userId, reminder.ID, today)
+		if err != nil && !errors.Is(err, ErrReminderNotFound) {
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+
+	return len(due) - orphaned, sent, errors.Join(errs...)
+}
+
+// streaks loads the user's streaks as of today, see Streaks.
+func (r *Runner) streaks(ctx context.Context, userId, today string) (map[string]int, error) {
+	day, err := time.Parse(time.DateOnly, today)
+	if err != nil {
+		return nil, err
+	}
+
+	from := day.AddDate(0, 0, -StreakLookback).Format(time.DateOnly)
+	yesterday := day.AddDate(0, 0, -1).Format(time.DateOnly)
+	logs, err := r.habits.GetHabitLogsInRange(ctx, userId, from, yesterday, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return Streaks(logs, today), nil
+}
@@ -0,0 +1,193 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+func TestRunnerRun(t *testing.T) {
+	db, cfg := setupTestTable(t)
+	storage := NewReminderStorage(db, cfg)
+	habitStorage := habits.NewHabitStorage(db, cfg)
+	userStorage := user.NewUserStorage(db, cfg)
+	ctx := context.Background()
+
+	// Monday 2026-03-02 20:30 in Stockholm
+	now := time.Date(2026, 3, 2, 19, 30, 0, 0, time.UTC)
+	userStorage.PutSettings(ctx, "user-1", user.SettingsModel{TimeZone: "Europe/Stockholm", WeekStart: "monday", Locale: "sv-SE"})
+
+	habitStorage.CreateHabit(ctx, "user-1", habits.HabitModel{ID: "read", Name: "Read"})
+	habitStorage.CreateHabit(ctx, "user-1", habits.HabitModel{ID: "run", Name: "Run"})
+	habitStorage.CreateHabitLog(ctx, "user-1", habits.HabitLogModel{ID: "log-1", HabitId: "run", Date: "2026-03-02"})
+	storage.PutReminder(ctx, "user-1", makeReminder("read-evening", "read", "20:00"))
+	storage.PutReminder(ctx, "user-1", makeReminder("run-evening", "run", "20:00"))
+	storage.PutReminder(ctx, "user-1", makeReminder("deleted-habit", "gone", "20:00"))
+
+	// Reminders are turned off for user-2
+	habitStorage.CreateHabit(ctx, "user-2", habits.HabitModel{ID: "read", Name: "Read"})
+	storage.PutReminder(ctx, "user-2", makeReminder("read-evening", "read", "19:30"))
+	profile := user.DefaultProfile()
+	profile.Notifications.Reminders = false
+	userStorage.PutProfile(ctx, "user-2", profile)
+
+	var sent []Notification
+	notifier := NotifierFunc(func(ctx context.Context, n Notification) error {
+		sent = append(sent, n)
+		return nil
+	})
+	runner := NewRunner(storage, habitStorage, userStorage, notifier, NewEvaluator())
+
+	result, err := runner.Run(ctx, now)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result != (RunResult{Users: 2, Due: 1, Sent: 1}) {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 notification, got %+v", sent)
+	}
+	n := sent[0]
+	if n.UserId != "user-1" || n.ReminderId != "read-evening" || n.HabitName != "Read" || n.Date != "2026-03-02" {
+		t.Errorf("unexpected notification %+v", n)
+	}
+
+	marked, _ := storage.FindReminderById(ctx, "user-1", "read-evening")
+	if marked.LastSentOn != "2026-03-02" {
+		t.Errorf("expected the reminder to be marked sent, got %q", marked.LastSentOn)
+	}
+	if _, err := storage.FindReminderById(ctx, "user-1", "deleted-habit"); !errors.Is(err, ErrReminderNotFound) {
+		t.Errorf("expected the reminder of a deleted habit to be removed, got %v", err)
+	}
+
+	t.Run("fires once a day", func(t *testing.T) {
+		sent = nil
+		result, err := runner.Run(ctx, now.Add(10*time.Minute))
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.Sent != 0 || len(sent) != 0 {
+			t.Errorf("expected nothing sent, got %+v", sent)
+		}
+	})
+
+	t.Run("failed notifications are retried", func(t *testing.T) {
+		storage.PutReminder(ctx, "user-1", makeReminder("read-late", "read", "20:15"))
+		failing := NewRunner(storage, habitStorage, userStorage, NotifierFunc(func(context.Context, Notification) error {
+			return errors.New("push service down")
+		}), NewEvaluator())
+
+		result, err := failing.Run(ctx, now)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.Due != 1 || result.Failed != 1 {
+			t.Errorf("expected 1 failed reminder, got %+v", result)
+		}
+		found, _ := storage.FindReminderById(ctx, "user-1", "read-late")
+		if found.LastSentOn != "" {
+			t.Errorf("expected a failed reminder to stay unsent, got %q", found.LastSentOn)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		sent = nil
+		runner.DryRun = true
+		defer func() { runner.DryRun = false }()
+
+		result, err := runner.Run(ctx, now)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.Sent != 1 || len(sent) != 0 {
+			t.Errorf("expected a dry run to count without notifying, got %+v and %+v", result, sent)
+		}
+		found, _ := storage.FindReminderById(ctx, "user-1", "read-late")
+		if found.LastSentOn != "" {
+			t.Errorf("expected a dry run not to mark reminders, got %q", found.LastSentOn)
+		}
+	})
+
+	t.Run("sent once one channel delivers", func(t *testing.T) {
+		sent = nil
+		down := NotifierFunc(func(context.Context, Notification) error {
+			return errors.New("push service down")
+		})
+		partial := NewRunner(storage, habitStorage, userStorage, MultiNotifier{down, notifier}, NewEvaluator())
+
+		for range 2 {
+			if _, err := partial.Run(ctx, now); err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+		}
+		if len(sent) != 1 {
+			t.Errorf("expected the working channel to send once, got %+v", sent)
+		}
+		found, _ := storage.FindReminderById(ctx, "user-1", "read-late")
+		if found.LastSentOn != "2026-03-02" {
+			t.Errorf("expected the reminder to be marked sent, got %q", found.LastSentOn)
+		}
+	})
+}
+
+func TestRunnerStreakAlerts(t *testing.T) {
+	db, cfg := setupTestTable(t)
+	storage := NewReminderStorage(db, cfg)
+	habitStorage := habits.NewHabitStorage(db, cfg)
+	userStorage := user.NewUserStorage(db, cfg)
+	ctx := context.Background()
+
+	// Monday 2026-03-02 20:30 in Stockholm
+	now := time.Date(2026, 3, 2, 19, 30, 0, 0, time.UTC)
+	userStorage.PutSettings(ctx, "user-1", user.SettingsModel{TimeZone: "Europe/Stockholm", WeekStart: "monday", Locale: "sv-SE"})
+
+	habitStorage.CreateHabit(ctx, "user-1", habits.HabitModel{ID: "read", Name: "Read"})
+	habitStorage.CreateHabit(ctx, "user-1", habits.HabitModel{ID: "run", Name: "Run"})
+	for i, date := range []string{"2026-02-27", "2026-02-28", "2026-03-01"} {
+		habitStorage.CreateHabitLog(ctx, "user-1", habits.HabitLogModel{ID: fmt.Sprintf("read-%d", i), HabitId: "read", Date: date})
+	}
+	habitStorage.CreateHabitLog(ctx, "user-1", habits.HabitLogModel{ID: "run-1", HabitId: "run", Date: "2026-03-01"})
+	storage.PutReminder(ctx, "user-1", makeReminder("read-evening", "read", "20:00"))
+	storage.PutReminder(ctx, "user-1", makeReminder("run-evening", "run", "20:00"))
+
+	var sent []Notification
+	runner := NewRunner(storage, habitStorage, userStorage, NotifierFunc(func(ctx context.Context, n Notification) error {
+		sent = append(sent, n)
+		return nil
+	}), NewEvaluator())
+
+	t.Run("only streaks without reminders", func(t *testing.T) {
+		profile := user.DefaultProfile()
+		profile.Notifications.Reminders = false
+		userStorage.PutProfile(ctx, "user-1", profile)
+
+		result, err := runner.Run(ctx, now)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.Sent != 1 || len(sent) != 1 || sent[0].ReminderId != "read-evening" || sent[0].Streak != 3 || !strings.Contains(sent[0].Body, "3 day streak") {
+			t.Errorf("expected one streak alert for read, got %+v", sent)
+		}
+	})
+
+	t.Run("reminders without streak alerts", func(t *testing.T) {
+		sent = nil
+		profile := user.DefaultProfile()
+		profile.Notifications.StreakAlerts = false
+		userStorage.PutProfile(ctx, "user-1", profile)
+
+		if _, err := runner.Run(ctx, now); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(sent) != 1 || sent[0].ReminderId != "run-evening" || sent[0].Streak != 0 {
+			t.Errorf("expected a plain reminder for run, got %+v", sent)
+		}
+	})
+}
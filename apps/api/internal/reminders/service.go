@@ -0,0 +1,106 @@
+package reminders
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/tracing"
+)
+
+// HabitFinder checks the habit a reminder is for.
+type HabitFinder interface {
+	FindHabitById(ctx context.Context, userId, habitId string) (habits.HabitModel, error)
+}
+
+type ReminderService struct {
+	storage *ReminderStorage
+	habits  HabitFinder
+	now     func() time.Time
+}
+
+func NewReminderService(storage *ReminderStorage, habits HabitFinder) *ReminderService {
+	return &ReminderService{
+		storage: storage,
+		habits:  habits,
+		now:     time.Now,
+	}
+}
+
+// GetReminders returns the user's reminders, only those for habitId unless
+// it is empty. It fails with habits.ErrHabitNotFound for an unknown habit.
+func (s *ReminderService) GetReminders(ctx context.Context, userId, habitId string) ([]ReminderModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ReminderService.GetReminders")
+	defer span.End()
+
+	if habitId != "" {
+		if _, err := s.habits.FindHabitById(ctx, userId, habitId); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.storage.GetReminders(ctx, userId, habitId)
+}
+
+func (s *ReminderService) CreateReminder(ctx context.Context, userId, habitId string, req ReminderReq) (ReminderModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ReminderService.CreateReminder")
+	defer span.End()
+
+	if _, err := s.habits.FindHabitById(ctx, userId, habitId); err != nil {
+		return ReminderModel{}, err
+	}
+
+	reminder := ReminderModel{
+		ID:        uuid.New().String(),
+		HabitId:   habitId,
+		Time:      req.Time,
+		Days:      days(req.Days),
+		Paused:    req.Paused,
+		CreatedAt: s.now().Unix(),
+		UpdatedAt: s.now().Unix(),
+	}
+
+	return reminder, s.storage.PutReminder(ctx, userId, reminder)
+}
+
+func (s *ReminderService) UpdateReminder(ctx context.Context, userId, reminderId string, req ReminderReq) (ReminderModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ReminderService.UpdateReminder")
+	defer span.End()
+
+	existing, err := s.storage.FindReminderById(ctx, userId, reminderId)
+	if err != nil {
+		return ReminderModel{}, err
+	}
+
+	// A reminder moved to a new time may fire again the same day
+	if existing.Time != req.Time {
+		existing.LastSentOn = ""
+	}
+	existing.Time = req.Time
+	existing.Days = days(req.Days)
+	existing.Paused = req.Paused
+	existing.UpdatedAt = s.now().Unix()
+
+	err = s.storage.PutReminder(ctx, userId, existing)
+	if err != nil {
+		return ReminderModel{}, err
+	}
+
+	return existing, nil
+}
+
+func (s *ReminderService) DeleteReminder(ctx context.Context, userId, reminderId string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "ReminderService.DeleteReminder")
+	defer span.End()
+
+	return s.storage.DeleteReminder(ctx, userId, reminderId)
+}
+
+// days keeps every day reminders as an empty list rather than null.
+func days(days []string) []string {
+	if days == nil {
+		return []string{}
+	}
+	return days
+}
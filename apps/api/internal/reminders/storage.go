@@ -0,0 +1,244 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/database"
+)
+
+const (
+	itemPrefixReminder = "reminder#"
+
+	// IndexReminders is a sparse index holding every user's reminders, so
+	// the scheduled run can find them without scanning the table. Reminders
+	// are spread over database.IndexShards partitions, keyed by
+	// ReminderIndex and sorted by itemId.
+	IndexReminders = "byReminder"
+
+	reminderIndexKey = "reminder"
+)
+
+var ErrReminderNotFound = errors.New("could not find a reminder with that ID")
+
+type ReminderStorage struct {
+	db  *dynamodb.Client
+	cfg *config.Config
+}
+
+func NewReminderStorage(db *dynamodb.Client, cfg *config.Config) *ReminderStorage {
+	return &ReminderStorage{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// ScheduledReminder is a reminder with the user it belongs to.
+type ScheduledReminder struct {
+	UserId string
+	ReminderModel
+}
+
+func (s *ReminderStorage) PutReminder(ctx context.Context, userId string, reminder ReminderModel) error {
+	item := reminderItem{
+		UserId:        userId,
+		ItemId:        itemPrefixReminder + reminder.ID,
+		ReminderIndex: database.ShardKey(reminderIndexKey, reminder.ID),
+		ReminderModel: reminder,
+	}
+
+	attributeValue, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal reminder", "error", err)
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      attributeValue,
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "reminderId", reminder.ID)
+		return err
+	}
+
+	return nil
+}
+
+// GetReminders returns the user's reminders, only those for habitId unless
+// it is empty.
+func (s *ReminderStorage) GetReminders(ctx context.Context, userId, habitId string) ([]ReminderModel, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixReminder},
+		},
+	}
+	if habitId != "" {
+		input.FilterExpression = aws.String("HabitId = :habitId")
+		input.ExpressionAttributeValues[":habitId"] = &types.AttributeValueMemberS{Value: habitId}
+	}
+
+	result, err := s.db.Query(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err)
+		return nil, err
+	}
+
+	var items []reminderItem
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal reminders", "error", err)
+		return nil, err
+	}
+
+	reminders := make([]ReminderModel, len(items))
+	for i, item := range items {
+		reminders[i] = item.ReminderModel
+	}
+
+	return reminders, nil
+}
+
+func (s *ReminderStorage) FindReminderById(ctx context.Context, userId, reminderId string) (ReminderModel, error) {
+	var item reminderItem
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixReminder + reminderId},
+		},
+	}
+
+	result, err := s.db.GetItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err, "reminderId", reminderId)
+		return ReminderModel{}, err
+	}
+
+	if result.Item == nil {
+		return ReminderModel{}, ErrReminderNotFound
+	}
+
+	err = attributevalue.UnmarshalMap(result.Item, &item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal reminder", "error", err)
+		return ReminderModel{}, err
+	}
+
+	return item.ReminderModel, nil
+}
+
+func (s *ReminderStorage) DeleteReminder(ctx context.Context, userId, reminderId string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixReminder + reminderId},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	}
+
+	result, err := s.db.DeleteItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB DeleteItem failed", "error", err, "reminderId", reminderId)
+		return err
+	}
+
+	if result.Attributes == nil {
+		return ErrReminderNotFound
+	}
+
+	slog.InfoContext(ctx, "Reminder deleted", "reminderId", reminderId)
+	return nil
+}
+
+// GetAllReminders reads every user's reminders from IndexReminders, in the
+// order of their item IDs.
+func (s *ReminderStorage) GetAllReminders(ctx context.Context) ([]ScheduledReminder, error) {
+	items, err := database.QueryShards(ctx, reminderIndexKey, s.getShardReminders)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(items, func(a, b reminderItem) int { return strings.Compare(a.ItemId, b.ItemId) })
+
+	reminders := make([]ScheduledReminder, len(items))
+	for i, item := range items {
+		reminders[i] = ScheduledReminder{UserId: item.UserId, ReminderModel: item.ReminderModel}
+	}
+
+	return reminders, nil
+}
+
+func (s *ReminderStorage) getShardReminders(ctx context.Context, shardKey string) ([]reminderItem, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		IndexName:              aws.String(IndexReminders),
+		KeyConditionExpression: aws.String("ReminderIndex = :key"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":key": &types.AttributeValueMemberS{Value: shardKey},
+		},
+	}
+
+	var items []reminderItem
+	paginator := dynamodb.NewQueryPaginator(s.db, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err, "index", IndexReminders, "shard", shardKey)
+			return nil, err
+		}
+
+		var pageItems []reminderItem
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageItems)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to unmarshal reminders", "error", err)
+			return nil, err
+		}
+		items = append(items, pageItems...)
+	}
+
+	return items, nil
+}
+
+// MarkSent records the date a reminder fired on. It fails with
+// ErrReminderNotFound when the reminder was deleted meanwhile.
+func (s *ReminderStorage) MarkSent(ctx context.Context, userId, reminderId, date string) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixReminder + reminderId},
+		},
+		UpdateExpression:    aws.String("SET LastSentOn = :date"),
+		ConditionExpression: aws.String("attribute_exists(itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":date": &types.AttributeValueMemberS{Value: date},
+		},
+	}
+
+	_, err := s.db.UpdateItem(ctx, input)
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return ErrReminderNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB UpdateItem failed", "error", err, "reminderId", reminderId)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-reminders"
+
+func setupTestTable(t *testing.T) (*dynamodb.Client, *config.Config) {
+	t.Helper()
+
+	return dynamotest.NewTable(t, testTableName)
+}
+
+func setupTestDB(t *testing.T) *ReminderStorage {
+	t.Helper()
+
+	return NewReminderStorage(setupTestTable(t))
+}
+
+func makeReminder(id, habitId, at string) ReminderModel {
+	return ReminderModel{
+		ID:        id,
+		HabitId:   habitId,
+		Time:      at,
+		Days:      []string{},
+		CreatedAt: 1,
+		UpdatedAt: 1,
+	}
+}
+
+func TestStorageReminders(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	first := makeReminder("r1", "habit-1", "08:00")
+	second := makeReminder("r2", "habit-2", "20:00")
+	second.Days = []string{"monday", "friday"}
+	other := makeReminder("r3", "habit-3", "12:00")
+	for _, r := range []ReminderModel{first, second} {
+		if err := storage.PutReminder(ctx, "user-1", r); err != nil {
+			t.Fatalf("PutReminder failed: %v", err)
+		}
+	}
+	if err := storage.PutReminder(ctx, "user-2", other); err != nil {
+		t.Fatalf("PutReminder failed: %v", err)
+	}
+
+	t.Run("by user", func(t *testing.T) {
+		reminders, err := storage.GetReminders(ctx, "user-1", "")
+		if err != nil {
+			t.Fatalf("GetReminders failed: %v", err)
+		}
+		if !reflect.DeepEqual(reminders, []ReminderModel{first, second}) {
+			t.Errorf("expected both reminders, got %+v", reminders)
+		}
+	})
+
+	t.Run("by habit", func(t *testing.T) {
+		reminders, err := storage.GetReminders(ctx, "user-1", "habit-2")
+		if err != nil {
+			t.Fatalf("GetReminders failed: %v", err)
+		}
+		if !reflect.DeepEqual(reminders, []ReminderModel{second}) {
+			t.Errorf("expected the habit-2 reminder, got %+v", reminders)
+		}
+	})
+
+	t.Run("all users", func(t *testing.T) {
+		all, err := storage.GetAllReminders(ctx)
+		if err != nil {
+			t.Fatalf("GetAllReminders failed: %v", err)
+		}
+		if len(all) != 3 {
+			t.Fatalf("expected 3 reminders, got %d", len(all))
+		}
+		owners := map[string]string{}
+		for _, r := range all {
+			owners[r.ID] = r.UserId
+		}
+		if owners["r1"] != "user-1" || owners["r3"] != "user-2" {
+			t.Errorf("unexpected owners %v", owners)
+		}
+	})
+
+	t.Run("find and delete", func(t *testing.T) {
+		found, err := storage.FindReminderById(ctx, "user-1", "r1")
+		if err != nil || !reflect.DeepEqual(found, first) {
+			t.Fatalf("expected %+v, got %+v (%v)", first, found, err)
+		}
+		if _, err := storage.FindReminderById(ctx, "user-2", "r1"); !errors.Is(err, ErrReminderNotFound) {
+			t.Errorf("expected ErrReminderNotFound for another user, got %v", err)
+		}
+
+		if err := storage.DeleteReminder(ctx, "user-1", "r1"); err != nil {
+			t.Fatalf("DeleteReminder failed: %v", err)
+		}
+		if err := storage.DeleteReminder(ctx, "user-1", "r1"); !errors.Is(err, ErrReminderNotFound) {
+			t.Errorf("expected ErrReminderNotFound deleting twice, got %v", err)
+		}
+	})
+}
+
+func TestStorageMarkSent(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	storage.PutReminder(ctx, "user-1", makeReminder("r1", "habit-1", "08:00"))
+
+	if err := storage.MarkSent(ctx, "user-1", "r1", "2026-03-02"); err != nil {
+		t.Fatalf("MarkSent failed: %v", err)
+	}
+	found, _ := storage.FindReminderById(ctx, "user-1", "r1")
+	if found.LastSentOn != "2026-03-02" {
+		t.Errorf("expected LastSentOn 2026-03-02, got %q", found.LastSentOn)
+	}
+
+	if err := storage.MarkSent(ctx, "user-1", "missing", "2026-03-02"); !errors.Is(err, ErrReminderNotFound) {
+		t.Errorf("expected ErrReminderNotFound, got %v", err)
+	}
+	if _, err := storage.FindReminderById(ctx, "user-1", "missing"); !errors.Is(err, ErrReminderNotFound) {
+		t.Errorf("MarkSent must not create reminders, got %v", err)
+	}
+}
+
+func TestStorageGetAllRemindersUnshardedKey(t *testing.T) {
+	db, cfg := setupTestTable(t)
+	storage := NewReminderStorage(db, cfg)
+	ctx := context.Background()
+
+	storage.PutReminder(ctx, "user-1", makeReminder("r2", "habit-1", "08:00"))
+
+	// Written before IndexReminders was sharded
+	item, err := attributevalue.MarshalMap(reminderItem{
+		UserId:        "user-2",
+		ItemId:        itemPrefixReminder + "r1",
+		ReminderIndex: reminderIndexKey,
+		ReminderModel: makeReminder("r1", "habit-2", "09:00"),
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal reminder: %v", err)
+	}
+	_, err = db.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(cfg.TableName), Item: item})
+	if err != nil {
+		t.Fatalf("PutItem failed: %v", err)
+	}
+
+	all, err := storage.GetAllReminders(ctx)
+	if err != nil {
+		t.Fatalf("GetAllReminders failed: %v", err)
+	}
+	if len(all) != 2 || all[0].ID != "r1" || all[1].ID != "r2" {
+		t.Errorf("expected both reminders in item ID order, got %+v", all)
+	}
+}
@@ -0,0 +1,118 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MaxBodyBytes caps JSON request bodies. Habits and logs are a few hundred
+// bytes, so this leaves plenty of room.
+const MaxBodyBytes = 64 << 10
+
+// Error is a request body problem with the status code and message to send
+// back to the client.
+type Error struct {
+	Status  int
+	Message string
+	// Field is the JSON field at fault, when the problem is a single field.
+	Field string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func badRequest(format string, args ...any) *Error {
+	return &Error{Status: http.StatusBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+// StatusCode returns the status code for an error from DecodeJSON, or 400 for
+// any other error.
+func StatusCode(err error) int {
+	var requestErr *Error
+	if errors.As(err, &requestErr) {
+		return requestErr.Status
+	}
+	return http.StatusBadRequest
+}
+
+// Field returns the JSON field an error from DecodeJSON is about, if any.
+func Field(err error) string {
+	var requestErr *Error
+	if errors.As(err, &requestErr) {
+		return requestErr.Field
+	}
+	return ""
+}
+
+// DecodeJSON decodes a single JSON object from the request body into dst. It
+// requires an application/json content type, rejects unknown fields, bodies
+// over MaxBodyBytes and anything after the object. Errors are *Error values
+// whose message is safe to show the client.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return &Error{Status: http.StatusUnsupportedMediaType, Message: "Content-Type must be application/json"}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(dst)
+	if err != nil {
+		return decodeError(err)
+	}
+
+	// A second value, or garbage after the first, means the body was not a
+	// single JSON object
+	err = decoder.Decode(&struct{}{})
+	if !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return decodeError(err)
+		}
+		return badRequest("Request body must contain a single JSON object")
+	}
+
+	return nil
+}
+
+func decodeError(err error) *Error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &Error{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesErr.Limit),
+		}
+	case errors.As(err, &syntaxErr):
+		return badRequest("Request body contains malformed JSON at position %d", syntaxErr.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return badRequest("Request body contains malformed JSON")
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			fieldErr := badRequest("Field %q must be a %s", typeErr.Field, typeErr.Type)
+			fieldErr.Field = typeErr.Field
+			return fieldErr
+		}
+		return badRequest("Request body must be a JSON %s", typeErr.Type)
+	case errors.Is(err, io.EOF):
+		return badRequest("Request body must not be empty")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		fieldErr := badRequest("Request body contains unknown field %s", field)
+		fieldErr.Field = strings.Trim(field, `"`)
+		return fieldErr
+	default:
+		return badRequest("Could not parse JSON")
+	}
+}
@@ -0,0 +1,69 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testPayload struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "valid", contentType: "application/json", body: `{"name":"read","count":2}`},
+		{name: "charset parameter", contentType: "application/json; charset=utf-8", body: `{"name":"read"}`},
+		{name: "missing content type", body: `{"name":"read"}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "wrong content type", contentType: "text/plain", body: `{"name":"read"}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "empty body", contentType: "application/json", body: "", wantStatus: http.StatusBadRequest, wantMessage: "must not be empty"},
+		{name: "malformed", contentType: "application/json", body: `{"name":`, wantStatus: http.StatusBadRequest, wantMessage: "malformed JSON"},
+		{name: "syntax error", contentType: "application/json", body: `{"name" "read"}`, wantStatus: http.StatusBadRequest, wantMessage: "position"},
+		{name: "unknown field", contentType: "application/json", body: `{"nmae":"read"}`, wantStatus: http.StatusBadRequest, wantMessage: `unknown field "nmae"`},
+		{name: "wrong type", contentType: "application/json", body: `{"count":"two"}`, wantStatus: http.StatusBadRequest, wantMessage: `Field "count" must be a int`},
+		{name: "not an object", contentType: "application/json", body: `["read"]`, wantStatus: http.StatusBadRequest},
+		{name: "trailing data", contentType: "application/json", body: `{"name":"read"} {"name":"write"}`, wantStatus: http.StatusBadRequest, wantMessage: "single JSON object"},
+		{name: "trailing garbage", contentType: "application/json", body: `{"name":"read"}x`, wantStatus: http.StatusBadRequest, wantMessage: "single JSON object"},
+		{name: "too large", contentType: "application/json", body: `{"name":"` + strings.Repeat("a", MaxBodyBytes) + `"}`, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var payload testPayload
+			err := DecodeJSON(httptest.NewRecorder(), req, &payload)
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if payload.Name != "read" {
+					t.Errorf("expected decoded payload, got %+v", payload)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if StatusCode(err) != tt.wantStatus {
+				t.Errorf("expected status %d, got %d (%v)", tt.wantStatus, StatusCode(err), err)
+			}
+			if !strings.Contains(err.Error(), tt.wantMessage) {
+				t.Errorf("expected message containing %q, got %q", tt.wantMessage, err.Error())
+			}
+		})
+	}
+}
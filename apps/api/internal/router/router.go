@@ -1,59 +1,208 @@
 package router
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/jimvid/sidekick/internal/account"
 	"github.com/jimvid/sidekick/internal/config"
-	"github.com/jimvid/sidekick/internal/database"
 	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/health"
+	"github.com/jimvid/sidekick/internal/logging"
+	"github.com/jimvid/sidekick/internal/metrics"
 	"github.com/jimvid/sidekick/internal/middleware"
+	"github.com/jimvid/sidekick/internal/openapi"
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/push"
+	"github.com/jimvid/sidekick/internal/ratelimit"
+	"github.com/jimvid/sidekick/internal/reminders"
+	"github.com/jimvid/sidekick/internal/startup"
+	"github.com/jimvid/sidekick/internal/tokens"
+	"github.com/jimvid/sidekick/internal/tracing"
+	"github.com/jimvid/sidekick/internal/user"
+	"github.com/jimvid/sidekick/internal/webhooks"
 )
 
-func NewRouter(cfg *config.Config) *chi.Mux {
-
+// NewRouter wires every package and mounts its routes. It fails on settings
+// config.Load leaves to their packages to check, like RATE_LIMITS.
+func NewRouter(cfg *config.Config, recorder metrics.Recorder) (*chi.Mux, error) {
 	r := chi.NewRouter()
-	db := database.NewDynamoDB()
+	db := startup.DynamoDB(cfg, metrics.DynamoDBOptions(recorder))
+
+	// User
+	userStorage := user.NewUserStorage(db, cfg)
+	userService := user.NewUserService(userStorage)
+	userHandler := user.NewUserHandler(userService)
+
+	// Webhooks
+	webhookStorage := webhooks.NewWebhookStorage(db, cfg)
+	webhookService := webhooks.NewWebhookService(webhookStorage, webhooks.NewSender())
+	webhookHandler := webhooks.NewWebhookHandler(webhookService)
+
+	// Events, published by the services after every change
+	bus := webhooks.NewEventBus(db, cfg, webhookService)
 
 	// Habits
 	habitStorage := habits.NewHabitStorage(db, cfg)
-	habitService := habits.NewHabitService(habitStorage)
+	habitService := habits.NewHabitService(habitStorage, userService, bus)
 	habitHandler := habits.NewHabitHandler(habitService)
 
+	// Reminders
+	reminderStorage := reminders.NewReminderStorage(db, cfg)
+	reminderService := reminders.NewReminderService(reminderStorage, habitStorage)
+	reminderHandler := reminders.NewReminderHandler(reminderService)
+
+	// Push, sending needs VAPID keys, which were validated by config.Load
+	var pushSender *push.Sender
+	if cfg.PushEnabled() {
+		pushSender, _ = push.NewSender(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+	}
+	pushStorage := push.NewPushStorage(db, cfg)
+	pushService := push.NewPushService(pushStorage, pushSender)
+	pushHandler := push.NewPushHandler(pushService)
+
+	// API tokens
+	tokenStorage := tokens.NewTokenStorage(db, cfg)
+	tokenService := tokens.NewTokenService(tokenStorage)
+	tokenHandler := tokens.NewTokenHandler(tokenService)
+
+	// Account
+	accountStorage := account.NewAccountStorage(db, cfg)
+	accountService := account.NewAccountService(accountStorage, tokenService)
+	accountHandler := account.NewAccountHandler(accountService, cfg.ClerkWebhookSecret)
+
+	// Health
+	healthHandler := health.NewHealthHandler(
+		health.DynamoDBCheck(db, cfg.TableName),
+		health.AuthCheck(cfg.ClerkSecret),
+		health.BuildCheck(),
+	)
+
+	// Rate limiting
+	limits, err := ratelimit.ParseLimits(cfg.RateLimits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: RATE_LIMITS: %w", err)
+	}
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	switch cfg.RateLimitBackend {
+	case config.RateLimitBackendDynamoDB:
+		limitStore = ratelimit.NewDynamoStore(db, cfg.TableName)
+	case config.RateLimitBackendNone:
+		limits = ratelimit.Limits{}
+	}
+	limiter := ratelimit.NewLimiter(limitStore, limits)
+
 	// Cors
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
+		AllowedOrigins: cfg.CORSOrigins,
+		AllowedMethods: cfg.CORSMethods,
+		AllowedHeaders: cfg.CORSHeaders,
+		ExposedHeaders: []string{"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
+		MaxAge:         cfg.CORSMaxAge,
 	}))
 
 	// Middleware
-	r.Use(chimiddleware.Logger)
-	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.RequestID)
+	r.Use(logging.RequestAttrs)
+	r.Use(logging.AccessLog(slog.Default()))
+	r.Use(tracing.HTTPMiddleware)
+	r.Use(metrics.HTTPMiddleware(recorder))
+	r.Use(chimiddleware.Recoverer)
+
+	r.NotFound(problem.Handler(problem.NotFound("No route matches the request")))
+	r.MethodNotAllowed(problem.Handler(problem.New(http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "The route does not support this method")))
+
+	// Public routes are rate limited per IP
+	public := r.With(limiter.Middleware)
 
 	// Health
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+	public.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
+	public.Get("/health/live", healthHandler.Live)
+	public.Get("/health/ready", healthHandler.Ready)
+
+	// Metrics, only when the recorder can serve them
+	if handler, ok := recorder.(http.Handler); ok {
+		public.Method(http.MethodGet, "/metrics", handler)
+	}
+
+	// API description, see internal/openapi
+	public.Get("/openapi.json", openapi.Handler)
+
+	// Routes below need a signed in user and are rate limited per user
+	authed := r.With(middleware.AuthMiddleware(tokenService), limiter.Middleware)
+
+	// Routes a leaked API token must not reach need a Clerk session
+	session := authed.With(middleware.SessionOnly)
 
 	// Habits
-	r.With(middleware.AuthMiddleware).Post("/habits", habitHandler.CreateHabit)
-	r.With(middleware.AuthMiddleware).Get("/habits", habitHandler.GetAllHabits)
-	r.With(middleware.AuthMiddleware).Get("/habits/{habitId}", habitHandler.FindHabitById)
-	r.With(middleware.AuthMiddleware).Delete("/habits/{habitId}", habitHandler.DeleteHabit)
-	r.With(middleware.AuthMiddleware).Put("/habits/{habitId}", habitHandler.UpdateHabit)
+	authed.Post("/habits", habitHandler.CreateHabit)
+	authed.Get("/habits", habitHandler.GetAllHabits)
+	authed.Get("/habits/{habitId}", habitHandler.FindHabitById)
+	authed.Delete("/habits/{habitId}", habitHandler.DeleteHabit)
+	authed.Put("/habits/{habitId}", habitHandler.UpdateHabit)
 
 	// Logs
-	r.With(middleware.AuthMiddleware).Post("/habit-logs", habitHandler.CreateHabitLog)
-	r.With(middleware.AuthMiddleware).Get("/habit-logs", habitHandler.GetAllHabitLogs)
-	r.With(middleware.AuthMiddleware).Get("/habit-logs/{id}", habitHandler.FindHabitLogById)
-	r.With(middleware.AuthMiddleware).Delete("/habit-logs/{id}", habitHandler.DeleteHabitLog)
-	r.With(middleware.AuthMiddleware).Put("/habit-logs/{id}", habitHandler.UpdateHabitLog)
+	authed.Post("/habit-logs", habitHandler.CreateHabitLog)
+	authed.Get("/habit-logs", habitHandler.GetAllHabitLogs)
+	authed.Get("/habit-logs/{id}", habitHandler.FindHabitLogById)
+	authed.Delete("/habit-logs/{id}", habitHandler.DeleteHabitLog)
+	authed.Put("/habit-logs/{id}", habitHandler.UpdateHabitLog)
+
+	// Days
+	authed.Get("/days/{date}", habitHandler.GetDay)
+
+	// Stats
+	authed.Get("/heatmap", habitHandler.GetHeatmap)
+
+	// Reminders
+	if cfg.FeatureEnabled(config.FeatureReminders) {
+		authed.Get("/reminders", reminderHandler.GetAllReminders)
+		authed.Get("/habits/{habitId}/reminders", reminderHandler.GetHabitReminders)
+		authed.Post("/habits/{habitId}/reminders", reminderHandler.CreateReminder)
+		authed.Put("/reminders/{reminderId}", reminderHandler.UpdateReminder)
+		authed.Delete("/reminders/{reminderId}", reminderHandler.DeleteReminder)
+	}
+
+	// Push
+	if cfg.FeatureEnabled(config.FeaturePush) {
+		authed.Get("/push/public-key", pushHandler.GetPublicKey)
+		authed.Get("/me/push-subscriptions", pushHandler.GetSubscriptions)
+		authed.Post("/me/push-subscriptions", pushHandler.Subscribe)
+		authed.Post("/me/push-subscriptions/test", pushHandler.SendTest)
+		authed.Delete("/me/push-subscriptions/{subscriptionId}", pushHandler.DeleteSubscription)
+	}
+
+	// Webhooks
+	if cfg.FeatureEnabled(config.FeatureWebhooks) {
+		authed.Get("/me/webhooks", webhookHandler.GetAllWebhooks)
+		authed.Post("/me/webhooks", webhookHandler.CreateWebhook)
+		authed.Get("/me/webhooks/{webhookId}", webhookHandler.GetWebhook)
+		authed.Put("/me/webhooks/{webhookId}", webhookHandler.UpdateWebhook)
+		authed.Delete("/me/webhooks/{webhookId}", webhookHandler.DeleteWebhook)
+		authed.Get("/me/webhooks/{webhookId}/deliveries", webhookHandler.GetDeliveries)
+		authed.Post("/me/webhooks/{webhookId}/test", webhookHandler.TestWebhook)
+		authed.Post("/me/webhooks/{webhookId}/rotate-secret", webhookHandler.RotateSecret)
+	}
+
+	// Account
+	authed.Get("/me", userHandler.GetMe)
+	session.Delete("/me", accountHandler.DeleteMe)
+	authed.Get("/me/profile", userHandler.GetProfile)
+	authed.Put("/me/profile", userHandler.UpdateProfile)
+	authed.Put("/me/onboarding/{step}", userHandler.CompleteOnboardingStep)
+	authed.Get("/me/settings", userHandler.GetSettings)
+	authed.Put("/me/settings", userHandler.UpdateSettings)
+	session.Get("/me/tokens", tokenHandler.GetTokens)
+	session.Post("/me/tokens", tokenHandler.CreateToken)
+	authed.Delete("/me/tokens/{tokenId}", tokenHandler.DeleteToken)
+	public.Post("/webhooks/clerk", accountHandler.ClerkWebhook)
 
-	return r
+	return r, nil
 }
@@ -0,0 +1,159 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/metrics"
+	"github.com/jimvid/sidekick/internal/openapi"
+)
+
+func TestCORS(t *testing.T) {
+	cfg := config.Default()
+	cfg.TableName = "test"
+	cfg.CORSOrigins = []string{"https://sidekick.example.com", "https://*.preview.example.com"}
+	r, err := NewRouter(cfg, metrics.Nop{})
+	if err != nil {
+		t.Fatalf("NewRouter failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{name: "exact origin", origin: "https://sidekick.example.com", allowed: true},
+		{name: "preview subdomain", origin: "https://pr-42.preview.example.com", allowed: true},
+		{name: "unknown origin", origin: "https://evil.example.com", allowed: false},
+		{name: "wrong scheme", origin: "http://sidekick.example.com", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/habits", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "authorization,content-type")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && got != tt.origin {
+				t.Errorf("expected origin %q to be allowed, got %q", tt.origin, got)
+			}
+			if !tt.allowed && got != "" {
+				t.Errorf("expected origin %q to be rejected, got %q", tt.origin, got)
+			}
+			if tt.allowed && w.Header().Get("Access-Control-Max-Age") != "300" {
+				t.Errorf("expected max age 300, got %q", w.Header().Get("Access-Control-Max-Age"))
+			}
+			if w.Header().Get("Access-Control-Allow-Credentials") != "" {
+				t.Error("expected credentials not to be allowed")
+			}
+		})
+	}
+}
+
+func TestNewRouterRejectsRateLimits(t *testing.T) {
+	cfg := config.Default()
+	cfg.TableName = "test"
+	cfg.RateLimits = []string{"*=lots"}
+
+	_, err := NewRouter(cfg, metrics.Nop{})
+	if err == nil || !strings.Contains(err.Error(), "RATE_LIMITS") {
+		t.Errorf("expected a RATE_LIMITS error, got %v", err)
+	}
+}
+
+func TestPublicRoutesRateLimited(t *testing.T) {
+	cfg := config.Default()
+	cfg.TableName = "test"
+	cfg.RateLimits = []string{"*=2/m"}
+	r, err := NewRouter(cfg, metrics.Nop{})
+	if err != nil {
+		t.Fatalf("NewRouter failed: %v", err)
+	}
+
+	codes := make([]int, 3)
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/clerk", strings.NewReader("{}"))
+		req.RemoteAddr = "203.0.113.7:1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes[i] = w.Code
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("expected the third request from one IP to be limited, got %v", codes)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.RemoteAddr = "198.51.100.1:1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("RateLimit-Limit") != "2" {
+		t.Errorf("expected another IP to have its own bucket, got headers %v", w.Header())
+	}
+}
+
+func TestFeaturesTurnOffRoutes(t *testing.T) {
+	cfg := config.Default()
+	cfg.TableName = "test"
+	cfg.Features = []string{config.FeatureReminders}
+	r, err := NewRouter(cfg, metrics.Nop{})
+	if err != nil {
+		t.Fatalf("NewRouter failed: %v", err)
+	}
+
+	for path, want := range map[string]bool{
+		"/reminders":             true,
+		"/me/webhooks":           false,
+		"/me/push-subscriptions": false,
+	} {
+		found := r.Match(chi.NewRouteContext(), http.MethodGet, path)
+		if found != want {
+			t.Errorf("expected GET %s mounted to be %v", path, want)
+		}
+	}
+}
+
+// TestRoutesDocumented fails when a route is added to or removed from the
+// router without updating openapi.Routes.
+func TestRoutesDocumented(t *testing.T) {
+	cfg := config.Default()
+	cfg.TableName = "test"
+	// The registry serves /metrics, which is documented
+	r, err := NewRouter(cfg, metrics.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewRouter failed: %v", err)
+	}
+
+	registered := map[string]bool{}
+	err = chi.Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		registered[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk routes: %v", err)
+	}
+
+	documented := map[string]bool{}
+	for _, route := range openapi.Routes {
+		documented[route.Method+" "+route.Path] = true
+	}
+
+	for route := range registered {
+		if !documented[route] {
+			t.Errorf("route %s is not in openapi.Routes", route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			t.Errorf("openapi.Routes documents %s, which the router does not serve", route)
+		}
+	}
+}
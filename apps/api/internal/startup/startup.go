@@ -0,0 +1,52 @@
+// Package startup sets up what every command needs before its work:
+// configuration, logging, tracing and a DynamoDB client.
+package startup
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/database"
+	"github.com/jimvid/sidekick/internal/logging"
+	"github.com/jimvid/sidekick/internal/tracing"
+)
+
+// Setup loads the configuration, logs at its level and starts tracing. It
+// exits when the configuration is invalid. Call the returned function
+// before the command returns to flush traces.
+func Setup() (*config.Config, func()) {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.SetDefault(logging.New(os.Stdout, slog.LevelInfo))
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// LOG_LEVEL is validated by config.Load
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	slog.SetDefault(logging.New(os.Stdout, level))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter: cfg.TraceExporter,
+		Writer:   os.Stdout,
+		Sync:     config.InLambda(),
+	})
+	if err != nil {
+		slog.Warn("Tracing disabled", "exporter", cfg.TraceExporter, "error", err)
+		return cfg, func() {}
+	}
+
+	return cfg, func() { shutdownTracing(context.Background()) }
+}
+
+// DynamoDB returns a traced client for the configured region and endpoint,
+// with optFns added, such as metrics.
+func DynamoDB(cfg *config.Config, optFns ...func(*dynamodb.Options)) *dynamodb.Client {
+	return database.NewDynamoDB(append([]func(*dynamodb.Options){
+		database.WithOverrides(cfg.DynamoDBRegion, cfg.DynamoDBEndpoint),
+		tracing.DynamoDBOptions(),
+	}, optFns...)...)
+}
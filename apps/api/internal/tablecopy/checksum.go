@@ -0,0 +1,86 @@
+package tablecopy
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Checksum is an order independent digest of a set of items, built by
+// XOR-ing the SHA-256 of each item's canonical encoding.
+type Checksum [sha256.Size]byte
+
+func (c *Checksum) Add(item map[string]types.AttributeValue) {
+	sum := sha256.Sum256([]byte(canonicalItem(item)))
+	for i := range c {
+		c[i] ^= sum[i]
+	}
+}
+
+func (c Checksum) String() string {
+	return fmt.Sprintf("%x", c[:8])
+}
+
+func canonicalItem(item map[string]types.AttributeValue) string {
+	keys := make([]string, 0, len(item))
+	for k := range item {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("{")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%q:%s,", k, canonicalValue(item[k]))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func canonicalValue(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return "S" + fmt.Sprintf("%q", v.Value)
+	case *types.AttributeValueMemberN:
+		return "N" + v.Value
+	case *types.AttributeValueMemberB:
+		return "B" + base64.StdEncoding.EncodeToString(v.Value)
+	case *types.AttributeValueMemberBOOL:
+		return fmt.Sprintf("BOOL%t", v.Value)
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return "SS" + sortedJoin(v.Value, func(s string) string { return fmt.Sprintf("%q", s) })
+	case *types.AttributeValueMemberNS:
+		return "NS" + sortedJoin(v.Value, func(s string) string { return s })
+	case *types.AttributeValueMemberBS:
+		encoded := make([]string, len(v.Value))
+		for i, b := range v.Value {
+			encoded[i] = base64.StdEncoding.EncodeToString(b)
+		}
+		return "BS" + sortedJoin(encoded, func(s string) string { return s })
+	case *types.AttributeValueMemberL:
+		parts := make([]string, len(v.Value))
+		for i, elem := range v.Value {
+			parts[i] = canonicalValue(elem)
+		}
+		return "L[" + strings.Join(parts, ",") + "]"
+	case *types.AttributeValueMemberM:
+		return "M" + canonicalItem(v.Value)
+	default:
+		return fmt.Sprintf("?%T", av)
+	}
+}
+
+func sortedJoin(values []string, format func(string) string) string {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = format(v)
+	}
+	sort.Strings(formatted)
+	return "[" + strings.Join(formatted, ",") + "]"
+}
@@ -0,0 +1,279 @@
+package tablecopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	batchWriteLimit         = 25
+	DefaultProgressInterval = 5 * time.Second
+)
+
+var ErrVerificationFailed = errors.New("target table does not match source table")
+
+// DynamoAPI is the subset of the DynamoDB client used by the copier.
+type DynamoAPI interface {
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+}
+
+type Options struct {
+	Source           string
+	Target           string
+	Segments         int
+	DryRun           bool
+	MaxRetries       int
+	InitialBackoff   time.Duration
+	ProgressInterval time.Duration
+}
+
+type Stats struct {
+	Scanned int64
+	Written int64
+	Retries int64
+}
+
+type Summary struct {
+	Count    int64
+	Checksum Checksum
+}
+
+type Copier struct {
+	db   DynamoAPI
+	opts Options
+}
+
+func NewCopier(db DynamoAPI, opts Options) *Copier {
+	if opts.Segments < 1 {
+		opts.Segments = 1
+	}
+	if opts.MaxRetries < 1 {
+		opts.MaxRetries = 8
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = 100 * time.Millisecond
+	}
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = DefaultProgressInterval
+	}
+
+	return &Copier{
+		db:   db,
+		opts: opts,
+	}
+}
+
+// Copy scans the source table in parallel segments and writes every item to
+// the target table. In dry-run mode items are scanned and counted only.
+func (c *Copier) Copy(ctx context.Context) (Stats, error) {
+	var stats Stats
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go c.reportProgress(done, &stats)
+	defer close(done)
+
+	var wg sync.WaitGroup
+	errs := make([]error, c.opts.Segments)
+	for segment := range c.opts.Segments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.copySegment(ctx, int32(segment), &stats)
+			if err != nil {
+				errs[segment] = fmt.Errorf("segment %d: %w", segment, err)
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	result := Stats{
+		Scanned: atomic.LoadInt64(&stats.Scanned),
+		Written: atomic.LoadInt64(&stats.Written),
+		Retries: atomic.LoadInt64(&stats.Retries),
+	}
+	slog.InfoContext(ctx, "Copy finished", "source", c.opts.Source, "target", c.opts.Target, "scanned", result.Scanned, "written", result.Written, "retries", result.Retries, "dryRun", c.opts.DryRun)
+
+	return result, errors.Join(errs...)
+}
+
+func (c *Copier) copySegment(ctx context.Context, segment int32, stats *Stats) error {
+	return c.scan(ctx, c.opts.Source, segment, false, func(items []map[string]types.AttributeValue) error {
+		atomic.AddInt64(&stats.Scanned, int64(len(items)))
+		if c.opts.DryRun {
+			return nil
+		}
+
+		for i := 0; i < len(items); i += batchWriteLimit {
+			end := min(i+batchWriteLimit, len(items))
+			requests := make([]types.WriteRequest, 0, end-i)
+			for _, item := range items[i:end] {
+				requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+			}
+
+			err := c.batchWrite(ctx, requests, stats)
+			if err != nil {
+				return err
+			}
+			atomic.AddInt64(&stats.Written, int64(len(requests)))
+		}
+		return nil
+	})
+}
+
+// scan reads one segment of table. A consistent scan sees every write
+// acknowledged before it started, at twice the read capacity.
+func (c *Copier) scan(ctx context.Context, table string, segment int32, consistent bool, handle func([]map[string]types.AttributeValue) error) error {
+	input := &dynamodb.ScanInput{
+		TableName:      &table,
+		ConsistentRead: aws.Bool(consistent),
+	}
+	if c.opts.Segments > 1 {
+		total := int32(c.opts.Segments)
+		input.Segment = &segment
+		input.TotalSegments = &total
+	}
+
+	for {
+		out, err := c.db.Scan(ctx, input)
+		if err != nil {
+			return fmt.Errorf("scan %s: %w", table, err)
+		}
+
+		err = handle(out.Items)
+		if err != nil {
+			return err
+		}
+
+		if out.LastEvaluatedKey == nil {
+			return nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// batchWrite writes up to 25 requests, retrying unprocessed items and
+// throttled or transient request errors with exponential backoff. Any other
+// error, such as a validation error or denied access, fails at once.
+func (c *Copier) batchWrite(ctx context.Context, requests []types.WriteRequest, stats *Stats) error {
+	pending := map[string][]types.WriteRequest{c.opts.Target: requests}
+	backoff := c.opts.InitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		out, err := c.db.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: pending})
+		if err == nil && len(out.UnprocessedItems) == 0 {
+			return nil
+		}
+		if err != nil && !retryable(err) {
+			return fmt.Errorf("batch write to %s: %w", c.opts.Target, err)
+		}
+		if err == nil {
+			pending = out.UnprocessedItems
+		}
+
+		if attempt == c.opts.MaxRetries {
+			if err != nil {
+				return fmt.Errorf("batch write to %s: %w", c.opts.Target, err)
+			}
+			return fmt.Errorf("batch write to %s: %d items still unprocessed after %d retries", c.opts.Target, len(pending[c.opts.Target]), attempt)
+		}
+
+		atomic.AddInt64(&stats.Retries, 1)
+		slog.DebugContext(ctx, "Retrying batch write", "attempt", attempt+1, "backoff", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+var (
+	throttles  = retry.IsErrorThrottles(retry.DefaultThrottles)
+	retryables = retry.IsErrorRetryables(retry.DefaultRetryables)
+)
+
+// retryable reports whether a failed request is worth sending again, by the
+// same rules the SDK's own retryer uses.
+func retryable(err error) bool {
+	return throttles.IsErrorThrottle(err).Bool() || retryables.IsErrorRetryable(err).Bool()
+}
+
+func (c *Copier) reportProgress(done <-chan struct{}, stats *Stats) {
+	ticker := time.NewTicker(c.opts.ProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			slog.Info("Copy progress", "scanned", atomic.LoadInt64(&stats.Scanned), "written", atomic.LoadInt64(&stats.Written), "retries", atomic.LoadInt64(&stats.Retries))
+		}
+	}
+}
+
+// Summarize scans a table and returns its item count and checksum. The scan
+// is consistent, so writes the copy just made are counted.
+func (c *Copier) Summarize(ctx context.Context, table string) (Summary, error) {
+	var mu sync.Mutex
+	var summary Summary
+
+	var wg sync.WaitGroup
+	errs := make([]error, c.opts.Segments)
+	for segment := range c.opts.Segments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[segment] = c.scan(ctx, table, int32(segment), true, func(items []map[string]types.AttributeValue) error {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, item := range items {
+					summary.Count++
+					summary.Checksum.Add(item)
+				}
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	return summary, errors.Join(errs...)
+}
+
+// Verify compares item counts and checksums of the source and target tables.
+func (c *Copier) Verify(ctx context.Context) (source, target Summary, err error) {
+	source, err = c.Summarize(ctx, c.opts.Source)
+	if err != nil {
+		return source, target, err
+	}
+
+	target, err = c.Summarize(ctx, c.opts.Target)
+	if err != nil {
+		return source, target, err
+	}
+
+	slog.InfoContext(ctx, "Verification", "sourceCount", source.Count, "sourceChecksum", source.Checksum.String(), "targetCount", target.Count, "targetChecksum", target.Checksum.String())
+
+	if source.Count != target.Count || source.Checksum != target.Checksum {
+		return source, target, ErrVerificationFailed
+	}
+
+	return source, target, nil
+}
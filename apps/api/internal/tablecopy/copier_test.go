@@ -0,0 +1,276 @@
+package tablecopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const (
+	testSourceTable = "test-copy-source"
+	testTargetTable = "test-copy-target"
+)
+
+func setupTestDB(t *testing.T) *dynamodb.Client {
+	t.Helper()
+
+	db, _ := dynamotest.NewTable(t, testSourceTable)
+	dynamotest.NewTable(t, testTargetTable)
+	return db
+}
+
+func seed(t *testing.T, db *dynamodb.Client, table string, count int) {
+	t.Helper()
+
+	for i := range count {
+		_, err := db.PutItem(context.Background(), &dynamodb.PutItemInput{
+			TableName: aws.String(table),
+			Item: map[string]types.AttributeValue{
+				"userId":    &types.AttributeValueMemberS{Value: fmt.Sprintf("user-%d", i%7)},
+				"itemId":    &types.AttributeValueMemberS{Value: fmt.Sprintf("habit#%03d", i)},
+				"Name":      &types.AttributeValueMemberS{Value: "Exercise"},
+				"CreatedAt": &types.AttributeValueMemberN{Value: "1700000000"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to seed item: %v", err)
+		}
+	}
+}
+
+func TestCopierCopy(t *testing.T) {
+	db := setupTestDB(t)
+	seed(t, db, testSourceTable, 80)
+
+	copier := NewCopier(db, Options{Source: testSourceTable, Target: testTargetTable, Segments: 3})
+
+	stats, err := copier.Copy(context.Background())
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if stats.Scanned != 80 || stats.Written != 80 {
+		t.Errorf("expected 80 scanned and written, got %+v", stats)
+	}
+
+	source, target, err := copier.Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if source.Count != 80 || target.Count != 80 {
+		t.Errorf("expected 80 items in both tables, got %d and %d", source.Count, target.Count)
+	}
+}
+
+func TestCopierDryRun(t *testing.T) {
+	db := setupTestDB(t)
+	seed(t, db, testSourceTable, 10)
+
+	copier := NewCopier(db, Options{Source: testSourceTable, Target: testTargetTable, DryRun: true})
+
+	stats, err := copier.Copy(context.Background())
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if stats.Scanned != 10 || stats.Written != 0 {
+		t.Errorf("expected 10 scanned and 0 written, got %+v", stats)
+	}
+
+	target, err := copier.Summarize(context.Background(), testTargetTable)
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if target.Count != 0 {
+		t.Errorf("expected empty target table, got %d items", target.Count)
+	}
+}
+
+func TestCopierVerifyDetectsMismatch(t *testing.T) {
+	db := setupTestDB(t)
+	seed(t, db, testSourceTable, 5)
+
+	copier := NewCopier(db, Options{Source: testSourceTable, Target: testTargetTable})
+	if _, err := copier.Copy(context.Background()); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	// Same count, different content
+	_, err := db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(testTargetTable),
+		Item: map[string]types.AttributeValue{
+			"userId":    &types.AttributeValueMemberS{Value: "user-0"},
+			"itemId":    &types.AttributeValueMemberS{Value: "habit#000"},
+			"Name":      &types.AttributeValueMemberS{Value: "Changed"},
+			"CreatedAt": &types.AttributeValueMemberN{Value: "1700000000"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to modify target: %v", err)
+	}
+
+	_, _, err = copier.Verify(context.Background())
+	if err != ErrVerificationFailed {
+		t.Fatalf("expected ErrVerificationFailed, got %v", err)
+	}
+}
+
+// flakyWriter returns the second half of every batch as unprocessed once.
+type flakyWriter struct {
+	DynamoAPI
+	calls int
+}
+
+func (f *flakyWriter) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	f.calls++
+	for table, requests := range params.RequestItems {
+		if f.calls%2 == 1 && len(requests) > 1 {
+			half := len(requests) / 2
+			out, err := f.DynamoAPI.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]types.WriteRequest{table: requests[:half]},
+			}, optFns...)
+			if err != nil {
+				return out, err
+			}
+			out.UnprocessedItems = map[string][]types.WriteRequest{table: requests[half:]}
+			return out, nil
+		}
+	}
+	return f.DynamoAPI.BatchWriteItem(ctx, params, optFns...)
+}
+
+func TestCopierRetriesUnprocessedItems(t *testing.T) {
+	db := setupTestDB(t)
+	seed(t, db, testSourceTable, 30)
+
+	writer := &flakyWriter{DynamoAPI: db}
+	copier := NewCopier(writer, Options{Source: testSourceTable, Target: testTargetTable, InitialBackoff: time.Millisecond})
+
+	stats, err := copier.Copy(context.Background())
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if stats.Retries == 0 {
+		t.Error("expected retries for unprocessed items")
+	}
+
+	if _, _, err := copier.Verify(context.Background()); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+}
+
+// failingWriter fails every batch write with err.
+type failingWriter struct {
+	DynamoAPI
+	err   error
+	calls int
+}
+
+func (f *failingWriter) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func TestCopierRetriesOnlyRetryableErrors(t *testing.T) {
+	db := setupTestDB(t)
+	seed(t, db, testSourceTable, 1)
+
+	for name, tc := range map[string]struct {
+		err   error
+		calls int
+	}{
+		"throttled":     {&types.ProvisionedThroughputExceededException{Message: aws.String("slow down")}, 3},
+		"request limit": {&types.RequestLimitExceeded{Message: aws.String("slow down")}, 3},
+		"validation":    {&smithy.GenericAPIError{Code: "ValidationException", Message: "bad item"}, 1},
+		"access denied": {&smithy.GenericAPIError{Code: "AccessDeniedException", Message: "no"}, 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			writer := &failingWriter{DynamoAPI: db, err: tc.err}
+			copier := NewCopier(writer, Options{Source: testSourceTable, Target: testTargetTable, MaxRetries: 2, InitialBackoff: time.Millisecond})
+
+			_, err := copier.Copy(context.Background())
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected the write error, got %v", err)
+			}
+			if writer.calls != tc.calls {
+				t.Errorf("expected %d calls, got %d", tc.calls, writer.calls)
+			}
+		})
+	}
+}
+
+// recordingScanner records whether each scan was consistent.
+type recordingScanner struct {
+	DynamoAPI
+	mu         sync.Mutex
+	consistent map[string][]bool
+}
+
+func (r *recordingScanner) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	r.mu.Lock()
+	r.consistent[*params.TableName] = append(r.consistent[*params.TableName], aws.ToBool(params.ConsistentRead))
+	r.mu.Unlock()
+	return r.DynamoAPI.Scan(ctx, params, optFns...)
+}
+
+func TestCopierVerifyScansConsistently(t *testing.T) {
+	db := setupTestDB(t)
+	seed(t, db, testSourceTable, 10)
+
+	scanner := &recordingScanner{DynamoAPI: db, consistent: map[string][]bool{}}
+	copier := NewCopier(scanner, Options{Source: testSourceTable, Target: testTargetTable, Segments: 2})
+
+	if _, err := copier.Copy(context.Background()); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	for _, consistent := range scanner.consistent[testSourceTable] {
+		if consistent {
+			t.Error("expected the copy to scan with eventual consistency")
+		}
+	}
+
+	scanner.consistent = map[string][]bool{}
+	if _, _, err := copier.Verify(context.Background()); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	for _, table := range []string{testSourceTable, testTargetTable} {
+		if len(scanner.consistent[table]) == 0 {
+			t.Fatalf("expected %s to be scanned", table)
+		}
+		for _, consistent := range scanner.consistent[table] {
+			if !consistent {
+				t.Errorf("expected every verify scan of %s to be consistent", table)
+			}
+		}
+	}
+}
+
+func TestChecksumOrderIndependent(t *testing.T) {
+	a := map[string]types.AttributeValue{
+		"userId": &types.AttributeValueMemberS{Value: "user-1"},
+		"Tags":   &types.AttributeValueMemberSS{Value: []string{"x", "y"}},
+	}
+	b := map[string]types.AttributeValue{
+		"userId": &types.AttributeValueMemberS{Value: "user-2"},
+		"Nested": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"n": &types.AttributeValueMemberN{Value: "1"},
+		}},
+	}
+
+	var first, second Checksum
+	first.Add(a)
+	first.Add(b)
+	second.Add(b)
+	second.Add(a)
+
+	if first != second {
+		t.Errorf("expected equal checksums, got %s and %s", first, second)
+	}
+}
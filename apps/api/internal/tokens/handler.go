@@ -0,0 +1,126 @@
+package tokens
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/request"
+	"github.com/jimvid/sidekick/internal/tracing"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+type TokenHandler struct {
+	service   *TokenService
+	getUserId func(r *http.Request) (string, error)
+}
+
+func NewTokenHandler(service *TokenService) *TokenHandler {
+	return &TokenHandler{
+		service:   service,
+		getUserId: user.GetUserId,
+	}
+}
+
+func (h *TokenHandler) writeErrorResponse(w http.ResponseWriter, r *http.Request, p problem.Problem) {
+	problem.Write(w, r, p)
+}
+
+func (h *TokenHandler) writeSuccessResponse(w http.ResponseWriter, r *http.Request, statusCode int, data any) {
+	_, span := tracing.Tracer().Start(r.Context(), "json.encode")
+	defer span.End()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *TokenHandler) GetTokens(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	tokens, err := h.service.GetTokens(r.Context(), userId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get API tokens", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get API tokens"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, tokens)
+}
+
+// CreateToken responds with the token itself, which cannot be read again.
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	var tokenReq TokenReq
+	err := request.DecodeJSON(w, r, &tokenReq)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := tokenReq.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	token, err := h.service.CreateToken(r.Context(), userId, tokenReq)
+	if err != nil {
+		if errors.Is(err, ErrTooManyTokens) {
+			h.writeErrorResponse(w, r, problem.BadRequest(fmt.Sprintf("Cannot have more than %d API tokens", MaxTokens)))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to create API token", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Could not create API token"))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "API token created", "tokenId", token.ID)
+	h.writeSuccessResponse(w, r, http.StatusCreated, token)
+}
+
+func (h *TokenHandler) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	tokenId := chi.URLParam(r, "tokenId")
+	if tokenId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	// An API token may revoke itself, as sidekick logout does, but no other
+	if current, ok := TokenIdFromContext(r.Context()); ok && current != tokenId {
+		h.writeErrorResponse(w, r, problem.Forbidden("API tokens can only revoke themselves"))
+		return
+	}
+
+	err = h.service.DeleteToken(r.Context(), userId, tokenId)
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find API token by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not delete API token", "error", err, "tokenId", tokenId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not delete API token"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, map[string]string{"message": "Successfully deleted API token"})
+}
@@ -0,0 +1,42 @@
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHandlerDeleteTokenWithAPIToken(t *testing.T) {
+	service := NewTokenService(setupTestDB(t))
+	handler := &TokenHandler{
+		service:   service,
+		getUserId: func(*http.Request) (string, error) { return "user-1", nil },
+	}
+	r := chi.NewRouter()
+	r.Delete("/me/tokens/{tokenId}", handler.DeleteToken)
+	ctx := context.Background()
+
+	laptop, _ := service.CreateToken(ctx, "user-1", TokenReq{Name: "Laptop"})
+	cli, _ := service.CreateToken(ctx, "user-1", TokenReq{Name: "CLI"})
+
+	deleteAs := func(tokenId, signedInWith string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/me/tokens/"+tokenId, nil)
+		req = req.WithContext(WithTokenId(req.Context(), signedInWith))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := deleteAs(laptop.ID, cli.ID); code != http.StatusForbidden {
+		t.Errorf("expected a token not to revoke another, got status %d", code)
+	}
+	if code := deleteAs(cli.ID, cli.ID); code != http.StatusOK {
+		t.Errorf("expected a token to revoke itself, got status %d", code)
+	}
+	if tokens, _ := service.GetTokens(ctx, "user-1"); len(tokens) != 1 || tokens[0].ID != laptop.ID {
+		t.Errorf("expected only the laptop token to be left, got %+v", tokens)
+	}
+}
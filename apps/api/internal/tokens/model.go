@@ -0,0 +1,75 @@
+package tokens
+
+import (
+	"strings"
+
+	"github.com/jimvid/sidekick/internal/problem"
+)
+
+// Prefix starts every personal API token, so AuthMiddleware can tell them
+// from Clerk session tokens without a lookup.
+const Prefix = "skpat_"
+
+// MaxTokens is how many personal API tokens a user may have.
+const MaxTokens = 20
+
+// MaxExpiresInDays is the longest lifetime a token can be created with.
+const MaxExpiresInDays = 365
+
+type tokenItem struct {
+	UserId string `json:"-" dynamodbav:"userId"`
+	ItemId string `json:"-" dynamodbav:"itemId"`
+	// Hash is the SHA-256 of the token, the token itself is never stored.
+	Hash string `json:"-" dynamodbav:"Hash"`
+	TokenModel
+}
+
+// lookupItem finds the owner of a token by its hash. It lives in its own
+// partition because the request only carries the token.
+type lookupItem struct {
+	UserId    string `dynamodbav:"userId"` // Always lookupPartition
+	ItemId    string `dynamodbav:"itemId"` // The token's hash
+	OwnerId   string `dynamodbav:"OwnerId"`
+	TokenId   string `dynamodbav:"TokenId"`
+	ExpiresAt int64  `dynamodbav:"ExpiresAt,omitempty"`
+}
+
+// TokenModel is a personal API token without its secret.
+type TokenModel struct {
+	ID   string `json:"id" dynamodbav:"ID"`
+	Name string `json:"name" dynamodbav:"Name"`
+	// Hint is the end of the token, to recognise it by.
+	Hint       string `json:"hint" dynamodbav:"Hint"`
+	CreatedAt  int64  `json:"createdAt" dynamodbav:"CreatedAt"`
+	LastUsedAt int64  `json:"lastUsedAt,omitempty" dynamodbav:"LastUsedAt,omitempty"`
+	// ExpiresAt is in unix seconds, left out when the token does not expire.
+	// The table's TTL removes the token after it.
+	ExpiresAt int64 `json:"expiresAt,omitempty" dynamodbav:"ExpiresAt,omitempty"`
+}
+
+// CreatedTokenModel is returned once when a token is created, it is the
+// only time the token can be read.
+type CreatedTokenModel struct {
+	TokenModel
+	Token string `json:"token"`
+}
+
+type TokenReq struct {
+	Name string `json:"name"`
+	// ExpiresInDays is left out for a token that does not expire.
+	ExpiresInDays int `json:"expiresInDays,omitempty"`
+}
+
+func (r TokenReq) validate() []problem.InvalidParam {
+	var params []problem.InvalidParam
+
+	name := strings.TrimSpace(r.Name)
+	if name == "" || len(name) > 100 {
+		params = append(params, problem.InvalidParam{Name: "name", Reason: "Name must be between 1 and 100 characters"})
+	}
+	if r.ExpiresInDays < 0 || r.ExpiresInDays > MaxExpiresInDays {
+		params = append(params, problem.InvalidParam{Name: "expiresInDays", Reason: "ExpiresInDays must be between 1 and 365, or left out"})
+	}
+
+	return params
+}
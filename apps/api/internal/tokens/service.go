@@ -0,0 +1,157 @@
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jimvid/sidekick/internal/tracing"
+)
+
+var (
+	ErrTooManyTokens = errors.New("too many API tokens")
+	// ErrInvalidToken means the token is unknown, revoked or expired.
+	ErrInvalidToken = errors.New("invalid API token")
+)
+
+// touchInterval is how stale LastUsedAt may get, so that a busy token is
+// not written on every request.
+const touchInterval = time.Hour
+
+type TokenService struct {
+	storage *TokenStorage
+	now     func() time.Time
+}
+
+func NewTokenService(storage *TokenStorage) *TokenService {
+	return &TokenService{
+		storage: storage,
+		now:     time.Now,
+	}
+}
+
+type contextKey struct{}
+
+// WithTokenId marks the request context as signed in with the personal API
+// token tokenId.
+func WithTokenId(ctx context.Context, tokenId string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tokenId)
+}
+
+// TokenIdFromContext returns the ID of the personal API token the request
+// was signed in with, false for a Clerk session.
+func TokenIdFromContext(ctx context.Context) (string, bool) {
+	tokenId, ok := ctx.Value(contextKey{}).(string)
+	return tokenId, ok
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateToken makes a new token for the user. The token is only returned
+// here, just its hash is stored.
+func (s *TokenService) CreateToken(ctx context.Context, userId string, req TokenReq) (CreatedTokenModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TokenService.CreateToken")
+	defer span.End()
+
+	existing, err := s.storage.GetTokens(ctx, userId)
+	if err != nil {
+		return CreatedTokenModel{}, err
+	}
+	if len(existing) >= MaxTokens {
+		return CreatedTokenModel{}, ErrTooManyTokens
+	}
+
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return CreatedTokenModel{}, err
+	}
+	value := Prefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	now := s.now()
+	token := TokenModel{
+		ID:        uuid.Must(uuid.NewV7()).String(),
+		Name:      strings.TrimSpace(req.Name),
+		Hint:      value[len(value)-4:],
+		CreatedAt: now.Unix(),
+	}
+	if req.ExpiresInDays > 0 {
+		token.ExpiresAt = now.AddDate(0, 0, req.ExpiresInDays).Unix()
+	}
+
+	err = s.storage.PutToken(ctx, userId, token, hashToken(value))
+	if err != nil {
+		return CreatedTokenModel{}, err
+	}
+
+	return CreatedTokenModel{TokenModel: token, Token: value}, nil
+}
+
+func (s *TokenService) GetTokens(ctx context.Context, userId string) ([]TokenModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TokenService.GetTokens")
+	defer span.End()
+
+	return s.storage.GetTokens(ctx, userId)
+}
+
+func (s *TokenService) DeleteToken(ctx context.Context, userId, tokenId string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "TokenService.DeleteToken")
+	defer span.End()
+
+	return s.storage.DeleteToken(ctx, userId, tokenId)
+}
+
+// EraseUser removes the lookup items of the user's tokens, which account
+// erasure cannot find in the user's partition. It implements
+// account.Eraser.
+func (s *TokenService) EraseUser(ctx context.Context, userId string) (int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TokenService.EraseUser")
+	defer span.End()
+
+	return s.storage.DeleteLookups(ctx, userId)
+}
+
+// Verify returns the ID of the user the token belongs to and the token's
+// ID. It implements middleware.TokenVerifier.
+func (s *TokenService) Verify(ctx context.Context, value string) (string, string, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TokenService.Verify")
+	defer span.End()
+
+	if !strings.HasPrefix(value, Prefix) {
+		return "", "", ErrInvalidToken
+	}
+
+	userId, token, err := s.storage.FindTokenByHash(ctx, hashToken(value))
+	if errors.Is(err, ErrTokenNotFound) {
+		return "", "", ErrInvalidToken
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	now := s.now()
+	// The table's TTL can take a while to remove an expired token
+	if token.ExpiresAt != 0 && now.Unix() >= token.ExpiresAt {
+		return "", "", ErrInvalidToken
+	}
+
+	if now.Sub(time.Unix(token.LastUsedAt, 0)) >= touchInterval {
+		err = s.storage.TouchToken(ctx, userId, token.ID, now.Unix())
+		if err != nil {
+			// Not worth failing the request over
+			slog.WarnContext(ctx, "Failed to record API token use", "error", err, "tokenId", token.ID)
+		}
+	}
+
+	return userId, token.ID, nil
+}
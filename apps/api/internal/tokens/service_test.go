@@ -0,0 +1,121 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestServiceCreateAndVerify(t *testing.T) {
+	service := NewTokenService(setupTestDB(t))
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	created, err := service.CreateToken(ctx, "user-1", TokenReq{Name: " CLI ", ExpiresInDays: 30})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if !strings.HasPrefix(created.Token, Prefix) || !strings.HasSuffix(created.Token, created.Hint) {
+		t.Errorf("unexpected token %q with hint %q", created.Token, created.Hint)
+	}
+	if created.Name != "CLI" || created.ExpiresAt != now.AddDate(0, 0, 30).Unix() {
+		t.Errorf("unexpected token %+v", created.TokenModel)
+	}
+
+	userId, tokenId, err := service.Verify(ctx, created.Token)
+	if err != nil || userId != "user-1" || tokenId != created.ID {
+		t.Fatalf("expected the token to belong to user-1, got %q %q %v", userId, tokenId, err)
+	}
+	tokens, _ := service.GetTokens(ctx, "user-1")
+	if len(tokens) != 1 || tokens[0].LastUsedAt != now.Unix() {
+		t.Errorf("expected the use to be recorded, got %+v", tokens)
+	}
+
+	for name, token := range map[string]string{
+		"session token": "eyJhbGciOiJSUzI1NiJ9.e30.sig",
+		"unknown token": Prefix + "unknown",
+		"wrong secret":  created.Token[:len(created.Token)-1] + "x",
+	} {
+		if _, _, err := service.Verify(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+
+	now = now.AddDate(0, 0, 30)
+	if _, _, err := service.Verify(ctx, created.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected an expired token to be rejected, got %v", err)
+	}
+}
+
+func TestServiceDeleteRevokes(t *testing.T) {
+	service := NewTokenService(setupTestDB(t))
+	ctx := context.Background()
+
+	created, err := service.CreateToken(ctx, "user-1", TokenReq{Name: "CLI"})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if created.ExpiresAt != 0 {
+		t.Errorf("expected a token that does not expire, got %d", created.ExpiresAt)
+	}
+
+	if err := service.DeleteToken(ctx, "user-2", created.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected another user not to delete the token, got %v", err)
+	}
+	if err := service.DeleteToken(ctx, "user-1", created.ID); err != nil {
+		t.Fatalf("DeleteToken failed: %v", err)
+	}
+	if _, _, err := service.Verify(ctx, created.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestServiceMaxTokens(t *testing.T) {
+	service := NewTokenService(setupTestDB(t))
+	ctx := context.Background()
+
+	for range MaxTokens {
+		if _, err := service.CreateToken(ctx, "user-1", TokenReq{Name: "CLI"}); err != nil {
+			t.Fatalf("CreateToken failed: %v", err)
+		}
+	}
+	if _, err := service.CreateToken(ctx, "user-1", TokenReq{Name: "CLI"}); !errors.Is(err, ErrTooManyTokens) {
+		t.Errorf("expected ErrTooManyTokens, got %v", err)
+	}
+}
+
+func TestServiceEraseUser(t *testing.T) {
+	service := NewTokenService(setupTestDB(t))
+	ctx := context.Background()
+
+	created, err := service.CreateToken(ctx, "user-1", TokenReq{Name: "CLI"})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	other, _ := service.CreateToken(ctx, "user-2", TokenReq{Name: "CLI"})
+
+	erased, err := service.EraseUser(ctx, "user-1")
+	if err != nil || erased != 1 {
+		t.Fatalf("expected one lookup erased, got %d: %v", erased, err)
+	}
+	result, err := service.storage.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(testTableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: lookupPartition},
+			"itemId": &types.AttributeValueMemberS{Value: hashToken(created.Token)},
+		},
+	})
+	if err != nil || result.Item != nil {
+		t.Errorf("expected the lookup to be removed, got %v %v", result.Item, err)
+	}
+	if _, _, err := service.Verify(ctx, other.Token); err != nil {
+		t.Errorf("expected other users' tokens to keep working, got %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/config"
+)
+
+const (
+	itemPrefixToken = "api-token#"
+	lookupPartition = "api-tokens"
+)
+
+var ErrTokenNotFound = errors.New("could not find an API token with that ID")
+
+type TokenStorage struct {
+	db  *dynamodb.Client
+	cfg *config.Config
+}
+
+func NewTokenStorage(db *dynamodb.Client, cfg *config.Config) *TokenStorage {
+	return &TokenStorage{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// PutToken saves the token and its lookup item together, so a token can
+// never be found without its owner or the other way around.
+func (s *TokenStorage) PutToken(ctx context.Context, userId string, token TokenModel, hash string) error {
+	tokenValue, err := attributevalue.MarshalMap(tokenItem{
+		UserId:     userId,
+		ItemId:     itemPrefixToken + token.ID,
+		Hash:       hash,
+		TokenModel: token,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal API token", "error", err)
+		return err
+	}
+
+	lookupValue, err := attributevalue.MarshalMap(lookupItem{
+		UserId:    lookupPartition,
+		ItemId:    hash,
+		OwnerId:   userId,
+		TokenId:   token.ID,
+		ExpiresAt: token.ExpiresAt,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal API token lookup", "error", err)
+		return err
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{TableName: aws.String(s.cfg.TableName), Item: tokenValue}},
+			{Put: &types.Put{TableName: aws.String(s.cfg.TableName), Item: lookupValue}},
+		},
+	}
+
+	_, err = s.db.TransactWriteItems(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB TransactWriteItems failed", "error", err, "tokenId", token.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (s *TokenStorage) GetTokens(ctx context.Context, userId string) ([]TokenModel, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixToken},
+		},
+	}
+
+	result, err := s.db.Query(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err)
+		return nil, err
+	}
+
+	var items []tokenItem
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal API tokens", "error", err)
+		return nil, err
+	}
+
+	tokens := make([]TokenModel, len(items))
+	for i, item := range items {
+		tokens[i] = item.TokenModel
+	}
+
+	return tokens, nil
+}
+
+// FindTokenByHash returns the owner and the token with the given hash. A
+// lookup item whose token is gone, because the token was revoked or its
+// owner deleted their account, is removed and reported as not found.
+func (s *TokenStorage) FindTokenByHash(ctx context.Context, hash string) (string, TokenModel, error) {
+	result, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: lookupPartition},
+			"itemId": &types.AttributeValueMemberS{Value: hash},
+		},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err)
+		return "", TokenModel{}, err
+	}
+	if result.Item == nil {
+		return "", TokenModel{}, ErrTokenNotFound
+	}
+
+	var lookup lookupItem
+	err = attributevalue.UnmarshalMap(result.Item, &lookup)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal API token lookup", "error", err)
+		return "", TokenModel{}, err
+	}
+
+	result, err = s.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: lookup.OwnerId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixToken + lookup.TokenId},
+		},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err, "tokenId", lookup.TokenId)
+		return "", TokenModel{}, err
+	}
+	if result.Item == nil {
+		s.deleteLookup(ctx, hash)
+		return "", TokenModel{}, ErrTokenNotFound
+	}
+
+	var item tokenItem
+	err = attributevalue.UnmarshalMap(result.Item, &item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal API token", "error", err)
+		return "", TokenModel{}, err
+	}
+	if item.Hash != hash {
+		return "", TokenModel{}, ErrTokenNotFound
+	}
+
+	return lookup.OwnerId, item.TokenModel, nil
+}
+
+// TouchToken records when the token was last used.
+func (s *TokenStorage) TouchToken(ctx context.Context, userId, tokenId string, at int64) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixToken + tokenId},
+		},
+		UpdateExpression:    aws.String("SET LastUsedAt = :at"),
+		ConditionExpression: aws.String("attribute_exists(itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":at": &types.AttributeValueMemberN{Value: strconv.FormatInt(at, 10)},
+		},
+	}
+
+	_, err := s.db.UpdateItem(ctx, input)
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return ErrTokenNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB UpdateItem failed", "error", err, "tokenId", tokenId)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteToken revokes the token. Its lookup item is removed after it, one
+// left behind is cleaned up by FindTokenByHash.
+func (s *TokenStorage) DeleteToken(ctx context.Context, userId, tokenId string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixToken + tokenId},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	}
+
+	result, err := s.db.DeleteItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB DeleteItem failed", "error", err, "tokenId", tokenId)
+		return err
+	}
+
+	if result.Attributes == nil {
+		return ErrTokenNotFound
+	}
+
+	var item tokenItem
+	err = attributevalue.UnmarshalMap(result.Attributes, &item)
+	if err == nil {
+		s.deleteLookup(ctx, item.Hash)
+	}
+
+	slog.InfoContext(ctx, "API token deleted", "tokenId", tokenId)
+	return nil
+}
+
+// DeleteLookups removes the lookup items of every token of the user, which
+// live outside their partition, and returns how many there were. The
+// tokens are left for the account erasure deleting the partition.
+func (s *TokenStorage) DeleteLookups(ctx context.Context, userId string) (int, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
+		ProjectionExpression:   aws.String("Hash"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixToken},
+		},
+	}
+
+	result, err := s.db.Query(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err)
+		return 0, err
+	}
+
+	var items []tokenItem
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal API tokens", "error", err)
+		return 0, err
+	}
+
+	for i, item := range items {
+		if err := s.deleteLookup(ctx, item.Hash); err != nil {
+			return i, err
+		}
+	}
+
+	return len(items), nil
+}
+
+func (s *TokenStorage) deleteLookup(ctx context.Context, hash string) error {
+	_, err := s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: lookupPartition},
+			"itemId": &types.AttributeValueMemberS{Value: hash},
+		},
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to delete API token lookup", "error", err)
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-tokens"
+
+func setupTestDB(t *testing.T) *TokenStorage {
+	t.Helper()
+
+	return NewTokenStorage(dynamotest.NewTable(t, testTableName))
+}
+
+func TestStorageTokens(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	laptop := TokenModel{ID: "laptop", Name: "Laptop", Hint: "abcd", CreatedAt: 1}
+	if err := storage.PutToken(ctx, "user-1", laptop, "hash-1"); err != nil {
+		t.Fatalf("PutToken failed: %v", err)
+	}
+
+	tokens, err := storage.GetTokens(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetTokens failed: %v", err)
+	}
+	if !reflect.DeepEqual(tokens, []TokenModel{laptop}) {
+		t.Errorf("expected %+v, got %+v", laptop, tokens)
+	}
+
+	userId, found, err := storage.FindTokenByHash(ctx, "hash-1")
+	if err != nil || userId != "user-1" || found.ID != "laptop" {
+		t.Errorf("expected the laptop token of user-1, got %q %+v %v", userId, found, err)
+	}
+
+	if err := storage.TouchToken(ctx, "user-1", "laptop", 5); err != nil {
+		t.Fatalf("TouchToken failed: %v", err)
+	}
+	_, found, _ = storage.FindTokenByHash(ctx, "hash-1")
+	if found.LastUsedAt != 5 {
+		t.Errorf("expected LastUsedAt 5, got %d", found.LastUsedAt)
+	}
+
+	if err := storage.DeleteToken(ctx, "user-1", "laptop"); err != nil {
+		t.Fatalf("DeleteToken failed: %v", err)
+	}
+	if err := storage.DeleteToken(ctx, "user-1", "laptop"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound for a deleted token, got %v", err)
+	}
+	if _, _, err := storage.FindTokenByHash(ctx, "hash-1"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected a deleted token not to be found, got %v", err)
+	}
+	if err := storage.TouchToken(ctx, "user-1", "laptop", 6); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected TouchToken not to recreate a deleted token, got %v", err)
+	}
+}
+
+// Deleting an account removes the user's items but not the lookup items,
+// which must then not sign anyone in.
+func TestStorageFindOrphanedLookup(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	if err := storage.PutToken(ctx, "user-1", TokenModel{ID: "laptop"}, "hash-1"); err != nil {
+		t.Fatalf("PutToken failed: %v", err)
+	}
+	_, err := storage.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(testTableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: "user-1"},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixToken + "laptop"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DeleteItem failed: %v", err)
+	}
+
+	if _, _, err := storage.FindTokenByHash(ctx, "hash-1"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound, got %v", err)
+	}
+
+	result, err := storage.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(testTableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: lookupPartition},
+			"itemId": &types.AttributeValueMemberS{Value: "hash-1"},
+		},
+	})
+	if err != nil || result.Item != nil {
+		t.Errorf("expected the orphaned lookup to be removed, got %v %v", result.Item, err)
+	}
+}
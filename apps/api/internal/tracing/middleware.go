@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/jimvid/sidekick/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTPMiddleware starts a server span for every request, continuing the trace
+// from an incoming traceparent header. The span is named after the chi route
+// pattern once routing has finished, and the trace ID is added to the
+// request's log attributes.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			attr := slog.String("traceId", sc.TraceID().String())
+			logging.AddAccessAttrs(ctx, attr)
+			ctx = logging.WithAttrs(ctx, attr)
+		}
+
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+		}
+	})
+}
+
+// DynamoDBOptions creates a client span around every call made by a DynamoDB
+// client.
+func DynamoDBOptions() func(*dynamodb.Options) {
+	return func(o *dynamodb.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("SidekickTracing", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				operation := awsmiddleware.GetOperationName(ctx)
+
+				ctx, span := Tracer().Start(ctx, "DynamoDB."+operation,
+					trace.WithSpanKind(trace.SpanKindClient),
+					trace.WithAttributes(
+						semconv.DBSystemDynamoDB,
+						semconv.RPCSystemKey.String("aws-api"),
+						semconv.RPCService("DynamoDB"),
+						semconv.RPCMethod(operation),
+					),
+				)
+				defer span.End()
+
+				if table := tableName(in.Parameters); table != "" {
+					span.SetAttributes(semconv.AWSDynamoDBTableNames(table))
+				}
+
+				out, metadata, err := next.HandleInitialize(ctx, in)
+				if err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
+				}
+
+				return out, metadata, err
+			}), middleware.After)
+		})
+	}
+}
+
+func tableName(params any) string {
+	var table *string
+
+	switch in := params.(type) {
+	case *dynamodb.GetItemInput:
+		table = in.TableName
+	case *dynamodb.PutItemInput:
+		table = in.TableName
+	case *dynamodb.DeleteItemInput:
+		table = in.TableName
+	case *dynamodb.UpdateItemInput:
+		table = in.TableName
+	case *dynamodb.QueryInput:
+		table = in.TableName
+	case *dynamodb.ScanInput:
+		table = in.TableName
+	case *dynamodb.DescribeTableInput:
+		table = in.TableName
+	}
+
+	if table == nil {
+		return ""
+	}
+	return *table
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+	})
+
+	return recorder
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	r := chi.NewRouter()
+	r.Use(HTTPMiddleware)
+	r.Get("/habits/{habitId}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Tracer().Start(r.Context(), "child")
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/habits/abc", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	child, server := spans[0], spans[1]
+	if server.Name() != "GET /habits/{habitId}" {
+		t.Errorf("expected span named after the route, got %q", server.Name())
+	}
+	if server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("expected server span, got %v", server.SpanKind())
+	}
+	if server.Parent().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || !server.Parent().IsRemote() {
+		t.Errorf("expected trace to continue from traceparent, got parent %v", server.Parent())
+	}
+	if server.Status().Code != codes.Error {
+		t.Errorf("expected error status for a 500, got %v", server.Status())
+	}
+	if child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("expected handler span to be a child of the server span")
+	}
+}
+
+func TestDynamoDBOptions(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	db := dynamodb.New(dynamodb.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String("http://localhost:8000"),
+		Credentials:  credentials.NewStaticCredentialsProvider("fake", "fake", ""),
+	}, DynamoDBOptions())
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("does-not-exist"),
+		Key:       map[string]types.AttributeValue{"userId": &types.AttributeValueMemberS{Value: "user-1"}},
+	})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	call := spans[0]
+	if call.Name() != "DynamoDB.GetItem" {
+		t.Errorf("expected DynamoDB.GetItem span, got %q", call.Name())
+	}
+	if call.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("expected DynamoDB span to be a child of the caller's span")
+	}
+	if call.Status().Code != codes.Error {
+		t.Errorf("expected failed call to be marked as an error, got %v", call.Status())
+	}
+}
+
+func TestSetupUnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), Options{Exporter: "zipkin"})
+	if err == nil {
+		t.Fatal("expected an error for an unknown exporter")
+	}
+}
@@ -0,0 +1,173 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/request"
+	"github.com/jimvid/sidekick/internal/tracing"
+)
+
+type UserHandler struct {
+	service   *UserService
+	getUserId func(r *http.Request) (string, error)
+}
+
+func NewUserHandler(service *UserService) *UserHandler {
+	return &UserHandler{
+		service:   service,
+		getUserId: GetUserId,
+	}
+}
+
+func (h *UserHandler) writeErrorResponse(w http.ResponseWriter, r *http.Request, p problem.Problem) {
+	problem.Write(w, r, p)
+}
+
+func (h *UserHandler) writeSuccessResponse(w http.ResponseWriter, r *http.Request, statusCode int, data any) {
+	_, span := tracing.Tracer().Start(r.Context(), "json.encode")
+	defer span.End()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	found, err := h.service.GetUser(r.Context(), userId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get user", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get user"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, found)
+}
+
+func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	profile, err := h.service.GetProfile(r.Context(), userId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get profile", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get profile"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, profile)
+}
+
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var profileReq ProfileReq
+	err := request.DecodeJSON(w, r, &profileReq)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := profileReq.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(r.Context(), userId, profileReq)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to update profile", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Could not update profile"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, profile)
+}
+
+func (h *UserHandler) CompleteOnboardingStep(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	step := chi.URLParam(r, "step")
+	profile, err := h.service.CompleteOnboardingStep(r.Context(), userId, step)
+	if err != nil {
+		if errors.Is(err, ErrUnknownOnboardingStep) {
+			h.writeErrorResponse(w, r, problem.Validation([]problem.InvalidParam{
+				{Name: "step", Reason: fmt.Sprintf("Step must be one of %s", strings.Join(OnboardingSteps, ", "))},
+			}))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to complete onboarding step", "error", err, "step", step)
+		h.writeErrorResponse(w, r, problem.Internal("Could not complete onboarding step"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, profile)
+}
+
+func (h *UserHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	settings, err := h.service.GetSettings(r.Context(), userId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get settings", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get settings"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, settings)
+}
+
+func (h *UserHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var settingsReq SettingsReq
+	err := request.DecodeJSON(w, r, &settingsReq)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := settingsReq.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(r.Context(), userId, settingsReq)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to update settings", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Could not update settings"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, settings)
+}
@@ -0,0 +1,210 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/problem"
+)
+
+const testUserId = "test-user-1"
+
+func setupHandler(t *testing.T) (*UserHandler, *chi.Mux) {
+	t.Helper()
+
+	storage := setupTestDB(t)
+	service := NewUserService(storage)
+	handler := &UserHandler{
+		service: service,
+		getUserId: func(r *http.Request) (string, error) {
+			return testUserId, nil
+		},
+	}
+
+	r := chi.NewRouter()
+	r.Get("/me", handler.GetMe)
+	r.Get("/me/profile", handler.GetProfile)
+	r.Put("/me/profile", handler.UpdateProfile)
+	r.Put("/me/onboarding/{step}", handler.CompleteOnboardingStep)
+	r.Get("/me/settings", handler.GetSettings)
+	r.Put("/me/settings", handler.UpdateSettings)
+
+	return handler, r
+}
+
+func TestHandlerSettings(t *testing.T) {
+	_, router := setupHandler(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me/settings", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var settings SettingsModel
+		json.NewDecoder(w.Body).Decode(&settings)
+		if settings != DefaultSettings() {
+			t.Errorf("expected defaults, got %+v", settings)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		body := `{"timeZone":"Europe/Stockholm","weekStart":"sunday","locale":"sv-SE"}`
+		req := httptest.NewRequest(http.MethodPut, "/me/settings", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/me/settings", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var settings SettingsModel
+		json.NewDecoder(w.Body).Decode(&settings)
+		if settings.TimeZone != "Europe/Stockholm" || settings.WeekStart != "sunday" || settings.Locale != "sv-SE" || settings.UpdatedAt == 0 {
+			t.Errorf("expected the saved settings, got %+v", settings)
+		}
+	})
+
+	t.Run("empty fields reset", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/me/settings", strings.NewReader(`{"timeZone":"Asia/Tokyo"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		var settings SettingsModel
+		json.NewDecoder(w.Body).Decode(&settings)
+		if settings.TimeZone != "Asia/Tokyo" || settings.WeekStart != DefaultWeekStart || settings.Locale != DefaultLocale {
+			t.Errorf("expected Asia/Tokyo with default week start and locale, got %+v", settings)
+		}
+	})
+
+	t.Run("validation failed", func(t *testing.T) {
+		tests := []struct {
+			body  string
+			param string
+		}{
+			{body: `{"timeZone":"Mars/Olympus"}`, param: "timeZone"},
+			{body: `{"timeZone":"Local"}`, param: "timeZone"},
+			{body: `{"weekStart":"Monday"}`, param: "weekStart"},
+			{body: `{"locale":"english please"}`, param: "locale"},
+		}
+
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodPut, "/me/settings", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("%s: expected status %d, got %d", tt.body, http.StatusBadRequest, w.Code)
+			}
+
+			var body problem.Problem
+			json.NewDecoder(w.Body).Decode(&body)
+			if len(body.InvalidParams) != 1 || body.InvalidParams[0].Name != tt.param {
+				t.Errorf("%s: expected invalid %s, got %+v", tt.body, tt.param, body.InvalidParams)
+			}
+		}
+	})
+}
+
+func TestHandlerProfile(t *testing.T) {
+	_, router := setupHandler(t)
+
+	t.Run("update", func(t *testing.T) {
+		body := `{"displayName":"Jim","notifications":{"reminders":true,"streakAlerts":false,"push":true,"email":false}}`
+		req := httptest.NewRequest(http.MethodPut, "/me/profile", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/me/profile", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var profile ProfileModel
+		json.NewDecoder(w.Body).Decode(&profile)
+		if profile.DisplayName != "Jim" || profile.Notifications.StreakAlerts {
+			t.Errorf("expected the saved profile, got %+v", profile)
+		}
+	})
+
+	t.Run("display name too long", func(t *testing.T) {
+		body := `{"displayName":"` + strings.Repeat("a", 51) + `"}`
+		req := httptest.NewRequest(http.MethodPut, "/me/profile", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("onboarding step", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/me/onboarding/log-habit", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var profile ProfileModel
+		json.NewDecoder(w.Body).Decode(&profile)
+		if len(profile.Onboarding.CompletedSteps) != 1 || profile.Onboarding.CompletedSteps[0] != "log-habit" {
+			t.Errorf("expected log-habit to be done, got %+v", profile.Onboarding)
+		}
+	})
+
+	t.Run("unknown onboarding step", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/me/onboarding/fly", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("me", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var found UserModel
+		json.NewDecoder(w.Body).Decode(&found)
+		if found.ID != testUserId || found.Profile.DisplayName != "Jim" || found.Settings != DefaultSettings() {
+			t.Errorf("expected the profile with default settings, got %+v", found)
+		}
+	})
+}
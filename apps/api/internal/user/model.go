@@ -0,0 +1,228 @@
+package user
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+	// Lambda images do not ship a zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/jimvid/sidekick/internal/problem"
+)
+
+const (
+	DefaultTimeZone  = "UTC"
+	DefaultWeekStart = "monday"
+	DefaultLocale    = "en-US"
+)
+
+type profileItem struct {
+	UserId string `dynamodbav:"userId"`
+	ItemId string `dynamodbav:"itemId"`
+	ProfileModel
+}
+
+// ProfileModel is what the app knows about a user beyond their Clerk account.
+type ProfileModel struct {
+	DisplayName   string                  `json:"displayName" dynamodbav:"DisplayName"`
+	Onboarding    OnboardingModel         `json:"onboarding" dynamodbav:"Onboarding"`
+	Notifications NotificationPreferences `json:"notifications" dynamodbav:"Notifications"`
+	CreatedAt     int64                   `json:"createdAt" dynamodbav:"CreatedAt"`
+	UpdatedAt     int64                   `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// OnboardingModel tracks the steps of the first run guide.
+type OnboardingModel struct {
+	CompletedSteps []string `json:"completedSteps" dynamodbav:"CompletedSteps"`
+	// CompletedAt is set once every step in OnboardingSteps is done.
+	CompletedAt int64 `json:"completedAt,omitempty" dynamodbav:"CompletedAt,omitempty"`
+}
+
+// NotificationPreferences says what a user wants to hear about and where.
+type NotificationPreferences struct {
+	Reminders bool `json:"reminders" dynamodbav:"Reminders"`
+	// StreakAlerts makes a reminder say when it is the last chance to keep a
+	// streak going. With Reminders off, only those reminders are sent.
+	StreakAlerts bool `json:"streakAlerts" dynamodbav:"StreakAlerts"`
+	Push         bool `json:"push" dynamodbav:"Push"`
+	Email        bool `json:"email" dynamodbav:"Email"`
+}
+
+type ProfileReq struct {
+	DisplayName   string                  `json:"displayName"`
+	Notifications NotificationPreferences `json:"notifications"`
+}
+
+// UserModel is everything stored about the signed in user.
+type UserModel struct {
+	ID       string        `json:"id"`
+	Profile  ProfileModel  `json:"profile"`
+	Settings SettingsModel `json:"settings"`
+}
+
+// OnboardingSteps are the steps of the first run guide, in order.
+var OnboardingSteps = []string{"create-habit", "log-habit", "set-time-zone", "enable-notifications"}
+
+// DefaultProfile is used until a user saves their own.
+func DefaultProfile() ProfileModel {
+	return ProfileModel{
+		Onboarding: OnboardingModel{CompletedSteps: []string{}},
+		Notifications: NotificationPreferences{
+			Reminders:    true,
+			StreakAlerts: true,
+			Push:         true,
+		},
+	}
+}
+
+// CompleteStep marks an onboarding step done and reports whether it was new.
+func (o *OnboardingModel) CompleteStep(step string, now int64) bool {
+	if slices.Contains(o.CompletedSteps, step) {
+		return false
+	}
+	o.CompletedSteps = append(o.CompletedSteps, step)
+
+	done := true
+	for _, s := range OnboardingSteps {
+		done = done && slices.Contains(o.CompletedSteps, s)
+	}
+	if done && o.CompletedAt == 0 {
+		o.CompletedAt = now
+	}
+	return true
+}
+
+type settingsItem struct {
+	UserId string `dynamodbav:"userId"`
+	ItemId string `dynamodbav:"itemId"`
+	SettingsModel
+}
+
+// SettingsModel holds the preferences dates are computed with. Dates in the
+// API are days in TimeZone, and weeks start on WeekStart.
+type SettingsModel struct {
+	TimeZone  string `json:"timeZone" dynamodbav:"TimeZone"`
+	WeekStart string `json:"weekStart" dynamodbav:"WeekStart"`
+	Locale    string `json:"locale" dynamodbav:"Locale"`
+	UpdatedAt int64  `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// SettingsReq replaces the settings. Empty fields reset to the defaults.
+type SettingsReq struct {
+	TimeZone  string `json:"timeZone"`
+	WeekStart string `json:"weekStart"`
+	Locale    string `json:"locale"`
+}
+
+// DefaultSettings are used until a user saves their own.
+func DefaultSettings() SettingsModel {
+	return SettingsModel{
+		TimeZone:  DefaultTimeZone,
+		WeekStart: DefaultWeekStart,
+		Locale:    DefaultLocale,
+	}
+}
+
+// Weekdays are the names week starts and reminder days are given in.
+var Weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// ParseWeekday accepts lower case weekday names like monday.
+func ParseWeekday(name string) (time.Weekday, bool) {
+	weekday, ok := weekdays[name]
+	return weekday, ok
+}
+
+// Location is the settings' time zone, UTC when it cannot be loaded.
+func (s SettingsModel) Location() *time.Location {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Today is the YYYY-MM-DD date it is at now in the user's time zone.
+func (s SettingsModel) Today(now time.Time) string {
+	return now.In(s.Location()).Format(time.DateOnly)
+}
+
+// DateOf is the YYYY-MM-DD date a Unix timestamp falls on for the user.
+func (s SettingsModel) DateOf(unix int64) string {
+	return time.Unix(unix, 0).In(s.Location()).Format(time.DateOnly)
+}
+
+// FirstDayOfWeek is the weekday weeks start on, Monday when unset.
+func (s SettingsModel) FirstDayOfWeek() time.Weekday {
+	weekday, ok := ParseWeekday(s.WeekStart)
+	if !ok {
+		return time.Monday
+	}
+	return weekday
+}
+
+// StartOfWeek returns the first day of the week day is in.
+func (s SettingsModel) StartOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) - int(s.FirstDayOfWeek()) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func (r ProfileReq) validate() []problem.InvalidParam {
+	var params []problem.InvalidParam
+
+	if utf8.RuneCountInString(strings.TrimSpace(r.DisplayName)) > 50 {
+		params = append(params, problem.InvalidParam{Name: "displayName", Reason: "Display name must be at most 50 characters"})
+	}
+
+	return params
+}
+
+// locale loosely matches BCP 47 tags like en, en-US or zh-Hant-TW.
+var locale = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+func (r SettingsReq) validate() []problem.InvalidParam {
+	var params []problem.InvalidParam
+
+	// Local depends on the host, so only named zones are accepted
+	if r.TimeZone != "" {
+		if _, err := time.LoadLocation(r.TimeZone); err != nil || r.TimeZone == "Local" {
+			params = append(params, problem.InvalidParam{Name: "timeZone", Reason: "Time zone must be an IANA name like Europe/Stockholm"})
+		}
+	}
+	if r.WeekStart != "" {
+		if _, ok := weekdays[r.WeekStart]; !ok {
+			params = append(params, problem.InvalidParam{Name: "weekStart", Reason: "Week start must be a lower case weekday like monday"})
+		}
+	}
+	if r.Locale != "" && !locale.MatchString(r.Locale) {
+		params = append(params, problem.InvalidParam{Name: "locale", Reason: "Locale must be a language tag like en-US"})
+	}
+
+	return params
+}
+
+// settings applies the request over the defaults.
+func (r SettingsReq) settings() SettingsModel {
+	settings := DefaultSettings()
+	if r.TimeZone != "" {
+		settings.TimeZone = r.TimeZone
+	}
+	if r.WeekStart != "" {
+		settings.WeekStart = r.WeekStart
+	}
+	if r.Locale != "" {
+		settings.Locale = r.Locale
+	}
+	return settings
+}
@@ -0,0 +1,32 @@
+package user
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSettingsDates(t *testing.T) {
+	settings := SettingsModel{TimeZone: "America/New_York", WeekStart: "sunday"}
+	// 01:30 UTC is still the evening before in New York
+	now := time.Date(2026, 2, 9, 1, 30, 0, 0, time.UTC)
+
+	if got := settings.Today(now); got != "2026-02-08" {
+		t.Errorf("expected today to be 2026-02-08, got %s", got)
+	}
+	if got := settings.DateOf(now.Unix()); got != "2026-02-08" {
+		t.Errorf("expected the timestamp to fall on 2026-02-08, got %s", got)
+	}
+
+	wednesday := time.Date(2026, 2, 11, 0, 0, 0, 0, time.UTC)
+	if got := settings.StartOfWeek(wednesday).Format(time.DateOnly); got != "2026-02-08" {
+		t.Errorf("expected the week to start on Sunday 2026-02-08, got %s", got)
+	}
+	if got := DefaultSettings().StartOfWeek(wednesday).Format(time.DateOnly); got != "2026-02-09" {
+		t.Errorf("expected the week to start on Monday 2026-02-09, got %s", got)
+	}
+
+	broken := SettingsModel{TimeZone: "Nowhere/City"}
+	if broken.Location() != time.UTC || broken.FirstDayOfWeek() != time.Monday {
+		t.Errorf("expected UTC and Monday for unknown settings")
+	}
+}
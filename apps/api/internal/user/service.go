@@ -0,0 +1,103 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jimvid/sidekick/internal/tracing"
+)
+
+var ErrUnknownOnboardingStep = errors.New("unknown onboarding step")
+
+type UserService struct {
+	storage *UserStorage
+}
+
+func NewUserService(storage *UserStorage) *UserService {
+	return &UserService{
+		storage: storage,
+	}
+}
+
+func (s *UserService) GetSettings(ctx context.Context, userId string) (SettingsModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.GetSettings")
+	defer span.End()
+
+	return s.storage.GetSettings(ctx, userId)
+}
+
+func (s *UserService) UpdateSettings(ctx context.Context, userId string, req SettingsReq) (SettingsModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.UpdateSettings")
+	defer span.End()
+
+	settings := req.settings()
+	settings.UpdatedAt = time.Now().Unix()
+
+	return settings, s.storage.PutSettings(ctx, userId, settings)
+}
+
+func (s *UserService) GetUser(ctx context.Context, userId string) (UserModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.GetUser")
+	defer span.End()
+
+	return s.storage.GetUser(ctx, userId)
+}
+
+func (s *UserService) GetProfile(ctx context.Context, userId string) (ProfileModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.GetProfile")
+	defer span.End()
+
+	return s.storage.GetProfile(ctx, userId)
+}
+
+// UpdateProfile replaces the display name and notification preferences.
+// Onboarding progress is kept.
+func (s *UserService) UpdateProfile(ctx context.Context, userId string, req ProfileReq) (ProfileModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.UpdateProfile")
+	defer span.End()
+
+	profile, err := s.storage.GetProfile(ctx, userId)
+	if err != nil {
+		return ProfileModel{}, err
+	}
+
+	now := time.Now().Unix()
+	if profile.CreatedAt == 0 {
+		profile.CreatedAt = now
+	}
+	profile.DisplayName = strings.TrimSpace(req.DisplayName)
+	profile.Notifications = req.Notifications
+	profile.UpdatedAt = now
+
+	return profile, s.storage.PutProfile(ctx, userId, profile)
+}
+
+// CompleteOnboardingStep marks a step done. Completing a step twice is not
+// an error and does not write.
+func (s *UserService) CompleteOnboardingStep(ctx context.Context, userId, step string) (ProfileModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.CompleteOnboardingStep")
+	defer span.End()
+
+	if !slices.Contains(OnboardingSteps, step) {
+		return ProfileModel{}, ErrUnknownOnboardingStep
+	}
+
+	profile, err := s.storage.GetProfile(ctx, userId)
+	if err != nil {
+		return ProfileModel{}, err
+	}
+
+	now := time.Now().Unix()
+	if !profile.Onboarding.CompleteStep(step, now) {
+		return profile, nil
+	}
+	if profile.CreatedAt == 0 {
+		profile.CreatedAt = now
+	}
+	profile.UpdatedAt = now
+
+	return profile, s.storage.PutProfile(ctx, userId, profile)
+}
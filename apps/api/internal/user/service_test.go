@@ -0,0 +1,72 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestServiceUpdateProfile(t *testing.T) {
+	storage := setupTestDB(t)
+	service := NewUserService(storage)
+	ctx := context.Background()
+
+	service.CompleteOnboardingStep(ctx, "user-1", "create-habit")
+
+	profile, err := service.UpdateProfile(ctx, "user-1", ProfileReq{
+		DisplayName:   "  Jim ",
+		Notifications: NotificationPreferences{Reminders: true, Email: true},
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile failed: %v", err)
+	}
+
+	if profile.DisplayName != "Jim" {
+		t.Errorf("expected the display name to be trimmed, got %q", profile.DisplayName)
+	}
+	if profile.Notifications != (NotificationPreferences{Reminders: true, Email: true}) {
+		t.Errorf("expected the notification preferences to be replaced, got %+v", profile.Notifications)
+	}
+	if !reflect.DeepEqual(profile.Onboarding.CompletedSteps, []string{"create-habit"}) {
+		t.Errorf("expected onboarding progress to be kept, got %+v", profile.Onboarding)
+	}
+	if profile.CreatedAt == 0 || profile.UpdatedAt == 0 {
+		t.Errorf("expected timestamps, got %+v", profile)
+	}
+}
+
+func TestServiceCompleteOnboardingStep(t *testing.T) {
+	storage := setupTestDB(t)
+	service := NewUserService(storage)
+	ctx := context.Background()
+
+	for _, step := range OnboardingSteps[:len(OnboardingSteps)-1] {
+		profile, err := service.CompleteOnboardingStep(ctx, "user-1", step)
+		if err != nil {
+			t.Fatalf("CompleteOnboardingStep(%s) failed: %v", step, err)
+		}
+		if profile.Onboarding.CompletedAt != 0 {
+			t.Fatalf("expected onboarding to be unfinished after %s", step)
+		}
+	}
+
+	// Repeating a step changes nothing
+	profile, _ := service.CompleteOnboardingStep(ctx, "user-1", OnboardingSteps[0])
+	if len(profile.Onboarding.CompletedSteps) != len(OnboardingSteps)-1 {
+		t.Errorf("expected a repeated step to be ignored, got %v", profile.Onboarding.CompletedSteps)
+	}
+
+	profile, err := service.CompleteOnboardingStep(ctx, "user-1", OnboardingSteps[len(OnboardingSteps)-1])
+	if err != nil {
+		t.Fatalf("CompleteOnboardingStep failed: %v", err)
+	}
+	if profile.Onboarding.CompletedAt == 0 {
+		t.Errorf("expected onboarding to be finished, got %+v", profile.Onboarding)
+	}
+
+	_, err = service.CompleteOnboardingStep(ctx, "user-1", "fly")
+	if !errors.Is(err, ErrUnknownOnboardingStep) {
+		t.Errorf("expected ErrUnknownOnboardingStep, got %v", err)
+	}
+}
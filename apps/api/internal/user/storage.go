@@ -0,0 +1,197 @@
+package user
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/config"
+)
+
+const (
+	itemPrefixUser = "user#"
+
+	itemIdProfile  = itemPrefixUser + "profile"
+	itemIdSettings = itemPrefixUser + "settings"
+)
+
+type UserStorage struct {
+	db  *dynamodb.Client
+	cfg *config.Config
+}
+
+func NewUserStorage(db *dynamodb.Client, cfg *config.Config) *UserStorage {
+	return &UserStorage{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// GetUser reads every user# item in one query. Items the user has not saved
+// yet are filled with defaults.
+func (s *UserStorage) GetUser(ctx context.Context, userId string) (UserModel, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixUser},
+		},
+	}
+
+	result, err := s.db.Query(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err)
+		return UserModel{}, err
+	}
+
+	found := UserModel{
+		ID:       userId,
+		Profile:  DefaultProfile(),
+		Settings: DefaultSettings(),
+	}
+	for _, item := range result.Items {
+		itemId, _ := item["itemId"].(*types.AttributeValueMemberS)
+		if itemId == nil {
+			continue
+		}
+
+		switch itemId.Value {
+		case itemIdProfile:
+			var profile profileItem
+			err = attributevalue.UnmarshalMap(item, &profile)
+			found.Profile = profile.ProfileModel
+		case itemIdSettings:
+			var settings settingsItem
+			err = attributevalue.UnmarshalMap(item, &settings)
+			found.Settings = settings.SettingsModel
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to unmarshal user item", "error", err, "itemId", itemId.Value)
+			return UserModel{}, err
+		}
+	}
+
+	return found, nil
+}
+
+// GetProfile returns the user's profile, or the default profile when they
+// have not saved one.
+func (s *UserStorage) GetProfile(ctx context.Context, userId string) (ProfileModel, error) {
+	var item profileItem
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemIdProfile},
+		},
+	}
+
+	result, err := s.db.GetItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err, "table", s.cfg.TableName)
+		return ProfileModel{}, err
+	}
+
+	if result.Item == nil {
+		return DefaultProfile(), nil
+	}
+
+	err = attributevalue.UnmarshalMap(result.Item, &item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal profile", "error", err)
+		return ProfileModel{}, err
+	}
+
+	return item.ProfileModel, nil
+}
+
+func (s *UserStorage) PutProfile(ctx context.Context, userId string, profile ProfileModel) error {
+	item := profileItem{
+		UserId:       userId,
+		ItemId:       itemIdProfile,
+		ProfileModel: profile,
+	}
+
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal profile", "error", err)
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      av,
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "table", s.cfg.TableName)
+		return err
+	}
+
+	return nil
+}
+
+// GetSettings returns the user's settings, or the defaults when they have
+// not saved any.
+func (s *UserStorage) GetSettings(ctx context.Context, userId string) (SettingsModel, error) {
+	var item settingsItem
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemIdSettings},
+		},
+	}
+
+	result, err := s.db.GetItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err, "table", s.cfg.TableName)
+		return SettingsModel{}, err
+	}
+
+	if result.Item == nil {
+		return DefaultSettings(), nil
+	}
+
+	err = attributevalue.UnmarshalMap(result.Item, &item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal settings", "error", err)
+		return SettingsModel{}, err
+	}
+
+	return item.SettingsModel, nil
+}
+
+func (s *UserStorage) PutSettings(ctx context.Context, userId string, settings SettingsModel) error {
+	item := settingsItem{
+		UserId:        userId,
+		ItemId:        itemIdSettings,
+		SettingsModel: settings,
+	}
+
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal settings", "error", err)
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      av,
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "table", s.cfg.TableName)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package user
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-user"
+
+func setupTestDB(t *testing.T) *UserStorage {
+	t.Helper()
+
+	return NewUserStorage(dynamotest.NewTable(t, testTableName))
+}
+
+func TestStorageGetSettings(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	t.Run("defaults when not saved", func(t *testing.T) {
+		settings, err := storage.GetSettings(ctx, "user-1")
+		if err != nil {
+			t.Fatalf("GetSettings failed: %v", err)
+		}
+		if settings != DefaultSettings() {
+			t.Errorf("expected defaults, got %+v", settings)
+		}
+	})
+
+	t.Run("saved settings", func(t *testing.T) {
+		saved := SettingsModel{TimeZone: "Europe/Stockholm", WeekStart: "sunday", Locale: "sv-SE", UpdatedAt: 10}
+		err := storage.PutSettings(ctx, "user-1", saved)
+		if err != nil {
+			t.Fatalf("PutSettings failed: %v", err)
+		}
+
+		settings, err := storage.GetSettings(ctx, "user-1")
+		if err != nil {
+			t.Fatalf("GetSettings failed: %v", err)
+		}
+		if settings != saved {
+			t.Errorf("expected %+v, got %+v", saved, settings)
+		}
+
+		other, _ := storage.GetSettings(ctx, "user-2")
+		if other != DefaultSettings() {
+			t.Errorf("expected another user to have defaults, got %+v", other)
+		}
+	})
+}
+
+func TestStorageGetProfile(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	profile, err := storage.GetProfile(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetProfile failed: %v", err)
+	}
+	if !reflect.DeepEqual(profile, DefaultProfile()) {
+		t.Errorf("expected the default profile, got %+v", profile)
+	}
+
+	saved := DefaultProfile()
+	saved.DisplayName = "Jim"
+	saved.Onboarding.CompletedSteps = []string{"create-habit"}
+	saved.Notifications.Email = true
+	saved.CreatedAt = 1
+	saved.UpdatedAt = 2
+	err = storage.PutProfile(ctx, "user-1", saved)
+	if err != nil {
+		t.Fatalf("PutProfile failed: %v", err)
+	}
+
+	profile, err = storage.GetProfile(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetProfile failed: %v", err)
+	}
+	if !reflect.DeepEqual(profile, saved) {
+		t.Errorf("expected %+v, got %+v", saved, profile)
+	}
+}
+
+func TestStorageGetUser(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	t.Run("defaults", func(t *testing.T) {
+		found, err := storage.GetUser(ctx, "user-1")
+		if err != nil {
+			t.Fatalf("GetUser failed: %v", err)
+		}
+		if found.ID != "user-1" || !reflect.DeepEqual(found.Profile, DefaultProfile()) || found.Settings != DefaultSettings() {
+			t.Errorf("expected defaults, got %+v", found)
+		}
+	})
+
+	t.Run("saved items", func(t *testing.T) {
+		profile := DefaultProfile()
+		profile.DisplayName = "Jim"
+		storage.PutProfile(ctx, "user-1", profile)
+		settings := SettingsModel{TimeZone: "Europe/Stockholm", WeekStart: "monday", Locale: "sv-SE"}
+		storage.PutSettings(ctx, "user-1", settings)
+
+		found, err := storage.GetUser(ctx, "user-1")
+		if err != nil {
+			t.Fatalf("GetUser failed: %v", err)
+		}
+		if found.Profile.DisplayName != "Jim" || found.Settings != settings {
+			t.Errorf("expected both saved items, got %+v", found)
+		}
+	})
+}
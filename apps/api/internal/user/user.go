@@ -11,7 +11,7 @@ import (
 func GetUserId(r *http.Request) (string, error) {
 	claims, ok := clerk.SessionClaimsFromContext(r.Context())
 	if !ok {
-		slog.Warn("No session claims in context")
+		slog.WarnContext(r.Context(), "No session claims in context")
 		return "", fmt.Errorf("No session claims in context")
 	}
 
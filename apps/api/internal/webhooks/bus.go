@@ -0,0 +1,29 @@
+package webhooks
+
+import (
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/events"
+	"github.com/jimvid/sidekick/internal/habits"
+)
+
+// NewEventBus registers the domain events and their subscribers. The API
+// publishes on it and cmd/outbox relays on it, so both need the same
+// subscribers. In Lambda, webhooks are left to the relay, as the process is
+// frozen once the request is answered.
+func NewEventBus(db *dynamodb.Client, cfg *config.Config, service *WebhookService) *events.Bus {
+	bus := events.NewBus(events.NewOutboxStorage(db, cfg))
+	bus.Register(habits.Events...)
+	bus.RelayOnly = config.InLambda()
+
+	// Audit log
+	bus.Subscribe(events.AllEvents, events.LogHandler(slog.Default()))
+
+	if cfg.FeatureEnabled(config.FeatureWebhooks) {
+		bus.SubscribeAsyncWhen("webhooks", events.AllEvents, service.Subscribed, service.HandleEvent)
+	}
+
+	return bus
+}
@@ -0,0 +1,275 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimvid/sidekick/internal/problem"
+	"github.com/jimvid/sidekick/internal/request"
+	"github.com/jimvid/sidekick/internal/tracing"
+	"github.com/jimvid/sidekick/internal/user"
+)
+
+type WebhookHandler struct {
+	service   *WebhookService
+	getUserId func(r *http.Request) (string, error)
+}
+
+func NewWebhookHandler(service *WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		service:   service,
+		getUserId: user.GetUserId,
+	}
+}
+
+func (h *WebhookHandler) writeErrorResponse(w http.ResponseWriter, r *http.Request, p problem.Problem) {
+	problem.Write(w, r, p)
+}
+
+func (h *WebhookHandler) writeSuccessResponse(w http.ResponseWriter, r *http.Request, statusCode int, data any) {
+	_, span := tracing.Tracer().Start(r.Context(), "json.encode")
+	defer span.End()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *WebhookHandler) GetAllWebhooks(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	webhooks, err := h.service.GetWebhooks(r.Context(), userId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get webhooks", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get webhooks"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+	if webhookId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	webhook, err := h.service.FindWebhookById(r.Context(), userId, webhookId)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find webhook by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to get webhook", "error", err, "webhookId", webhookId)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get webhook"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhookReq WebhookReq
+	err := request.DecodeJSON(w, r, &webhookReq)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := webhookReq.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(r.Context(), userId, webhookReq)
+	if err != nil {
+		if errors.Is(err, ErrTooManyWebhooks) {
+			h.writeErrorResponse(w, r, problem.BadRequest(fmt.Sprintf("Cannot have more than %d webhooks", MaxWebhooks)))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to create webhook", "error", err)
+		h.writeErrorResponse(w, r, problem.Internal("Could not create webhook"))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Webhook created", "webhookId", webhook.ID)
+	h.writeSuccessResponse(w, r, http.StatusCreated, webhook.withSecret())
+}
+
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req WebhookReq
+	err := request.DecodeJSON(w, r, &req)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Invalid request body", "error", err)
+		h.writeErrorResponse(w, r, problem.FromRequestError(err))
+		return
+	}
+	if params := req.validate(); len(params) > 0 {
+		h.writeErrorResponse(w, r, problem.Validation(params))
+		return
+	}
+
+	webhookId := chi.URLParam(r, "webhookId")
+	if webhookId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	updated, err := h.service.UpdateWebhook(r.Context(), userId, webhookId, req)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find webhook by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not update webhook", "error", err, "webhookId", webhookId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not update webhook"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, updated)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+	if webhookId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	err = h.service.DeleteWebhook(r.Context(), userId, webhookId)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find webhook by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not delete webhook", "error", err, "webhookId", webhookId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not delete webhook"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, map[string]string{"message": "Successfully deleted webhook"})
+}
+
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+	if webhookId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(r.Context(), userId, webhookId)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find webhook by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to get webhook deliveries", "error", err, "webhookId", webhookId)
+		h.writeErrorResponse(w, r, problem.Internal("Failed to get webhook deliveries"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, deliveries)
+}
+
+// RotateSecret responds with the new secret, which cannot be read again.
+func (h *WebhookHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+	if webhookId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	webhook, err := h.service.RotateSecret(r.Context(), userId, webhookId)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find webhook by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not rotate webhook secret", "error", err, "webhookId", webhookId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not rotate webhook secret"))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Webhook secret rotated", "webhookId", webhook.ID)
+	h.writeSuccessResponse(w, r, http.StatusOK, webhook.withSecret())
+}
+
+// TestWebhook answers 200 with the delivery whether or not the webhook
+// accepted it, its status says how it went.
+func (h *WebhookHandler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+	if webhookId == "" {
+		slog.WarnContext(r.Context(), "Could not get ID from URL")
+		h.writeErrorResponse(w, r, problem.BadRequest("Could not get ID from URL"))
+		return
+	}
+
+	userId, err := h.getUserId(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, problem.Unauthenticated())
+		return
+	}
+
+	delivery, err := h.service.Test(r.Context(), userId, webhookId)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			h.writeErrorResponse(w, r, problem.NotFound("Could not find webhook by ID"))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Could not test webhook", "error", err, "webhookId", webhookId)
+		h.writeErrorResponse(w, r, problem.Internal("Could not test webhook"))
+		return
+	}
+
+	h.writeSuccessResponse(w, r, http.StatusOK, delivery)
+}
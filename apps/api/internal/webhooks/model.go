@@ -0,0 +1,162 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/problem"
+)
+
+// EventTest is only sent by the test-fire endpoint, to any webhook.
+const EventTest = "webhook.test"
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Statuses lists every delivery status.
+var Statuses = []string{StatusPending, StatusSucceeded, StatusFailed}
+
+const (
+	// MaxWebhooks is how many webhooks a user can have.
+	MaxWebhooks = 10
+	maxURL      = 2048
+	maxDesc     = 200
+)
+
+type webhookItem struct {
+	UserId string `json:"-" dynamodbav:"userId"`
+	ItemId string `json:"-" dynamodbav:"itemId"`
+	WebhookModel
+}
+
+// WebhookModel posts the events it subscribes to to a URL the user owns.
+type WebhookModel struct {
+	ID          string   `json:"id" dynamodbav:"ID"`
+	URL         string   `json:"url" dynamodbav:"URL"`
+	Description string   `json:"description" dynamodbav:"Description"`
+	Events      []string `json:"events" dynamodbav:"Events"`
+	Active      bool     `json:"active" dynamodbav:"Active"`
+	// Secret signs every delivery, in the "whsec_" format Svix and the
+	// Standard Webhooks libraries verify. It is only sent back by
+	// WebhookWithSecretModel.
+	Secret    string `json:"-" dynamodbav:"Secret"`
+	CreatedAt int64  `json:"createdAt" dynamodbav:"CreatedAt"`
+	UpdatedAt int64  `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// WebhookWithSecretModel is the response of creating a webhook and of
+// rotating its secret, the only times the secret can be read.
+type WebhookWithSecretModel struct {
+	WebhookModel
+	Secret string `json:"secret"`
+}
+
+func (w WebhookModel) withSecret() WebhookWithSecretModel {
+	return WebhookWithSecretModel{WebhookModel: w, Secret: w.Secret}
+}
+
+// subscribes reports whether the webhook wants events of eventType.
+func (w WebhookModel) subscribes(eventType string) bool {
+	return w.Active && slices.Contains(w.Events, eventType)
+}
+
+type WebhookReq struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+	// Active defaults to true.
+	Active *bool `json:"active"`
+}
+
+func (r WebhookReq) active() bool {
+	return r.Active == nil || *r.Active
+}
+
+func (r WebhookReq) validate() []problem.InvalidParam {
+	var params []problem.InvalidParam
+
+	// Internal addresses are refused when delivering, the host name can
+	// resolve to another address by then
+	u, err := url.Parse(r.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil || len(r.URL) > maxURL {
+		params = append(params, problem.InvalidParam{Name: "url", Reason: "URL must be an https URL without credentials, at most 2048 characters"})
+	}
+	if len(r.Description) > maxDesc {
+		params = append(params, problem.InvalidParam{Name: "description", Reason: "Description must be at most 200 characters"})
+	}
+	if len(r.Events) == 0 {
+		params = append(params, problem.InvalidParam{Name: "events", Reason: "Events must list at least one event type"})
+	}
+	for i, event := range r.Events {
+		if !slices.Contains(habits.EventTypes, event) {
+			params = append(params, problem.InvalidParam{Name: "events", Reason: "Events must be one of " + strings.Join(habits.EventTypes, ", ")})
+			break
+		}
+		if slices.Contains(r.Events[:i], event) {
+			params = append(params, problem.InvalidParam{Name: "events", Reason: "Events must not repeat"})
+			break
+		}
+	}
+
+	return params
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	// ID is the event's ID, the same for every webhook and retry, so
+	// receivers can drop duplicates.
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Timestamp int64  `json:"timestamp"`
+	Data      any    `json:"data"`
+}
+
+type deliveryItem struct {
+	UserId string `json:"-" dynamodbav:"userId"`
+	ItemId string `json:"-" dynamodbav:"itemId"`
+	// RetryIndex puts pending deliveries in IndexRetries.
+	RetryIndex string `json:"-" dynamodbav:"RetryIndex,omitempty"`
+	// ExpiresAt lets the table's TTL clear the delivery log, in unix seconds.
+	ExpiresAt int64 `json:"-" dynamodbav:"ExpiresAt"`
+	DeliveryModel
+}
+
+// DeliveryModel is an event sent, or being sent, to a webhook.
+type DeliveryModel struct {
+	// ID sorts by time, the delivery log is newest first.
+	ID        string          `json:"id" dynamodbav:"ID"`
+	WebhookId string          `json:"webhookId" dynamodbav:"WebhookId"`
+	EventId   string          `json:"eventId" dynamodbav:"EventId"`
+	Event     string          `json:"event" dynamodbav:"Event"`
+	Payload   json.RawMessage `json:"payload" dynamodbav:"Payload"`
+	Status    string          `json:"status" dynamodbav:"Status"`
+	Attempts  int             `json:"attempts" dynamodbav:"Attempts"`
+	// NextAttemptAt is when a pending delivery is retried, in unix seconds.
+	NextAttemptAt int64 `json:"nextAttemptAt,omitempty" dynamodbav:"NextAttemptAt,omitempty"`
+	// ResponseStatus and Error describe the last attempt.
+	ResponseStatus int    `json:"responseStatus,omitempty" dynamodbav:"ResponseStatus,omitempty"`
+	Error          string `json:"error,omitempty" dynamodbav:"Error,omitempty"`
+	DurationMs     int64  `json:"durationMs" dynamodbav:"DurationMs"`
+	CreatedAt      int64  `json:"createdAt" dynamodbav:"CreatedAt"`
+	UpdatedAt      int64  `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// PendingDelivery is a delivery due for a retry, with its owner.
+type PendingDelivery struct {
+	UserId string
+	DeliveryModel
+}
+
+// RetryResult counts the deliveries of a retry run.
+type RetryResult struct {
+	Due       int `json:"due"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
@@ -0,0 +1,178 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Deliveries are signed like Standard Webhooks and Svix, which Clerk uses
+// too: HMAC-SHA256 of "id.timestamp.body" keyed with the base64 part of a
+// "whsec_" secret.
+const (
+	secretPrefix    = "whsec_"
+	headerId        = "webhook-id"
+	headerTimestamp = "webhook-timestamp"
+	headerSignature = "webhook-signature"
+	headerEvent     = "webhook-event"
+)
+
+// DeliveryTimeout bounds an attempt. The first attempt is made while the
+// change that caused it is being saved.
+const DeliveryTimeout = 5 * time.Second
+
+// ErrPrivateAddress means the webhook URL resolved to an address inside the
+// network, which deliveries are never sent to.
+var ErrPrivateAddress = errors.New("webhook URL resolves to a private address")
+
+// blockedPrefixes are ranges outside those netip reports as private,
+// loopback or link-local that still reach internal services, like the
+// shared address space some clouds put their metadata service in.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// publicAddress reports whether a delivery may connect to addr.
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialControl runs after DNS resolution, so a public host name resolving to
+// an internal address is refused too.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddress(addrPort.Addr()) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	key := make([]byte, 24)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// sign returns the webhook-signature header for a delivery.
+func sign(secret, id string, timestamp int64, body []byte) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(secret[len(secretPrefix):])
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Sender posts signed deliveries to webhook URLs.
+type Sender struct {
+	now func() time.Time
+
+	// Client posts deliveries, tests swap it for one trusting a fake.
+	Client *http.Client
+}
+
+func NewSender() *Sender {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on the sender's behalf, past dialControl
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: DeliveryTimeout,
+		Control: dialControl,
+	}).DialContext
+
+	return &Sender{
+		now: time.Now,
+		Client: &http.Client{
+			Transport: transport,
+			Timeout:   DeliveryTimeout,
+			// A redirect could lead anywhere, the URL is what was agreed to
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send makes one attempt and returns the response status, 0 when there was
+// no response. Anything but a 2xx response is an error.
+func (s *Sender) Send(ctx context.Context, webhook WebhookModel, delivery DeliveryModel) (int, error) {
+	timestamp := s.now().Unix()
+	signature, err := sign(webhook.Secret, delivery.EventId, timestamp, delivery.Payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Sidekick-Webhooks/1.0")
+	req.Header.Set(headerId, delivery.EventId)
+	req.Header.Set(headerTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(headerSignature, signature)
+	req.Header.Set(headerEvent, delivery.Event)
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook responded %s", res.Status)
+	}
+
+	return res.StatusCode, nil
+}
+
+// describe is what the delivery log shows for a failed attempt. The
+// response body and network details like resolved addresses are left out,
+// they only go to the logs.
+func describe(status int, err error) string {
+	var netErr net.Error
+	switch {
+	case status != 0:
+		return fmt.Sprintf("webhook responded %d %s", status, http.StatusText(status))
+	case errors.Is(err, ErrPrivateAddress):
+		return ErrPrivateAddress.Error()
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "webhook timed out"
+	default:
+		return "webhook could not be reached"
+	}
+}
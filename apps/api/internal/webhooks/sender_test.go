@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// received is a request a test receiver accepted.
+type received struct {
+	Header http.Header
+	Body   string
+}
+
+// receiver is a webhook endpoint answering with status, which tests can
+// change.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []received
+}
+
+func newReceiver(t *testing.T) *receiver {
+	t.Helper()
+
+	rec := &receiver{status: http.StatusOK}
+	rec.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.requests = append(rec.requests, received{Header: r.Header, Body: string(body)})
+		w.WriteHeader(rec.status)
+		if rec.status >= 300 {
+			w.Write([]byte("nope"))
+		}
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+func (r *receiver) respond(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) received() []received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]received(nil), r.requests...)
+}
+
+// setupSender returns a sender trusting the receiver's certificate.
+func setupSender(rec *receiver) *Sender {
+	sender := NewSender()
+	client := rec.Client()
+	client.CheckRedirect = sender.Client.CheckRedirect
+	sender.Client = client
+	return sender
+}
+
+// verify checks a delivery the way a Standard Webhooks library would.
+func verify(t *testing.T, secret string, r received) {
+	t.Helper()
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		t.Fatalf("invalid secret %q: %v", secret, err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(r.Header.Get("webhook-id") + "." + r.Header.Get("webhook-timestamp") + "." + r.Body))
+	want := "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if got := r.Header.Get("webhook-signature"); got != want {
+		t.Errorf("expected signature %s, got %s", want, got)
+	}
+}
+
+func TestSenderSend(t *testing.T) {
+	rec := newReceiver(t)
+	sender := setupSender(rec)
+	sender.now = func() time.Time { return time.Unix(1700000000, 0) }
+	ctx := context.Background()
+
+	secret, err := generateSecret()
+	if err != nil {
+		t.Fatalf("generateSecret failed: %v", err)
+	}
+	webhook := WebhookModel{ID: "webhook-1", URL: rec.URL + "/hooks", Secret: secret}
+	delivery := DeliveryModel{ID: "delivery-1", EventId: "evt_1", Event: "habit.created", Payload: []byte(`{"id":"evt_1"}`)}
+
+	t.Run("signed", func(t *testing.T) {
+		status, err := sender.Send(ctx, webhook, delivery)
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %v", status, err)
+		}
+
+		got := rec.received()[0]
+		if got.Body != `{"id":"evt_1"}` {
+			t.Errorf("expected the payload, got %s", got.Body)
+		}
+		if got.Header.Get("webhook-id") != "evt_1" || got.Header.Get("webhook-timestamp") != "1700000000" || got.Header.Get("webhook-event") != "habit.created" {
+			t.Errorf("expected the webhook headers, got %v", got.Header)
+		}
+		verify(t, secret, got)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		rec.respond(http.StatusInternalServerError)
+		defer rec.respond(http.StatusOK)
+
+		status, err := sender.Send(ctx, webhook, delivery)
+		if status != http.StatusInternalServerError || err == nil || strings.Contains(err.Error(), "nope") {
+			t.Errorf("expected a 500 error without the body, got %d: %v", status, err)
+		}
+		if got := describe(status, err); got != "webhook responded 500 Internal Server Error" {
+			t.Errorf("unexpected description %q", got)
+		}
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		rec.respond(http.StatusFound)
+		defer rec.respond(http.StatusOK)
+
+		status, err := sender.Send(ctx, webhook, delivery)
+		if status != http.StatusFound || err == nil {
+			t.Errorf("expected the redirect to fail, got %d: %v", status, err)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		unreachable := webhook
+		unreachable.URL = "https://127.0.0.1:1/hooks"
+
+		status, err := sender.Send(ctx, unreachable, delivery)
+		if status != 0 || err == nil {
+			t.Errorf("expected no response, got %d: %v", status, err)
+		}
+	})
+}
+
+func TestSenderRefusesPrivateAddresses(t *testing.T) {
+	rec := newReceiver(t)
+	sender := NewSender()
+	// Trust the receiver's certificate without replacing the transport
+	sender.Client.Transport.(*http.Transport).TLSClientConfig = rec.Client().Transport.(*http.Transport).TLSClientConfig
+	delivery := DeliveryModel{ID: "delivery-1", EventId: "evt_1", Event: "habit.created", Payload: []byte(`{}`)}
+
+	for _, url := range []string{rec.URL, strings.Replace(rec.URL, "127.0.0.1", "localhost", 1)} {
+		status, err := sender.Send(context.Background(), WebhookModel{URL: url, Secret: "whsec_c2VjcmV0"}, delivery)
+		if status != 0 || !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("%s: expected ErrPrivateAddress, got %d: %v", url, status, err)
+		}
+		if got := describe(status, err); got != ErrPrivateAddress.Error() {
+			t.Errorf("unexpected description %q", got)
+		}
+	}
+	if len(rec.received()) != 0 {
+		t.Error("expected nothing to reach the receiver")
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.100.100.200", false},
+		{"0.0.0.0", false},
+		{"fd00:ec2::254", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddress(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("%s: expected public %v, got %v", tt.addr, tt.public, got)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute}
+	for i, wait := range want {
+		if got := backoff(i + 1); got != wait {
+			t.Errorf("attempt %d: expected %s, got %s", i+1, wait, got)
+		}
+	}
+}
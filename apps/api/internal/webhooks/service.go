@@ -0,0 +1,392 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jimvid/sidekick/internal/events"
+	"github.com/jimvid/sidekick/internal/logging"
+	"github.com/jimvid/sidekick/internal/tracing"
+)
+
+const (
+	// MaxAttempts is how many times an event is sent before it is failed.
+	MaxAttempts = 6
+	// RetryBackoff is the wait before the first retry, it doubles for each
+	// one after: 1, 2, 4, 8 and 16 minutes.
+	RetryBackoff = time.Minute
+	// DeliveryLogLimit is how many deliveries the delivery log shows.
+	DeliveryLogLimit = 50
+)
+
+var ErrTooManyWebhooks = errors.New("too many webhooks")
+
+type WebhookService struct {
+	storage *WebhookStorage
+	sender  *Sender
+	now     func() time.Time
+}
+
+func NewWebhookService(storage *WebhookStorage, sender *Sender) *WebhookService {
+	return &WebhookService{
+		storage: storage,
+		sender:  sender,
+		now:     time.Now,
+	}
+}
+
+// backoff is the wait after a failed attempt before the next one.
+func backoff(attempts int) time.Duration {
+	return RetryBackoff << (attempts - 1)
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, userId string) ([]WebhookModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.GetWebhooks")
+	defer span.End()
+
+	return s.storage.GetWebhooks(ctx, userId)
+}
+
+func (s *WebhookService) FindWebhookById(ctx context.Context, userId, webhookId string) (WebhookModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.FindWebhookById")
+	defer span.End()
+
+	return s.storage.FindWebhookById(ctx, userId, webhookId)
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, userId string, req WebhookReq) (WebhookModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.CreateWebhook")
+	defer span.End()
+
+	existing, err := s.storage.GetWebhooks(ctx, userId)
+	if err != nil {
+		return WebhookModel{}, err
+	}
+	if len(existing) >= MaxWebhooks {
+		return WebhookModel{}, ErrTooManyWebhooks
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return WebhookModel{}, err
+	}
+
+	webhook := WebhookModel{
+		ID:          uuid.New().String(),
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      req.Events,
+		Active:      req.active(),
+		Secret:      secret,
+		CreatedAt:   s.now().Unix(),
+		UpdatedAt:   s.now().Unix(),
+	}
+
+	return webhook, s.storage.PutWebhook(ctx, userId, webhook)
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, userId, webhookId string, req WebhookReq) (WebhookModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.UpdateWebhook")
+	defer span.End()
+
+	existing, err := s.storage.FindWebhookById(ctx, userId, webhookId)
+	if err != nil {
+		return WebhookModel{}, err
+	}
+
+	existing.URL = req.URL
+	existing.Description = req.Description
+	existing.Events = req.Events
+	existing.Active = req.active()
+	existing.UpdatedAt = s.now().Unix()
+
+	err = s.storage.PutWebhook(ctx, userId, existing)
+	if err != nil {
+		return WebhookModel{}, err
+	}
+
+	return existing, nil
+}
+
+// RotateSecret replaces the webhook's secret. Deliveries, retries included,
+// are signed with the new one from then on.
+func (s *WebhookService) RotateSecret(ctx context.Context, userId, webhookId string) (WebhookModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.RotateSecret")
+	defer span.End()
+
+	existing, err := s.storage.FindWebhookById(ctx, userId, webhookId)
+	if err != nil {
+		return WebhookModel{}, err
+	}
+
+	existing.Secret, err = generateSecret()
+	if err != nil {
+		return WebhookModel{}, err
+	}
+	existing.UpdatedAt = s.now().Unix()
+
+	err = s.storage.PutWebhook(ctx, userId, existing)
+	if err != nil {
+		return WebhookModel{}, err
+	}
+
+	return existing, nil
+}
+
+// DeleteWebhook stops the webhook's pending retries too, they fail on their
+// next attempt. Its delivery log expires on its own.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userId, webhookId string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.DeleteWebhook")
+	defer span.End()
+
+	return s.storage.DeleteWebhook(ctx, userId, webhookId)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, userId, webhookId string) ([]DeliveryModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.GetDeliveries")
+	defer span.End()
+
+	if _, err := s.storage.FindWebhookById(ctx, userId, webhookId); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetDeliveries(ctx, userId, webhookId, DeliveryLogLimit)
+}
+
+// Subscribed reports whether any of the user's active webhooks subscribes
+// to eventType, so the bus only records the events HandleEvent sends.
+func (s *WebhookService) Subscribed(ctx context.Context, userId, eventType string) (bool, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.Subscribed")
+	defer span.End()
+
+	webhooks, err := s.storage.GetWebhooks(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(webhooks, func(webhook WebhookModel) bool {
+		return webhook.subscribes(eventType)
+	}), nil
+}
+
+// HandleEvent sends the event to each of the user's webhooks subscribed to
+// it, making the first attempts before it returns. Failed attempts are left
+// pending for RetryDue. It fails only when a delivery could not be saved, so
+// the bus runs it again. Handling an event again skips the webhooks that
+// already have its delivery, so receivers see it once.
+func (s *WebhookService) HandleEvent(ctx context.Context, env events.Envelope) error {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.HandleEvent")
+	defer span.End()
+
+	webhooks, err := s.storage.GetWebhooks(ctx, env.UserId)
+	if err != nil {
+		return err
+	}
+
+	var subscribed []WebhookModel
+	for _, webhook := range webhooks {
+		if webhook.subscribes(env.Type) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	// The event's ID, so a relayed event has the same one
+	eventId := "evt_" + env.ID
+	payload, err := json.Marshal(Payload{
+		ID:        eventId,
+		Type:      env.Type,
+		Timestamp: env.OccurredAt.Unix(),
+		Data:      env.Event,
+	})
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(subscribed))
+	for i, webhook := range subscribed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.enqueue(ctx, env.UserId, webhook, eventId, env.Type, payload)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// deliveryId is the same every time an event is handled for a webhook. It
+// is a UUIDv7 with the timestamp of the event's, so the delivery log stays
+// in order.
+func deliveryId(eventId, webhookId string) string {
+	sum := sha256.Sum256([]byte(eventId + "#" + webhookId))
+	event, err := uuid.Parse(strings.TrimPrefix(eventId, "evt_"))
+	if err != nil || event.Version() != 7 {
+		return uuid.NewSHA1(uuid.Nil, sum[:]).String()
+	}
+
+	var id uuid.UUID
+	copy(id[:6], event[:6])
+	copy(id[6:], sum[:10])
+	id[6] = id[6]&0x0f | 0x70
+	id[8] = id[8]&0x3f | 0x80
+	return id.String()
+}
+
+// enqueue saves a pending delivery before the first attempt, so the event
+// is retried even when this process stops before the attempt finishes. An
+// event that already has a delivery is left to it.
+func (s *WebhookService) enqueue(ctx context.Context, userId string, webhook WebhookModel, eventId, eventType string, payload []byte) error {
+	now := s.now()
+	delivery := DeliveryModel{
+		ID:        deliveryId(eventId, webhook.ID),
+		WebhookId: webhook.ID,
+		EventId:   eventId,
+		Event:     eventType,
+		Payload:   payload,
+		Status:    StatusPending,
+		// Leave the attempt below time to finish before a retry run
+		NextAttemptAt: now.Add(RetryBackoff).Unix(),
+		CreatedAt:     now.Unix(),
+		UpdatedAt:     now.Unix(),
+	}
+
+	err := s.storage.CreateDelivery(ctx, userId, delivery)
+	if errors.Is(err, ErrDeliveryExists) {
+		slog.InfoContext(ctx, "Webhook delivery exists, skipping", "webhookId", webhook.ID, "deliveryId", delivery.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.attempt(ctx, userId, webhook, delivery, true)
+	return nil
+}
+
+// attempt sends the delivery once and saves the outcome. A failure is
+// retried later when retry is set and attempts are left.
+func (s *WebhookService) attempt(ctx context.Context, userId string, webhook WebhookModel, delivery DeliveryModel, retry bool) DeliveryModel {
+	started := s.now()
+	status, err := s.sender.Send(ctx, webhook, delivery)
+
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.DurationMs = s.now().Sub(started).Milliseconds()
+	delivery.UpdatedAt = s.now().Unix()
+	delivery.NextAttemptAt = 0
+	delivery.Error = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = StatusSucceeded
+	case retry && delivery.Attempts < MaxAttempts:
+		delivery.Status = StatusPending
+		delivery.Error = describe(status, err)
+		delivery.NextAttemptAt = s.now().Add(backoff(delivery.Attempts)).Unix()
+	default:
+		delivery.Status = StatusFailed
+		delivery.Error = describe(status, err)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Webhook delivery failed", "error", err, "webhookId", webhook.ID, "deliveryId", delivery.ID, "attempts", delivery.Attempts)
+	}
+
+	if err := s.storage.PutDelivery(ctx, userId, delivery); err != nil {
+		slog.ErrorContext(ctx, "Failed to save webhook delivery", "error", err, "deliveryId", delivery.ID)
+	}
+
+	return delivery
+}
+
+// Test sends a webhook.test event to the webhook once, whatever events it
+// subscribes to and even when it is inactive, and returns the delivery.
+func (s *WebhookService) Test(ctx context.Context, userId, webhookId string) (DeliveryModel, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.Test")
+	defer span.End()
+
+	webhook, err := s.storage.FindWebhookById(ctx, userId, webhookId)
+	if err != nil {
+		return DeliveryModel{}, err
+	}
+
+	now := s.now()
+	eventId := "evt_" + uuid.New().String()
+	payload, err := json.Marshal(Payload{
+		ID:        eventId,
+		Type:      EventTest,
+		Timestamp: now.Unix(),
+		Data:      map[string]string{"webhookId": webhook.ID},
+	})
+	if err != nil {
+		return DeliveryModel{}, err
+	}
+
+	delivery := DeliveryModel{
+		ID:        uuid.Must(uuid.NewV7()).String(),
+		WebhookId: webhook.ID,
+		EventId:   eventId,
+		Event:     EventTest,
+		Payload:   payload,
+		CreatedAt: now.Unix(),
+	}
+
+	return s.attempt(ctx, userId, webhook, delivery, false), nil
+}
+
+// RetryDue retries every pending delivery that is due. Deliveries of
+// deleted or inactive webhooks are failed rather than sent.
+func (s *WebhookService) RetryDue(ctx context.Context, now time.Time) (RetryResult, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.RetryDue")
+	defer span.End()
+
+	due, err := s.storage.GetDueDeliveries(ctx, now)
+	if err != nil {
+		return RetryResult{}, err
+	}
+
+	result := RetryResult{Due: len(due)}
+	var errs []error
+	for _, pending := range due {
+		ctx := logging.WithAttrs(ctx, slog.String("userId", pending.UserId))
+
+		webhook, err := s.storage.FindWebhookById(ctx, pending.UserId, pending.WebhookId)
+		if errors.Is(err, ErrWebhookNotFound) || err == nil && !webhook.Active {
+			delivery := pending.DeliveryModel
+			delivery.Status = StatusFailed
+			delivery.Error = "webhook was deleted or deactivated"
+			delivery.NextAttemptAt = 0
+			delivery.UpdatedAt = s.now().Unix()
+			if err := s.storage.PutDelivery(ctx, pending.UserId, delivery); err != nil {
+				errs = append(errs, err)
+			}
+			result.Failed++
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		delivery := s.attempt(ctx, pending.UserId, webhook, pending.DeliveryModel, true)
+		if delivery.Status == StatusSucceeded {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+
+	slog.InfoContext(ctx, "Webhook retries done", "due", result.Due, "succeeded", result.Succeeded, "failed", result.Failed)
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,281 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jimvid/sidekick/internal/events"
+	"github.com/jimvid/sidekick/internal/habits"
+)
+
+func envelope(userId string, event events.Event, at time.Time) events.Envelope {
+	return events.Envelope{
+		ID:         uuid.New().String(),
+		Type:       event.EventType(),
+		UserId:     userId,
+		OccurredAt: at,
+		Event:      event,
+	}
+}
+
+func setupService(t *testing.T) (*WebhookService, *receiver) {
+	t.Helper()
+
+	rec := newReceiver(t)
+	return NewWebhookService(setupTestDB(t), setupSender(rec)), rec
+}
+
+func createWebhook(t *testing.T, service *WebhookService, userId, url string, events ...string) WebhookModel {
+	t.Helper()
+
+	webhook, err := service.CreateWebhook(context.Background(), userId, WebhookReq{URL: url, Events: events})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	return webhook
+}
+
+func TestServiceCreateWebhook(t *testing.T) {
+	service, rec := setupService(t)
+	ctx := context.Background()
+
+	webhook := createWebhook(t, service, "user-1", rec.URL, "habit.created")
+	if webhook.ID == "" || !webhook.Active || len(webhook.Secret) < len(secretPrefix)+32 {
+		t.Errorf("expected an active webhook with a secret, got %+v", webhook)
+	}
+
+	inactive := false
+	updated, err := service.UpdateWebhook(ctx, "user-1", webhook.ID, WebhookReq{URL: rec.URL + "/v2", Events: []string{"log.created"}, Active: &inactive})
+	if err != nil {
+		t.Fatalf("UpdateWebhook failed: %v", err)
+	}
+	if updated.Active || updated.URL != rec.URL+"/v2" || updated.Secret != webhook.Secret || updated.CreatedAt != webhook.CreatedAt {
+		t.Errorf("expected the update to keep the secret, got %+v", updated)
+	}
+
+	rotated, err := service.RotateSecret(ctx, "user-1", webhook.ID)
+	if err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+	stored, _ := service.FindWebhookById(ctx, "user-1", webhook.ID)
+	if rotated.Secret == webhook.Secret || stored.Secret != rotated.Secret || stored.URL != updated.URL {
+		t.Errorf("expected only the secret to be replaced, got %+v", stored)
+	}
+
+	for range MaxWebhooks - 1 {
+		createWebhook(t, service, "user-1", rec.URL, "habit.created")
+	}
+	_, err = service.CreateWebhook(ctx, "user-1", WebhookReq{URL: rec.URL, Events: []string{"habit.created"}})
+	if !errors.Is(err, ErrTooManyWebhooks) {
+		t.Errorf("expected ErrTooManyWebhooks, got %v", err)
+	}
+}
+
+func TestServiceSubscribed(t *testing.T) {
+	service, rec := setupService(t)
+	ctx := context.Background()
+
+	createWebhook(t, service, "user-1", rec.URL, "habit.created")
+	inactive := false
+	service.CreateWebhook(ctx, "user-1", WebhookReq{URL: rec.URL, Events: []string{"log.created"}, Active: &inactive})
+
+	for _, tc := range []struct {
+		userId, eventType string
+		want              bool
+	}{
+		{"user-1", "habit.created", true},
+		{"user-1", "habit.deleted", false},
+		{"user-1", "log.created", false},
+		{"user-2", "habit.created", false},
+	} {
+		got, err := service.Subscribed(ctx, tc.userId, tc.eventType)
+		if err != nil || got != tc.want {
+			t.Errorf("%s %s: expected %v, got %v %v", tc.userId, tc.eventType, tc.want, got, err)
+		}
+	}
+}
+
+func TestServiceHandleEvent(t *testing.T) {
+	service, rec := setupService(t)
+	ctx := context.Background()
+
+	habitHook := createWebhook(t, service, "user-1", rec.URL+"/habits", "habit.created")
+	logHook := createWebhook(t, service, "user-1", rec.URL+"/logs", "log.created")
+	createWebhook(t, service, "user-2", rec.URL+"/other", "habit.created")
+
+	habit := habits.HabitModel{ID: "habit-1", Name: "Read"}
+	env := envelope("user-1", habits.HabitCreated{HabitModel: habit}, time.Unix(1700000000, 0))
+	if err := service.HandleEvent(ctx, env); err != nil {
+		t.Fatalf("HandleEvent failed: %v", err)
+	}
+
+	got := rec.received()
+	if len(got) != 1 {
+		t.Fatalf("expected one delivery, got %d", len(got))
+	}
+	verify(t, habitHook.Secret, got[0])
+
+	var payload struct {
+		ID        string            `json:"id"`
+		Type      string            `json:"type"`
+		Timestamp int64             `json:"timestamp"`
+		Data      habits.HabitModel `json:"data"`
+	}
+	json.Unmarshal([]byte(got[0].Body), &payload)
+	if payload.ID != "evt_"+env.ID || payload.ID != got[0].Header.Get("webhook-id") || payload.Type != "habit.created" || payload.Timestamp != 1700000000 || payload.Data.Name != "Read" {
+		t.Errorf("expected the event in the payload, got %+v", payload)
+	}
+
+	deliveries, err := service.GetDeliveries(ctx, "user-1", habitHook.ID)
+	if err != nil {
+		t.Fatalf("GetDeliveries failed: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != StatusSucceeded || deliveries[0].Attempts != 1 || deliveries[0].ResponseStatus != http.StatusOK || deliveries[0].NextAttemptAt != 0 {
+		t.Errorf("expected one successful delivery, got %+v", deliveries)
+	}
+	if deliveries, _ := service.GetDeliveries(ctx, "user-1", logHook.ID); len(deliveries) != 0 {
+		t.Errorf("expected nothing for the log webhook, got %+v", deliveries)
+	}
+
+	t.Run("handling an event again sends nothing", func(t *testing.T) {
+		if err := service.HandleEvent(ctx, env); err != nil {
+			t.Fatalf("HandleEvent failed: %v", err)
+		}
+		if got := rec.received(); len(got) != 1 {
+			t.Errorf("expected one request, got %d", len(got))
+		}
+		if deliveries, _ := service.GetDeliveries(ctx, "user-1", habitHook.ID); len(deliveries) != 1 {
+			t.Errorf("expected one delivery, got %+v", deliveries)
+		}
+	})
+}
+
+func TestDeliveryId(t *testing.T) {
+	event := uuid.Must(uuid.NewV7())
+	id := deliveryId("evt_"+event.String(), "webhook-1")
+
+	if id != deliveryId("evt_"+event.String(), "webhook-1") {
+		t.Error("expected the same ID for the same event and webhook")
+	}
+	if id == deliveryId("evt_"+event.String(), "webhook-2") {
+		t.Error("expected another ID for another webhook")
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil || parsed.Version() != 7 || parsed.Time() != event.Time() {
+		t.Errorf("expected a UUIDv7 at the event's time, got %s: %v", id, err)
+	}
+	if _, err := uuid.Parse(deliveryId("evt_1", "webhook-1")); err != nil {
+		t.Errorf("expected a UUID for any event ID, got %v", err)
+	}
+}
+
+func TestServiceRetries(t *testing.T) {
+	service, rec := setupService(t)
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	service.now = func() time.Time { return now }
+
+	webhook := createWebhook(t, service, "user-1", rec.URL, "log.created")
+
+	rec.respond(http.StatusServiceUnavailable)
+	service.HandleEvent(ctx, envelope("user-1", habits.LogCreated{HabitLogModel: habits.HabitLogModel{ID: "log-1"}}, now))
+
+	deliveries, _ := service.GetDeliveries(ctx, "user-1", webhook.ID)
+	if len(deliveries) != 1 {
+		t.Fatalf("expected one delivery, got %+v", deliveries)
+	}
+	first := deliveries[0]
+	if first.Status != StatusPending || first.Attempts != 1 || first.NextAttemptAt != now.Add(time.Minute).Unix() || first.Error == "" {
+		t.Errorf("expected a pending delivery retried in a minute, got %+v", first)
+	}
+
+	t.Run("not due yet", func(t *testing.T) {
+		result, err := service.RetryDue(ctx, now)
+		if err != nil || result.Due != 0 {
+			t.Errorf("expected nothing due, got %+v: %v", result, err)
+		}
+	})
+
+	t.Run("backs off until failed", func(t *testing.T) {
+		for attempt := 2; attempt <= MaxAttempts; attempt++ {
+			now = now.Add(backoff(attempt - 1))
+			result, err := service.RetryDue(ctx, now)
+			if err != nil || result.Due != 1 || result.Failed != 1 {
+				t.Fatalf("attempt %d: expected one failed retry, got %+v: %v", attempt, result, err)
+			}
+		}
+
+		deliveries, _ := service.GetDeliveries(ctx, "user-1", webhook.ID)
+		if deliveries[0].Status != StatusFailed || deliveries[0].Attempts != MaxAttempts || deliveries[0].NextAttemptAt != 0 {
+			t.Errorf("expected the delivery to fail after %d attempts, got %+v", MaxAttempts, deliveries[0])
+		}
+
+		result, _ := service.RetryDue(ctx, now.Add(24*time.Hour))
+		if result.Due != 0 {
+			t.Errorf("expected no more retries, got %+v", result)
+		}
+		if len(rec.received()) != MaxAttempts {
+			t.Errorf("expected %d requests, got %d", MaxAttempts, len(rec.received()))
+		}
+	})
+
+	t.Run("recovers", func(t *testing.T) {
+		service.HandleEvent(ctx, envelope("user-1", habits.LogCreated{HabitLogModel: habits.HabitLogModel{ID: "log-2"}}, now))
+		rec.respond(http.StatusOK)
+
+		result, err := service.RetryDue(ctx, now.Add(time.Minute))
+		if err != nil || result.Due != 1 || result.Succeeded != 1 {
+			t.Fatalf("expected one successful retry, got %+v: %v", result, err)
+		}
+
+		// Every attempt carries the same event ID
+		got := rec.received()
+		if got[len(got)-1].Header.Get("webhook-id") != got[len(got)-2].Header.Get("webhook-id") {
+			t.Error("expected the retry to keep the event ID")
+		}
+	})
+
+	t.Run("deleted webhook", func(t *testing.T) {
+		rec.respond(http.StatusServiceUnavailable)
+		service.HandleEvent(ctx, envelope("user-1", habits.LogCreated{HabitLogModel: habits.HabitLogModel{ID: "log-3"}}, now))
+		service.DeleteWebhook(ctx, "user-1", webhook.ID)
+		sent := len(rec.received())
+
+		result, err := service.RetryDue(ctx, now.Add(time.Minute))
+		if err != nil || result.Due != 1 || result.Failed != 1 || len(rec.received()) != sent {
+			t.Errorf("expected the retry to fail without sending, got %+v: %v", result, err)
+		}
+	})
+}
+
+func TestServiceTest(t *testing.T) {
+	service, rec := setupService(t)
+	ctx := context.Background()
+
+	inactive := false
+	webhook, _ := service.CreateWebhook(ctx, "user-1", WebhookReq{URL: rec.URL, Events: []string{"habit.deleted"}, Active: &inactive})
+
+	delivery, err := service.Test(ctx, "user-1", webhook.ID)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	if delivery.Status != StatusSucceeded || delivery.Event != EventTest {
+		t.Errorf("expected a successful test delivery, got %+v", delivery)
+	}
+	verify(t, webhook.Secret, rec.received()[0])
+
+	rec.respond(http.StatusNotFound)
+	delivery, _ = service.Test(ctx, "user-1", webhook.ID)
+	if delivery.Status != StatusFailed || delivery.ResponseStatus != http.StatusNotFound {
+		t.Errorf("expected a failed test delivery that is not retried, got %+v", delivery)
+	}
+
+	if _, err := service.Test(ctx, "user-2", webhook.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package webhooks
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/database"
+)
+
+const (
+	itemPrefixWebhook  = "webhook#"
+	itemPrefixDelivery = "webhook-delivery#"
+
+	// IndexRetries is a sparse index holding every user's pending
+	// deliveries, so the scheduled retry run can find those due without
+	// scanning the table. They are spread over database.IndexShards
+	// partitions, keyed by RetryIndex and sorted by NextAttemptAt.
+	IndexRetries = "byRetry"
+
+	retryIndexKey = "pending"
+
+	// DeliveryRetention is how long the delivery log keeps a delivery.
+	DeliveryRetention = 30 * 24 * time.Hour
+)
+
+var ErrWebhookNotFound = errors.New("could not find a webhook with that ID")
+
+// ErrDeliveryExists means the event was already delivered, or is being
+// delivered, to the webhook.
+var ErrDeliveryExists = errors.New("webhook delivery already exists")
+
+type WebhookStorage struct {
+	db  *dynamodb.Client
+	cfg *config.Config
+}
+
+func NewWebhookStorage(db *dynamodb.Client, cfg *config.Config) *WebhookStorage {
+	return &WebhookStorage{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+func (s *WebhookStorage) PutWebhook(ctx context.Context, userId string, webhook WebhookModel) error {
+	item := webhookItem{
+		UserId:       userId,
+		ItemId:       itemPrefixWebhook + webhook.ID,
+		WebhookModel: webhook,
+	}
+
+	attributeValue, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal webhook", "error", err)
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      attributeValue,
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "webhookId", webhook.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (s *WebhookStorage) GetWebhooks(ctx context.Context, userId string) ([]WebhookModel, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixWebhook},
+		},
+	}
+
+	result, err := s.db.Query(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err)
+		return nil, err
+	}
+
+	var items []webhookItem
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal webhooks", "error", err)
+		return nil, err
+	}
+
+	webhooks := make([]WebhookModel, len(items))
+	for i, item := range items {
+		webhooks[i] = item.WebhookModel
+	}
+
+	return webhooks, nil
+}
+
+func (s *WebhookStorage) FindWebhookById(ctx context.Context, userId, webhookId string) (WebhookModel, error) {
+	var item webhookItem
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixWebhook + webhookId},
+		},
+	}
+
+	result, err := s.db.GetItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB GetItem failed", "error", err, "webhookId", webhookId)
+		return WebhookModel{}, err
+	}
+
+	if result.Item == nil {
+		return WebhookModel{}, ErrWebhookNotFound
+	}
+
+	err = attributevalue.UnmarshalMap(result.Item, &item)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal webhook", "error", err)
+		return WebhookModel{}, err
+	}
+
+	return item.WebhookModel, nil
+}
+
+func (s *WebhookStorage) DeleteWebhook(ctx context.Context, userId, webhookId string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userId},
+			"itemId": &types.AttributeValueMemberS{Value: itemPrefixWebhook + webhookId},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	}
+
+	result, err := s.db.DeleteItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB DeleteItem failed", "error", err, "webhookId", webhookId)
+		return err
+	}
+
+	if result.Attributes == nil {
+		return ErrWebhookNotFound
+	}
+
+	slog.InfoContext(ctx, "Webhook deleted", "webhookId", webhookId)
+	return nil
+}
+
+func (s *WebhookStorage) marshalDelivery(userId string, delivery DeliveryModel) (map[string]types.AttributeValue, error) {
+	item := deliveryItem{
+		UserId:        userId,
+		ItemId:        itemPrefixDelivery + delivery.WebhookId + "#" + delivery.ID,
+		ExpiresAt:     time.Unix(delivery.CreatedAt, 0).Add(DeliveryRetention).Unix(),
+		DeliveryModel: delivery,
+	}
+	if delivery.Status == StatusPending {
+		item.RetryIndex = database.ShardKey(retryIndexKey, delivery.ID)
+	}
+
+	return attributevalue.MarshalMap(item)
+}
+
+// CreateDelivery saves a new delivery, or fails with ErrDeliveryExists when
+// one with its ID was saved before.
+func (s *WebhookStorage) CreateDelivery(ctx context.Context, userId string, delivery DeliveryModel) error {
+	attributeValue, err := s.marshalDelivery(userId, delivery)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal webhook delivery", "error", err)
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(s.cfg.TableName),
+		Item:                attributeValue,
+		ConditionExpression: aws.String("attribute_not_exists(itemId)"),
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return ErrDeliveryExists
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "deliveryId", delivery.ID)
+		return err
+	}
+
+	return nil
+}
+
+// PutDelivery saves a delivery after each attempt. Only pending deliveries
+// are in IndexRetries.
+func (s *WebhookStorage) PutDelivery(ctx context.Context, userId string, delivery DeliveryModel) error {
+	attributeValue, err := s.marshalDelivery(userId, delivery)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal webhook delivery", "error", err)
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.cfg.TableName),
+		Item:      attributeValue,
+	}
+
+	_, err = s.db.PutItem(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB PutItem failed", "error", err, "deliveryId", delivery.ID)
+		return err
+	}
+
+	return nil
+}
+
+// GetDeliveries returns up to limit of a webhook's deliveries, newest first.
+func (s *WebhookStorage) GetDeliveries(ctx context.Context, userId, webhookId string, limit int) ([]DeliveryModel, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		KeyConditionExpression: aws.String("userId = :userId AND begins_with(itemId, :itemId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+			":itemId": &types.AttributeValueMemberS{Value: itemPrefixDelivery + webhookId + "#"},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(int32(limit)),
+	}
+
+	result, err := s.db.Query(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err, "webhookId", webhookId)
+		return nil, err
+	}
+
+	var items []deliveryItem
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &items)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal webhook deliveries", "error", err)
+		return nil, err
+	}
+
+	deliveries := make([]DeliveryModel, len(items))
+	for i, item := range items {
+		deliveries[i] = item.DeliveryModel
+	}
+
+	return deliveries, nil
+}
+
+// GetDueDeliveries returns every user's pending deliveries to be retried
+// at or before now, the longest due first.
+func (s *WebhookStorage) GetDueDeliveries(ctx context.Context, now time.Time) ([]PendingDelivery, error) {
+	items, err := database.QueryShards(ctx, retryIndexKey, func(ctx context.Context, shardKey string) ([]deliveryItem, error) {
+		return s.getShardDueDeliveries(ctx, shardKey, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(items, func(a, b deliveryItem) int { return cmp.Compare(a.NextAttemptAt, b.NextAttemptAt) })
+
+	deliveries := make([]PendingDelivery, len(items))
+	for i, item := range items {
+		deliveries[i] = PendingDelivery{UserId: item.UserId, DeliveryModel: item.DeliveryModel}
+	}
+
+	return deliveries, nil
+}
+
+func (s *WebhookStorage) getShardDueDeliveries(ctx context.Context, shardKey string, now time.Time) ([]deliveryItem, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.cfg.TableName),
+		IndexName:              aws.String(IndexRetries),
+		KeyConditionExpression: aws.String("RetryIndex = :key AND NextAttemptAt <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":key": &types.AttributeValueMemberS{Value: shardKey},
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	}
+
+	var items []deliveryItem
+	paginator := dynamodb.NewQueryPaginator(s.db, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "DynamoDB Query failed", "error", err, "index", IndexRetries, "shard", shardKey)
+			return nil, err
+		}
+
+		var pageItems []deliveryItem
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageItems)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to unmarshal webhook deliveries", "error", err)
+			return nil, err
+		}
+		items = append(items, pageItems...)
+	}
+
+	return items, nil
+}
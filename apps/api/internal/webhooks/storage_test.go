@@ -0,0 +1,170 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/google/uuid"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+)
+
+const testTableName = "test-webhooks"
+
+func setupTestTable(t *testing.T) (*dynamodb.Client, *config.Config) {
+	t.Helper()
+
+	return dynamotest.NewTable(t, testTableName)
+}
+
+func setupTestDB(t *testing.T) *WebhookStorage {
+	t.Helper()
+
+	return NewWebhookStorage(setupTestTable(t))
+}
+
+func TestStorageWebhooks(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	webhook := WebhookModel{
+		ID:        "webhook-1",
+		URL:       "https://example.com/hooks",
+		Events:    []string{"habit.created", "log.created"},
+		Active:    true,
+		Secret:    "whsec_c2VjcmV0",
+		CreatedAt: 1,
+		UpdatedAt: 2,
+	}
+	err := storage.PutWebhook(ctx, "user-1", webhook)
+	if err != nil {
+		t.Fatalf("PutWebhook failed: %v", err)
+	}
+
+	found, err := storage.FindWebhookById(ctx, "user-1", "webhook-1")
+	if err != nil {
+		t.Fatalf("FindWebhookById failed: %v", err)
+	}
+	if !reflect.DeepEqual(found, webhook) {
+		t.Errorf("expected %+v, got %+v", webhook, found)
+	}
+
+	// Deliveries share the prefix's start but are not webhooks
+	storage.PutDelivery(ctx, "user-1", DeliveryModel{ID: "delivery-1", WebhookId: "webhook-1", Status: StatusSucceeded})
+
+	all, err := storage.GetWebhooks(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetWebhooks failed: %v", err)
+	}
+	if len(all) != 1 || all[0].ID != "webhook-1" {
+		t.Errorf("expected only webhook-1, got %+v", all)
+	}
+
+	if _, err := storage.FindWebhookById(ctx, "user-2", "webhook-1"); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected another user not to find it, got %v", err)
+	}
+
+	if err := storage.DeleteWebhook(ctx, "user-1", "webhook-1"); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+	if err := storage.DeleteWebhook(ctx, "user-1", "webhook-1"); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+}
+
+func TestStorageGetDeliveries(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+
+	var ids []string
+	for range 3 {
+		id := uuid.Must(uuid.NewV7()).String()
+		ids = append(ids, id)
+		err := storage.PutDelivery(ctx, "user-1", DeliveryModel{ID: id, WebhookId: "webhook-1", Status: StatusSucceeded, Payload: []byte(`{}`)})
+		if err != nil {
+			t.Fatalf("PutDelivery failed: %v", err)
+		}
+	}
+	storage.PutDelivery(ctx, "user-1", DeliveryModel{ID: "other", WebhookId: "webhook-2", Status: StatusSucceeded})
+
+	deliveries, err := storage.GetDeliveries(ctx, "user-1", "webhook-1", 2)
+	if err != nil {
+		t.Fatalf("GetDeliveries failed: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != ids[2] || deliveries[1].ID != ids[1] {
+		t.Errorf("expected the newest two of webhook-1, got %+v", deliveries)
+	}
+	if string(deliveries[0].Payload) != `{}` {
+		t.Errorf("expected the payload, got %s", deliveries[0].Payload)
+	}
+}
+
+func TestStorageGetDueDeliveries(t *testing.T) {
+	storage := setupTestDB(t)
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	storage.PutDelivery(ctx, "user-1", DeliveryModel{ID: "due", WebhookId: "webhook-1", Status: StatusPending, NextAttemptAt: now.Unix() - 60})
+	storage.PutDelivery(ctx, "user-2", DeliveryModel{ID: "now", WebhookId: "webhook-2", Status: StatusPending, NextAttemptAt: now.Unix()})
+	storage.PutDelivery(ctx, "user-1", DeliveryModel{ID: "later", WebhookId: "webhook-1", Status: StatusPending, NextAttemptAt: now.Unix() + 60})
+	storage.PutDelivery(ctx, "user-1", DeliveryModel{ID: "done", WebhookId: "webhook-1", Status: StatusSucceeded})
+
+	due, err := storage.GetDueDeliveries(ctx, now)
+	if err != nil {
+		t.Fatalf("GetDueDeliveries failed: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, delivery := range due {
+		got[delivery.ID] = delivery.UserId
+	}
+	want := map[string]string{"due": "user-1", "now": "user-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Finishing a delivery takes it out of the index
+	storage.PutDelivery(ctx, "user-1", DeliveryModel{ID: "due", WebhookId: "webhook-1", Status: StatusSucceeded})
+	due, _ = storage.GetDueDeliveries(ctx, now)
+	if len(due) != 1 || due[0].ID != "now" {
+		t.Errorf("expected only the other pending delivery, got %+v", due)
+	}
+}
+
+func TestStorageGetDueDeliveriesUnshardedKey(t *testing.T) {
+	db, cfg := setupTestTable(t)
+	storage := NewWebhookStorage(db, cfg)
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	storage.PutDelivery(ctx, "user-1", DeliveryModel{ID: "sharded", WebhookId: "webhook-1", Status: StatusPending, NextAttemptAt: now.Unix() - 30})
+
+	// Written before IndexRetries was sharded
+	item, err := attributevalue.MarshalMap(deliveryItem{
+		UserId:        "user-2",
+		ItemId:        itemPrefixDelivery + "webhook-2#unsharded",
+		RetryIndex:    retryIndexKey,
+		DeliveryModel: DeliveryModel{ID: "unsharded", WebhookId: "webhook-2", Status: StatusPending, NextAttemptAt: now.Unix() - 60},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal delivery: %v", err)
+	}
+	_, err = db.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(cfg.TableName), Item: item})
+	if err != nil {
+		t.Fatalf("PutItem failed: %v", err)
+	}
+
+	due, err := storage.GetDueDeliveries(ctx, now)
+	if err != nil {
+		t.Fatalf("GetDueDeliveries failed: %v", err)
+	}
+	if len(due) != 2 || due[0].ID != "unsharded" || due[1].ID != "sharded" {
+		t.Errorf("expected both deliveries, longest due first, got %+v", due)
+	}
+}
@@ -0,0 +1,324 @@
+// Package client is a typed Go client for the Sidekick API.
+//
+//	c := client.New("https://api.sidekick.jimvid.xyz", client.WithToken(token))
+//	habits, err := c.Habits.List(ctx)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is sent in the User-Agent header.
+const Version = "0.1.0"
+
+const (
+	defaultMaxRetries = 3
+	defaultMinBackoff = 200 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+// TokenSource returns the bearer token for a request. Clerk session tokens
+// are short lived, so tools that run for long should fetch a fresh one here
+// or use a personal API token, see TokensService.
+type TokenSource func(ctx context.Context) (string, error)
+
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	tokenSource TokenSource
+	userAgent   string
+	maxRetries  int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	sleep       func(ctx context.Context, d time.Duration) error
+	now         func() time.Time
+
+	Habits    *HabitsService
+	Logs      *LogsService
+	Days      *DaysService
+	Stats     *StatsService
+	Settings  *SettingsService
+	Profile   *ProfileService
+	Reminders *RemindersService
+	Push      *PushService
+	Webhooks  *WebhooksService
+	Tokens    *TokensService
+}
+
+type Option func(*Client)
+
+// WithToken authenticates every request with a fixed bearer token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.tokenSource = func(context.Context) (string, error) {
+			return token, nil
+		}
+	}
+}
+
+// WithTokenSource authenticates every request with a token from source.
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = source
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent + " " + c.userAgent
+	}
+}
+
+// WithRetries sets how many times a request is retried after a 429, a 5xx or
+// a network error. Zero disables retries.
+func WithRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff sets the bounds of the exponential backoff between retries.
+func WithBackoff(minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "sidekick-go/" + Version,
+		maxRetries: defaultMaxRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+		sleep:      sleep,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.Habits = &HabitsService{client: c}
+	c.Logs = &LogsService{client: c}
+	c.Days = &DaysService{client: c}
+	c.Stats = &StatsService{client: c}
+	c.Settings = &SettingsService{client: c}
+	c.Profile = &ProfileService{client: c}
+	c.Reminders = &RemindersService{client: c}
+	c.Push = &PushService{client: c}
+	c.Webhooks = &WebhooksService{client: c}
+	c.Tokens = &TokensService{client: c}
+	return c
+}
+
+// InvalidParam is a field that failed validation.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Error is a problem details response from the API.
+type Error struct {
+	StatusCode    int            `json:"status"`
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Detail        string         `json:"detail"`
+	Code          string         `json:"code"`
+	Instance      string         `json:"instance"`
+	InvalidParams []InvalidParam `json:"invalid-params"`
+}
+
+func (e *Error) Error() string {
+	message := e.Detail
+	if message == "" {
+		message = e.Title
+	}
+	if e.Code == "" {
+		return fmt.Sprintf("sidekick: %d %s", e.StatusCode, message)
+	}
+	return fmt.Sprintf("sidekick: %d %s: %s", e.StatusCode, e.Code, message)
+}
+
+// IsNotFound reports whether err is a 404 from the API.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends a request and decodes a JSON response into out, retrying when the
+// API asks to or is briefly unavailable.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	_, err := c.doPage(ctx, method, c.baseURL+path, body, out)
+	return err
+}
+
+// doPage is do for a full URL, returning the response headers so list
+// iterators can follow pagination links.
+func (c *Client) doPage(ctx context.Context, method, url string, body, out any) (http.Header, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("sidekick: encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		res, err := c.send(ctx, method, url, payload)
+		if err != nil {
+			if ctx.Err() != nil || attempt >= c.maxRetries || !idempotent(method) {
+				return nil, err
+			}
+			err = c.sleep(ctx, c.backoff(attempt))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if res.StatusCode < 300 {
+			defer res.Body.Close()
+			if out == nil {
+				return res.Header, nil
+			}
+			err = json.NewDecoder(res.Body).Decode(out)
+			if err != nil {
+				return nil, fmt.Errorf("sidekick: decode %s %s response: %w", method, url, err)
+			}
+			return res.Header, nil
+		}
+
+		apiErr := decodeError(res)
+		if attempt >= c.maxRetries || !retryable(method, res.StatusCode) {
+			return nil, apiErr
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After"), c.now()); ok {
+			wait = retryAfter
+		}
+		err = c.sleep(ctx, wait)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, url string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("sidekick: build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.tokenSource != nil {
+		token, err := c.tokenSource(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("sidekick: get token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+func decodeError(res *http.Response) *Error {
+	defer res.Body.Close()
+
+	apiErr := &Error{}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	if json.Unmarshal(body, apiErr) != nil {
+		apiErr = &Error{Title: http.StatusText(res.StatusCode)}
+	}
+	// Trust the status line over the body
+	apiErr.StatusCode = res.StatusCode
+	return apiErr
+}
+
+// backoff is exponential with full jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.minBackoff << attempt
+	if ceiling > c.maxBackoff || ceiling <= 0 {
+		ceiling = c.maxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// retryable reports whether a response is worth retrying. A 429 was rejected
+// before it was handled so it is always safe, other failures are only
+// retried when repeating the request cannot create anything twice.
+func retryable(method string, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	switch status {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter accepts both forms of Retry-After, seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
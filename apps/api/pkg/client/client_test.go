@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client for handler that records waits instead of
+// sleeping.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) (*Client, *[]time.Duration) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := New(server.URL, append([]Option{WithToken("test-token")}, opts...)...)
+	var waits []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	return c, &waits
+}
+
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"type":     "https://sidekick.jimvid.xyz/problems/" + code,
+		"title":    http.StatusText(status),
+		"status":   status,
+		"detail":   detail,
+		"code":     code,
+		"instance": "req-1",
+	})
+}
+
+func TestAuthentication(t *testing.T) {
+	var gotAuth, gotAgent string
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotAgent = r.Header.Get("User-Agent")
+		w.Write([]byte(`[]`))
+	}, WithUserAgent("habit-bot/1.0"))
+
+	_, err := c.Habits.List(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAuth != "Bearer test-token" {
+		t.Errorf("expected bearer token, got %q", gotAuth)
+	}
+	if gotAgent != "habit-bot/1.0 sidekick-go/"+Version {
+		t.Errorf("expected user agent to include both products, got %q", gotAgent)
+	}
+}
+
+func TestTokenSourceError(t *testing.T) {
+	var calls atomic.Int32
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}, WithTokenSource(func(context.Context) (string, error) {
+		return "", errors.New("signed out")
+	}))
+
+	_, err := c.Habits.Get(context.Background(), "abc")
+	if err == nil {
+		t.Fatal("expected token error")
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected no request without a token, got %d", calls.Load())
+	}
+}
+
+func TestRetries(t *testing.T) {
+	t.Run("429 waits for Retry-After", func(t *testing.T) {
+		var calls atomic.Int32
+		c, waits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "2")
+				writeProblem(w, http.StatusTooManyRequests, "rate_limited", "Rate limit exceeded")
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"abc","name":"Read"}`))
+		})
+
+		habit, err := c.Habits.Create(context.Background(), HabitRequest{Name: "Read"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if habit.ID != "abc" {
+			t.Errorf("expected habit abc, got %q", habit.ID)
+		}
+		if len(*waits) != 1 || (*waits)[0] != 2*time.Second {
+			t.Errorf("expected one 2s wait, got %v", *waits)
+		}
+	})
+
+	t.Run("5xx retried for GET", func(t *testing.T) {
+		var calls atomic.Int32
+		c, waits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				writeProblem(w, http.StatusServiceUnavailable, "unavailable", "Try again")
+				return
+			}
+			w.Write([]byte(`{"id":"abc"}`))
+		}, WithBackoff(10*time.Millisecond, 15*time.Millisecond))
+
+		_, err := c.Habits.Get(context.Background(), "abc")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("expected 3 calls, got %d", calls.Load())
+		}
+		for _, wait := range *waits {
+			if wait < 0 || wait > 15*time.Millisecond {
+				t.Errorf("expected backoff within bounds, got %v", wait)
+			}
+		}
+	})
+
+	t.Run("5xx not retried for POST", func(t *testing.T) {
+		var calls atomic.Int32
+		c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			writeProblem(w, http.StatusInternalServerError, "internal_error", "Failed to create habit")
+		})
+
+		_, err := c.Habits.Create(context.Background(), HabitRequest{Name: "Read"})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if calls.Load() != 1 {
+			t.Errorf("expected a single call, got %d", calls.Load())
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		var calls atomic.Int32
+		c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			writeProblem(w, http.StatusBadGateway, "unavailable", "Bad gateway")
+		}, WithRetries(2))
+
+		err := c.Habits.Delete(context.Background(), "abc")
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+			t.Fatalf("expected 502 error, got %v", err)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("expected 3 calls, got %d", calls.Load())
+		}
+	})
+
+	t.Run("stops when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			cancel()
+			writeProblem(w, http.StatusServiceUnavailable, "unavailable", "Try again")
+		})
+
+		_, err := c.Habits.List(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestErrors(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusNotFound, "not_found", "Could not find habit by ID")
+	})
+
+	_, err := c.Habits.Get(context.Background(), "missing")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	var apiErr *Error
+	errors.As(err, &apiErr)
+	if apiErr.Code != "not_found" || apiErr.Instance != "req-1" {
+		t.Errorf("expected problem fields to be decoded, got %+v", apiErr)
+	}
+	if err.Error() != "sidekick: 404 not_found: Could not find habit by ID" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestPagination(t *testing.T) {
+	var calls atomic.Int32
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		page := r.URL.Query().Get("page")
+		switch page {
+		case "":
+			w.Header().Set("Link", `</habits?page=2>; rel="next", </habits>; rel="first"`)
+			w.Write([]byte(`[{"id":"1"},{"id":"2"}]`))
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/habits?page=3>; rel="next"`, "http://"+r.Host))
+			w.Write([]byte(`[{"id":"3"}]`))
+		default:
+			w.Write([]byte(`[{"id":"4"}]`))
+		}
+	})
+
+	var ids []string
+	for habit, err := range c.Habits.All(context.Background()) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, habit.ID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4]" {
+		t.Errorf("expected all pages, got %v", ids)
+	}
+
+	t.Run("break stops fetching", func(t *testing.T) {
+		calls.Store(0)
+		for habit := range c.Habits.All(context.Background()) {
+			if habit.ID == "1" {
+				break
+			}
+		}
+		if calls.Load() != 1 {
+			t.Errorf("expected only the first page to be fetched, got %d requests", calls.Load())
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 2, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "3", want: 3 * time.Second, ok: true},
+		{value: now.Add(10 * time.Second).Format(http.TimeFormat), want: 10 * time.Second, ok: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, ok: true},
+		{value: "", ok: false},
+		{value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, expected %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
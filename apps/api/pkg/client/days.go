@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// DayHabit is a habit as it stands on one day.
+type DayHabit struct {
+	Habit     Habit      `json:"habit"`
+	Scheduled bool       `json:"scheduled"`
+	Done      bool       `json:"done"`
+	Logs      []HabitLog `json:"logs"`
+}
+
+// Day is every habit with its logs for a date, and how many were done.
+type Day struct {
+	Date      string     `json:"date"`
+	Habits    []DayHabit `json:"habits"`
+	Scheduled int        `json:"scheduled"`
+	Completed int        `json:"completed"`
+	Progress  float64    `json:"progress"`
+}
+
+type DaysService struct {
+	client *Client
+}
+
+// Get returns the day for a YYYY-MM-DD date, or today for the current date
+// in the user's time zone.
+func (s *DaysService) Get(ctx context.Context, date string) (Day, error) {
+	var day Day
+	err := s.client.do(ctx, http.MethodGet, "/days/"+url.PathEscape(date), nil, &day)
+	return day, err
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+type Habit struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	CreatedAt   int64  `json:"createdAt"`
+	UpdatedAt   int64  `json:"updatedAt"`
+}
+
+type HabitRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+}
+
+type HabitsService struct {
+	client *Client
+}
+
+// All iterates over the user's habits.
+func (s *HabitsService) All(ctx context.Context) iter.Seq2[Habit, error] {
+	return list[Habit](ctx, s.client, "/habits")
+}
+
+func (s *HabitsService) List(ctx context.Context) ([]Habit, error) {
+	return collect(s.All(ctx))
+}
+
+func (s *HabitsService) Get(ctx context.Context, habitId string) (Habit, error) {
+	var habit Habit
+	err := s.client.do(ctx, http.MethodGet, "/habits/"+url.PathEscape(habitId), nil, &habit)
+	return habit, err
+}
+
+func (s *HabitsService) Create(ctx context.Context, req HabitRequest) (Habit, error) {
+	var habit Habit
+	err := s.client.do(ctx, http.MethodPost, "/habits", req, &habit)
+	return habit, err
+}
+
+func (s *HabitsService) Update(ctx context.Context, habitId string, req HabitRequest) (Habit, error) {
+	var habit Habit
+	err := s.client.do(ctx, http.MethodPut, "/habits/"+url.PathEscape(habitId), req, &habit)
+	return habit, err
+}
+
+func (s *HabitsService) Delete(ctx context.Context, habitId string) error {
+	return s.client.do(ctx, http.MethodDelete, "/habits/"+url.PathEscape(habitId), nil, nil)
+}
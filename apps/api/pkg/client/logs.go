@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+type HabitLog struct {
+	ID        string `json:"id"`
+	HabitId   string `json:"habitId"`
+	Date      string `json:"date"`
+	Note      string `json:"note"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+type HabitLogRequest struct {
+	HabitId string `json:"habitId"`
+	Date    string `json:"date"`
+	Note    string `json:"note"`
+}
+
+type LogsService struct {
+	client *Client
+}
+
+// All iterates over the user's habit logs.
+func (s *LogsService) All(ctx context.Context) iter.Seq2[HabitLog, error] {
+	return list[HabitLog](ctx, s.client, "/habit-logs")
+}
+
+func (s *LogsService) List(ctx context.Context) ([]HabitLog, error) {
+	return collect(s.All(ctx))
+}
+
+func (s *LogsService) Get(ctx context.Context, logId string) (HabitLog, error) {
+	var log HabitLog
+	err := s.client.do(ctx, http.MethodGet, "/habit-logs/"+url.PathEscape(logId), nil, &log)
+	return log, err
+}
+
+func (s *LogsService) Create(ctx context.Context, req HabitLogRequest) (HabitLog, error) {
+	var log HabitLog
+	err := s.client.do(ctx, http.MethodPost, "/habit-logs", req, &log)
+	return log, err
+}
+
+func (s *LogsService) Update(ctx context.Context, logId string, req HabitLogRequest) (HabitLog, error) {
+	var log HabitLog
+	err := s.client.do(ctx, http.MethodPut, "/habit-logs/"+url.PathEscape(logId), req, &log)
+	return log, err
+}
+
+func (s *LogsService) Delete(ctx context.Context, logId string) error {
+	return s.client.do(ctx, http.MethodDelete, "/habit-logs/"+url.PathEscape(logId), nil, nil)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// list iterates over every item of a list endpoint. The API returns whole
+// lists today; when a response carries a Link header with rel="next" the
+// iterator follows it, so callers keep working once lists are paginated.
+func list[T any](ctx context.Context, c *Client, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		next := c.baseURL + path
+		for next != "" {
+			var page []T
+			header, err := c.doPage(ctx, http.MethodGet, next, nil, &page)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			next = nextLink(header.Get("Link"), next)
+		}
+	}
+}
+
+// collect drains an iterator into a slice, stopping at the first error.
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	items := []T{}
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// nextLink returns the rel="next" target of an RFC 8288 Link header,
+// resolved against the URL of the current page.
+func nextLink(header, current string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if name != "rel" || !hasRel(strings.Trim(value, `"`), "next") {
+				continue
+			}
+
+			base, err := url.Parse(current)
+			if err != nil {
+				return ""
+			}
+			ref, err := url.Parse(strings.Trim(target, "<>"))
+			if err != nil {
+				return ""
+			}
+			return base.ResolveReference(ref).String()
+		}
+	}
+	return ""
+}
+
+func hasRel(value, rel string) bool {
+	for _, v := range strings.Fields(value) {
+		if strings.EqualFold(v, rel) {
+			return true
+		}
+	}
+	return false
+}
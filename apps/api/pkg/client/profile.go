@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type Profile struct {
+	DisplayName   string                  `json:"displayName"`
+	Onboarding    Onboarding              `json:"onboarding"`
+	Notifications NotificationPreferences `json:"notifications"`
+	CreatedAt     int64                   `json:"createdAt"`
+	UpdatedAt     int64                   `json:"updatedAt"`
+}
+
+// Onboarding tracks the steps of the app's first run guide.
+type Onboarding struct {
+	CompletedSteps []string `json:"completedSteps"`
+	CompletedAt    int64    `json:"completedAt,omitempty"`
+}
+
+type NotificationPreferences struct {
+	Reminders    bool `json:"reminders"`
+	StreakAlerts bool `json:"streakAlerts"`
+	Push         bool `json:"push"`
+	Email        bool `json:"email"`
+}
+
+type ProfileRequest struct {
+	DisplayName   string                  `json:"displayName"`
+	Notifications NotificationPreferences `json:"notifications"`
+}
+
+// User is everything stored about the signed in user.
+type User struct {
+	ID       string   `json:"id"`
+	Profile  Profile  `json:"profile"`
+	Settings Settings `json:"settings"`
+}
+
+type ProfileService struct {
+	client *Client
+}
+
+// Me returns the signed in user's profile and settings in one request.
+func (s *ProfileService) Me(ctx context.Context) (User, error) {
+	var user User
+	err := s.client.do(ctx, http.MethodGet, "/me", nil, &user)
+	return user, err
+}
+
+func (s *ProfileService) Get(ctx context.Context) (Profile, error) {
+	var profile Profile
+	err := s.client.do(ctx, http.MethodGet, "/me/profile", nil, &profile)
+	return profile, err
+}
+
+func (s *ProfileService) Update(ctx context.Context, req ProfileRequest) (Profile, error) {
+	var profile Profile
+	err := s.client.do(ctx, http.MethodPut, "/me/profile", req, &profile)
+	return profile, err
+}
+
+func (s *ProfileService) CompleteOnboardingStep(ctx context.Context, step string) (Profile, error) {
+	var profile Profile
+	err := s.client.do(ctx, http.MethodPut, "/me/onboarding/"+url.PathEscape(step), nil, &profile)
+	return profile, err
+}
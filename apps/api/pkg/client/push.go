@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// PushSubscription is a device push notifications are sent to. Its keys
+// are never returned.
+type PushSubscription struct {
+	ID        string `json:"id"`
+	Endpoint  string `json:"endpoint"`
+	UserAgent string `json:"userAgent,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+type PushSubscriptionKeys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// PushSubscriptionRequest is the JSON of a browser's PushSubscription.
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	// ExpirationTime is in unix milliseconds, nil when it does not expire.
+	ExpirationTime *int64               `json:"expirationTime"`
+	Keys           PushSubscriptionKeys `json:"keys"`
+}
+
+type PushPublicKey struct {
+	PublicKey string `json:"publicKey"`
+}
+
+type PushTestResult struct {
+	Sent int `json:"sent"`
+}
+
+type PushService struct {
+	client *Client
+}
+
+// PublicKey returns the VAPID key to subscribe with. It fails with a 503
+// when the API has no VAPID keys.
+func (s *PushService) PublicKey(ctx context.Context) (string, error) {
+	var key PushPublicKey
+	err := s.client.do(ctx, http.MethodGet, "/push/public-key", nil, &key)
+	return key.PublicKey, err
+}
+
+func (s *PushService) List(ctx context.Context) ([]PushSubscription, error) {
+	var subscriptions []PushSubscription
+	err := s.client.do(ctx, http.MethodGet, "/me/push-subscriptions", nil, &subscriptions)
+	return subscriptions, err
+}
+
+// Subscribe adds the device, or updates it when it subscribed before.
+func (s *PushService) Subscribe(ctx context.Context, req PushSubscriptionRequest) (PushSubscription, error) {
+	var subscription PushSubscription
+	err := s.client.do(ctx, http.MethodPost, "/me/push-subscriptions", req, &subscription)
+	return subscription, err
+}
+
+// Test sends a test notification to every device and returns how many
+// accepted it.
+func (s *PushService) Test(ctx context.Context) (int, error) {
+	var result PushTestResult
+	err := s.client.do(ctx, http.MethodPost, "/me/push-subscriptions/test", nil, &result)
+	return result.Sent, err
+}
+
+func (s *PushService) Delete(ctx context.Context, subscriptionId string) error {
+	return s.client.do(ctx, http.MethodDelete, "/me/push-subscriptions/"+url.PathEscape(subscriptionId), nil, nil)
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Reminder fires at Time, HH:MM in the user's time zone, on Days, when its
+// habit has not been logged yet that day.
+type Reminder struct {
+	ID      string `json:"id"`
+	HabitId string `json:"habitId"`
+	Time    string `json:"time"`
+	// Days are lower case weekdays, empty for every day.
+	Days       []string `json:"days"`
+	Paused     bool     `json:"paused"`
+	LastSentOn string   `json:"lastSentOn,omitempty"`
+	CreatedAt  int64    `json:"createdAt"`
+	UpdatedAt  int64    `json:"updatedAt"`
+}
+
+type ReminderRequest struct {
+	Time   string   `json:"time"`
+	Days   []string `json:"days"`
+	Paused bool     `json:"paused"`
+}
+
+type RemindersService struct {
+	client *Client
+}
+
+func (s *RemindersService) List(ctx context.Context) ([]Reminder, error) {
+	var reminders []Reminder
+	err := s.client.do(ctx, http.MethodGet, "/reminders", nil, &reminders)
+	return reminders, err
+}
+
+func (s *RemindersService) ListForHabit(ctx context.Context, habitId string) ([]Reminder, error) {
+	var reminders []Reminder
+	err := s.client.do(ctx, http.MethodGet, "/habits/"+url.PathEscape(habitId)+"/reminders", nil, &reminders)
+	return reminders, err
+}
+
+func (s *RemindersService) Create(ctx context.Context, habitId string, req ReminderRequest) (Reminder, error) {
+	var reminder Reminder
+	err := s.client.do(ctx, http.MethodPost, "/habits/"+url.PathEscape(habitId)+"/reminders", req, &reminder)
+	return reminder, err
+}
+
+func (s *RemindersService) Update(ctx context.Context, reminderId string, req ReminderRequest) (Reminder, error) {
+	var reminder Reminder
+	err := s.client.do(ctx, http.MethodPut, "/reminders/"+url.PathEscape(reminderId), req, &reminder)
+	return reminder, err
+}
+
+func (s *RemindersService) Delete(ctx context.Context, reminderId string) error {
+	return s.client.do(ctx, http.MethodDelete, "/reminders/"+url.PathEscape(reminderId), nil, nil)
+}
@@ -0,0 +1,302 @@
+package client_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/jimvid/sidekick/internal/config"
+	"github.com/jimvid/sidekick/internal/database/dynamotest"
+	"github.com/jimvid/sidekick/internal/habits"
+	"github.com/jimvid/sidekick/internal/metrics"
+	"github.com/jimvid/sidekick/internal/push"
+	"github.com/jimvid/sidekick/internal/reminders"
+	"github.com/jimvid/sidekick/internal/router"
+	"github.com/jimvid/sidekick/internal/tokens"
+	"github.com/jimvid/sidekick/internal/user"
+	"github.com/jimvid/sidekick/internal/webhooks"
+	"github.com/jimvid/sidekick/pkg/client"
+)
+
+const (
+	testTableName = "sidekick-client-test"
+	testKeyID     = "client-test-key"
+	testUserId    = "user_client_test"
+)
+
+// fakeClerk serves a JWKS for a key it signs session tokens with, so requests
+// pass the real AuthMiddleware.
+func fakeClerk(t *testing.T) func(userId string) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: key.Public(), KeyID: testKeyID, Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	}))
+	t.Cleanup(server.Close)
+	clerk.SetBackend(clerk.NewBackend(&clerk.BackendConfig{URL: clerk.String(server.URL)}))
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", testKeyID),
+	)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	return func(userId string) string {
+		token, err := jwt.Signed(signer).Claims(jwt.Claims{
+			Issuer:   "https://clerk.test.example.com",
+			Subject:  userId,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}).CompactSerialize()
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return token
+	}
+}
+
+func setupAPI(t *testing.T) string {
+	t.Helper()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "fake")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "fake")
+
+	dynamotest.NewTable(t, testTableName)
+
+	cfg := config.Default()
+	cfg.TableName = testTableName
+	cfg.DynamoDBEndpoint = dynamotest.Endpoint
+	cfg.DynamoDBRegion = "us-east-1"
+	cfg.RateLimitBackend = config.RateLimitBackendNone
+
+	r, err := router.NewRouter(cfg, metrics.Nop{})
+	if err != nil {
+		t.Fatalf("NewRouter failed: %v", err)
+	}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestClientAgainstRouter(t *testing.T) {
+	token := fakeClerk(t)
+	baseURL := setupAPI(t)
+	c := client.New(baseURL, client.WithToken(token(testUserId)))
+	ctx := context.Background()
+
+	habit, err := c.Habits.Create(ctx, client.HabitRequest{Name: "Read", Color: "#22c55e"})
+	if err != nil {
+		t.Fatalf("failed to create habit: %v", err)
+	}
+	if habit.ID == "" || habit.Name != "Read" {
+		t.Fatalf("unexpected habit %+v", habit)
+	}
+
+	habit, err = c.Habits.Update(ctx, habit.ID, client.HabitRequest{Name: "Read a book", Color: "#22c55e"})
+	if err != nil {
+		t.Fatalf("failed to update habit: %v", err)
+	}
+
+	today := time.Now().Format(time.DateOnly)
+	yesterday := time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
+	for _, date := range []string{yesterday, today} {
+		_, err = c.Logs.Create(ctx, client.HabitLogRequest{HabitId: habit.ID, Date: date})
+		if err != nil {
+			t.Fatalf("failed to create log: %v", err)
+		}
+	}
+
+	list, err := c.Habits.List(ctx)
+	if err != nil {
+		t.Fatalf("failed to list habits: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "Read a book" {
+		t.Errorf("expected the updated habit, got %+v", list)
+	}
+
+	stats, err := c.Stats.Habit(ctx, habit.ID)
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	if stats.TotalDays != 2 || stats.CurrentStreak != 2 {
+		t.Errorf("expected a two day streak, got %+v", stats)
+	}
+
+	settings, err := c.Settings.Update(ctx, client.SettingsRequest{TimeZone: "Europe/Stockholm"})
+	if err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+	if settings.TimeZone != "Europe/Stockholm" || settings.WeekStart != "monday" {
+		t.Errorf("expected Europe/Stockholm with the default week start, got %+v", settings)
+	}
+
+	_, err = c.Profile.Update(ctx, client.ProfileRequest{DisplayName: "Tester", Notifications: client.NotificationPreferences{Reminders: true}})
+	if err != nil {
+		t.Fatalf("failed to update profile: %v", err)
+	}
+	me, err := c.Profile.Me(ctx)
+	if err != nil {
+		t.Fatalf("failed to get me: %v", err)
+	}
+	if me.Profile.DisplayName != "Tester" || me.Settings.TimeZone != "Europe/Stockholm" {
+		t.Errorf("expected the saved profile and settings, got %+v", me)
+	}
+
+	reminder, err := c.Reminders.Create(ctx, habit.ID, client.ReminderRequest{Time: "20:00", Days: []string{"monday"}})
+	if err != nil {
+		t.Fatalf("failed to create reminder: %v", err)
+	}
+	habitReminders, err := c.Reminders.ListForHabit(ctx, habit.ID)
+	if err != nil {
+		t.Fatalf("failed to list reminders: %v", err)
+	}
+	if len(habitReminders) != 1 || habitReminders[0].ID != reminder.ID {
+		t.Errorf("expected the created reminder, got %+v", habitReminders)
+	}
+
+	day, err := c.Days.Get(ctx, today)
+	if err != nil {
+		t.Fatalf("failed to get day: %v", err)
+	}
+	if len(day.Habits) != 1 || !day.Habits[0].Done || day.Progress != 1 {
+		t.Errorf("expected the habit to be done today, got %+v", day)
+	}
+
+	heatmap, err := c.Stats.Heatmap(ctx, client.HeatmapOptions{From: yesterday, To: today, HabitId: habit.ID})
+	if err != nil {
+		t.Fatalf("failed to get heatmap: %v", err)
+	}
+	if heatmap.Total != 2 || len(heatmap.Days) != 2 || heatmap.Days[1].Ratio != 1 {
+		t.Errorf("expected both days done, got %+v", heatmap)
+	}
+
+	_, err = c.Habits.Create(ctx, client.HabitRequest{})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != "validation_failed" || len(apiErr.InvalidParams) == 0 {
+		t.Errorf("expected validation problem, got %v", err)
+	}
+
+	err = c.Habits.Delete(ctx, habit.ID)
+	if err != nil {
+		t.Fatalf("failed to delete habit: %v", err)
+	}
+	_, err = c.Habits.Get(ctx, habit.ID)
+	if !client.IsNotFound(err) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+
+	t.Run("personal API token", func(t *testing.T) {
+		created, err := c.Tokens.Create(ctx, client.TokenRequest{Name: "CLI"})
+		if err != nil {
+			t.Fatalf("failed to create token: %v", err)
+		}
+
+		withToken := client.New(baseURL, client.WithToken(created.Value))
+		if _, err := withToken.Habits.List(ctx); err != nil {
+			t.Fatalf("expected the token to authenticate, got %v", err)
+		}
+		list, err := c.Tokens.List(ctx)
+		if err != nil {
+			t.Fatalf("failed to list tokens: %v", err)
+		}
+		if len(list) != 1 || list[0].ID != created.ID || list[0].LastUsedAt == 0 {
+			t.Errorf("expected the used token, got %+v", list)
+		}
+
+		// A leaked token cannot mint more tokens or delete the account
+		_, err = withToken.Tokens.Create(ctx, client.TokenRequest{Name: "Leaked"})
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+			t.Errorf("expected 403 creating a token with a token, got %v", err)
+		}
+
+		// but can revoke itself, as sidekick logout does
+		err = withToken.Tokens.Delete(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("failed to revoke the token with itself: %v", err)
+		}
+		_, err = withToken.Habits.List(ctx)
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected 401 for a revoked token, got %v", err)
+		}
+	})
+
+	t.Run("rejects missing token", func(t *testing.T) {
+		anonymous := client.New(baseURL)
+		_, err := anonymous.Habits.List(ctx)
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %v", err)
+		}
+	})
+}
+
+// TestTypesMatchAPI fails when a model gains or renames a field the client
+// does not know about.
+func TestTypesMatchAPI(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  any
+		client any
+	}{
+		{name: "habit", model: habits.HabitModel{ID: "1", Name: "n", Description: "d", Color: "c", CreatedAt: 1, UpdatedAt: 2}, client: &client.Habit{}},
+		{name: "habit request", model: habits.HabitReq{Name: "n", Description: "d", Color: "c"}, client: &client.HabitRequest{}},
+		{name: "habit log", model: habits.HabitLogModel{ID: "1", HabitId: "h", Date: "2026-02-08", Note: "n", CreatedAt: 1, UpdatedAt: 2}, client: &client.HabitLog{}},
+		{name: "habit log request", model: habits.HabitLogReq{HabitId: "h", Date: "2026-02-08", Note: "n"}, client: &client.HabitLogRequest{}},
+		{name: "day", model: habits.DayModel{Date: "2026-02-08", Habits: []habits.DayHabit{{Habit: habits.HabitModel{ID: "1", Name: "n", Description: "d", Color: "c", CreatedAt: 1, UpdatedAt: 2}, Scheduled: true, Done: true, Logs: []habits.HabitLogModel{{ID: "1", HabitId: "h", Date: "2026-02-08", Note: "n", CreatedAt: 1, UpdatedAt: 2}}}}, Scheduled: 1, Completed: 1, Progress: 1}, client: &client.Day{}},
+		{name: "settings", model: user.SettingsModel{TimeZone: "UTC", WeekStart: "monday", Locale: "en-US", UpdatedAt: 1}, client: &client.Settings{}},
+		{name: "settings request", model: user.SettingsReq{TimeZone: "UTC", WeekStart: "monday", Locale: "en-US"}, client: &client.SettingsRequest{}},
+		{name: "profile", model: user.ProfileModel{DisplayName: "n", Onboarding: user.OnboardingModel{CompletedSteps: []string{"s"}, CompletedAt: 1}, Notifications: user.NotificationPreferences{Reminders: true, StreakAlerts: true, Push: true, Email: true}, CreatedAt: 1, UpdatedAt: 2}, client: &client.Profile{}},
+		{name: "profile request", model: user.ProfileReq{DisplayName: "n", Notifications: user.NotificationPreferences{Reminders: true, StreakAlerts: true, Push: true, Email: true}}, client: &client.ProfileRequest{}},
+		{name: "user", model: user.UserModel{ID: "1", Profile: user.ProfileModel{DisplayName: "n", Onboarding: user.OnboardingModel{CompletedSteps: []string{}}}, Settings: user.SettingsModel{TimeZone: "UTC"}}, client: &client.User{}},
+		{name: "reminder", model: reminders.ReminderModel{ID: "1", HabitId: "h", Time: "20:00", Days: []string{"monday"}, Paused: true, LastSentOn: "2026-02-08", CreatedAt: 1, UpdatedAt: 2}, client: &client.Reminder{}},
+		{name: "reminder request", model: reminders.ReminderReq{Time: "20:00", Days: []string{"monday"}, Paused: true}, client: &client.ReminderRequest{}},
+		{name: "push subscription", model: push.SubscriptionModel{ID: "1", Endpoint: "https://push.example.com", Keys: push.SubscriptionKeys{P256dh: "k", Auth: "a"}, UserAgent: "u", CreatedAt: 1, UpdatedAt: 2}, client: &client.PushSubscription{}},
+		{name: "push subscription request", model: push.SubscriptionReq{Endpoint: "https://push.example.com", ExpirationTime: new(int64), Keys: push.SubscriptionKeys{P256dh: "k", Auth: "a"}}, client: &client.PushSubscriptionRequest{}},
+		{name: "push public key", model: push.PublicKeyModel{PublicKey: "k"}, client: &client.PushPublicKey{}},
+		{name: "push test result", model: push.TestResult{Sent: 1}, client: &client.PushTestResult{}},
+		{name: "webhook", model: webhooks.WebhookModel{ID: "1", URL: "https://example.com", Description: "d", Events: []string{"habit.created"}, Active: true, Secret: "whsec_s", CreatedAt: 1, UpdatedAt: 2}, client: &client.Webhook{}},
+		{name: "webhook with secret", model: webhooks.WebhookWithSecretModel{WebhookModel: webhooks.WebhookModel{ID: "1", URL: "https://example.com", Description: "d", Events: []string{"habit.created"}, Active: true, CreatedAt: 1, UpdatedAt: 2}, Secret: "whsec_s"}, client: &client.WebhookWithSecret{}},
+		{name: "webhook request", model: webhooks.WebhookReq{URL: "https://example.com", Description: "d", Events: []string{"habit.created"}, Active: new(bool)}, client: &client.WebhookRequest{}},
+		{name: "webhook delivery", model: webhooks.DeliveryModel{ID: "1", WebhookId: "w", EventId: "evt_1", Event: "habit.created", Payload: []byte(`{"id":"evt_1"}`), Status: "pending", Attempts: 1, NextAttemptAt: 3, ResponseStatus: 500, Error: "e", DurationMs: 4, CreatedAt: 1, UpdatedAt: 2}, client: &client.WebhookDelivery{}},
+		{name: "token", model: tokens.TokenModel{ID: "1", Name: "n", Hint: "abcd", CreatedAt: 1, LastUsedAt: 2, ExpiresAt: 3}, client: &client.Token{}},
+		{name: "created token", model: tokens.CreatedTokenModel{TokenModel: tokens.TokenModel{ID: "1", Name: "n", Hint: "abcd", CreatedAt: 1, LastUsedAt: 2, ExpiresAt: 3}, Token: "skpat_abcd"}, client: &client.CreatedToken{}},
+		{name: "token request", model: tokens.TokenReq{Name: "n", ExpiresInDays: 30}, client: &client.TokenRequest{}},
+		{name: "heatmap", model: habits.HeatmapModel{From: "2026-02-07", To: "2026-02-08", HabitId: "h", Total: 1, Days: []habits.HeatmapDay{{Date: "2026-02-08", Count: 1, Scheduled: 2, Ratio: 0.5}}}, client: &client.Heatmap{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modelJSON, _ := json.Marshal(tt.model)
+			err := json.Unmarshal(modelJSON, tt.client)
+			if err != nil {
+				t.Fatalf("failed to decode: %v", err)
+			}
+
+			clientJSON, _ := json.Marshal(tt.client)
+			var want, got map[string]any
+			json.Unmarshal(modelJSON, &want)
+			json.Unmarshal(clientJSON, &got)
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("client type does not match the API model\nAPI:    %s\nclient: %s", modelJSON, clientJSON)
+			}
+		})
+	}
+}
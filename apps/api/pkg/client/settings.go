@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Settings are the preferences the API computes dates with.
+type Settings struct {
+	TimeZone  string `json:"timeZone"`
+	WeekStart string `json:"weekStart"`
+	Locale    string `json:"locale"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// SettingsRequest replaces the settings. Empty fields reset to the defaults.
+type SettingsRequest struct {
+	TimeZone  string `json:"timeZone"`
+	WeekStart string `json:"weekStart"`
+	Locale    string `json:"locale"`
+}
+
+type SettingsService struct {
+	client *Client
+}
+
+func (s *SettingsService) Get(ctx context.Context) (Settings, error) {
+	var settings Settings
+	err := s.client.do(ctx, http.MethodGet, "/me/settings", nil, &settings)
+	return settings, err
+}
+
+func (s *SettingsService) Update(ctx context.Context, req SettingsRequest) (Settings, error) {
+	var settings Settings
+	err := s.client.do(ctx, http.MethodPut, "/me/settings", req, &settings)
+	return settings, err
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// HabitStats summarizes how consistently a habit has been logged.
+type HabitStats struct {
+	HabitId string
+	// TotalDays is the number of distinct days the habit was logged.
+	TotalDays int
+	// CurrentStreak counts consecutive logged days up to today, or up to
+	// yesterday when today has not been logged yet.
+	CurrentStreak int
+	LongestStreak int
+	// LastLogged is the most recent logged day, empty when never logged.
+	LastLogged string
+}
+
+// StatsService reads statistics about the user's logs. Streaks have no
+// endpoint, so they are computed client side from a full log listing.
+type StatsService struct {
+	client *Client
+}
+
+// Habits returns stats for every habit, keyed by habit ID. Habits without
+// logs are included with zero values.
+func (s *StatsService) Habits(ctx context.Context) (map[string]HabitStats, error) {
+	habits, err := s.client.Habits.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.client.Logs.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := ComputeStats(logs, s.client.now())
+	for _, habit := range habits {
+		if _, ok := stats[habit.ID]; !ok {
+			stats[habit.ID] = HabitStats{HabitId: habit.ID}
+		}
+	}
+	return stats, nil
+}
+
+// Habit returns stats for a single habit.
+func (s *StatsService) Habit(ctx context.Context, habitId string) (HabitStats, error) {
+	logs, err := s.client.Logs.List(ctx)
+	if err != nil {
+		return HabitStats{}, err
+	}
+
+	stats, ok := ComputeStats(logs, s.client.now())[habitId]
+	if !ok {
+		return HabitStats{HabitId: habitId}, nil
+	}
+	return stats, nil
+}
+
+// HeatmapDay is how many habits were done on a day, out of those scheduled.
+type HeatmapDay struct {
+	Date      string  `json:"date"`
+	Count     int     `json:"count"`
+	Scheduled int     `json:"scheduled"`
+	Ratio     float64 `json:"ratio"`
+}
+
+type Heatmap struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	HabitId string       `json:"habitId,omitempty"`
+	Total   int          `json:"total"`
+	Days    []HeatmapDay `json:"days"`
+}
+
+// HeatmapOptions selects the days of a heatmap. Dates are YYYY-MM-DD and
+// empty fields use the API defaults, 53 weeks up to today.
+type HeatmapOptions struct {
+	From    string
+	To      string
+	HabitId string
+}
+
+// Heatmap returns per day completion counts, with every day in the range.
+func (s *StatsService) Heatmap(ctx context.Context, opts HeatmapOptions) (Heatmap, error) {
+	query := url.Values{}
+	if opts.From != "" {
+		query.Set("from", opts.From)
+	}
+	if opts.To != "" {
+		query.Set("to", opts.To)
+	}
+	if opts.HabitId != "" {
+		query.Set("habitId", opts.HabitId)
+	}
+
+	path := "/heatmap"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var heatmap Heatmap
+	err := s.client.do(ctx, http.MethodGet, path, nil, &heatmap)
+	return heatmap, err
+}
+
+// ComputeStats groups logs by habit and computes their stats relative to
+// today. Logs with malformed dates are ignored.
+func ComputeStats(logs []HabitLog, today time.Time) map[string]HabitStats {
+	days := map[string]map[time.Time]bool{}
+	for _, log := range logs {
+		day, err := time.Parse(time.DateOnly, log.Date)
+		if err != nil {
+			continue
+		}
+		if days[log.HabitId] == nil {
+			days[log.HabitId] = map[time.Time]bool{}
+		}
+		days[log.HabitId][day] = true
+	}
+
+	todayDate, _ := time.Parse(time.DateOnly, today.Format(time.DateOnly))
+
+	stats := map[string]HabitStats{}
+	for habitId, logged := range days {
+		sorted := make([]time.Time, 0, len(logged))
+		for day := range logged {
+			sorted = append(sorted, day)
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+		habitStats := HabitStats{
+			HabitId:    habitId,
+			TotalDays:  len(sorted),
+			LastLogged: sorted[len(sorted)-1].Format(time.DateOnly),
+		}
+
+		run := 0
+		for i, day := range sorted {
+			if i > 0 && day.Sub(sorted[i-1]) == 24*time.Hour {
+				run++
+			} else {
+				run = 1
+			}
+			habitStats.LongestStreak = max(habitStats.LongestStreak, run)
+		}
+
+		start := todayDate
+		if !logged[start] {
+			start = start.AddDate(0, 0, -1)
+		}
+		for day := start; logged[day]; day = day.AddDate(0, 0, -1) {
+			habitStats.CurrentStreak++
+		}
+
+		stats[habitId] = habitStats
+	}
+	return stats
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStats(t *testing.T) {
+	today := time.Date(2026, 2, 8, 18, 30, 0, 0, time.UTC)
+	logs := []HabitLog{
+		// Streak of three ending today, with a duplicate day
+		{HabitId: "read", Date: "2026-02-06"},
+		{HabitId: "read", Date: "2026-02-07"},
+		{HabitId: "read", Date: "2026-02-08"},
+		{HabitId: "read", Date: "2026-02-08"},
+		// Longer streak in the past
+		{HabitId: "read", Date: "2026-01-01"},
+		{HabitId: "read", Date: "2026-01-02"},
+		{HabitId: "read", Date: "2026-01-03"},
+		{HabitId: "read", Date: "2026-01-04"},
+		// Logged yesterday but not yet today
+		{HabitId: "run", Date: "2026-02-06"},
+		{HabitId: "run", Date: "2026-02-07"},
+		// Broken streak
+		{HabitId: "swim", Date: "2026-02-05"},
+		{HabitId: "swim", Date: "not-a-date"},
+	}
+
+	stats := ComputeStats(logs, today)
+
+	want := map[string]HabitStats{
+		"read": {HabitId: "read", TotalDays: 7, CurrentStreak: 3, LongestStreak: 4, LastLogged: "2026-02-08"},
+		"run":  {HabitId: "run", TotalDays: 2, CurrentStreak: 2, LongestStreak: 2, LastLogged: "2026-02-07"},
+		"swim": {HabitId: "swim", TotalDays: 1, CurrentStreak: 0, LongestStreak: 1, LastLogged: "2026-02-05"},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("expected %d habits, got %d", len(want), len(stats))
+	}
+	for habitId, expected := range want {
+		if stats[habitId] != expected {
+			t.Errorf("expected %s stats %+v, got %+v", habitId, expected, stats[habitId])
+		}
+	}
+}
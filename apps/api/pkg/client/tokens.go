@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// TokenPrefix starts every personal API token.
+const TokenPrefix = "skpat_"
+
+// Token is a personal API token without its secret. ExpiresAt is 0 for a
+// token that does not expire.
+type Token struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Hint       string `json:"hint"`
+	CreatedAt  int64  `json:"createdAt"`
+	LastUsedAt int64  `json:"lastUsedAt,omitempty"`
+	ExpiresAt  int64  `json:"expiresAt,omitempty"`
+}
+
+// CreatedToken holds the token itself, which the API only returns once.
+type CreatedToken struct {
+	Token
+	Value string `json:"token"`
+}
+
+type TokenRequest struct {
+	Name string `json:"name"`
+	// ExpiresInDays is 0 for a token that does not expire.
+	ExpiresInDays int `json:"expiresInDays,omitempty"`
+}
+
+type TokensService struct {
+	client *Client
+}
+
+func (s *TokensService) List(ctx context.Context) ([]Token, error) {
+	var tokens []Token
+	err := s.client.do(ctx, http.MethodGet, "/me/tokens", nil, &tokens)
+	return tokens, err
+}
+
+// Create makes a personal API token, which unlike a Clerk session token
+// can be used with WithToken for as long as it is not revoked.
+func (s *TokensService) Create(ctx context.Context, req TokenRequest) (CreatedToken, error) {
+	var token CreatedToken
+	err := s.client.do(ctx, http.MethodPost, "/me/tokens", req, &token)
+	return token, err
+}
+
+func (s *TokensService) Delete(ctx context.Context, tokenId string) error {
+	return s.client.do(ctx, http.MethodDelete, "/me/tokens/"+url.PathEscape(tokenId), nil, nil)
+}
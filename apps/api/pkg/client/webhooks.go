@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Webhook posts the user's habit and log events to a URL. Deliveries are
+// signed with its secret like Standard Webhooks.
+type Webhook struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+	Active      bool     `json:"active"`
+	CreatedAt   int64    `json:"createdAt"`
+	UpdatedAt   int64    `json:"updatedAt"`
+}
+
+// WebhookWithSecret holds the secret, which the API only returns when the
+// webhook is created or its secret rotated.
+type WebhookWithSecret struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type WebhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+	// Active defaults to true when nil.
+	Active *bool `json:"active"`
+}
+
+// WebhookDelivery is an event sent, or being sent, to a webhook. Status is
+// pending, succeeded or failed.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	WebhookId      string          `json:"webhookId"`
+	EventId        string          `json:"eventId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  int64           `json:"nextAttemptAt,omitempty"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	DurationMs     int64           `json:"durationMs"`
+	CreatedAt      int64           `json:"createdAt"`
+	UpdatedAt      int64           `json:"updatedAt"`
+}
+
+type WebhooksService struct {
+	client *Client
+}
+
+func (s *WebhooksService) List(ctx context.Context) ([]Webhook, error) {
+	var webhooks []Webhook
+	err := s.client.do(ctx, http.MethodGet, "/me/webhooks", nil, &webhooks)
+	return webhooks, err
+}
+
+func (s *WebhooksService) Get(ctx context.Context, webhookId string) (Webhook, error) {
+	var webhook Webhook
+	err := s.client.do(ctx, http.MethodGet, "/me/webhooks/"+url.PathEscape(webhookId), nil, &webhook)
+	return webhook, err
+}
+
+func (s *WebhooksService) Create(ctx context.Context, req WebhookRequest) (WebhookWithSecret, error) {
+	var webhook WebhookWithSecret
+	err := s.client.do(ctx, http.MethodPost, "/me/webhooks", req, &webhook)
+	return webhook, err
+}
+
+func (s *WebhooksService) Update(ctx context.Context, webhookId string, req WebhookRequest) (Webhook, error) {
+	var webhook Webhook
+	err := s.client.do(ctx, http.MethodPut, "/me/webhooks/"+url.PathEscape(webhookId), req, &webhook)
+	return webhook, err
+}
+
+func (s *WebhooksService) Delete(ctx context.Context, webhookId string) error {
+	return s.client.do(ctx, http.MethodDelete, "/me/webhooks/"+url.PathEscape(webhookId), nil, nil)
+}
+
+// Deliveries returns the webhook's latest deliveries, newest first.
+func (s *WebhooksService) Deliveries(ctx context.Context, webhookId string) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := s.client.do(ctx, http.MethodGet, "/me/webhooks/"+url.PathEscape(webhookId)+"/deliveries", nil, &deliveries)
+	return deliveries, err
+}
+
+// Test sends a webhook.test event once. The delivery's Status says whether
+// the URL accepted it.
+func (s *WebhooksService) Test(ctx context.Context, webhookId string) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := s.client.do(ctx, http.MethodPost, "/me/webhooks/"+url.PathEscape(webhookId)+"/test", nil, &delivery)
+	return delivery, err
+}
+
+// RotateSecret replaces the webhook's secret and returns the new one.
+func (s *WebhooksService) RotateSecret(ctx context.Context, webhookId string) (WebhookWithSecret, error) {
+	var webhook WebhookWithSecret
+	err := s.client.do(ctx, http.MethodPost, "/me/webhooks/"+url.PathEscape(webhookId)+"/rotate-secret", nil, &webhook)
+	return webhook, err
+}
@@ -0,0 +1,112 @@
+// Generates src/types/api.ts from the API's OpenAPI document. With --check
+// it only compares, and fails when the committed file is stale.
+import { readFileSync, writeFileSync } from 'node:fs'
+import { dirname, resolve } from 'node:path'
+import { fileURLToPath } from 'node:url'
+
+const root = resolve(dirname(fileURLToPath(import.meta.url)), '..')
+const specPath = resolve(root, '../api/internal/openapi/openapi.json')
+const outPath = resolve(root, 'src/types/api.ts')
+const printWidth = 80
+
+const refPrefix = '#/components/schemas/'
+
+function typeOf(schema) {
+  if (schema.$ref) return schema.$ref.slice(refPrefix.length)
+  if (schema.enum) return schema.enum.map((v) => `'${v}'`).join(' | ')
+
+  switch (schema.type) {
+    case 'string':
+      return 'string'
+    case 'integer':
+    case 'number':
+      return 'number'
+    case 'boolean':
+      return 'boolean'
+    case 'array':
+      return `Array<${typeOf(schema.items)}>`
+    case 'object':
+      if (schema.additionalProperties && !schema.properties) {
+        return `Record<string, ${typeOf(schema.additionalProperties)}>`
+      }
+      return 'Record<string, unknown>'
+    default:
+      // No type is any JSON value
+      return 'unknown'
+  }
+}
+
+function comment(description, indent) {
+  return description
+    ? description.split('\n').map((line) => `${indent}// ${line}\n`)
+    : []
+}
+
+function propertyName(name) {
+  return /^[A-Za-z_$][\w$]*$/.test(name) ? name : `'${name}'`
+}
+
+// Unions too long for one line are split with a leading | per member, as
+// prettier does
+function alias(name, schema) {
+  const members = schema.enum.map((v) => `'${v}'`)
+  const line = `export type ${name} = ${members.join(' | ')}`
+  if (line.length <= printWidth) return `${line}\n`
+  return `export type ${name} =\n${members.map((m) => `  | ${m}`).join('\n')}\n`
+}
+
+function iface(name, schema) {
+  const properties = Object.entries(schema.properties ?? {})
+  if (properties.length === 0) {
+    return `export type ${name} = ${typeOf(schema)}\n`
+  }
+
+  const required = new Set(schema.required ?? [])
+  const lines = [`export interface ${name} {\n`]
+  for (const [property, propertySchema] of properties) {
+    const optional = required.has(property) ? '' : '?'
+    lines.push(...comment(propertySchema.description, '  '))
+    lines.push(
+      `  ${propertyName(property)}${optional}: ${typeOf(propertySchema)}\n`,
+    )
+  }
+  lines.push('}\n')
+  return lines.join('')
+}
+
+function generate(spec) {
+  const blocks = Object.entries(spec.components.schemas)
+    .sort(([a], [b]) => a.localeCompare(b))
+    .map(([name, schema]) =>
+      [
+        ...comment(schema.description, ''),
+        schema.enum ? alias(name, schema) : iface(name, schema),
+      ].join(''),
+    )
+
+  return [
+    "// Generated from the API's OpenAPI document by scripts/openapi-types.mjs,\n" +
+      '// do not edit. Run `npm run types` after changing the API.\n',
+    ...blocks,
+  ].join('\n')
+}
+
+const spec = JSON.parse(readFileSync(specPath, 'utf8'))
+const generated = generate(spec)
+
+if (process.argv.includes('--check')) {
+  let current = ''
+  try {
+    current = readFileSync(outPath, 'utf8')
+  } catch {
+    // Missing counts as stale
+  }
+  if (current !== generated) {
+    console.error(
+      'src/types/api.ts does not match the OpenAPI document, run `npm run types`',
+    )
+    process.exit(1)
+  }
+} else {
+  writeFileSync(outPath, generated)
+}
@@ -0,0 +1,20 @@
+import { useAuth } from '@clerk/clerk-react'
+import { useQuery } from '@tanstack/react-query'
+import type { Day } from '@/types/habits'
+import { api } from '@/lib/api'
+
+// Nested under the habit logs key so logging a habit refreshes the day
+const DAYS_KEY = ['habit-logs', 'days']
+
+// useDay loads a YYYY-MM-DD date, or today for the current date in UTC
+export function useDay(date: string) {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: [...DAYS_KEY, date],
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<Day>(`/days/${date}`, { token })
+    },
+  })
+}
@@ -0,0 +1,30 @@
+import { useAuth } from '@clerk/clerk-react'
+import { useQuery } from '@tanstack/react-query'
+import type { Heatmap } from '@/types/habits'
+import { api } from '@/lib/api'
+
+// Nested under the habit logs key so logging a habit refreshes the heatmap
+const HEATMAP_KEY = ['habit-logs', 'heatmap']
+
+interface HeatmapParams {
+  from?: string
+  to?: string
+  habitId?: string
+}
+
+export function useHeatmap(params: HeatmapParams = {}) {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: [...HEATMAP_KEY, params],
+    queryFn: async () => {
+      const token = await getToken()
+      const query = new URLSearchParams()
+      for (const [key, value] of Object.entries(params)) {
+        if (value) query.set(key, value)
+      }
+      const search = query.size > 0 ? `?${query}` : ''
+      return api.get<Heatmap>(`/heatmap${search}`, { token })
+    },
+  })
+}
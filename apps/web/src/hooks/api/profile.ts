@@ -0,0 +1,66 @@
+import { useAuth } from '@clerk/clerk-react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import type { OnboardingStep, Profile, ProfileReq, User } from '@/types/user'
+import { api } from '@/lib/api'
+import { notifyError, notifySuccess } from '@/lib/notify'
+
+const ME_KEY = ['me']
+const PROFILE_KEY = [...ME_KEY, 'profile']
+
+export function useMe() {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: ME_KEY,
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<User>('/me', { token })
+    },
+  })
+}
+
+export function useProfile() {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: PROFILE_KEY,
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<Profile>('/me/profile', { token })
+    },
+  })
+}
+
+export function useUpdateProfile() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (data: ProfileReq) => {
+      const token = await getToken()
+      return api.put<Profile>('/me/profile', { token }, data)
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ME_KEY })
+      notifySuccess('Profile saved')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+export function useCompleteOnboardingStep() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (step: OnboardingStep) => {
+      const token = await getToken()
+      return api.put<Profile>(`/me/onboarding/${step}`, { token }, null)
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ME_KEY })
+    },
+  })
+}
@@ -0,0 +1,141 @@
+import { useAuth } from '@clerk/clerk-react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import type {
+  PushPublicKey,
+  PushSubscriptionInfo,
+  PushSubscriptionReq,
+  PushTestResult,
+} from '@/types/push'
+import { api } from '@/lib/api'
+import { notifyError, notifySuccess } from '@/lib/notify'
+
+const PUSH_KEY = ['me', 'push-subscriptions']
+
+// The VAPID key is base64url, PushManager.subscribe wants the bytes
+function decodeKey(key: string): Uint8Array {
+  const base64 = (key + '='.repeat((4 - (key.length % 4)) % 4))
+    .replace(/-/g, '+')
+    .replace(/_/g, '/')
+  return Uint8Array.from(atob(base64), (c) => c.charCodeAt(0))
+}
+
+export function usePushSubscriptions() {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: PUSH_KEY,
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<Array<PushSubscriptionInfo>>('/me/push-subscriptions', {
+        token,
+      })
+    },
+  })
+}
+
+// Asks for permission, subscribes this browser and registers it with the API
+export function useEnablePush() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async () => {
+      if (!('serviceWorker' in navigator) || !('PushManager' in window)) {
+        throw new Error('Push notifications are not supported in this browser')
+      }
+      const permission = await Notification.requestPermission()
+      if (permission !== 'granted') {
+        throw new Error('Notifications are blocked for this site')
+      }
+
+      const token = await getToken()
+      const { publicKey } = await api.get<PushPublicKey>('/push/public-key', {
+        token,
+      })
+      const registration = await navigator.serviceWorker.ready
+      const subscription = await registration.pushManager.subscribe({
+        userVisibleOnly: true,
+        applicationServerKey: decodeKey(publicKey),
+      })
+
+      return api.post<PushSubscriptionInfo>(
+        '/me/push-subscriptions',
+        { token },
+        subscription.toJSON() as PushSubscriptionReq,
+      )
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: PUSH_KEY })
+      notifySuccess('Notifications enabled')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+// Unsubscribes this browser, or removes another device by ID
+export function useDisablePush() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (id?: string) => {
+      const token = await getToken()
+      if (id) {
+        return api.delete<{ message: string }>(
+          `/me/push-subscriptions/${id}`,
+          { token },
+        )
+      }
+
+      const registration = await navigator.serviceWorker.ready
+      const subscription = await registration.pushManager.getSubscription()
+      if (!subscription) return
+      const subscriptions = await api.get<Array<PushSubscriptionInfo>>(
+        '/me/push-subscriptions',
+        { token },
+      )
+      const current = subscriptions.find(
+        (s) => s.endpoint === subscription.endpoint,
+      )
+      await subscription.unsubscribe()
+      if (current) {
+        await api.delete<{ message: string }>(
+          `/me/push-subscriptions/${current.id}`,
+          { token },
+        )
+      }
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: PUSH_KEY })
+      notifySuccess('Notifications turned off')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+export function useTestPush() {
+  const { getToken } = useAuth()
+
+  return useMutation({
+    mutationFn: async () => {
+      const token = await getToken()
+      return api.post<PushTestResult>(
+        '/me/push-subscriptions/test',
+        { token },
+        null,
+      )
+    },
+    onSuccess: ({ sent }) => {
+      notifySuccess(
+        sent === 1 ? 'Sent to 1 device' : `Sent to ${sent} devices`,
+      )
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
@@ -0,0 +1,96 @@
+import { useAuth } from '@clerk/clerk-react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import type { Reminder, ReminderReq } from '@/types/reminders'
+import { api } from '@/lib/api'
+import { notifyError, notifySuccess } from '@/lib/notify'
+
+const REMINDERS_KEY = ['reminders']
+
+export function useReminders() {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: REMINDERS_KEY,
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<Array<Reminder>>('/reminders', { token })
+    },
+  })
+}
+
+export function useHabitReminders(habitId: string) {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: [...REMINDERS_KEY, 'habit', habitId],
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<Array<Reminder>>(`/habits/${habitId}/reminders`, {
+        token,
+      })
+    },
+  })
+}
+
+export function useCreateReminder() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async ({
+      habitId,
+      data,
+    }: {
+      habitId: string
+      data: ReminderReq
+    }) => {
+      const token = await getToken()
+      return api.post<Reminder>(`/habits/${habitId}/reminders`, { token }, data)
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: REMINDERS_KEY })
+      notifySuccess('Reminder created')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+export function useUpdateReminder() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async ({ id, data }: { id: string; data: ReminderReq }) => {
+      const token = await getToken()
+      return api.put<Reminder>(`/reminders/${id}`, { token }, data)
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: REMINDERS_KEY })
+      notifySuccess('Reminder updated')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+export function useDeleteReminder() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (id: string) => {
+      const token = await getToken()
+      return api.delete<{ message: string }>(`/reminders/${id}`, { token })
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: REMINDERS_KEY })
+      notifySuccess('Reminder deleted')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
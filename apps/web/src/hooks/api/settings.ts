@@ -0,0 +1,40 @@
+import { useAuth } from '@clerk/clerk-react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import type { Settings, SettingsReq } from '@/types/settings'
+import { api } from '@/lib/api'
+import { notifyError, notifySuccess } from '@/lib/notify'
+
+const SETTINGS_KEY = ['settings']
+
+export function useSettings() {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: SETTINGS_KEY,
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<Settings>('/me/settings', { token })
+    },
+  })
+}
+
+export function useUpdateSettings() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (data: SettingsReq) => {
+      const token = await getToken()
+      return api.put<Settings>('/me/settings', { token }, data)
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: SETTINGS_KEY })
+      // Days and the heatmap follow the time zone and week start
+      queryClient.invalidateQueries({ queryKey: ['habit-logs'] })
+      notifySuccess('Settings saved')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
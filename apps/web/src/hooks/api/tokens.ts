@@ -0,0 +1,57 @@
+import { useAuth } from '@clerk/clerk-react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import type { ApiToken, ApiTokenReq, CreatedToken } from '@/types/tokens'
+import { api } from '@/lib/api'
+import { notifyError, notifySuccess } from '@/lib/notify'
+
+const TOKENS_KEY = ['me', 'tokens']
+
+export function useApiTokens() {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: TOKENS_KEY,
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<Array<ApiToken>>('/me/tokens', { token })
+    },
+  })
+}
+
+// The created token is only returned once, show it before it is lost
+export function useCreateApiToken() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (data: ApiTokenReq) => {
+      const token = await getToken()
+      return api.post<CreatedToken>('/me/tokens', { token }, data)
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: TOKENS_KEY })
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+export function useDeleteApiToken() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (id: string) => {
+      const token = await getToken()
+      return api.delete<{ message: string }>(`/me/tokens/${id}`, { token })
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: TOKENS_KEY })
+      notifySuccess('API token revoked')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
@@ -0,0 +1,147 @@
+import { useAuth } from '@clerk/clerk-react'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import type {
+  Webhook,
+  WebhookDelivery,
+  WebhookReq,
+  WebhookWithSecret,
+} from '@/types/webhooks'
+import { api } from '@/lib/api'
+import { notifyError, notifySuccess } from '@/lib/notify'
+
+const WEBHOOKS_KEY = ['me', 'webhooks']
+
+export function useWebhooks() {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: WEBHOOKS_KEY,
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<Array<Webhook>>('/me/webhooks', { token })
+    },
+  })
+}
+
+export function useWebhookDeliveries(webhookId: string) {
+  const { getToken } = useAuth()
+
+  return useQuery({
+    queryKey: [...WEBHOOKS_KEY, webhookId, 'deliveries'],
+    queryFn: async () => {
+      const token = await getToken()
+      return api.get<Array<WebhookDelivery>>(
+        `/me/webhooks/${webhookId}/deliveries`,
+        { token },
+      )
+    },
+  })
+}
+
+// The secret is only returned here and by useRotateWebhookSecret, show it
+// before it is lost
+export function useCreateWebhook() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (data: WebhookReq) => {
+      const token = await getToken()
+      return api.post<WebhookWithSecret>('/me/webhooks', { token }, data)
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: WEBHOOKS_KEY })
+      notifySuccess('Webhook created')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+export function useUpdateWebhook() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async ({ id, data }: { id: string; data: WebhookReq }) => {
+      const token = await getToken()
+      return api.put<Webhook>(`/me/webhooks/${id}`, { token }, data)
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: WEBHOOKS_KEY })
+      notifySuccess('Webhook updated')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+export function useDeleteWebhook() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (id: string) => {
+      const token = await getToken()
+      return api.delete<{ message: string }>(`/me/webhooks/${id}`, { token })
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: WEBHOOKS_KEY })
+      notifySuccess('Webhook deleted')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+export function useRotateWebhookSecret() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (id: string) => {
+      const token = await getToken()
+      return api.post<WebhookWithSecret>(
+        `/me/webhooks/${id}/rotate-secret`,
+        { token },
+        null,
+      )
+    },
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: WEBHOOKS_KEY })
+      notifySuccess('Webhook secret rotated')
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
+
+// Sends a webhook.test event once, the delivery says if the URL accepted it
+export function useTestWebhook() {
+  const { getToken } = useAuth()
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: async (id: string) => {
+      const token = await getToken()
+      return api.post<WebhookDelivery>(`/me/webhooks/${id}/test`, { token }, null)
+    },
+    onSuccess: (delivery) => {
+      queryClient.invalidateQueries({
+        queryKey: [...WEBHOOKS_KEY, delivery.webhookId, 'deliveries'],
+      })
+      if (delivery.status === 'succeeded') {
+        notifySuccess('Test event delivered')
+      } else {
+        notifyError(delivery.error ?? 'Test event was not accepted')
+      }
+    },
+    onError: (err) => {
+      notifyError(err.message)
+    },
+  })
+}
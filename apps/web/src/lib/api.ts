@@ -4,6 +4,48 @@ type RequestOptions = {
   token: string | null
 }
 
+export type InvalidParam = {
+  name: string
+  reason: string
+}
+
+// RFC 7807 problem details returned by the API for every error
+export type Problem = {
+  type: string
+  title: string
+  status: number
+  detail?: string
+  instance?: string
+  code: string
+  'invalid-params'?: InvalidParam[]
+}
+
+export class ApiError extends Error {
+  readonly status: number
+  readonly code: string
+  readonly invalidParams: InvalidParam[]
+  readonly requestId?: string
+
+  constructor(problem: Problem) {
+    super(problem.detail ?? problem.title)
+    this.name = 'ApiError'
+    this.status = problem.status
+    this.code = problem.code
+    this.invalidParams = problem['invalid-params'] ?? []
+    this.requestId = problem.instance
+  }
+}
+
+function randomHex(bytes: number): string {
+  const values = crypto.getRandomValues(new Uint8Array(bytes))
+  return Array.from(values, (b) => b.toString(16).padStart(2, '0')).join('')
+}
+
+// W3C trace context header so API traces start at the browser request
+function traceparent(): string {
+  return `00-${randomHex(16)}-${randomHex(8)}-01`
+}
+
 async function request<T>(
   method: string,
   path: string,
@@ -12,6 +54,7 @@ async function request<T>(
 ): Promise<T> {
   const headers: Record<string, string> = {
     'Content-Type': 'application/json',
+    traceparent: traceparent(),
   }
 
   if (opts.token) {
@@ -25,8 +68,13 @@ async function request<T>(
   })
 
   if (!res.ok) {
-    const err = await res.json().catch(() => ({ error: res.statusText }))
-    throw new Error(err.error ?? 'Request failed')
+    const problem: Problem = await res.json().catch(() => ({
+      type: 'about:blank',
+      title: res.statusText || 'Request failed',
+      status: res.status,
+      code: 'unknown',
+    }))
+    throw new ApiError(problem)
   }
 
   return res.json() as Promise<T>
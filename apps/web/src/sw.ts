@@ -3,6 +3,7 @@ import { cleanupOutdatedCaches, precacheAndRoute } from 'workbox-precaching'
 import { registerRoute } from 'workbox-routing'
 import { NetworkFirst } from 'workbox-strategies'
 import { CacheableResponsePlugin } from 'workbox-cacheable-response'
+import type { PushMessage } from './types/push'
 
 declare const self: ServiceWorkerGlobalScope
 
@@ -34,3 +35,37 @@ self.addEventListener('message', (event) => {
     })
   }
 })
+
+// Show reminders sent by the API as Web Push messages
+self.addEventListener('push', (event) => {
+  const message: PushMessage | undefined = event.data?.json()
+  if (!message) return
+
+  event.waitUntil(
+    self.registration.showNotification(message.title, {
+      body: message.body,
+      tag: message.tag,
+      icon: '/logo192.png',
+      data: { url: message.url ?? '/dashboard' },
+    }),
+  )
+})
+
+// Focus an open tab on the message's page, or open a new one
+self.addEventListener('notificationclick', (event) => {
+  event.notification.close()
+  const url = new URL(event.notification.data?.url ?? '/', self.location.origin)
+
+  event.waitUntil(
+    self.clients
+      .matchAll({ type: 'window', includeUncontrolled: true })
+      .then((clients) => {
+        for (const client of clients) {
+          if (new URL(client.url).pathname === url.pathname) {
+            return client.focus()
+          }
+        }
+        return self.clients.openWindow(url.href)
+      }),
+  )
+})
@@ -0,0 +1,293 @@
+// Generated from the API's OpenAPI document by scripts/openapi-types.mjs,
+// do not edit. Run `npm run types` after changing the API.
+
+export interface BuildInfo {
+  goVersion?: string
+  modified?: boolean
+  revision?: string
+  version: string
+}
+
+export interface CheckResult {
+  error?: string
+  latencyMs: number
+  status: string
+}
+
+export interface ClerkWebhookEvent {
+  data: Record<string, unknown>
+  type: string
+}
+
+export interface CreatedToken {
+  createdAt: number
+  expiresAt?: number
+  hint: string
+  id: string
+  lastUsedAt?: number
+  name: string
+  token: string
+}
+
+export interface Day {
+  completed: number
+  date: string
+  habits: Array<DayHabit>
+  progress: number
+  scheduled: number
+}
+
+export interface DayHabit {
+  done: boolean
+  habit: Habit
+  logs: Array<HabitLog>
+  scheduled: boolean
+}
+
+export interface Delivery {
+  attempts: number
+  createdAt: number
+  durationMs: number
+  error?: string
+  event: DeliveryEvent
+  eventId: string
+  id: string
+  nextAttemptAt?: number
+  payload: unknown
+  responseStatus?: number
+  status: DeliveryStatus
+  updatedAt: number
+  webhookId: string
+}
+
+export type DeliveryEvent =
+  | 'habit.created'
+  | 'habit.updated'
+  | 'habit.deleted'
+  | 'log.created'
+  | 'log.deleted'
+  | 'webhook.test'
+
+export type DeliveryStatus = 'pending' | 'succeeded' | 'failed'
+
+export interface Habit {
+  color: string
+  createdAt: number
+  description: string
+  id: string
+  name: string
+  updatedAt: number
+}
+
+export interface HabitLog {
+  createdAt: number
+  date: string
+  habitId: string
+  id: string
+  note: string
+  updatedAt: number
+}
+
+export interface HabitLogReq {
+  date?: string
+  habitId?: string
+  note?: string
+}
+
+export interface HabitReq {
+  color?: string
+  description?: string
+  name?: string
+}
+
+export interface Heatmap {
+  days: Array<HeatmapDay>
+  from: string
+  habitId?: string
+  to: string
+  total: number
+}
+
+export interface HeatmapDay {
+  count: number
+  date: string
+  ratio: number
+  scheduled: number
+}
+
+export interface InvalidParam {
+  name: string
+  reason: string
+}
+
+export interface Message {
+  message: string
+}
+
+export interface NotificationPreferences {
+  email: boolean
+  push: boolean
+  reminders: boolean
+  streakAlerts: boolean
+}
+
+export interface Onboarding {
+  completedAt?: number
+  completedSteps: Array<OnboardingStep>
+}
+
+export type OnboardingStep =
+  | 'create-habit'
+  | 'log-habit'
+  | 'set-time-zone'
+  | 'enable-notifications'
+
+export interface Problem {
+  code: string
+  detail?: string
+  instance?: string
+  'invalid-params'?: Array<InvalidParam>
+  status: number
+  title: string
+  type: string
+}
+
+export interface Profile {
+  createdAt: number
+  displayName: string
+  notifications: NotificationPreferences
+  onboarding: Onboarding
+  updatedAt: number
+}
+
+export interface ProfileReq {
+  displayName?: string
+  notifications?: NotificationPreferences
+}
+
+export interface PublicKey {
+  publicKey: string
+}
+
+export interface Reminder {
+  createdAt: number
+  days: Array<WeekStart>
+  habitId: string
+  id: string
+  lastSentOn?: string
+  paused: boolean
+  time: string
+  updatedAt: number
+}
+
+export interface ReminderReq {
+  days?: Array<WeekStart>
+  paused?: boolean
+  time?: string
+}
+
+export interface Report {
+  build: BuildInfo
+  checks?: Record<string, CheckResult>
+  status: string
+}
+
+export interface Settings {
+  locale: string
+  timeZone: string
+  updatedAt: number
+  weekStart: WeekStart
+}
+
+export interface SettingsReq {
+  locale?: string
+  timeZone?: string
+  weekStart?: WeekStart
+}
+
+export interface Subscription {
+  createdAt: number
+  endpoint: string
+  id: string
+  updatedAt: number
+  userAgent?: string
+}
+
+export interface SubscriptionKeys {
+  auth: string
+  p256dh: string
+}
+
+export interface SubscriptionReq {
+  endpoint?: string
+  expirationTime?: number
+  keys?: SubscriptionKeys
+}
+
+export interface TestResult {
+  sent: number
+}
+
+export interface Token {
+  createdAt: number
+  expiresAt?: number
+  hint: string
+  id: string
+  lastUsedAt?: number
+  name: string
+}
+
+export interface TokenReq {
+  expiresInDays?: number
+  name?: string
+}
+
+export interface User {
+  id: string
+  profile: Profile
+  settings: Settings
+}
+
+export interface Webhook {
+  active: boolean
+  createdAt: number
+  description: string
+  events: Array<WebhookEvent>
+  id: string
+  updatedAt: number
+  url: string
+}
+
+export type WebhookEvent =
+  | 'habit.created'
+  | 'habit.updated'
+  | 'habit.deleted'
+  | 'log.created'
+  | 'log.deleted'
+
+export interface WebhookReq {
+  active?: boolean
+  description?: string
+  events?: Array<WebhookEvent>
+  url?: string
+}
+
+export interface WebhookWithSecret {
+  active: boolean
+  createdAt: number
+  description: string
+  events: Array<string>
+  id: string
+  secret: string
+  updatedAt: number
+  url: string
+}
+
+export type WeekStart =
+  | 'sunday'
+  | 'monday'
+  | 'tuesday'
+  | 'wednesday'
+  | 'thursday'
+  | 'friday'
+  | 'saturday'
@@ -1,32 +1,14 @@
-export interface Habit {
-  id: string
-  name: string
-  description: string
-  color: string
-  createdAt: number
-  updatedAt: number
-}
-
-export interface HabitReq {
-  name: string
-  description: string
-  color: string
-}
-
-export interface HabitLog {
-  id: string
-  habitId: string
-  date: string
-  note: string
-  createdAt: number
-  updatedAt: number
-}
-
-export interface HabitLogReq {
-  habitId: string
-  date: string
-  note: string
-}
+// API types are generated into @/types/api from the OpenAPI document
+export type {
+  Day,
+  DayHabit,
+  Habit,
+  HabitLog,
+  HabitLogReq,
+  HabitReq,
+  Heatmap,
+  HeatmapDay,
+} from '@/types/api'
 
 export interface QuarterInfo {
   label: string
@@ -0,0 +1,15 @@
+// API types are generated into @/types/api from the OpenAPI document
+export type {
+  PublicKey as PushPublicKey,
+  Subscription as PushSubscriptionInfo,
+  SubscriptionReq as PushSubscriptionReq,
+  TestResult as PushTestResult,
+} from '@/types/api'
+
+// The payload the service worker shows as a notification
+export interface PushMessage {
+  title: string
+  body: string
+  tag?: string
+  url?: string
+}
@@ -0,0 +1,2 @@
+// API types are generated into @/types/api from the OpenAPI document
+export type { Reminder, ReminderReq } from '@/types/api'
@@ -0,0 +1,6 @@
+// API types are generated into @/types/api from the OpenAPI document
+export type {
+  CreatedToken,
+  Token as ApiToken,
+  TokenReq as ApiTokenReq,
+} from '@/types/api'
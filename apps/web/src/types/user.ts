@@ -0,0 +1,9 @@
+// API types are generated into @/types/api from the OpenAPI document
+export type {
+  NotificationPreferences,
+  Onboarding,
+  OnboardingStep,
+  Profile,
+  ProfileReq,
+  User,
+} from '@/types/api'
@@ -0,0 +1,10 @@
+// API types are generated into @/types/api from the OpenAPI document
+export type {
+  Delivery as WebhookDelivery,
+  DeliveryEvent,
+  DeliveryStatus,
+  Webhook,
+  WebhookEvent,
+  WebhookReq,
+  WebhookWithSecret,
+} from '@/types/api'
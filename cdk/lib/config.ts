@@ -12,6 +12,11 @@ export interface EnvironmentConfig {
   apiDomainName: string;
   frontendDomainName: string;
   clerkSecret: string;
+  clerkWebhookSecret: string;
+  reminderWebhookUrl: string;
+  vapidPublicKey: string;
+  vapidPrivateKey: string;
+  vapidSubject: string;
 }
 
 const configs: Record<Environment, EnvironmentConfig> = {
@@ -22,6 +27,11 @@ const configs: Record<Environment, EnvironmentConfig> = {
     apiDomainName: "dev.api.sidekick.jimvid.xyz",
     frontendDomainName: "dev.sidekick.jimvid.xyz",
     clerkSecret: process.env.CLERK_SECRET || "",
+    clerkWebhookSecret: process.env.CLERK_WEBHOOK_SECRET || "",
+    reminderWebhookUrl: process.env.REMINDER_WEBHOOK_URL || "",
+    vapidPublicKey: process.env.VAPID_PUBLIC_KEY || "",
+    vapidPrivateKey: process.env.VAPID_PRIVATE_KEY || "",
+    vapidSubject: process.env.VAPID_SUBJECT || "",
   },
   prod: {
     env: "prod",
@@ -30,6 +40,11 @@ const configs: Record<Environment, EnvironmentConfig> = {
     apiDomainName: "api.sidekick.jimvid.xyz",
     frontendDomainName: "sidekick.jimvid.xyz",
     clerkSecret: process.env.CLERK_SECRET || "",
+    clerkWebhookSecret: process.env.CLERK_WEBHOOK_SECRET || "",
+    reminderWebhookUrl: process.env.REMINDER_WEBHOOK_URL || "",
+    vapidPublicKey: process.env.VAPID_PUBLIC_KEY || "",
+    vapidPrivateKey: process.env.VAPID_PRIVATE_KEY || "",
+    vapidSubject: process.env.VAPID_SUBJECT || "",
   },
 };
 
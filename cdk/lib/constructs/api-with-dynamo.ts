@@ -2,6 +2,8 @@ import * as cdk from "aws-cdk-lib";
 import * as lambda from "aws-cdk-lib/aws-lambda";
 import * as apigateway from "aws-cdk-lib/aws-apigateway";
 import * as cloudwatch from "aws-cdk-lib/aws-cloudwatch";
+import * as events from "aws-cdk-lib/aws-events";
+import * as eventsTargets from "aws-cdk-lib/aws-events-targets";
 import * as sqs from "aws-cdk-lib/aws-sqs";
 import * as route53 from "aws-cdk-lib/aws-route53";
 import * as route53Targets from "aws-cdk-lib/aws-route53-targets";
@@ -16,6 +18,72 @@ interface ApiStackProps extends cdk.StackProps {
 
 export class ApiStack extends cdk.Stack {}
 
+// The table's global secondary indexes, in the order they were added.
+// DynamoDB creates one index per table update, so an existing table gets
+// them one deploy at a time: the tableIndexes context limits how many are
+// deployed and scripts/build-and-deploy-api.sh raises it by one per deploy.
+// Add new indexes at the end.
+export const tableIndexes: cdk.aws_dynamodb.GlobalSecondaryIndexProps[] = [
+  // Habit logs by day, for range queries like the heatmap. Sparse, only
+  // items with a Date are indexed
+  {
+    indexName: "byDate",
+    partitionKey: {
+      name: "userId",
+      type: cdk.aws_dynamodb.AttributeType.STRING,
+    },
+    sortKey: {
+      name: "Date",
+      type: cdk.aws_dynamodb.AttributeType.STRING,
+    },
+    projectionType: cdk.aws_dynamodb.ProjectionType.INCLUDE,
+    nonKeyAttributes: ["HabitId"],
+  },
+  // Every user's reminders, read by the scheduled reminders Lambda. Sparse,
+  // only reminder items set ReminderIndex
+  {
+    indexName: "byReminder",
+    partitionKey: {
+      name: "ReminderIndex",
+      type: cdk.aws_dynamodb.AttributeType.STRING,
+    },
+    sortKey: {
+      name: "itemId",
+      type: cdk.aws_dynamodb.AttributeType.STRING,
+    },
+    projectionType: cdk.aws_dynamodb.ProjectionType.ALL,
+  },
+  // Every user's webhook deliveries waiting for a retry, read by the
+  // scheduled webhooks Lambda. Sparse, only pending deliveries set RetryIndex
+  {
+    indexName: "byRetry",
+    partitionKey: {
+      name: "RetryIndex",
+      type: cdk.aws_dynamodb.AttributeType.STRING,
+    },
+    sortKey: {
+      name: "NextAttemptAt",
+      type: cdk.aws_dynamodb.AttributeType.NUMBER,
+    },
+    projectionType: cdk.aws_dynamodb.ProjectionType.ALL,
+  },
+  // Every user's domain events whose asynchronous subscribers have not
+  // finished, read by the scheduled outbox Lambda. Sparse, records that
+  // were given up on drop OutboxIndex
+  {
+    indexName: "byOutbox",
+    partitionKey: {
+      name: "OutboxIndex",
+      type: cdk.aws_dynamodb.AttributeType.STRING,
+    },
+    sortKey: {
+      name: "CreatedAt",
+      type: cdk.aws_dynamodb.AttributeType.NUMBER,
+    },
+    projectionType: cdk.aws_dynamodb.ProjectionType.ALL,
+  },
+];
+
 export class ApiWithDynamo extends Construct {
   constructor(scope: Construct, id: string, props: ApiStackProps) {
     super(scope, id);
@@ -34,8 +102,17 @@ export class ApiWithDynamo extends Construct {
       },
       removalPolicy: cdk.RemovalPolicy.DESTROY, // WARNING: Deletes table on stack deletion
       billingMode: cdk.aws_dynamodb.BillingMode.PAY_PER_REQUEST,
+      timeToLiveAttribute: "ExpiresAt",
     });
 
+    // Indexes, see tableIndexes
+    const indexCount = Number(
+      this.node.tryGetContext("tableIndexes") ?? tableIndexes.length,
+    );
+    for (const index of tableIndexes.slice(0, indexCount)) {
+      table.addGlobalSecondaryIndex(index);
+    }
+
     // Setup domain
     const { domainName } = props;
     const rootDomain = domainName.split(".").slice(-2).join(".");
@@ -76,6 +153,13 @@ export class ApiWithDynamo extends Construct {
       environment: {
         TABLE_NAME: table.tableName,
         CLERK_SECRET: props.config.clerkSecret,
+        CLERK_WEBHOOK_SECRET: props.config.clerkWebhookSecret,
+        ENVIRONMENT: props.config.env,
+        // Share rate limit buckets across Lambda instances
+        RATE_LIMIT_BACKEND: "dynamodb",
+        VAPID_PUBLIC_KEY: props.config.vapidPublicKey,
+        VAPID_PRIVATE_KEY: props.config.vapidPrivateKey,
+        VAPID_SUBJECT: props.config.vapidSubject,
       },
     });
     // CloudWatch
@@ -89,11 +173,7 @@ export class ApiWithDynamo extends Construct {
     const api = new apigateway.RestApi(this, "SidekickApi", {
       restApiName: "Sidekick API",
       description: "rest API for 'Sidekick' application",
-      defaultCorsPreflightOptions: {
-        allowOrigins: apigateway.Cors.ALL_ORIGINS,
-        allowMethods: apigateway.Cors.ALL_METHODS,
-        allowHeaders: ["Content-Type", "Authorization"],
-      },
+      // CORS, including preflight requests, is handled by the Lambda
     });
 
     // Add domain name to API gateway
@@ -113,6 +193,118 @@ export class ApiWithDynamo extends Construct {
     table.grantReadData(apiLambda);
     table.grantWriteData(apiLambda);
 
+    // Lambda - Reminders, sends the reminders that are due every 5 minutes
+    const remindersLambda = new lambda.Function(this, "RemindersLambda", {
+      runtime: lambda.Runtime.PROVIDED_AL2023,
+      handler: "bootstrap",
+      timeout: cdk.Duration.minutes(2),
+      memorySize: 256,
+      tracing: lambda.Tracing.ACTIVE,
+      code: lambda.Code.fromAsset("../apps/api", {
+        bundling: {
+          image: lambda.Runtime.PROVIDED_AL2023.bundlingImage,
+          command: [
+            "bash",
+            "-c",
+            "export GOCACHE=/tmp/go-cache && export GOMODCACHE=/tmp/go-mod && GOOS=linux GOARCH=amd64 CGO_ENABLED=0 go build -o /asset-output/bootstrap ./cmd/reminders",
+          ],
+        },
+      }),
+      environment: {
+        TABLE_NAME: table.tableName,
+        CLERK_SECRET: props.config.clerkSecret,
+        ENVIRONMENT: props.config.env,
+        REMINDER_WEBHOOK_URL: props.config.reminderWebhookUrl,
+        VAPID_PUBLIC_KEY: props.config.vapidPublicKey,
+        VAPID_PRIVATE_KEY: props.config.vapidPrivateKey,
+        VAPID_SUBJECT: props.config.vapidSubject,
+      },
+    });
+    table.grantReadWriteData(remindersLambda);
+
+    new events.Rule(this, "RemindersSchedule", {
+      schedule: events.Schedule.rate(cdk.Duration.minutes(5)),
+      targets: [new eventsTargets.LambdaFunction(remindersLambda)],
+    });
+
+    new cloudwatch.Alarm(this, "RemindersLambdaErrorAlarm", {
+      metric: remindersLambda.metricErrors(),
+      threshold: 3,
+      evaluationPeriods: 2,
+    });
+
+    // Lambda - Webhooks, retries the failed webhook deliveries every minute
+    const webhooksLambda = new lambda.Function(this, "WebhooksLambda", {
+      runtime: lambda.Runtime.PROVIDED_AL2023,
+      handler: "bootstrap",
+      timeout: cdk.Duration.minutes(2),
+      memorySize: 256,
+      tracing: lambda.Tracing.ACTIVE,
+      code: lambda.Code.fromAsset("../apps/api", {
+        bundling: {
+          image: lambda.Runtime.PROVIDED_AL2023.bundlingImage,
+          command: [
+            "bash",
+            "-c",
+            "export GOCACHE=/tmp/go-cache && export GOMODCACHE=/tmp/go-mod && GOOS=linux GOARCH=amd64 CGO_ENABLED=0 go build -o /asset-output/bootstrap ./cmd/webhooks",
+          ],
+        },
+      }),
+      environment: {
+        TABLE_NAME: table.tableName,
+        CLERK_SECRET: props.config.clerkSecret,
+        ENVIRONMENT: props.config.env,
+      },
+    });
+    table.grantReadWriteData(webhooksLambda);
+
+    new events.Rule(this, "WebhooksSchedule", {
+      schedule: events.Schedule.rate(cdk.Duration.minutes(1)),
+      targets: [new eventsTargets.LambdaFunction(webhooksLambda)],
+    });
+
+    new cloudwatch.Alarm(this, "WebhooksLambdaErrorAlarm", {
+      metric: webhooksLambda.metricErrors(),
+      threshold: 3,
+      evaluationPeriods: 2,
+    });
+
+    // Lambda - Outbox, relays the domain events left unfinished every minute
+    const outboxLambda = new lambda.Function(this, "OutboxLambda", {
+      runtime: lambda.Runtime.PROVIDED_AL2023,
+      handler: "bootstrap",
+      timeout: cdk.Duration.minutes(2),
+      memorySize: 256,
+      tracing: lambda.Tracing.ACTIVE,
+      code: lambda.Code.fromAsset("../apps/api", {
+        bundling: {
+          image: lambda.Runtime.PROVIDED_AL2023.bundlingImage,
+          command: [
+            "bash",
+            "-c",
+            "export GOCACHE=/tmp/go-cache && export GOMODCACHE=/tmp/go-mod && GOOS=linux GOARCH=amd64 CGO_ENABLED=0 go build -o /asset-output/bootstrap ./cmd/outbox",
+          ],
+        },
+      }),
+      environment: {
+        TABLE_NAME: table.tableName,
+        CLERK_SECRET: props.config.clerkSecret,
+        ENVIRONMENT: props.config.env,
+      },
+    });
+    table.grantReadWriteData(outboxLambda);
+
+    new events.Rule(this, "OutboxSchedule", {
+      schedule: events.Schedule.rate(cdk.Duration.minutes(1)),
+      targets: [new eventsTargets.LambdaFunction(outboxLambda)],
+    });
+
+    new cloudwatch.Alarm(this, "OutboxLambdaErrorAlarm", {
+      metric: outboxLambda.metricErrors(),
+      threshold: 3,
+      evaluationPeriods: 2,
+    });
+
     // Integration
     const lambdaIntegration = new apigateway.LambdaIntegration(apiLambda);
 
@@ -55,6 +55,23 @@ npx cdk synth --context env=$ENV
 print_status "Deploying certificate stack: $CERT_STACK_NAME..."
 npx cdk deploy $CERT_STACK_NAME --context env=$ENV --require-approval never --force
 
+# DynamoDB creates one global secondary index per table update, so indexes
+# missing from an existing table are added one deploy at a time, in the
+# order of tableIndexes in cdk/lib/constructs/api-with-dynamo.ts. A new
+# table is created with all of them.
+TOTAL_INDEXES=$(npx ts-node -e 'console.log(require("./lib/constructs/api-with-dynamo").tableIndexes.length)')
+TABLE_NAME=$(aws cloudformation describe-stacks --stack-name "$STACK_NAME" \
+    --query "Stacks[0].Outputs[?starts_with(OutputKey, 'ApiWithDynamoDynamoTableName')].OutputValue" \
+    --output text 2>/dev/null || true)
+if [[ -n "$TABLE_NAME" && "$TABLE_NAME" != "None" ]]; then
+    DEPLOYED_INDEXES=$(aws dynamodb describe-table --table-name "$TABLE_NAME" \
+        --query 'length(Table.GlobalSecondaryIndexes || `[]`)' --output text)
+    for ((n = DEPLOYED_INDEXES + 1; n < TOTAL_INDEXES; n++)); do
+        print_status "Deploying CDK stack: $STACK_NAME with $n of $TOTAL_INDEXES table indexes..."
+        npx cdk deploy $STACK_NAME --context env=$ENV --context tableIndexes=$n --require-approval never
+    done
+fi
+
 print_status "Deploying CDK stack: $STACK_NAME..."
 npx cdk deploy $STACK_NAME --context env=$ENV --require-approval never
 